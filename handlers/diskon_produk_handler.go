@@ -6,6 +6,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -29,6 +30,16 @@ func (handler *DiskonProdukHandler) Route(app *fiber.App) {
 }
 
 func (handler *DiskonProdukHandler) ApplyDiscount(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
 	var input models.DiskonProdukRequest
 
 	if err := c.BodyParser(&input); err != nil {
@@ -40,9 +51,9 @@ func (handler *DiskonProdukHandler) ApplyDiscount(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := handler.DiskonProdukService.ApplyDiscount(input)
+	response, err := handler.DiskonProdukService.ApplyDiscount(uint(claims.UserId), claims.IsAdmin, input)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(discountErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to apply discount",
 			Error:   exceptions.NewString(err.Error()),
@@ -60,7 +71,7 @@ func (handler *DiskonProdukHandler) ApplyDiscount(c *fiber.Ctx) error {
 }
 
 func (handler *DiskonProdukHandler) GetAllDiscounts(c *fiber.Ctx) error {
-	responses, err := handler.DiskonProdukService.GetAll()
+	responses, err := handler.DiskonProdukService.GetAll(c.Query("tanpa_berakhir") == "true")
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
@@ -108,6 +119,16 @@ func (handler *DiskonProdukHandler) GetDiscountById(c *fiber.Ctx) error {
 }
 
 func (handler *DiskonProdukHandler) UpdateDiscount(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -128,9 +149,9 @@ func (handler *DiskonProdukHandler) UpdateDiscount(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := handler.DiskonProdukService.UpdateDiscount(uint(id), input)
+	response, err := handler.DiskonProdukService.UpdateDiscount(uint(claims.UserId), claims.IsAdmin, uint(id), input)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(discountErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to update discount",
 			Error:   exceptions.NewString(err.Error()),
@@ -148,6 +169,16 @@ func (handler *DiskonProdukHandler) UpdateDiscount(c *fiber.Ctx) error {
 
 // Add this new method
 func (handler *DiskonProdukHandler) DeleteDiscount(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -158,9 +189,9 @@ func (handler *DiskonProdukHandler) DeleteDiscount(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := handler.DiskonProdukService.DeleteDiscount(uint(id))
+	response, err := handler.DiskonProdukService.DeleteDiscount(uint(claims.UserId), claims.IsAdmin, uint(id))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(discountErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to delete discount",
 			Error:   exceptions.NewString(err.Error()),
@@ -175,3 +206,11 @@ func (handler *DiskonProdukHandler) DeleteDiscount(c *fiber.Ctx) error {
 		Data:    response,
 	})
 }
+
+// discountErrorStatus maps "forbidden" to 403, a missing discount or product to 404 and anything else to 400
+func discountErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return productNotFoundStatus(err)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocumentHandler struct {
+	service services.DocumentService
+}
+
+func NewDocumentHandler(service services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{service}
+}
+
+func (h *DocumentHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/dokumen")
+	routes.Use(middleware.JWTProtected())
+
+	routes.Get("/invoice/:id", h.TransactionInvoice)
+	routes.Get("/packing-slip/:id", h.PackingSlip)
+}
+
+func (h *DocumentHandler) TransactionInvoice(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	content, filename, err := h.service.TransactionInvoice(uint(claims.UserId), uint(id))
+	if err != nil {
+		return documentError(c, "Failed to generate invoice", err)
+	}
+	return sendPDF(c, content, filename)
+}
+
+func (h *DocumentHandler) PackingSlip(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	content, filename, err := h.service.PackingSlip(uint(claims.UserId), uint(id))
+	if err != nil {
+		return documentError(c, "Failed to generate packing slip", err)
+	}
+	return sendPDF(c, content, filename)
+}
+
+func sendPDF(c *fiber.Ctx, content []byte, filename string) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", filename))
+	return c.Status(http.StatusOK).Send(content)
+}
+
+func documentError(c *fiber.Ctx, message string, err error) error {
+	status := http.StatusBadRequest
+	switch err.Error() {
+	case "record not found":
+		status = http.StatusNotFound
+	case "forbidden":
+		status = http.StatusForbidden
+	}
+
+	return c.Status(status).JSON(responder.ApiResponse{
+		Status:  false,
+		Message: message,
+		Error:   exceptions.NewString(err.Error()),
+		Data:    nil,
+	})
+}
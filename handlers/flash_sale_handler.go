@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FlashSaleHandler struct {
+	service services.FlashSaleService
+}
+
+func NewFlashSaleHandler(service services.FlashSaleService) *FlashSaleHandler {
+	return &FlashSaleHandler{service}
+}
+
+func (h *FlashSaleHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/flash-sales")
+
+	routes.Get("/", h.GetCurrent)
+	routes.Get("/all", middleware.JWTProtected(), h.adminOnly, h.GetAll) // Before the /:id route
+	routes.Get("/:id", h.GetById)
+	routes.Post("/", middleware.JWTProtected(), h.adminOnly, h.Create)
+	routes.Put("/:id", middleware.JWTProtected(), h.adminOnly, h.Update)
+	routes.Delete("/:id", middleware.JWTProtected(), h.adminOnly, h.Delete)
+	routes.Post("/:id/items", middleware.JWTProtected(), h.adminOnly, h.AddItem)
+	routes.Put("/:id/items/:itemId", middleware.JWTProtected(), h.adminOnly, h.UpdateItem)
+	routes.Delete("/:id/items/:itemId", middleware.JWTProtected(), h.adminOnly, h.DeleteItem)
+}
+
+// adminOnly limits running flash sales to admins
+func (h *FlashSaleHandler) adminOnly(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if !claims.IsAdmin {
+		return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Access denied: Admin only",
+			Error:   exceptions.NewString("forbidden access"),
+			Data:    nil,
+		})
+	}
+
+	return c.Next()
+}
+
+func (h *FlashSaleHandler) GetCurrent(c *fiber.Ctx) error {
+	sales, err := h.service.GetCurrent()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get flash sales",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved flash sales",
+		Error:   nil,
+		Data:    sales,
+	})
+}
+
+func (h *FlashSaleHandler) GetAll(c *fiber.Ctx) error {
+	sales, err := h.service.GetAll()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get flash sales",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved flash sales",
+		Error:   nil,
+		Data:    sales,
+	})
+}
+
+func (h *FlashSaleHandler) GetById(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.GetById(uint(id))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get flash sale",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved flash sale",
+		Error:   nil,
+		Data:    sale,
+	})
+}
+
+func (h *FlashSaleHandler) Create(c *fiber.Ctx) error {
+	var request models.FlashSaleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.Create(request)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create flash sale",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully created flash sale",
+		Error:   nil,
+		Data:    sale,
+	})
+}
+
+func (h *FlashSaleHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.FlashSaleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.Update(uint(id), request)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update flash sale",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated flash sale",
+		Error:   nil,
+		Data:    sale,
+	})
+}
+
+func (h *FlashSaleHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if err := h.service.Delete(uint(id)); err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to delete flash sale",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully deleted flash sale",
+		Error:   nil,
+		Data:    nil,
+	})
+}
+
+func (h *FlashSaleHandler) AddItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.FlashSaleItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.AddItem(uint(id), request)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to add flash sale item",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully added flash sale item",
+		Error:   nil,
+		Data:    sale,
+	})
+}
+
+func (h *FlashSaleHandler) UpdateItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+	itemID, err := strconv.ParseUint(c.Params("itemId"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid item ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.FlashSaleItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.UpdateItem(uint(id), uint(itemID), request)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update flash sale item",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated flash sale item",
+		Error:   nil,
+		Data:    sale,
+	})
+}
+
+func (h *FlashSaleHandler) DeleteItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+	itemID, err := strconv.ParseUint(c.Params("itemId"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid item ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	sale, err := h.service.DeleteItem(uint(id), uint(itemID))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to remove flash sale item",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully removed flash sale item",
+		Error:   nil,
+		Data:    sale,
+	})
+}
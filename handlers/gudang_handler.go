@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WarehouseHandler struct {
+	service services.WarehouseService
+}
+
+func NewWarehouseHandler(service services.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{service: service}
+}
+
+func (h *WarehouseHandler) Route(app *fiber.App) {
+	app.Get("/api/v1/gudang", middleware.JWTProtected(), h.GetAll)
+	app.Post("/api/v1/gudang", middleware.JWTProtected(), h.Create)
+	// Transfers before /:id so "transfer" is not read as an ID
+	app.Get("/api/v1/gudang/transfer", middleware.JWTProtected(), h.GetTransfers)
+	app.Post("/api/v1/gudang/transfer", middleware.JWTProtected(), h.Transfer)
+	app.Get("/api/v1/gudang/:id", middleware.JWTProtected(), h.GetById)
+	app.Put("/api/v1/gudang/:id", middleware.JWTProtected(), h.Update)
+	app.Get("/api/v1/gudang/:id/stok", middleware.JWTProtected(), h.GetStock)
+}
+
+// GetAll lists the seller's warehouses; admins may pass ?id_toko=
+func (h *WarehouseHandler) GetAll(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeID, _ := strconv.ParseUint(c.Query("id_toko"), 10, 64)
+	warehouses, err := h.service.GetAll(uint(claims.UserId), claims.IsAdmin, uint(storeID))
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get warehouses",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get warehouses",
+		Error:   nil,
+		Data:    warehouses,
+	})
+}
+
+func (h *WarehouseHandler) GetById(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid warehouse ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	warehouse, err := h.service.GetById(uint(claims.UserId), claims.IsAdmin, uint(id))
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get warehouse",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get warehouse",
+		Error:   nil,
+		Data:    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) Create(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.WarehouseRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create warehouse",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	warehouse, err := h.service.Create(uint(claims.UserId), input)
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create warehouse",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to create warehouse",
+		Error:   nil,
+		Data:    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) Update(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid warehouse ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.WarehouseRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update warehouse",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	warehouse, err := h.service.Update(uint(claims.UserId), claims.IsAdmin, uint(id), input)
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update warehouse",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to update warehouse",
+		Error:   nil,
+		Data:    warehouse,
+	})
+}
+
+func (h *WarehouseHandler) GetStock(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid warehouse ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	stocks, err := h.service.GetStock(uint(claims.UserId), claims.IsAdmin, uint(id))
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get warehouse stock",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get warehouse stock",
+		Error:   nil,
+		Data:    stocks,
+	})
+}
+
+func (h *WarehouseHandler) Transfer(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.WarehouseTransferRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to transfer stock",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	transfer, err := h.service.Transfer(uint(claims.UserId), claims.IsAdmin, input)
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to transfer stock",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to transfer stock",
+		Error:   nil,
+		Data:    transfer,
+	})
+}
+
+// GetTransfers lists the store's transfers, e.g. ?limit=20&page=1
+func (h *WarehouseHandler) GetTransfers(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	result, err := h.service.GetTransfers(uint(claims.UserId), limit, page)
+	if err != nil {
+		return c.Status(warehouseErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get stock transfers",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get stock transfers",
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+// warehouseErrorStatus maps "forbidden" to 403, a missing warehouse or product to 404 and anything else to 400
+func warehouseErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return productNotFoundStatus(err)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PriceStockHandler struct {
+	service services.PriceStockService
+}
+
+func NewPriceStockHandler(service services.PriceStockService) *PriceStockHandler {
+	return &PriceStockHandler{service: service}
+}
+
+func (h *PriceStockHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/inventaris")
+	routes.Put("/stok-harga", middleware.JWTProtected(), h.BulkUpdate)
+	routes.Get("/produk/:id/riwayat-harga-stok", middleware.JWTProtected(), h.History)
+}
+
+func (h *PriceStockHandler) BulkUpdate(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.BulkPriceStockRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update stock and prices",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	result, err := h.service.BulkUpdate(uint(claims.UserId), claims.IsAdmin, input)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update stock and prices",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	// In atomic mode a single invalid item means nothing was saved
+	if result.Mode == services.BulkModeAtomic && result.Gagal > 0 {
+		return c.Status(http.StatusUnprocessableEntity).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update stock and prices",
+			Error:   exceptions.NewString(fmt.Sprintf("%d of %d items are invalid, nothing was saved", result.Gagal, len(result.Items))),
+			Data:    result,
+		})
+	}
+
+	message := "Succeed to update stock and prices"
+	if result.Gagal > 0 {
+		message = fmt.Sprintf("Updated %d items, %d failed", result.Berhasil, result.Gagal)
+	}
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: message,
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+func (h *PriceStockHandler) History(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	result, err := h.service.History(uint(claims.UserId), claims.IsAdmin, uint(id), limit, page)
+	if err != nil {
+		status := http.StatusNotFound
+		if err.Error() == "forbidden" {
+			status = http.StatusForbidden
+		}
+		return c.Status(status).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get price and stock history",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get price and stock history",
+		Error:   nil,
+		Data:    result,
+	})
+}
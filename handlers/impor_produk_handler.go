@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"mini-project-evermos/utils/spreadsheet"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProductImportHandler struct {
+	service services.ProductImportService
+}
+
+func NewProductImportHandler(service services.ProductImportService) *ProductImportHandler {
+	return &ProductImportHandler{service: service}
+}
+
+// Route must be registered before ProductHandler so /ekspor is not taken for a product ID
+func (h *ProductImportHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/produk")
+	routes.Post("/impor", middleware.JWTProtected(), h.Import)
+	routes.Get("/ekspor", middleware.JWTProtected(), h.Export)
+}
+
+// Import reads the multipart "file" field, e.g. ?dry_run=true to only validate it
+func (h *ProductImportHandler) Import(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeID, err := optionalStoreID(c.FormValue("store_id", c.Query("store_id")))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString("a csv or xlsx file is required in the file field"),
+			Data:    nil,
+		})
+	}
+	format := spreadsheet.FormatOf(file.Filename)
+	if format == "" {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString("only .csv and .xlsx files are supported"),
+			Data:    nil,
+		})
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	dryRun := c.Query("dry_run") == "true" || c.FormValue("dry_run") == "true"
+	result, err := h.service.Import(uint(claims.UserId), claims.IsAdmin, storeID, format, data, dryRun)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "forbidden" {
+			status = http.StatusForbidden
+		}
+		return c.Status(status).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	// Row errors are reported per row; nothing was saved
+	if result.Gagal > 0 {
+		return c.Status(http.StatusUnprocessableEntity).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to import products",
+			Error:   exceptions.NewString(fmt.Sprintf("%d of %d rows are invalid, nothing was saved", result.Gagal, result.TotalBaris)),
+			Data:    result,
+		})
+	}
+
+	message := "Succeed to import products"
+	if dryRun {
+		message = "All rows are valid, nothing was saved"
+	}
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: message,
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+// Export downloads the store's catalog, e.g. ?format=xlsx
+func (h *ProductImportHandler) Export(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeID, err := optionalStoreID(c.Query("store_id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to export products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	format := c.Query("format", spreadsheet.FormatCSV)
+	content, filename, err := h.service.Export(uint(claims.UserId), claims.IsAdmin, storeID, format)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "forbidden" {
+			status = http.StatusForbidden
+		}
+		return c.Status(status).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to export products",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, spreadsheet.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Status(http.StatusOK).Send(content)
+}
+
+// optionalStoreID parses a store_id parameter; empty means the caller's own store
+func optionalStoreID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid store_id: %s", value)
+	}
+	return uint(id), nil
+}
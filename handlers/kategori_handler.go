@@ -23,15 +23,14 @@ func NewCategoryHandler(categoryService *services.CategoryService) CategoryHandl
 func (handler *CategoryHandler) Route(app *fiber.App, db *gorm.DB) {
 	routes := app.Group("/api/v1/category")
 
-	// Protect all category routes with JWT and admin permission
-	routes.Use(middleware.JWTProtected())
-	routes.Use(middleware.RolePermissionAdmin())
-
+	// Reading categories is public, managing them is admin only
 	routes.Get("/", handler.CategoryList)
+	routes.Get("/tree", handler.CategoryTree)
+	routes.Get("/slug/:slug", handler.CategoryDetailBySlug)
 	routes.Get("/:id", handler.CategoryDetail)
-	routes.Post("/", handler.CategoryCreate)
-	routes.Put("/:id", handler.CategoryEdit)
-	routes.Delete("/:id", handler.CategoryDelete)
+	routes.Post("/", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.CategoryCreate)
+	routes.Put("/:id", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.CategoryEdit)
+	routes.Delete("/:id", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.CategoryDelete)
 }
 
 func (handler *CategoryHandler) CategoryList(c *fiber.Ctx) error {
@@ -53,6 +52,42 @@ func (handler *CategoryHandler) CategoryList(c *fiber.Ctx) error {
 	})
 }
 
+func (handler *CategoryHandler) CategoryTree(c *fiber.Ctx) error {
+	responses, err := handler.CategoryService.GetTree()
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    responses,
+	})
+}
+
+func (handler *CategoryHandler) CategoryDetailBySlug(c *fiber.Ctx) error {
+	response, err := handler.CategoryService.GetBySlug(c.Params("slug"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    response,
+	})
+}
+
 func (handler *CategoryHandler) CategoryDetail(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
 	"net/http"
 	"strconv"
 
@@ -26,6 +27,7 @@ func (handler *KeranjangBelanjaHandler) Route(app *fiber.App) {
 
 	// Place the /clear route before the /:id route to prevent parameter confusion
 	routes.Delete("/clear", handler.ClearAll)
+	routes.Get("/ringkasan", handler.Summary)
 
 	routes.Get("/", handler.GetAll)
 	routes.Get("/:id", handler.GetById)
@@ -197,3 +199,32 @@ func (handler *KeranjangBelanjaHandler) ClearAll(c *fiber.Ctx) error {
 		Data:    deletedItems, // Return the deleted items
 	})
 }
+
+func (handler *KeranjangBelanjaHandler) Summary(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	summary, err := handler.KeranjangBelanjaService.Summary(uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Success to GET data",
+		Error:   nil,
+		Data:    summary,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
 	"net/http"
 	"strconv"
 
@@ -28,12 +29,37 @@ func (h *ProductCouponHandler) Route(app *fiber.App) {
 	routes.Get("/:id", h.GetById)
 	routes.Get("/code/:code", h.GetByCode)
 	routes.Get("/product/:productId", h.GetByProduct)
-	routes.Post("/", h.Create)
-	routes.Put("/:id", h.Update)
-	routes.Delete("/:id", h.Delete)
+	routes.Get("/:id/redemptions", h.adminOnly, h.GetRedemptions)
+	routes.Post("/", h.adminOnly, h.Create)
+	routes.Put("/:id", h.adminOnly, h.Update)
+	routes.Delete("/:id", h.adminOnly, h.Delete)
 	routes.Post("/validate", h.ValidateCoupon)
 }
 
+// adminOnly limits managing platform coupons to admins
+func (h *ProductCouponHandler) adminOnly(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if !claims.IsAdmin {
+		return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Access denied: Admin only",
+			Error:   exceptions.NewString("forbidden access"),
+			Data:    nil,
+		})
+	}
+
+	return c.Next()
+}
+
 func (h *ProductCouponHandler) GetAll(c *fiber.Ctx) error {
 	coupons, err := h.service.GetAll()
 	if err != nil {
@@ -144,7 +170,7 @@ func (h *ProductCouponHandler) Create(c *fiber.Ctx) error {
 
 	coupon, err := h.service.Create(request)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to create coupon",
 			Error:   exceptions.NewString(err.Error()),
@@ -183,7 +209,7 @@ func (h *ProductCouponHandler) Update(c *fiber.Ctx) error {
 
 	coupon, err := h.service.Update(uint(id), request)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to update coupon",
 			Error:   exceptions.NewString(err.Error()),
@@ -212,7 +238,7 @@ func (h *ProductCouponHandler) Delete(c *fiber.Ctx) error {
 
 	deletedCoupon, err := h.service.Delete(uint(id))
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to delete coupon",
 			Error:   exceptions.NewString(err.Error()),
@@ -228,13 +254,48 @@ func (h *ProductCouponHandler) Delete(c *fiber.Ctx) error {
 	})
 }
 
+func (h *ProductCouponHandler) GetRedemptions(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	redemptions, err := h.service.GetRedemptions(uint(id))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get coupon redemptions",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved coupon redemptions",
+		Error:   nil,
+		Data:    redemptions,
+	})
+}
+
+// ValidateCoupon previews the discount of a coupon on the products of a checkout
 func (h *ProductCouponHandler) ValidateCoupon(c *fiber.Ctx) error {
-	type ValidateRequest struct {
-		Code      string `json:"code"`
-		ProductID uint   `json:"product_id"`
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
 	}
 
-	var request ValidateRequest
+	var request models.CouponValidateRequest
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
@@ -244,9 +305,9 @@ func (h *ProductCouponHandler) ValidateCoupon(c *fiber.Ctx) error {
 		})
 	}
 
-	coupon, err := h.service.ValidateCoupon(request.Code, request.ProductID)
+	quote, err := h.service.ValidateCoupon(uint(claims.UserId), request)
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Invalid or expired coupon",
 			Error:   exceptions.NewString(err.Error()),
@@ -258,6 +319,6 @@ func (h *ProductCouponHandler) ValidateCoupon(c *fiber.Ctx) error {
 		Status:  true,
 		Message: "Coupon is valid",
 		Error:   nil,
-		Data:    coupon,
+		Data:    quote,
 	})
 }
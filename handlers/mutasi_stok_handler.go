@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StockMovementHandler struct {
+	service services.StockMovementService
+}
+
+func NewStockMovementHandler(service services.StockMovementService) *StockMovementHandler {
+	return &StockMovementHandler{service: service}
+}
+
+func (h *StockMovementHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/inventaris/produk/:id")
+	routes.Get("/mutasi-stok", middleware.JWTProtected(), h.History)
+	routes.Post("/mutasi-stok", middleware.JWTProtected(), h.Record)
+	routes.Get("/validasi-stok", middleware.JWTProtected(), h.Validate)
+}
+
+// History lists the movements, e.g. ?jenis=sale&id_varian=3&limit=20&page=1
+func (h *StockMovementHandler) History(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var variantID *uint
+	if value := c.Query("id_varian"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Invalid variant ID",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+		id := uint(parsed)
+		variantID = &id
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	result, err := h.service.History(uint(claims.UserId), claims.IsAdmin, uint(id), variantID, c.Query("jenis"), limit, page)
+	if err != nil {
+		return c.Status(stockErrorStatus(err, http.StatusNotFound)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get stock movements",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get stock movements",
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+func (h *StockMovementHandler) Record(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.StockMovementRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to record stock movement",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	movement, err := h.service.Record(uint(claims.UserId), claims.IsAdmin, uint(id), input)
+	if err != nil {
+		return c.Status(stockErrorStatus(err, http.StatusBadRequest)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to record stock movement",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to record stock movement",
+		Error:   nil,
+		Data:    movement,
+	})
+}
+
+func (h *StockMovementHandler) Validate(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	result, err := h.service.Validate(uint(claims.UserId), claims.IsAdmin, uint(id))
+	if err != nil {
+		return c.Status(stockErrorStatus(err, http.StatusNotFound)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to validate stock",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	message := "Stock matches the ledger"
+	if !result.Sesuai {
+		message = "Stock does not match the ledger"
+	}
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: message,
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+// stockErrorStatus maps "forbidden" to 403 and anything else to fallback
+func stockErrorStatus(err error, fallback int) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return fallback
+}
@@ -6,6 +6,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
 	"net/http"
 	"strconv"
 
@@ -31,7 +32,16 @@ func (h *NotificationHandler) Route(app *fiber.App) {
 }
 
 func (h *NotificationHandler) GetAll(c *fiber.Ctx) error {
-	notifications, err := h.service.GetAll()
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+		})
+	}
+
+	notifications, err := h.service.GetAll(uint(claims.UserId))
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
 			Status:  false,
@@ -48,6 +58,15 @@ func (h *NotificationHandler) GetAll(c *fiber.Ctx) error {
 }
 
 func (h *NotificationHandler) GetById(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+		})
+	}
+
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -57,9 +76,9 @@ func (h *NotificationHandler) GetById(c *fiber.Ctx) error {
 		})
 	}
 
-	notification, err := h.service.GetById(uint(id))
+	notification, err := h.service.GetById(uint(id), uint(claims.UserId))
 	if err != nil {
-		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+		return c.Status(notificationErrorStatus(err, http.StatusNotFound)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Notification not found",
 			Error:   exceptions.NewString(err.Error()),
@@ -100,6 +119,15 @@ func (h *NotificationHandler) Create(c *fiber.Ctx) error {
 }
 
 func (h *NotificationHandler) Update(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+		})
+	}
+
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -118,9 +146,9 @@ func (h *NotificationHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
-	notification, err := h.service.Update(uint(id), input)
+	notification, err := h.service.Update(uint(id), uint(claims.UserId), input)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(notificationErrorStatus(err, http.StatusInternalServerError)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to update notification",
 			Error:   exceptions.NewString(err.Error()),
@@ -135,6 +163,15 @@ func (h *NotificationHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *NotificationHandler) Delete(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+		})
+	}
+
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -144,9 +181,9 @@ func (h *NotificationHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	notification, err := h.service.Delete(uint(id))
+	notification, err := h.service.Delete(uint(id), uint(claims.UserId))
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(notificationErrorStatus(err, http.StatusInternalServerError)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to delete notification",
 			Error:   exceptions.NewString(err.Error()),
@@ -159,3 +196,11 @@ func (h *NotificationHandler) Delete(c *fiber.Ctx) error {
 		Data:    notification,
 	})
 }
+
+// notificationErrorStatus answers 403 for a notification sent to another user
+func notificationErrorStatus(err error, fallback int) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return fallback
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShippingHandler struct {
+	service services.ShippingService
+}
+
+func NewShippingHandler(service services.ShippingService) *ShippingHandler {
+	return &ShippingHandler{service}
+}
+
+func (h *ShippingHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/ongkir")
+	routes.Use(middleware.JWTProtected())
+
+	routes.Post("/cek", h.Quote)
+
+	// Rate table management is admin only
+	routes.Get("/tarif", middleware.RolePermissionAdmin(), h.GetAllRates)
+	routes.Get("/tarif/:id", middleware.RolePermissionAdmin(), h.GetRateById)
+	routes.Post("/tarif", middleware.RolePermissionAdmin(), h.CreateRate)
+	routes.Put("/tarif/:id", middleware.RolePermissionAdmin(), h.UpdateRate)
+	routes.Delete("/tarif/:id", middleware.RolePermissionAdmin(), h.DeleteRate)
+}
+
+func (h *ShippingHandler) Quote(c *fiber.Ctx) error {
+	var request models.ShippingQuoteRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if request.AlamatPengiriman == 0 {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Alamat diperlukan untuk alamat kirim produk",
+			Error:   exceptions.NewString("alamat_pengiriman is required"),
+			Data:    nil,
+		})
+	}
+
+	quotes, err := h.service.Quote(request)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to calculate shipping cost",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully calculated shipping cost",
+		Error:   nil,
+		Data:    quotes,
+	})
+}
+
+func (h *ShippingHandler) GetAllRates(c *fiber.Ctx) error {
+	rates, err := h.service.GetAllRates()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get shipping rates",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved shipping rates",
+		Error:   nil,
+		Data:    rates,
+	})
+}
+
+func (h *ShippingHandler) GetRateById(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	rate, err := h.service.GetRateById(uint(id))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Shipping rate not found",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved shipping rate",
+		Error:   nil,
+		Data:    rate,
+	})
+}
+
+func (h *ShippingHandler) CreateRate(c *fiber.Ctx) error {
+	var request models.ShippingRateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	rate, err := h.service.CreateRate(request)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create shipping rate",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully created shipping rate",
+		Error:   nil,
+		Data:    rate,
+	})
+}
+
+func (h *ShippingHandler) UpdateRate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.ShippingRateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	rate, err := h.service.UpdateRate(uint(id), request)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update shipping rate",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated shipping rate",
+		Error:   nil,
+		Data:    rate,
+	})
+}
+
+func (h *ShippingHandler) DeleteRate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	rate, err := h.service.DeleteRate(uint(id))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to delete shipping rate",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully deleted shipping rate",
+		Error:   nil,
+		Data:    rate,
+	})
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShipmentHandler struct {
+	service services.ShipmentService
+}
+
+func NewShipmentHandler(service services.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{service}
+}
+
+func (h *ShipmentHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/pengiriman")
+	routes.Use(middleware.JWTProtected())
+
+	routes.Post("/detail-trx/:id", h.Ship)
+	routes.Post("/:id/checkpoint", h.AddCheckpoint)
+	routes.Post("/:id/sync", h.SyncFromCourier)
+	routes.Get("/trx/:id", h.GetTracking)
+}
+
+func (h *ShipmentHandler) Ship(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.ShipmentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	shipment, err := h.service.Ship(uint(claims.UserId), uint(id), request)
+	if err != nil {
+		return shipmentError(c, "Failed to ship transaction detail", err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully shipped transaction detail",
+		Error:   nil,
+		Data:    shipment,
+	})
+}
+
+func (h *ShipmentHandler) AddCheckpoint(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.ShipmentCheckpointRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	shipment, err := h.service.AddCheckpoint(uint(claims.UserId), uint(id), request)
+	if err != nil {
+		return shipmentError(c, "Failed to add tracking checkpoint", err)
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully added tracking checkpoint",
+		Error:   nil,
+		Data:    shipment,
+	})
+}
+
+func (h *ShipmentHandler) SyncFromCourier(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	shipment, err := h.service.SyncFromCourier(uint(claims.UserId), uint(id))
+	if err != nil {
+		return shipmentError(c, "Failed to sync tracking from courier", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully synced tracking from courier",
+		Error:   nil,
+		Data:    shipment,
+	})
+}
+
+func (h *ShipmentHandler) GetTracking(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	tracking, err := h.service.GetTracking(uint(claims.UserId), uint(id))
+	if err != nil {
+		return shipmentError(c, "Failed to get shipment tracking", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved shipment tracking",
+		Error:   nil,
+		Data:    tracking,
+	})
+}
+
+func shipmentError(c *fiber.Ctx, message string, err error) error {
+	status := http.StatusBadRequest
+	switch err.Error() {
+	case "record not found":
+		status = http.StatusNotFound
+	case "forbidden":
+		status = http.StatusForbidden
+	}
+
+	return c.Status(status).JSON(responder.ApiResponse{
+		Status:  false,
+		Message: message,
+		Error:   exceptions.NewString(err.Error()),
+		Data:    nil,
+	})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StockAlertHandler struct {
+	service services.StockAlertService
+}
+
+func NewStockAlertHandler(service services.StockAlertService) *StockAlertHandler {
+	return &StockAlertHandler{service: service}
+}
+
+func (h *StockAlertHandler) Route(app *fiber.App) {
+	// Sellers set the threshold, buyers subscribe to sold-out products
+	app.Put("/api/v1/inventaris/produk/:id/batas-stok", middleware.JWTProtected(), h.SetThreshold)
+	app.Post("/api/v1/produk/:id/langganan-stok", middleware.JWTProtected(), h.Subscribe)
+	app.Delete("/api/v1/produk/:id/langganan-stok", middleware.JWTProtected(), h.Unsubscribe)
+	app.Get("/api/v1/langganan-stok", middleware.JWTProtected(), h.GetMine)
+}
+
+func (h *StockAlertHandler) SetThreshold(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.LowStockThresholdRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to set low-stock threshold",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	result, err := h.service.SetThreshold(uint(claims.UserId), claims.IsAdmin, uint(id), input)
+	if err != nil {
+		return c.Status(stockAlertErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to set low-stock threshold",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to set low-stock threshold",
+		Error:   nil,
+		Data:    result,
+	})
+}
+
+func (h *StockAlertHandler) Subscribe(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.StockSubscriptionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Failed to subscribe",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+	}
+
+	subscription, err := h.service.Subscribe(uint(claims.UserId), uint(id), input)
+	if err != nil {
+		return c.Status(stockAlertErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to subscribe",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "You will be notified when the product is back in stock",
+		Error:   nil,
+		Data:    subscription,
+	})
+}
+
+// Unsubscribe cancels a waiting subscription, e.g. ?id_varian=3 for a variant
+func (h *StockAlertHandler) Unsubscribe(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid product ID",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var variantID *uint
+	if value := c.Query("id_varian"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Invalid variant ID",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+		id := uint(parsed)
+		variantID = &id
+	}
+
+	if err := h.service.Unsubscribe(uint(claims.UserId), uint(id), variantID); err != nil {
+		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to unsubscribe",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to unsubscribe",
+		Error:   nil,
+		Data:    nil,
+	})
+}
+
+func (h *StockAlertHandler) GetMine(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	subscriptions, err := h.service.GetMine(uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get stock subscriptions",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to get stock subscriptions",
+		Error:   nil,
+		Data:    subscriptions,
+	})
+}
+
+// stockAlertErrorStatus maps "forbidden" to 403, a missing product to 404 and anything else to 400
+func stockAlertErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return productNotFoundStatus(err)
+}
@@ -1,69 +1,153 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"mini-project-evermos/exceptions"
 	"mini-project-evermos/middleware"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/formatter"
 	"mini-project-evermos/utils/jwt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ProductHandler struct {
-	ProductService services.ProductService
+	ProductService  services.ProductService
+	ResellerService services.ResellerService
 }
 
-func NewProductHandler(productService *services.ProductService) ProductHandler {
-	return ProductHandler{*productService}
+func NewProductHandler(productService *services.ProductService, resellerService *services.ResellerService) ProductHandler {
+	return ProductHandler{*productService, *resellerService}
 }
 
 func (handler *ProductHandler) Route(app *fiber.App) {
 	routes := app.Group("/api/v1/produk")
 
-	// Public routes (with JWT protection only)
-	routes.Get("/", middleware.JWTProtected(), handler.GetAllProduct)
-	routes.Get("/search", middleware.JWTProtected(), handler.SearchProducts)
-	routes.Get("/:id", middleware.JWTProtected(), handler.ProductDetail)
-	routes.Get("/category/:category", middleware.JWTProtected(), handler.GetProductsByCategory)
-	routes.Get("/:id/related", middleware.JWTProtected(), handler.GetRelatedProducts)
+	// Public catalog routes, anonymous visitors only get the public fields
+	routes.Get("/", middleware.JWTOptional(), handler.GetAllProduct)
+	routes.Get("/search", middleware.JWTOptional(), handler.SearchProducts)
+	routes.Get("/toko-saya", middleware.JWTProtected(), handler.GetMyProducts)
+	routes.Get("/slug/:slug", middleware.JWTOptional(), handler.ProductDetailBySlug)
+	routes.Get("/:id", middleware.JWTOptional(), handler.ProductDetail)
+	routes.Get("/category/slug/:slug", middleware.JWTOptional(), handler.GetProductsByCategorySlug)
+	routes.Get("/category/:category", middleware.JWTOptional(), handler.GetProductsByCategory)
+	routes.Get("/:id/related", middleware.JWTOptional(), handler.GetRelatedProducts)
 	routes.Get("/photo/:id", handler.ServeProductPhoto)
 
 	// Admin only routes
 	routes.Post("/", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.ProductCreate)
 	routes.Put("/:id", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.ProductUpdate)
 	routes.Delete("/:id", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.ProductDelete)
+	routes.Put("/:id/ban", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.ProductBan)
+	routes.Delete("/:id/ban", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.ProductUnban)
+
+	// Store owner or admin
+	routes.Put("/:id/status", middleware.JWTProtected(), handler.ProductUpdateStatus)
 }
 
 func (handler *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
-	limit := 10
-	page := 1
-	keyword := ""
+	limit, page, filter, err := parseProductListQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
 
-	if c.Query("limit") != "" {
-		if val, err := strconv.Atoi(c.Query("limit")); err == nil {
-			limit = val
-		}
+	// Admins may list products in any status for moderation
+	filter.AnyStatus = filter.Status != "" && productViewer(c).IsAdmin
+
+	responses, err := handler.ProductService.FindAllPagination(limit, page, filter)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
 	}
 
-	if c.Query("page") != "" {
-		if val, err := strconv.Atoi(c.Query("page")); err == nil {
-			page = val
+	responses.Rows = handler.viewerRows(c, responses.Rows)
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    responses,
+	})
+}
+
+func (handler *ProductHandler) GetProductsByCategorySlug(c *fiber.Ctx) error {
+	limit, page, filter, err := parseProductListQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	responses, err := handler.ProductService.FindByCategorySlug(c.Params("slug"), limit, page, filter)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
 		}
+		return c.Status(status).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	responses.Rows = handler.viewerRows(c, responses.Rows)
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    responses,
+	})
+}
+
+// GetMyProducts lists the products of the caller's store in every status, e.g. ?status=draft
+func (handler *ProductHandler) GetMyProducts(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
 	}
 
-	if q := c.Query("q"); q != "" {
-		keyword = q
+	limit, page, filter, err := parseProductListQuery(c)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
 	}
 
-	responses, err := handler.ProductService.FindAllPagination(limit, page, keyword)
+	responses, err := handler.ProductService.FindMine(uint(claims.UserId), limit, page, filter)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
@@ -81,6 +165,126 @@ func (handler *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 	})
 }
 
+// isGuest reports whether the request was made without a login token
+func isGuest(c *fiber.Ctx) bool {
+	_, err := jwt.ExtractTokenMetadata(c)
+	return err != nil
+}
+
+// viewerProduct hides the fields guests may not see, and shows an approved
+// reseller the prices they pay
+func (handler *ProductHandler) viewerProduct(c *fiber.Ctx, product models.ProductResponse) models.ProductResponse {
+	if isGuest(c) {
+		return formatter.PublicProduct(product)
+	}
+	return handler.ResellerService.PriceProduct(productViewer(c).UserID, product)
+}
+
+func (handler *ProductHandler) viewerProducts(c *fiber.Ctx, products []models.ProductResponse) []models.ProductResponse {
+	if isGuest(c) {
+		return formatter.PublicProducts(products)
+	}
+	if products == nil {
+		return nil
+	}
+	return handler.ResellerService.PriceProductRows(productViewer(c).UserID, products).([]models.ProductResponse)
+}
+
+// viewerRows applies viewerProduct to the rows of a product page
+func (handler *ProductHandler) viewerRows(c *fiber.Ctx, rows interface{}) interface{} {
+	if isGuest(c) {
+		return formatter.PublicProductRows(rows)
+	}
+	return handler.ResellerService.PriceProductRows(productViewer(c).UserID, rows)
+}
+
+// productViewer identifies the caller for the product visibility rules; guests get the zero value
+func productViewer(c *fiber.Ctx) models.ProductViewer {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return models.ProductViewer{}
+	}
+	return models.ProductViewer{UserID: uint(claims.UserId), IsAdmin: claims.IsAdmin}
+}
+
+// productNotFoundStatus maps a product lookup error to 404 or 400
+func productNotFoundStatus(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) || strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+// parseProductListQuery reads the pagination and filter parameters of the product listing
+func parseProductListQuery(c *fiber.Ctx) (int, int, models.ProductFilter, error) {
+	limit := 10
+	page := 1
+	filter := models.ProductFilter{
+		Keyword:     c.Query("q"),
+		Sort:        c.Query("sort"),
+		Status:      c.Query("status"),
+		InStock:     c.Query("stok_tersedia") == "true",
+		HasDiscount: c.Query("diskon") == "true",
+	}
+
+	if c.Query("limit") != "" {
+		if val, err := strconv.Atoi(c.Query("limit")); err == nil {
+			limit = val
+		}
+	}
+
+	if c.Query("page") != "" {
+		if val, err := strconv.Atoi(c.Query("page")); err == nil {
+			page = val
+		}
+	}
+
+	if err := parseProductFilter(c, &filter); err != nil {
+		return 0, 0, models.ProductFilter{}, err
+	}
+	return limit, page, filter, nil
+}
+
+// parseProductFilter reads the numeric filter parameters of the product listing
+func parseProductFilter(c *fiber.Ctx, filter *models.ProductFilter) error {
+	if value := c.Query("category"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid category: %s", value)
+		}
+		filter.CategoryID = uint(id)
+	}
+	if value := c.Query("toko"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid toko: %s", value)
+		}
+		filter.StoreID = uint(id)
+	}
+	if value := c.Query("harga_min"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil || price < 0 {
+			return fmt.Errorf("invalid harga_min: %s", value)
+		}
+		filter.MinPrice = &price
+	}
+	if value := c.Query("harga_max"); value != "" {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil || price < 0 {
+			return fmt.Errorf("invalid harga_max: %s", value)
+		}
+		filter.MaxPrice = &price
+	}
+	if value := c.Query("rating_min"); value != "" {
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rating_min: %s", value)
+		}
+		filter.MinRating = rating
+	}
+	return nil
+}
+
 func (handler *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 	query := c.Query("q")
 	if query == "" {
@@ -92,9 +296,18 @@ func (handler *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 		})
 	}
 
-	products, err := handler.ProductService.SearchProducts(query)
+	limit := 10
+	page := 1
+	if val, err := strconv.Atoi(c.Query("limit")); err == nil && val > 0 {
+		limit = val
+	}
+	if val, err := strconv.Atoi(c.Query("page")); err == nil && val > 0 {
+		page = val
+	}
+
+	products, err := handler.ProductService.SearchProducts(query, c.Query("mode"), limit, page)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to search products",
 			Error:   exceptions.NewString(err.Error()),
@@ -102,6 +315,8 @@ func (handler *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 		})
 	}
 
+	products.Rows = handler.viewerRows(c, products.Rows)
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Succeed to search products",
@@ -111,19 +326,19 @@ func (handler *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 }
 
 func (handler *ProductHandler) ProductDetail(c *fiber.Ctx) error {
-	_, err := jwt.ExtractTokenMetadata(c)
+	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
 			Status:  false,
-			Message: "Failed to POST data",
+			Message: "Failed to GET data",
 			Error:   exceptions.NewString(err.Error()),
 			Data:    nil,
 		})
 	}
 
-	id, err := c.ParamsInt("id")
+	response, err := handler.ProductService.FindVisibleById(uint(id), productViewer(c))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to GET data",
 			Error:   exceptions.NewString(err.Error()),
@@ -131,9 +346,20 @@ func (handler *ProductHandler) ProductDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := handler.ProductService.FindById(uint(id))
+	response = handler.viewerProduct(c, response)
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    response,
+	})
+}
+
+func (handler *ProductHandler) ProductDetailBySlug(c *fiber.Ctx) error {
+	response, redirectSlug, err := handler.ProductService.FindBySlug(c.Params("slug"), productViewer(c))
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to GET data",
 			Error:   exceptions.NewString(err.Error()),
@@ -141,6 +367,23 @@ func (handler *ProductHandler) ProductDetail(c *fiber.Ctx) error {
 		})
 	}
 
+	// The product was renamed, point the client at its current URL
+	if redirectSlug != "" {
+		location := "/api/v1/produk/slug/" + redirectSlug
+		c.Location(location)
+		return c.Status(http.StatusMovedPermanently).JSON(responder.ApiResponse{
+			Status:  true,
+			Message: "Product has moved",
+			Error:   nil,
+			Data: models.ProductSlugRedirect{
+				Slug:     redirectSlug,
+				Location: location,
+			},
+		})
+	}
+
+	response = handler.viewerProduct(c, response)
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Succeed to GET data",
@@ -201,6 +444,20 @@ func (handler *ProductHandler) ProductCreate(c *fiber.Ctx) error {
 		})
 	}
 
+	var publishAt *time.Time
+	if value := c.FormValue("jadwal_terbit"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Invalid jadwal_terbit, use RFC 3339 e.g. 2024-07-01T09:00:00+07:00",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+		publishAt = &parsed
+	}
+
 	files := form.File["photo_files"]
 	var photoURLs []interface{}
 
@@ -240,14 +497,23 @@ func (handler *ProductHandler) ProductCreate(c *fiber.Ctx) error {
 	}
 
 	input := models.ProductRequest{
-		NamaProduk:    c.FormValue("nama_produk"),
-		CategoryID:    uint(category_id),
-		StoreID:       uint(store_id), // Use the parsed store_id from form
-		HargaReseller: c.FormValue("harga_reseller"),
-		HargaKonsumen: c.FormValue("harga_konsumen"),
-		Stok:          stok,
-		Deskripsi:     c.FormValue("deskripsi"),
-		PhotoURLs:     photoURLs,
+		NamaProduk:       c.FormValue("nama_produk"),
+		Slug:             c.FormValue("slug"),
+		SKU:              strings.TrimSpace(c.FormValue("sku")),
+		CategoryID:       uint(category_id),
+		StoreID:          uint(store_id), // Use the parsed store_id from form
+		HargaReseller:    c.FormValue("harga_reseller"),
+		HargaKonsumen:    c.FormValue("harga_konsumen"),
+		MinOrderReseller: formInt(c, "min_order_reseller"),
+		Stok:             stok,
+		Berat:            formInt(c, "berat"),
+		Panjang:          formInt(c, "panjang"),
+		Lebar:            formInt(c, "lebar"),
+		Tinggi:           formInt(c, "tinggi"),
+		Deskripsi:        c.FormValue("deskripsi"),
+		Status:           c.FormValue("status"),
+		JadwalTerbit:     publishAt,
+		PhotoURLs:        photoURLs,
 	}
 
 	// Pass the user ID separately for authorization
@@ -372,15 +638,22 @@ func (handler *ProductHandler) ProductUpdate(c *fiber.Ctx) error {
 	}
 
 	input := models.ProductRequest{
-		NamaProduk:    c.FormValue("nama_produk"),
-		CategoryID:    uint(category_id),
-		StoreID:       uint(store_id),
-		HargaReseller: c.FormValue("harga_reseller"),
-		HargaKonsumen: c.FormValue("harga_konsumen"),
-		Stok:          stok,
-		Deskripsi:     c.FormValue("deskripsi"),
-		PhotoURLs:     photoURLs,
-		PhotoIDs:      []uint{photoID},
+		NamaProduk:       c.FormValue("nama_produk"),
+		Slug:             c.FormValue("slug"),
+		SKU:              strings.TrimSpace(c.FormValue("sku")),
+		CategoryID:       uint(category_id),
+		StoreID:          uint(store_id),
+		HargaReseller:    c.FormValue("harga_reseller"),
+		HargaKonsumen:    c.FormValue("harga_konsumen"),
+		MinOrderReseller: formInt(c, "min_order_reseller"),
+		Stok:             stok,
+		Berat:            formInt(c, "berat"),
+		Panjang:          formInt(c, "panjang"),
+		Lebar:            formInt(c, "lebar"),
+		Tinggi:           formInt(c, "tinggi"),
+		Deskripsi:        c.FormValue("deskripsi"),
+		PhotoURLs:        photoURLs,
+		PhotoIDs:         []uint{photoID},
 	}
 
 	response, err := handler.ProductService.Update(uint(id), input, uint(claims.UserId))
@@ -442,8 +715,8 @@ func (handler *ProductHandler) ProductDelete(c *fiber.Ctx) error {
 	})
 }
 
-func (handler *ProductHandler) GetProductsByCategory(c *fiber.Ctx) error {
-	_, err := jwt.ExtractTokenMetadata(c)
+func (handler *ProductHandler) ProductUpdateStatus(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
 	if err != nil {
 		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
 			Status:  false,
@@ -453,6 +726,117 @@ func (handler *ProductHandler) GetProductsByCategory(c *fiber.Ctx) error {
 		})
 	}
 
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.ProductStatusRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	response, err := handler.ProductService.UpdateStatus(uint(id), uint(claims.UserId), claims.IsAdmin, input)
+	if err != nil {
+		status := productNotFoundStatus(err)
+		if err.Error() == "forbidden" {
+			status = http.StatusForbidden
+		}
+		return c.Status(status).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Error:   nil,
+		Data:    response,
+	})
+}
+
+func (handler *ProductHandler) ProductBan(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var input models.ProductBanRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	response, err := handler.ProductService.Ban(uint(id), input.Alasan)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Error:   nil,
+		Data:    response,
+	})
+}
+
+func (handler *ProductHandler) ProductUnban(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	response, err := handler.ProductService.Unban(uint(id))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to DELETE data",
+		Error:   nil,
+		Data:    response,
+	})
+}
+
+func (handler *ProductHandler) GetProductsByCategory(c *fiber.Ctx) error {
 	categoryID := c.Params("category")
 	if categoryID == "" {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -473,6 +857,8 @@ func (handler *ProductHandler) GetProductsByCategory(c *fiber.Ctx) error {
 		})
 	}
 
+	products = handler.viewerProducts(c, products)
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Succeed to GET products by category",
@@ -502,6 +888,8 @@ func (handler *ProductHandler) GetRelatedProducts(c *fiber.Ctx) error {
 		})
 	}
 
+	relatedProducts = handler.viewerProducts(c, relatedProducts)
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Successfully retrieved related products",
@@ -538,3 +926,12 @@ func (handler *ProductHandler) ServeProductPhoto(c *fiber.Ctx) error {
 		Data:    photo,
 	})
 }
+
+// formInt reads an optional integer form field, defaulting to zero when absent or malformed
+func formInt(c *fiber.Ctx, key string) int {
+	val, err := strconv.Atoi(c.FormValue(key))
+	if err != nil {
+		return 0
+	}
+	return val
+}
@@ -25,7 +25,7 @@ func NewProductPromoHandler(service *services.ProductPromoService) ProductPromoH
 func (h *ProductPromoHandler) Route(app *fiber.App) {
 	routes := app.Group("/api/v1/promos")
 	routes.Get("/", h.GetAll)
-	routes.Delete("/clear", middleware.JWTProtected(), h.ClearAll) // Move this route before the /:id routes
+	routes.Delete("/clear", middleware.JWTProtected(), h.adminOnly, h.ClearAll) // Move this route before the /:id routes
 	routes.Get("/:id", h.GetById)
 	routes.Get("/product/:productId", h.GetByProductId)
 	routes.Post("/", middleware.JWTProtected(), h.Create)
@@ -33,6 +33,30 @@ func (h *ProductPromoHandler) Route(app *fiber.App) {
 	routes.Delete("/:id", middleware.JWTProtected(), h.Delete)
 }
 
+// adminOnly limits clearing every store's promos to admins
+func (h *ProductPromoHandler) adminOnly(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if !claims.IsAdmin {
+		return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Access denied: Admin only",
+			Error:   exceptions.NewString("forbidden access"),
+			Data:    nil,
+		})
+	}
+
+	return c.Next()
+}
+
 func (h *ProductPromoHandler) GetAll(c *fiber.Ctx) error {
 	promos, err := h.service.GetAll()
 	if err != nil {
@@ -114,6 +138,16 @@ func (h *ProductPromoHandler) Create(c *fiber.Ctx) error {
 	// Add debug logging
 	fmt.Println("Received request to create promo")
 
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
 	var input models.ProductPromoRequest
 	if err := c.BodyParser(&input); err != nil {
 		fmt.Printf("Error parsing request body: %v\n", err)
@@ -127,9 +161,9 @@ func (h *ProductPromoHandler) Create(c *fiber.Ctx) error {
 
 	fmt.Printf("Parsed request body: %+v\n", input)
 
-	promo, err := h.service.Create(input)
+	promo, err := h.service.Create(input, uint64(claims.UserId), claims.IsAdmin)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(promoErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to create promo",
 			Error:   exceptions.NewString(err.Error()),
@@ -176,9 +210,9 @@ func (h *ProductPromoHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
-	promo, err := h.service.Update(input, id, uint64(claims.UserId))
+	promo, err := h.service.Update(input, id, uint64(claims.UserId), claims.IsAdmin)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(promoErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to update promo",
 			Error:   exceptions.NewString(err.Error()),
@@ -195,6 +229,16 @@ func (h *ProductPromoHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *ProductPromoHandler) Delete(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
 	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -216,9 +260,9 @@ func (h *ProductPromoHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.service.Delete(id, 0)
+	err = h.service.Delete(id, uint64(claims.UserId), claims.IsAdmin)
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+		return c.Status(promoErrorStatus(err)).JSON(responder.ApiResponse{
 			Status:  false,
 			Message: "Failed to delete promo",
 			Error:   exceptions.NewString(err.Error()),
@@ -253,3 +297,11 @@ func (h *ProductPromoHandler) ClearAll(c *fiber.Ctx) error {
 		Data:    promos,
 	})
 }
+
+// promoErrorStatus maps "forbidden" to 403 and anything else to 400
+func promoErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReferralHandler struct {
+	service services.ReferralService
+}
+
+func NewReferralHandler(service services.ReferralService) *ReferralHandler {
+	return &ReferralHandler{service}
+}
+
+func (h *ReferralHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/referrals")
+
+	routes.Post("/", middleware.JWTProtected(), h.CreateLink)
+	routes.Get("/", middleware.JWTProtected(), h.GetLinks)
+	routes.Get("/earnings", middleware.JWTProtected(), h.GetEarnings) // Before the /:kode route
+	routes.Get("/:kode", h.Open)
+}
+
+func (h *ReferralHandler) CreateLink(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	// Without a product the reseller gets their general store-wide link
+	var request models.ReferralLinkRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Invalid request body",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+	}
+
+	link, err := h.service.CreateLink(uint(claims.UserId), request)
+	if err != nil {
+		return c.Status(referralErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create referral link",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Referral link ready to share",
+		Error:   nil,
+		Data:    link,
+	})
+}
+
+func (h *ReferralHandler) GetLinks(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	links, err := h.service.GetLinks(uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get referral links",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved referral links",
+		Error:   nil,
+		Data:    links,
+	})
+}
+
+// GetEarnings returns the commission ledger, e.g. ?status=tertunda
+func (h *ReferralHandler) GetEarnings(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	earnings, err := h.service.GetEarnings(uint(claims.UserId), c.Query("status"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get earnings",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved earnings",
+		Error:   nil,
+		Data:    earnings,
+	})
+}
+
+// Open is public: it is where a shared referral link leads
+func (h *ReferralHandler) Open(c *fiber.Ctx) error {
+	link, err := h.service.Open(c.Params("kode"))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to open referral link",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved referral link",
+		Error:   nil,
+		Data:    link,
+	})
+}
+
+func referralErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return productNotFoundStatus(err)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ResellerHandler struct {
+	service services.ResellerService
+}
+
+func NewResellerHandler(service services.ResellerService) *ResellerHandler {
+	return &ResellerHandler{service}
+}
+
+func (h *ResellerHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/resellers")
+	routes.Use(middleware.JWTProtected())
+
+	// Place the fixed routes before the /:id routes
+	routes.Post("/apply", h.Apply)
+	routes.Get("/me", h.GetMine)
+
+	routes.Get("/", h.adminOnly, h.GetAll)
+	routes.Get("/:id", h.adminOnly, h.GetById)
+	routes.Put("/:id/approve", h.adminOnly, h.Approve)
+	routes.Put("/:id/reject", h.adminOnly, h.Reject)
+	routes.Put("/:id/revoke", h.adminOnly, h.Revoke)
+}
+
+// adminOnly limits reviewing reseller applications to admins
+func (h *ResellerHandler) adminOnly(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if !claims.IsAdmin {
+		return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Access denied: Admin only",
+			Error:   exceptions.NewString("forbidden access"),
+			Data:    nil,
+		})
+	}
+
+	return c.Next()
+}
+
+func (h *ResellerHandler) Apply(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.ResellerApplyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	reseller, err := h.service.Apply(uint(claims.UserId), request)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to apply as reseller",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Reseller application sent",
+		Error:   nil,
+		Data:    reseller,
+	})
+}
+
+func (h *ResellerHandler) GetMine(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	reseller, err := h.service.GetMine(uint(claims.UserId))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get reseller application",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved reseller application",
+		Error:   nil,
+		Data:    reseller,
+	})
+}
+
+// GetAll lists the applications, e.g. ?status=menunggu for the review queue
+func (h *ResellerHandler) GetAll(c *fiber.Ctx) error {
+	resellers, err := h.service.GetAll(c.Query("status"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get reseller applications",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved reseller applications",
+		Error:   nil,
+		Data:    resellers,
+	})
+}
+
+func (h *ResellerHandler) GetById(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	reseller, err := h.service.GetById(uint(id))
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get reseller application",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved reseller application",
+		Error:   nil,
+		Data:    reseller,
+	})
+}
+
+func (h *ResellerHandler) Approve(c *fiber.Ctx) error {
+	return h.decide(c, "Reseller application approved", func(id uint, adminId uint, request models.ResellerDecisionRequest) (models.ResellerResponse, error) {
+		return h.service.Approve(id, adminId)
+	})
+}
+
+func (h *ResellerHandler) Reject(c *fiber.Ctx) error {
+	return h.decide(c, "Reseller application rejected", h.service.Reject)
+}
+
+func (h *ResellerHandler) Revoke(c *fiber.Ctx) error {
+	return h.decide(c, "Reseller status revoked", h.service.Revoke)
+}
+
+// decide runs an admin decision on the application in the :id parameter
+func (h *ResellerHandler) decide(c *fiber.Ctx, message string, action func(uint, uint, models.ResellerDecisionRequest) (models.ResellerResponse, error)) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	// The note is optional on approval, so an empty body is fine
+	var request models.ResellerDecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Invalid request body",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+	}
+
+	reseller, err := action(uint(id), uint(claims.UserId), request)
+	if err != nil {
+		return c.Status(productNotFoundStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update reseller application",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: message,
+		Error:   nil,
+		Data:    reseller,
+	})
+}
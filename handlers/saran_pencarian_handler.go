@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchSuggestionHandler struct {
+	service services.SearchSuggestionService
+}
+
+func NewSearchSuggestionHandler(service services.SearchSuggestionService) *SearchSuggestionHandler {
+	return &SearchSuggestionHandler{service}
+}
+
+func (h *SearchSuggestionHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/pencarian")
+
+	routes.Get("/saran", h.Suggest)
+	routes.Post("/saran/rebuild", middleware.JWTProtected(), middleware.RolePermissionAdmin(), h.Rebuild)
+}
+
+func (h *SearchSuggestionHandler) Suggest(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Search query is required",
+			Error:   exceptions.NewString("query parameter 'q' is missing"),
+			Data:    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    h.service.Suggest(query, limit),
+	})
+}
+
+func (h *SearchSuggestionHandler) Rebuild(c *fiber.Ctx) error {
+	if err := h.service.Rebuild(); err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to rebuild search index",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Search index rebuilt",
+		Error:   nil,
+		Data:    nil,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/services"
+	"mini-project-evermos/utils/formatter"
 	"mini-project-evermos/utils/jwt"
 	"net/http"
 	"strconv"
@@ -27,10 +28,10 @@ func (handler *StoreHandler) Route(app *fiber.App) {
 	fmt.Println("Registering store routes") // Add debug log
 	routes := app.Group("/api/v1/toko")
 
-	// GET endpoints - accessible by all authenticated users
-	routes.Get("/", middleware.JWTProtected(), handler.GetAllStore)
+	// Public GET endpoints, anonymous visitors only get the public fields
+	routes.Get("/", middleware.JWTOptional(), handler.GetAllStore)
 	routes.Get("/my", middleware.JWTProtected(), handler.MyStore)
-	routes.Get("/:id_toko", middleware.JWTProtected(), handler.StoreDetail)
+	routes.Get("/:id_toko", middleware.JWTOptional(), handler.StoreDetail)
 
 	// POST/PUT/DELETE endpoints - admin only
 	routes.Post("/", middleware.JWTProtected(), handler.adminOnly, handler.StoreCreate)
@@ -129,6 +130,12 @@ func (handler *StoreHandler) GetAllStore(c *fiber.Ctx) error {
 		})
 	}
 
+	if isGuest(c) {
+		for i, store := range responses.Rows {
+			responses.Rows[i] = formatter.PublicStoreDetail(store)
+		}
+	}
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Succeed to GET data",
@@ -138,20 +145,12 @@ func (handler *StoreHandler) GetAllStore(c *fiber.Ctx) error {
 }
 
 func (handler *StoreHandler) StoreDetail(c *fiber.Ctx) error {
-	//claim
-	claims, err := jwt.ExtractTokenMetadata(c)
-	if err != nil {
-		// Return status 500 and JWT parse error.
-		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
-			Status:  false,
-			Message: "Failed to GET data",
-			Error:   exceptions.NewString(err.Error()),
-			Data:    nil,
-		})
+	//claim, optional since store pages are public
+	var user_id int64
+	if claims, err := jwt.ExtractTokenMetadata(c); err == nil {
+		user_id = claims.UserId
 	}
 
-	user_id := claims.UserId
-
 	id, err := c.ParamsInt("id_toko")
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
@@ -172,6 +171,10 @@ func (handler *StoreHandler) StoreDetail(c *fiber.Ctx) error {
 			Data:    nil,
 		})
 	}
+	if isGuest(c) {
+		response = formatter.PublicStore(response)
+	}
+
 	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
 		Status:  true,
 		Message: "Succeed to GET data",
@@ -204,6 +207,8 @@ func (handler *StoreHandler) EditStore(c *fiber.Ctx) error {
 
 	name_store := c.FormValue("nama_toko")
 	deskripsi_toko := c.FormValue("deskripsi_toko")
+	id_provinsi := c.FormValue("id_provinsi")
+	id_kota := c.FormValue("id_kota")
 
 	// Handle file upload
 	var photo_path string
@@ -238,6 +243,8 @@ func (handler *StoreHandler) EditStore(c *fiber.Ctx) error {
 		UserID:        uint(user_id),
 		NamaToko:      name_store,
 		DeskripsiToko: deskripsi_toko,
+		IDProvinsi:    id_provinsi,
+		IDKota:        id_kota,
 		URL:           url_foto,
 		Photo:         photo_path,
 	}
@@ -276,6 +283,8 @@ func (handler *StoreHandler) StoreCreate(c *fiber.Ctx) error {
 	id_user_str := c.FormValue("id_user") // Add this line
 	nama_toko := c.FormValue("nama_toko")
 	deskripsi_toko := c.FormValue("deskripsi_toko") // Add this line
+	id_provinsi := c.FormValue("id_provinsi")
+	id_kota := c.FormValue("id_kota")
 	url_foto := c.FormValue("url_foto")
 	id_foto_str := c.FormValue("id_foto")
 	var photo_path string
@@ -333,6 +342,8 @@ func (handler *StoreHandler) StoreCreate(c *fiber.Ctx) error {
 		UserID:        input_user_id, // Use the parsed id_user
 		NamaToko:      nama_toko,
 		DeskripsiToko: deskripsi_toko, // Add this field
+		IDProvinsi:    id_provinsi,
+		IDKota:        id_kota,
 		URL:           url_foto,
 		Photo:         photo_path, // Add photo path
 		IdFoto:        id_foto,    // Now using uint type
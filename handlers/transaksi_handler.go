@@ -27,6 +27,7 @@ func (handler *TransactionHandler) Route(app *fiber.App) {
 
 	// Public routes (with JWT protection only)
 	routes.Get("/", middleware.JWTProtected(), handler.GetAllTransaction)
+	routes.Get("/invoice/:kode", middleware.JWTProtected(), handler.DetailTransactionByInvoice)
 	routes.Get("/:id", middleware.JWTProtected(), handler.DetailTransaction)
 
 	// Admin only routes
@@ -35,24 +36,6 @@ func (handler *TransactionHandler) Route(app *fiber.App) {
 	routes.Delete("/:id", middleware.JWTProtected(), middleware.RolePermissionAdmin(), handler.DeleteTransaction)
 }
 
-func cleanTransactionResponse(response models.TransactionResponse) models.TransactionResponse {
-	// If there are no transaction details, return response without that field
-	if len(response.TransactionDetails) == 0 {
-		return models.TransactionResponse{
-			ID:          response.ID,
-			UserID:      response.UserID, // Make sure to include UserID
-			HargaTotal:  response.HargaTotal,
-			KodeInvoice: response.KodeInvoice,
-			MethodBayar: response.MethodBayar,
-			Address:     response.Address,
-			CreatedAt:   response.CreatedAt,
-			UpdatedAt:   response.UpdatedAt,
-			// TransactionDetails is omitted intentionally
-		}
-	}
-	return response
-}
-
 func (handler *TransactionHandler) GetAllTransaction(c *fiber.Ctx) error {
 	// Default values
 	defaultLimit := 10
@@ -131,7 +114,52 @@ func (handler *TransactionHandler) DetailTransaction(c *fiber.Ctx) error {
 		Status:  true,
 		Message: "Succeed to GET data",
 		Error:   nil,
-		Data:    cleanTransactionResponse(response),
+		Data:    response,
+	})
+}
+
+func (handler *TransactionHandler) DetailTransactionByInvoice(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	response, err := handler.TransactionService.GetByInvoiceCode(c.Params("kode"), uint(claims.UserId))
+	if err != nil {
+		if err.Error() == "record not found" {
+			return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Transaction not found",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+		if err.Error() == "forbidden" {
+			return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+				Status:  false,
+				Message: "Forbidden",
+				Error:   exceptions.NewString(err.Error()),
+				Data:    nil,
+			})
+		}
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to GET data",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Error:   nil,
+		Data:    response,
 	})
 }
 
@@ -170,10 +198,28 @@ func (handler *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	// Use the user_id from the request body instead of claims
-	fmt.Printf("Request user_id: %d\n", input.UserID)
+	if input.Kurir == "" || input.Layanan == "" {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Kurir dan layanan pengiriman diperlukan",
+			Error:   exceptions.NewString("kurir and layanan are required"),
+			Data:    nil,
+		})
+	}
+
+	// The buyer is the signed-in user; reseller prices, vouchers, coupons and
+	// flash sale limits all go by the buyer
+	if input.UserID != 0 && input.UserID != uint(claims.UserId) {
+		return c.Status(http.StatusForbidden).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to POST data",
+			Error:   exceptions.NewString("user_id does not match the signed-in user"),
+			Data:    nil,
+		})
+	}
+	input.UserID = uint(claims.UserId)
 
-	response, err := handler.TransactionService.Create(input, input.UserID) // Use input.UserID instead of claims.UserId
+	response, err := handler.TransactionService.Create(input, uint(claims.UserId))
 	if err != nil {
 		fmt.Printf("Service Error: %v\n", err)
 		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
@@ -188,7 +234,7 @@ func (handler *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 		Status:  true,
 		Message: "Succeed to POST data",
 		Error:   nil,
-		Data:    cleanTransactionResponse(response),
+		Data:    response,
 	})
 }
 
@@ -255,7 +301,7 @@ func (handler *TransactionHandler) UpdateTransaction(c *fiber.Ctx) error {
 		Status:  true,
 		Message: "Transaction updated successfully",
 		Error:   nil,
-		Data:    cleanTransactionResponse(response),
+		Data:    response,
 	})
 }
 
@@ -307,6 +353,6 @@ func (handler *TransactionHandler) DeleteTransaction(c *fiber.Ctx) error {
 		Status:  true,
 		Message: "Successfully deleted transaction",
 		Error:   nil,
-		Data:    cleanTransactionResponse(transaction),
+		Data:    transaction,
 	})
 }
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StoreOrderHandler struct {
+	service services.StoreOrderService
+}
+
+func NewStoreOrderHandler(service services.StoreOrderService) *StoreOrderHandler {
+	return &StoreOrderHandler{service}
+}
+
+func (h *StoreOrderHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/pesanan-toko")
+	routes.Use(middleware.JWTProtected())
+
+	routes.Get("/toko-saya", h.GetMine)
+	routes.Get("/trx/:id", h.GetByTrxId)
+	routes.Get("/:id", h.GetById)
+	routes.Put("/:id/status", h.UpdateStatus)
+}
+
+func (h *StoreOrderHandler) GetMine(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeOrders, err := h.service.GetMine(uint(claims.UserId), c.Query("status"))
+	if err != nil {
+		return storeOrderError(c, "Failed to get store orders", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved store orders",
+		Error:   nil,
+		Data:    storeOrders,
+	})
+}
+
+func (h *StoreOrderHandler) GetByTrxId(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeOrders, err := h.service.GetByTrxId(uint(claims.UserId), uint(id))
+	if err != nil {
+		return storeOrderError(c, "Failed to get store orders", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved store orders",
+		Error:   nil,
+		Data:    storeOrders,
+	})
+}
+
+func (h *StoreOrderHandler) GetById(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeOrder, err := h.service.GetById(uint(claims.UserId), uint(id))
+	if err != nil {
+		return storeOrderError(c, "Failed to get store order", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved store order",
+		Error:   nil,
+		Data:    storeOrder,
+	})
+}
+
+func (h *StoreOrderHandler) UpdateStatus(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.StoreOrderStatusRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeOrder, err := h.service.UpdateStatus(uint(claims.UserId), uint(id), request)
+	if err != nil {
+		return storeOrderError(c, "Failed to update store order status", err)
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated store order status",
+		Error:   nil,
+		Data:    storeOrder,
+	})
+}
+
+func storeOrderError(c *fiber.Ctx, message string, err error) error {
+	status := http.StatusBadRequest
+	switch err.Error() {
+	case "record not found":
+		status = http.StatusNotFound
+	case "forbidden":
+		status = http.StatusForbidden
+	case "store order status has changed, please reload it":
+		status = http.StatusConflict
+	}
+
+	return c.Status(status).JSON(responder.ApiResponse{
+		Status:  false,
+		Message: message,
+		Error:   exceptions.NewString(err.Error()),
+		Data:    nil,
+	})
+}
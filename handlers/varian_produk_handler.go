@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/formatter"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProductVariantHandler struct {
+	service services.ProductVariantService
+}
+
+func NewProductVariantHandler(service services.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{service}
+}
+
+func (h *ProductVariantHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/produk/:id/varian")
+
+	routes.Get("/", middleware.JWTOptional(), h.GetByProductId)
+
+	// Admin only routes, matching product management
+	routes.Put("/opsi", middleware.JWTProtected(), middleware.RolePermissionAdmin(), h.SetOptions)
+	routes.Put("/:variantId", middleware.JWTProtected(), middleware.RolePermissionAdmin(), h.Update)
+}
+
+func (h *ProductVariantHandler) GetByProductId(c *fiber.Ctx) error {
+	// Anonymous visitors are allowed, only admins see deactivated combinations
+	isAdmin := false
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err == nil {
+		isAdmin = claims.IsAdmin
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	variants, err := h.service.GetByProductId(uint(id), isAdmin)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get product variants",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if isGuest(c) {
+		for i, variant := range variants.Varian {
+			variants.Varian[i] = formatter.PublicVariant(variant)
+		}
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved product variants",
+		Error:   nil,
+		Data:    variants,
+	})
+}
+
+func (h *ProductVariantHandler) SetOptions(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.VariantOptionsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	variants, err := h.service.SetOptions(uint(id), request, uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update variant options",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated variant options",
+		Error:   nil,
+		Data:    variants,
+	})
+}
+
+func (h *ProductVariantHandler) Update(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	variantID, err := c.ParamsInt("variantId")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid variant ID parameter",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.VariantUpdateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	variant, err := h.service.Update(uint(id), uint(variantID), request, uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update product variant",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated product variant",
+		Error:   nil,
+		Data:    variant,
+	})
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"mini-project-evermos/exceptions"
+	"mini-project-evermos/middleware"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/responder"
+	"mini-project-evermos/services"
+	"mini-project-evermos/utils/jwt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StoreVoucherHandler struct {
+	service services.StoreVoucherService
+}
+
+func NewStoreVoucherHandler(service services.StoreVoucherService) *StoreVoucherHandler {
+	return &StoreVoucherHandler{service}
+}
+
+func (h *StoreVoucherHandler) Route(app *fiber.App) {
+	routes := app.Group("/api/v1/store-vouchers")
+
+	// Place the fixed routes before the /:id routes
+	routes.Get("/wallet", middleware.JWTProtected(), h.GetWallet)
+	routes.Get("/store/:storeId", h.GetClaimable)
+	routes.Get("/store/:storeId/all", middleware.JWTProtected(), h.GetByStore)
+
+	routes.Get("/:id", h.GetById)
+	routes.Post("/", middleware.JWTProtected(), h.Create)
+	routes.Put("/:id", middleware.JWTProtected(), h.Update)
+	routes.Delete("/:id", middleware.JWTProtected(), h.Delete)
+	routes.Post("/:id/claim", middleware.JWTProtected(), h.Claim)
+}
+
+func (h *StoreVoucherHandler) GetClaimable(c *fiber.Ctx) error {
+	storeID, err := strconv.ParseUint(c.Params("storeId"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid store ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	vouchers, err := h.service.GetClaimable(uint(storeID))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get vouchers",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved vouchers",
+		Error:   nil,
+		Data:    vouchers,
+	})
+}
+
+func (h *StoreVoucherHandler) GetByStore(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	storeID, err := strconv.ParseUint(c.Params("storeId"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid store ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	vouchers, err := h.service.GetByStore(uint(storeID), uint(claims.UserId), claims.IsAdmin)
+	if err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get vouchers",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved vouchers",
+		Error:   nil,
+		Data:    vouchers,
+	})
+}
+
+func (h *StoreVoucherHandler) GetById(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	voucher, err := h.service.GetById(uint(id))
+	if err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get voucher",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved voucher",
+		Error:   nil,
+		Data:    voucher,
+	})
+}
+
+func (h *StoreVoucherHandler) Create(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.StoreVoucherRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	voucher, err := h.service.Create(request, uint(claims.UserId), claims.IsAdmin)
+	if err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to create voucher",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully created voucher",
+		Error:   nil,
+		Data:    voucher,
+	})
+}
+
+func (h *StoreVoucherHandler) Update(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	var request models.StoreVoucherRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid request body",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	voucher, err := h.service.Update(uint(id), request, uint(claims.UserId), claims.IsAdmin)
+	if err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to update voucher",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully updated voucher",
+		Error:   nil,
+		Data:    voucher,
+	})
+}
+
+func (h *StoreVoucherHandler) Delete(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	if err := h.service.Delete(uint(id), uint(claims.UserId), claims.IsAdmin); err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to delete voucher",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully deleted voucher",
+		Error:   nil,
+		Data:    nil,
+	})
+}
+
+func (h *StoreVoucherHandler) Claim(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Invalid ID format",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	claim, err := h.service.Claim(uint(id), uint(claims.UserId))
+	if err != nil {
+		return c.Status(voucherErrorStatus(err)).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to claim voucher",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.Status(http.StatusCreated).JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully claimed voucher",
+		Error:   nil,
+		Data:    claim,
+	})
+}
+
+func (h *StoreVoucherHandler) GetWallet(c *fiber.Ctx) error {
+	claims, err := jwt.ExtractTokenMetadata(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Unauthorized",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	wallet, err := h.service.GetWallet(uint(claims.UserId))
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(responder.ApiResponse{
+			Status:  false,
+			Message: "Failed to get voucher wallet",
+			Error:   exceptions.NewString(err.Error()),
+			Data:    nil,
+		})
+	}
+
+	return c.JSON(responder.ApiResponse{
+		Status:  true,
+		Message: "Successfully retrieved voucher wallet",
+		Error:   nil,
+		Data:    wallet,
+	})
+}
+
+// voucherErrorStatus maps "forbidden" to 403, a missing voucher or store to 404
+// and anything else to 400
+func voucherErrorStatus(err error) int {
+	if err.Error() == "forbidden" {
+		return http.StatusForbidden
+	}
+	return productNotFoundStatus(err)
+}
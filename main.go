@@ -11,10 +11,12 @@ import (
 	"mini-project-evermos/repositories"
 	"mini-project-evermos/services"
 	"mini-project-evermos/utils" // Add this line
+	"mini-project-evermos/utils/courier"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -62,6 +64,7 @@ func main() {
 	storeRepository := repositories.NewStoreRepository(database)
 	storePhotoRepository := repositories.NewStorePhotoRepository(database)
 	productRepository := repositories.NewProductRepository(database)
+	productVariantRepository := repositories.NewProductVariantRepository(database)
 	fotoProdukRepository := repositories.NewFotoProdukRepository(database)
 	transactionRepository := repositories.NewTransactionRepository(database)
 	productLogRepository := repositories.NewProductLogRepository(database)
@@ -74,16 +77,50 @@ func main() {
 	diskonProdukRepo := repositories.NewDiskonProdukRepository(database)
 	orderRepository := repositories.NewOrderRepository(database)
 	couponRepository := repositories.NewProductCouponRepository(database)
+	shippingRateRepository := repositories.NewShippingRateRepository(database)
+	shipmentRepository := repositories.NewShipmentRepository(database)
+	storeOrderRepository := repositories.NewStoreOrderRepository(database)
+	invoiceSequenceRepository := repositories.NewInvoiceSequenceRepository(database)
+	searchSuggestionRepository := repositories.NewSearchSuggestionRepository(database)
+	priceStockHistoryRepository := repositories.NewPriceStockHistoryRepository(database)
+	stockMovementRepository := repositories.NewStockMovementRepository(database)
+	stockSubscriptionRepository := repositories.NewStockSubscriptionRepository(database)
+	warehouseRepository := repositories.NewWarehouseRepository(database)
+	flashSaleRepository := repositories.NewFlashSaleRepository(database)
+	storeVoucherRepository := repositories.NewStoreVoucherRepository(database)
+	resellerRepository := repositories.NewResellerRepository(database)
+	referralRepository := repositories.NewReferralRepository(database)
 
 	// Initialize services
 	regionService := services.NewRegionService()
+	searchSuggestionService := services.NewSearchSuggestionService(searchSuggestionRepository)
+	if err := searchSuggestionService.Rebuild(); err != nil {
+		log.Printf("Failed to build search suggestion index: %v", err)
+	}
 	userService := services.NewUserService(&userRepository)
 	authService := services.NewAuthService(&authRepository, &userRepository)
 	addressService := services.NewAddressService(&addressRepository, &userRepository, &regionService)
-	categoryService := services.NewCategoryService(&categoryRepository)
-	storeService := services.NewStoreService(&storeRepository, &storePhotoRepository)
+	categoryService := services.NewCategoryService(&categoryRepository, searchSuggestionService)
+	storeService := services.NewStoreService(&storeRepository, &storePhotoRepository, searchSuggestionService)
 	storePhotoService := services.NewStorePhotoService(storePhotoRepository)
-	productService := services.NewProductService(productRepository, storeRepository, categoryRepository)
+	productVariantService := services.NewProductVariantService(productVariantRepository, productRepository)
+	productService := services.NewProductService(productRepository, storeRepository, categoryRepository, productVariantRepository, searchSuggestionService)
+	productImportService := services.NewProductImportService(productRepository, storeRepository, categoryRepository, searchSuggestionService)
+	priceStockService := services.NewPriceStockService(priceStockHistoryRepository, productRepository, productVariantRepository, storeRepository)
+	stockMovementService := services.NewStockMovementService(stockMovementRepository, productRepository, productVariantRepository)
+	if _, err := stockMovementService.OpenBalances(); err != nil {
+		log.Printf("Failed to open stock ledger balances: %v", err)
+	}
+	stockAlertService := services.NewStockAlertService(stockSubscriptionRepository, productRepository, productVariantRepository)
+	warehouseService := services.NewWarehouseService(warehouseRepository, storeRepository, productRepository, productVariantRepository)
+	shippingService := services.NewShippingService(shippingRateRepository, productRepository, addressRepository, warehouseRepository)
+	invoiceNumberService := services.NewInvoiceNumberService(invoiceSequenceRepository)
+	couponService := services.NewProductCouponService(couponRepository, productRepository, categoryRepository, productVariantRepository)
+	promoService := services.NewProductPromoService(promoRepository, productRepository, storeRepository)
+	flashSaleService := services.NewFlashSaleService(flashSaleRepository, productRepository, productVariantRepository)
+	storeVoucherService := services.NewStoreVoucherService(storeVoucherRepository, storeRepository)
+	resellerService := services.NewResellerService(resellerRepository, productRepository)
+	referralService := services.NewReferralService(referralRepository, resellerRepository, productRepository)
 	transactionService := services.NewTransactionService(
 		&transactionRepository,
 		&productRepository,
@@ -91,18 +128,65 @@ func main() {
 		&userRepository, // Add user repository
 		&regionService,
 		&productLogRepository, // Add this
+		&shippingService,
+		&invoiceNumberService,
+		&productVariantRepository,
+		&couponService,
+		&promoService,
+		&flashSaleService,
+		&storeVoucherService,
+		&resellerService,
+		&referralService,
 	)
 	productLogService := services.NewProductLogService(&productLogRepository)
 	fotoProdukService := services.NewFotoProdukService(fotoProdukRepository, productRepository)
 	trxDetailService := services.NewTransactionDetailService(trxDetailRepo)
-	keranjangBelanjaService := services.NewKeranjangBelanjaService(&keranjangBelanjaRepository)
-	wishlistService := services.NewWishlistService(&wishlistRepo, &storeRepository, &productRepository)
+	keranjangBelanjaService := services.NewKeranjangBelanjaService(&keranjangBelanjaRepository, &productVariantRepository, &promoService, &resellerService)
+	wishlistService := services.NewWishlistService(&wishlistRepo, &storeRepository, &productRepository, &productVariantRepository)
 	productReviewService := services.NewProductReviewService(productReviewRepository, storeRepository)
 	notificationService := services.NewNotificationService(notificationRepository)
-	promoService := services.NewProductPromoService(promoRepository)
-	diskonProdukService := services.NewDiskonProdukService(diskonProdukRepo)
+	diskonProdukService := services.NewDiskonProdukService(diskonProdukRepo, productRepository)
+	if _, err := diskonProdukService.RestoreLegacyPrices(); err != nil {
+		log.Printf("Failed to restore prices of legacy discounts: %v", err)
+	}
+	if _, err := couponService.RestoreLegacyPrices(); err != nil {
+		log.Printf("Failed to restore prices of legacy coupons: %v", err)
+	}
+	if _, err := diskonProdukService.RefreshStatuses(); err != nil {
+		log.Printf("Failed to refresh discount statuses: %v", err)
+	}
 	orderService := services.NewOrderService(orderRepository, trxDetailRepo)
-	couponService := services.NewProductCouponService(couponRepository)
+	// The fake tracker makes up checkpoints and delivers after 60 hours, so it
+	// only stands in for couriers without an adapter when asked to, e.g. locally
+	var courierFallback courier.Tracker
+	if configuration.Get("COURIER_FAKE_TRACKING") == "true" {
+		courierFallback = courier.NewFakeTracker()
+	}
+	shipmentService := services.NewShipmentService(
+		shipmentRepository,
+		trxDetailRepo,
+		transactionRepository,
+		userRepository,
+		courier.NewRegistry(courierFallback),
+	)
+	storeOrderService := services.NewStoreOrderService(storeOrderRepository, transactionRepository, storeRepository, userRepository)
+	documentService := services.NewDocumentService(transactionRepository, storeOrderRepository, userRepository, regionService)
+
+	// Publish scheduled products and start or expire discounts once a minute;
+	// buyers already see them from their scheduled time, this only persists
+	// the status change
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := productService.PublishScheduled(); err != nil {
+				log.Printf("Failed to publish scheduled products: %v", err)
+			}
+			if _, err := diskonProdukService.RefreshStatuses(); err != nil {
+				log.Printf("Failed to refresh discount statuses: %v", err)
+			}
+		}
+	}()
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(&userService)
@@ -112,7 +196,13 @@ func main() {
 	categoryHandler := handlers.NewCategoryHandler(&categoryService)
 	storeHandler := handlers.NewStoreHandler(&storeService)
 	storePhotoHandler := handlers.NewStorePhotoHandler(storePhotoService)
-	productHandler := handlers.NewProductHandler(&productService)
+	productHandler := handlers.NewProductHandler(&productService, &resellerService)
+	productImportHandler := handlers.NewProductImportHandler(productImportService)
+	priceStockHandler := handlers.NewPriceStockHandler(priceStockService)
+	stockMovementHandler := handlers.NewStockMovementHandler(stockMovementService)
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlertService)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseService)
+	productVariantHandler := handlers.NewProductVariantHandler(productVariantService)
 	transactionHandler := handlers.NewTransactionHandler(&transactionService)
 	productLogHandler := handlers.NewProductLogHandler(&productLogService)
 	fotoProdukHandler := handlers.NewFotoProdukHandler(&fotoProdukService)
@@ -125,6 +215,15 @@ func main() {
 	diskonProdukHandler := handlers.NewDiskonProdukHandler(diskonProdukService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 	couponHandler := handlers.NewProductCouponHandler(couponService)
+	flashSaleHandler := handlers.NewFlashSaleHandler(flashSaleService)
+	storeVoucherHandler := handlers.NewStoreVoucherHandler(storeVoucherService)
+	resellerHandler := handlers.NewResellerHandler(resellerService)
+	referralHandler := handlers.NewReferralHandler(referralService)
+	shippingHandler := handlers.NewShippingHandler(shippingService)
+	shipmentHandler := handlers.NewShipmentHandler(shipmentService)
+	storeOrderHandler := handlers.NewStoreOrderHandler(storeOrderService)
+	documentHandler := handlers.NewDocumentHandler(documentService)
+	searchSuggestionHandler := handlers.NewSearchSuggestionHandler(searchSuggestionService)
 
 	// Setup Fiber
 	app := fiber.New(configs.NewFiberConfig())
@@ -163,7 +262,14 @@ func main() {
 	categoryHandler.Route(app, database) // Pass db here
 	storeHandler.Route(app)
 	storePhotoHandler.Route(app)
+	productImportHandler.Route(app)
 	productHandler.Route(app)
+	searchSuggestionHandler.Route(app)
+	productVariantHandler.Route(app)
+	priceStockHandler.Route(app)
+	stockMovementHandler.Route(app)
+	stockAlertHandler.Route(app)
+	warehouseHandler.Route(app)
 	transactionHandler.Route(app)
 	productLogHandler.Route(app)
 	fotoProdukHandler.Route(app)
@@ -176,6 +282,14 @@ func main() {
 	diskonProdukHandler.Route(app)
 	orderHandler.Route(app)
 	couponHandler.Route(app)
+	flashSaleHandler.Route(app)
+	storeVoucherHandler.Route(app)
+	resellerHandler.Route(app)
+	referralHandler.Route(app)
+	shippingHandler.Route(app)
+	shipmentHandler.Route(app)
+	storeOrderHandler.Route(app)
+	documentHandler.Route(app)
 
 	// Not Found Handler
 	app.Use(func(c *fiber.Ctx) error {
@@ -31,3 +31,16 @@ func jwtError(c *fiber.Ctx, err error) error {
 		Data:    nil,
 	})
 }
+
+// JWTOptional func for public routes: anonymous requests pass through, while a
+// request that does send a token must send a valid one.
+func JWTOptional() func(*fiber.Ctx) error {
+	protected := JWTProtected()
+
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return protected(c)
+	}
+}
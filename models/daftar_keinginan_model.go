@@ -3,21 +3,25 @@ package models
 import "time"
 
 type WishlistRequest struct {
-	ProductID uint `json:"product_id" form:"product_id"`
-	StoreID   uint `json:"store_id" form:"store_id"`
+	ProductID uint  `json:"product_id" form:"product_id"`
+	StoreID   uint  `json:"store_id" form:"store_id"`
+	VariantID *uint `json:"variant_id" form:"variant_id"`
 }
 
 type WishlistResponse struct {
-	ID       uint          `json:"id"`
-	IDToko   uint          `json:"id_toko"`
-	IDProduk uint          `json:"id_produk"`
-	Store    StoreResponse `json:"toko"`
+	ID       uint                    `json:"id"`
+	IDToko   uint                    `json:"id_toko"`
+	IDProduk uint                    `json:"id_produk"`
+	IDVarian *uint                   `json:"id_varian"`
+	Varian   *ProductVariantResponse `json:"varian"`
+	Store    StoreResponse           `json:"toko"`
 	Product  struct {
 		ID            uint                 `json:"id"`
 		NamaProduk    string               `json:"nama_produk"`
 		Slug          string               `json:"slug"`
 		HargaReseller string               `json:"harga_reseler"`
 		HargaKonsumen string               `json:"harga_konsumen"`
+		HargaCoret    string               `json:"harga_coret,omitempty"` // List price while a discount applies
 		Stok          int                  `json:"stok"`
 		Deskripsi     *string              `json:"deskripsi"`
 		FotoProduk    []FotoProdukResponse `json:"foto_produk"`
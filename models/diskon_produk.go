@@ -2,17 +2,33 @@ package models
 
 import "time"
 
+// DiskonProdukRequest schedules a discount of harga_konsumen percent, e.g. "10",
+// from mulai_pada (default now) until berakhir_pada
 type DiskonProdukRequest struct {
-	ProductID     uint   `json:"product_id" validate:"required"`
-	HargaKonsumen string `json:"harga_konsumen" validate:"required"`
+	ProductID     uint       `json:"product_id" validate:"required"`
+	HargaKonsumen string     `json:"harga_konsumen" validate:"required"`
+	MulaiPada     *time.Time `json:"mulai_pada"`
+	BerakhirPada  *time.Time `json:"berakhir_pada"`
 }
 
 type DiskonProdukResponse struct {
-	ID            uint      `json:"id"`
-	ProductID     uint      `json:"id_produk"`
-	HargaKonsumen string    `json:"diskon_produk"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint       `json:"id"`
+	ProductID     uint       `json:"id_produk"`
+	HargaKonsumen string     `json:"diskon_produk"`
+	Persen        float64    `json:"persen"`
+	MulaiPada     *time.Time `json:"mulai_pada"`
+	BerakhirPada  *time.Time `json:"berakhir_pada"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ActiveDiscountResponse is the discount behind the price of a product
+type ActiveDiscountResponse struct {
+	ID           uint       `json:"id"`
+	Persen       float64    `json:"persen"`
+	MulaiPada    *time.Time `json:"mulai_pada"`
+	BerakhirPada *time.Time `json:"berakhir_pada"`
 }
 
 type DiskonProduk struct {
@@ -5,11 +5,16 @@ import "time"
 type TrxDetail struct {
 	ID            uint       `json:"id" gorm:"primaryKey"`
 	IDTrx         uint       `json:"id_transaksi" gorm:"column:id_trx"`
+	IDTrxToko     *uint      `json:"id_trx_toko" gorm:"column:id_trx_toko;index"`
 	IDLogProduk   uint       `json:"id_log_produk"`
+	IDVarian      *uint      `json:"id_varian" gorm:"column:id_varian"`
 	IDToko        uint       `json:"id_toko"`
+	IDGudang      *uint      `json:"id_gudang" gorm:"column:id_gudang;index"` // Warehouse the line ships from
 	Kuantitas     int        `json:"kuantitas"`
 	HargaTotal    float64    `json:"harga_total"`
-	ProductStatus string     `json:"product_status"` // Make sure this matches your DB column
+	Diskon        float64    `json:"diskon" gorm:"column:diskon;default:0"`         // Promo and coupon discounts on the line, not taken off HargaTotal
+	Reseller      bool       `json:"reseller" gorm:"column:reseller;default:false"` // Sold at the reseller price of the product log
+	ProductStatus string     `json:"product_status"`                                // Make sure this matches your DB column
 	Store         Store      `json:"store" gorm:"foreignKey:IDToko"`
 	ProductLog    ProductLog `json:"product_log" gorm:"foreignKey:IDLogProduk"`
 	Transaction   Trx        `json:"transaction" gorm:"foreignKey:IDTrx"`
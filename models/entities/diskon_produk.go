@@ -1,16 +1,78 @@
 package entities
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Discount statuses, kept up to date by the scheduler. Prices do not depend
+// on them: a discount applies while now is within MulaiPada and BerakhirPada.
+const (
+	DiscountScheduled = "scheduled"
+	DiscountActive    = "active"
+	DiscountExpired   = "expired"
+)
 
 // Define field order in struct definition
 type DiskonProduk struct {
-	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	ProductID     uint      `gorm:"column:id_produk" json:"id_produk"`
-	HargaKonsumen string    `json:"diskon_produk"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProductID     uint       `gorm:"column:id_produk" json:"id_produk"`
+	HargaKonsumen string     `json:"diskon_produk"` // The percentage as entered, e.g. "10%"
+	Persen        float64    `gorm:"column:persen;not null;default:0" json:"persen"`
+	MulaiPada     *time.Time `gorm:"column:mulai_pada;index" json:"mulai_pada"`
+	BerakhirPada  *time.Time `gorm:"column:berakhir_pada;index" json:"berakhir_pada"` // Nil only for discounts made before they could expire
+	Status        string     `gorm:"column:status;size:20;not null;default:scheduled;index" json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 func (DiskonProduk) TableName() string {
 	return "diskon_produk"
 }
+
+// IsActive reports whether the discount applies at now
+func (diskon DiskonProduk) IsActive(now time.Time) bool {
+	if diskon.MulaiPada == nil || diskon.MulaiPada.After(now) {
+		return false
+	}
+	return diskon.BerakhirPada == nil || diskon.BerakhirPada.After(now)
+}
+
+// StatusAt is the status the discount has at now
+func (diskon DiskonProduk) StatusAt(now time.Time) string {
+	if diskon.IsActive(now) {
+		return DiscountActive
+	}
+	if diskon.BerakhirPada != nil && !diskon.BerakhirPada.After(now) {
+		return DiscountExpired
+	}
+	return DiscountScheduled
+}
+
+// Apply takes the discount off price, rounded to whole rupiah. A price that is
+// not a number is returned unchanged.
+func (diskon DiskonProduk) Apply(price string) string {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return price
+	}
+	return fmt.Sprintf("%.0f", value*(1-diskon.Persen/100))
+}
+
+// ActiveDiscount is the discount of the product that applies at now, the one
+// that started last when several do. Only the preloaded discounts are looked at.
+func (product Product) ActiveDiscount(now time.Time) *DiskonProduk {
+	var active *DiskonProduk
+	for i := range product.Diskon {
+		diskon := &product.Diskon[i]
+		if !diskon.IsActive(now) {
+			continue
+		}
+		if active == nil || diskon.MulaiPada.After(*active.MulaiPada) ||
+			(diskon.MulaiPada.Equal(*active.MulaiPada) && diskon.ID > active.ID) {
+			active = diskon
+		}
+	}
+	return active
+}
@@ -0,0 +1,96 @@
+package entities
+
+import "time"
+
+// Flash sale states as shown to buyers, worked out from the time window
+const (
+	FlashSaleUpcoming = "akan_datang"
+	FlashSaleLive     = "berlangsung"
+	FlashSaleEnded    = "berakhir"
+)
+
+// Purchase statuses; a released purchase gives its units back to the quota
+const (
+	FlashSaleReserved = "dipesan"
+	FlashSaleReleased = "dibatalkan"
+)
+
+// FlashSale is a campaign selling a few products at a sale price within a time window
+type FlashSale struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	Nama         string          `json:"nama" gorm:"column:nama;size:255;not null"`
+	Deskripsi    string          `json:"deskripsi" gorm:"column:deskripsi;type:text"`
+	MulaiPada    time.Time       `json:"mulai_pada" gorm:"column:mulai_pada;not null;index"`
+	BerakhirPada time.Time       `json:"berakhir_pada" gorm:"column:berakhir_pada;not null;index"`
+	Aktif        bool            `json:"aktif" gorm:"column:aktif;not null;default:false"`
+	Items        []FlashSaleItem `json:"items" gorm:"foreignKey:IDFlashSale"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time      `json:"updated_at"`
+}
+
+func (FlashSale) TableName() string {
+	return "flash_sale"
+}
+
+// StatusAt is the state the sale has at now
+func (sale FlashSale) StatusAt(now time.Time) string {
+	if now.Before(sale.MulaiPada) {
+		return FlashSaleUpcoming
+	}
+	if now.Before(sale.BerakhirPada) {
+		return FlashSaleLive
+	}
+	return FlashSaleEnded
+}
+
+// IsLive reports whether the sale price applies at now
+func (sale FlashSale) IsLive(now time.Time) bool {
+	return sale.Aktif && sale.StatusAt(now) == FlashSaleLive
+}
+
+// FlashSaleItem is a product, or one variant of it, on sale with a limited quota
+type FlashSaleItem struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	IDFlashSale  uint            `json:"id_flash_sale" gorm:"column:id_flash_sale;not null;index"`
+	IDProduk     uint            `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian     *uint           `json:"id_varian" gorm:"column:id_varian;index"` // Nil for every variant of the product
+	HargaFlash   float64         `json:"harga_flash" gorm:"column:harga_flash;not null"`
+	Kuota        int             `json:"kuota" gorm:"column:kuota;not null"`
+	Terjual      int             `json:"terjual" gorm:"column:terjual;not null;default:0"`               // Units reserved by checkouts
+	BatasPerUser int             `json:"batas_per_user" gorm:"column:batas_per_user;not null;default:0"` // 0 for no limit
+	FlashSale    FlashSale       `json:"-" gorm:"foreignKey:IDFlashSale"`
+	Product      Product         `json:"product" gorm:"foreignKey:IDProduk"`
+	Variant      *ProductVariant `json:"variant" gorm:"foreignKey:IDVarian"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	UpdatedAt    *time.Time      `json:"updated_at"`
+}
+
+func (FlashSaleItem) TableName() string {
+	return "flash_sale_produk"
+}
+
+// Sisa is what is left of the quota
+func (item FlashSaleItem) Sisa() int {
+	if item.Terjual >= item.Kuota {
+		return 0
+	}
+	return item.Kuota - item.Terjual
+}
+
+// FlashSalePurchase records the units of a flash sale item a checkout line reserved
+type FlashSalePurchase struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	IDFlashSaleItem uint       `json:"id_flash_sale_item" gorm:"column:id_flash_sale_produk;not null;index"`
+	IDUser          uint       `json:"id_user" gorm:"column:id_user;not null;index"`
+	IDTrx           uint       `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	IDTrxToko       *uint      `json:"id_trx_toko" gorm:"column:id_trx_toko;index"`
+	IDTrxDetail     uint       `json:"id_trx_detail" gorm:"column:id_trx_detail;not null;index"`
+	Jumlah          int        `json:"jumlah" gorm:"column:jumlah;not null"`
+	Status          string     `json:"status" gorm:"column:status;size:20;not null;default:dipesan"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+func (FlashSalePurchase) TableName() string {
+	return "flash_sale_pembelian"
+}
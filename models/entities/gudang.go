@@ -0,0 +1,59 @@
+package entities
+
+import "time"
+
+// Warehouse is a place a store ships from. Once a store has a warehouse its
+// stock is split across its warehouses; the primary one (Utama) receives stock
+// that was not assigned to a warehouse, e.g. from an edit of the product.
+type Warehouse struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	IDToko     uint       `json:"id_toko" gorm:"column:id_toko;not null;index"`
+	Nama       string     `json:"nama" gorm:"column:nama;size:100;not null"`
+	Alamat     string     `json:"alamat" gorm:"column:alamat;size:255"`
+	IDProvinsi string     `json:"id_provinsi" gorm:"column:id_provinsi"`
+	IDKota     string     `json:"id_kota" gorm:"column:id_kota;not null"` // Origin city for shipping
+	Utama      bool       `json:"utama" gorm:"column:utama;not null;default:false"`
+	Aktif      bool       `json:"aktif" gorm:"column:aktif;not null;default:true"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+func (Warehouse) TableName() string {
+	return "gudang"
+}
+
+// WarehouseStock is the stock of a product, or of one of its variants when
+// IDVarian is set, in one warehouse. Like the ledger, products with active
+// variants keep their stock in the variants' rows.
+type WarehouseStock struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	IDGudang  uint       `json:"id_gudang" gorm:"column:id_gudang;not null;index"`
+	IDProduk  uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian  *uint      `json:"id_varian" gorm:"column:id_varian;index"`
+	Stok      int        `json:"stok" gorm:"column:stok;not null;default:0"`
+	UpdatedAt *time.Time `json:"updated_at"`
+	Product   Product    `json:"-" gorm:"foreignKey:IDProduk"`
+}
+
+func (WarehouseStock) TableName() string {
+	return "gudang_stok"
+}
+
+// WarehouseTransfer moves stock between two warehouses of a store. The stock
+// of the product does not change, so transfers are not part of the ledger.
+type WarehouseTransfer struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	IDToko         uint       `json:"id_toko" gorm:"column:id_toko;not null;index"`
+	IDGudangAsal   uint       `json:"id_gudang_asal" gorm:"column:id_gudang_asal;not null;index"`
+	IDGudangTujuan uint       `json:"id_gudang_tujuan" gorm:"column:id_gudang_tujuan;not null;index"`
+	IDProduk       uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian       *uint      `json:"id_varian" gorm:"column:id_varian"`
+	Jumlah         int        `json:"jumlah" gorm:"column:jumlah;not null"`
+	IDUser         uint       `json:"id_user" gorm:"column:id_user;not null"`
+	Catatan        string     `json:"catatan" gorm:"column:catatan;size:255"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+func (WarehouseTransfer) TableName() string {
+	return "gudang_transfer"
+}
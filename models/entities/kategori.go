@@ -9,7 +9,11 @@ import (
 type Category struct {
 	gorm.Model
 	ID           uint   `gorm:"primaryKey"`
+	IDParent     *uint  `gorm:"column:id_parent;index"`
 	NamaCategory string `gorm:"size:255;not null"`
+	Slug         string `gorm:"size:255;not null;uniqueIndex"`
+	Icon         string `gorm:"size:255"`
+	Urutan       int    `gorm:"not null;default:0"`
 	CreatedAt    *time.Time
 	UpdatedAt    *time.Time
 }
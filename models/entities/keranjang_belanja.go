@@ -5,14 +5,16 @@ import (
 )
 
 type KeranjangBelanja struct {
-	ID           uint      `gorm:"primaryKey;column:id"`
-	IDToko       uint      `gorm:"column:id_toko;not null"`
-	IDProduk     uint      `gorm:"column:id_produk;not null"`
-	JumlahProduk int       `gorm:"column:jumlah_produk;not null;default:1"`
-	CreatedAt    time.Time `gorm:"column:created_at"`
-	UpdatedAt    time.Time `gorm:"column:updated_at"`
-	Store        Store     `gorm:"foreignKey:IDToko"`
-	Product      Product   `gorm:"foreignKey:IDProduk"`
+	ID           uint            `gorm:"primaryKey;column:id"`
+	IDToko       uint            `gorm:"column:id_toko;not null"`
+	IDProduk     uint            `gorm:"column:id_produk;not null"`
+	IDVarian     *uint           `gorm:"column:id_varian"`
+	JumlahProduk int             `gorm:"column:jumlah_produk;not null;default:1"`
+	CreatedAt    time.Time       `gorm:"column:created_at"`
+	UpdatedAt    time.Time       `gorm:"column:updated_at"`
+	Store        Store           `gorm:"foreignKey:IDToko"`
+	Product      Product         `gorm:"foreignKey:IDProduk"`
+	Variant      *ProductVariant `gorm:"foreignKey:IDVarian"`
 }
 
 func (KeranjangBelanja) TableName() string {
@@ -2,20 +2,70 @@ package entities
 
 import "time"
 
+// Coupon kinds: Discount is a percentage or an amount in rupiah
+const (
+	CouponPercentage = "persen"
+	CouponFixed      = "nominal"
+)
+
+// Coupon scopes: the lines of a checkout a coupon discounts
+const (
+	CouponScopeProduct  = "produk"
+	CouponScopeStore    = "toko"
+	CouponScopeCategory = "kategori"
+	CouponScopeCart     = "keranjang"
+)
+
+// Redemption statuses; a released redemption no longer counts against the limits
+const (
+	CouponRedeemed = "terpakai"
+	CouponReleased = "dibatalkan"
+)
+
 type ProductCoupon struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	IDProduk  uint       `json:"id_produk" gorm:"column:id_produk;index:idx_product_code,unique"`
-	Product   Product    `json:"product" gorm:"foreignKey:IDProduk"`
-	Code      string     `json:"code" gorm:"column:kode_kupon;index:idx_product_code,unique"` // Changed to composite unique index
-	Discount  float64    `json:"discount" gorm:"column:diskon"`
-	ValidFrom time.Time  `json:"valid_from" gorm:"column:berlaku_dari"`
-	ValidTo   time.Time  `json:"valid_to" gorm:"column:berlaku_sampai"`
-	IsActive  bool       `json:"is_active" gorm:"column:aktif;default:true"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Code         string     `json:"code" gorm:"column:kode_kupon;size:50;uniqueIndex:idx_kupon_produk_kode_unik"` // Stored in upper case
+	Jenis        string     `json:"jenis" gorm:"column:jenis;size:20;not null;default:persen"`
+	Cakupan      string     `json:"cakupan" gorm:"column:cakupan;size:20;not null;default:produk"`
+	IDProduk     *uint      `json:"id_produk" gorm:"column:id_produk;index"`
+	IDToko       *uint      `json:"id_toko" gorm:"column:id_toko;index"`
+	IDCategory   *uint      `json:"id_category" gorm:"column:id_category;index"`
+	Discount     float64    `json:"discount" gorm:"column:diskon"`
+	MinBelanja   float64    `json:"min_belanja" gorm:"column:min_belanja;default:0"`       // Spend needed on the lines in scope
+	MaksDiskon   float64    `json:"maks_diskon" gorm:"column:maks_diskon;default:0"`       // Cap of a percentage discount, 0 for none
+	KuotaTotal   int        `json:"kuota_total" gorm:"column:kuota_total;default:0"`       // 0 for unlimited
+	KuotaPerUser int        `json:"kuota_per_user" gorm:"column:kuota_per_user;default:0"` // 0 for unlimited
+	Terpakai     int        `json:"terpakai" gorm:"column:terpakai;not null;default:0"`
+	ValidFrom    time.Time  `json:"valid_from" gorm:"column:berlaku_dari"`
+	ValidTo      time.Time  `json:"valid_to" gorm:"column:berlaku_sampai"`
+	IsActive     bool       `json:"is_active" gorm:"column:aktif;default:true"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
 }
 
 // TableName specifies the table name for GORM
 func (ProductCoupon) TableName() string {
 	return "kupon_produk"
 }
+
+// IsValidAt reports whether the coupon can be used at now, quotas aside
+func (coupon ProductCoupon) IsValidAt(now time.Time) bool {
+	return coupon.IsActive && !coupon.ValidFrom.After(now) && !coupon.ValidTo.Before(now)
+}
+
+// CouponRedemption records a coupon used by a checkout
+type CouponRedemption struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	IDKupon   uint       `json:"id_kupon" gorm:"column:id_kupon;not null;index"`
+	KodeKupon string     `json:"kode_kupon" gorm:"column:kode_kupon;size:50;not null"`
+	IDUser    uint       `json:"id_user" gorm:"column:id_user;not null;index"`
+	IDTrx     uint       `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	Diskon    float64    `json:"diskon" gorm:"column:diskon;not null"`
+	Status    string     `json:"status" gorm:"column:status;size:20;not null;default:terpakai"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func (CouponRedemption) TableName() string {
+	return "kupon_pemakaian"
+}
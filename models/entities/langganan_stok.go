@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// StockSubscription is a buyer's request to be told once a sold-out product,
+// or one of its variants, is back in stock. It fires once: DiberitahuPada is
+// set when the notification is sent.
+type StockSubscription struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	IDUser         uint       `json:"id_user" gorm:"column:id_user;not null;index"`
+	IDProduk       uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian       *uint      `json:"id_varian" gorm:"column:id_varian;index"`
+	DiberitahuPada *time.Time `json:"diberitahu_pada" gorm:"column:diberitahu_pada"`
+	CreatedAt      *time.Time `json:"created_at"`
+	Product        Product    `json:"-" gorm:"foreignKey:IDProduk"`
+}
+
+func (StockSubscription) TableName() string {
+	return "produk_langganan_stok"
+}
@@ -17,6 +17,9 @@ type ProductLog struct {
 	Deskripsi     *string    `json:"deskripsi" gorm:"column:deskripsi;type:text;default:null"`
 	IDToko        uint       `json:"id_toko" gorm:"column:id_toko;not null"`
 	IDCategory    uint       `json:"id_category" gorm:"column:id_category;not null"`
+	IDVarian      *uint      `json:"id_varian" gorm:"column:id_varian"`
+	SKU           string     `json:"sku" gorm:"column:sku;size:100"`
+	NamaVarian    string     `json:"nama_varian" gorm:"column:nama_varian;size:255"`
 	Kuantitas     int        `json:"kuantitas"`
 	HargaTotal    float64    `json:"harga_total"`
 	CreatedAt     *time.Time `json:"created_at"`
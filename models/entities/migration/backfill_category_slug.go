@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+// backfillCategorySlugs adds the slug column to an existing category table and
+// fills it before AutoMigrate creates the unique index, which would otherwise
+// fail on the empty slugs of the old rows
+func backfillCategorySlugs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.Category{}) || db.Migrator().HasColumn(&entities.Category{}, "slug") {
+		return nil
+	}
+
+	fmt.Println("Adding slug column to category...")
+	if err := db.Migrator().AddColumn(&entities.Category{}, "Slug"); err != nil {
+		return err
+	}
+
+	var categories []entities.Category
+	if err := db.Unscoped().Order("id").Find(&categories).Error; err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, category := range categories {
+		base := slug.Make(category.NamaCategory)
+		if base == "" {
+			base = "kategori"
+		}
+		candidate := base
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[candidate] = true
+
+		err := db.Unscoped().Model(&entities.Category{}).
+			Where("id = ?", category.ID).
+			Update("slug", candidate).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
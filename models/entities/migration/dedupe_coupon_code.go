@@ -0,0 +1,56 @@
+package migration
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// dedupeCouponCodes stores every existing coupon code in upper case and makes
+// them distinct before AutoMigrate creates the unique index on
+// kupon_produk.kode_kupon. Where coupons share a code the newest keeps it, as
+// checkout picked the newest; the older ones get their ID appended.
+func dedupeCouponCodes(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.ProductCoupon{}) || db.Migrator().HasIndex(&entities.ProductCoupon{}, "idx_kupon_produk_kode_unik") {
+		return nil
+	}
+
+	var coupons []entities.ProductCoupon
+	if err := db.Select("id", "kode_kupon").Order("id DESC").Find(&coupons).Error; err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, coupon := range coupons {
+		base := strings.ToUpper(strings.TrimSpace(coupon.Code))
+		if base == "" {
+			base = "KUPON"
+		}
+		candidate := base
+		if used[candidate] {
+			candidate = fmt.Sprintf("%s-%d", base, coupon.ID)
+		}
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s-%d-%d", base, coupon.ID, i)
+		}
+		used[candidate] = true
+
+		if candidate != coupon.Code {
+			fmt.Printf("Renaming coupon code %q to %q\n", coupon.Code, candidate)
+			err := db.Model(&entities.ProductCoupon{}).
+				Where("id = ?", coupon.ID).
+				Update("kode_kupon", candidate).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The unique index replaces the plain one
+	if db.Migrator().HasIndex(&entities.ProductCoupon{}, "idx_kupon_produk_kode_kupon") {
+		return db.Migrator().DropIndex(&entities.ProductCoupon{}, "idx_kupon_produk_kode_kupon")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package migration
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+
+	"gorm.io/gorm"
+)
+
+// dedupeInvoiceCodes gives every existing transaction a distinct invoice code
+// before AutoMigrate creates the unique index on trx.kode_invoice. The old
+// INV-<user>-<unix> codes collide when a user checked out twice in a second.
+func dedupeInvoiceCodes(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.Trx{}) || db.Migrator().HasIndex(&entities.Trx{}, "idx_trx_kode_invoice") {
+		return nil
+	}
+
+	var transactions []entities.Trx
+	if err := db.Select("id", "kode_invoice").Order("id").Find(&transactions).Error; err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, transaction := range transactions {
+		base := transaction.KodeInvoice
+		if base == "" {
+			base = fmt.Sprintf("INV-%d", transaction.ID)
+		}
+		candidate := base
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[candidate] = true
+
+		if candidate != transaction.KodeInvoice {
+			fmt.Printf("Renaming duplicate invoice code %q to %q\n", transaction.KodeInvoice, candidate)
+			err := db.Model(&entities.Trx{}).
+				Where("id = ?", transaction.ID).
+				Update("kode_invoice", candidate).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
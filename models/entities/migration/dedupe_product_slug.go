@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+// dedupeProductSlugs gives every existing product a distinct slug before
+// AutoMigrate creates the unique index on produk.slug
+func dedupeProductSlugs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entities.Product{}) || db.Migrator().HasIndex(&entities.Product{}, "idx_produk_slug") {
+		return nil
+	}
+
+	var products []entities.Product
+	if err := db.Unscoped().Select("id", "nama_produk", "slug").Order("id").Find(&products).Error; err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, product := range products {
+		base := product.Slug
+		if base == "" {
+			base = slug.Make(product.NamaProduk)
+		}
+		if base == "" {
+			base = "produk"
+		}
+		candidate := base
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[candidate] = true
+
+		if candidate != product.Slug {
+			fmt.Printf("Renaming duplicate product slug %q to %q\n", product.Slug, candidate)
+			err := db.Unscoped().Model(&entities.Product{}).
+				Where("id = ?", product.ID).
+				Update("slug", candidate).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
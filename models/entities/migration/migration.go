@@ -8,6 +8,19 @@ import (
 )
 
 func RunMigration(db *gorm.DB) {
+	if err := backfillCategorySlugs(db); err != nil {
+		log.Fatalf("Failed to backfill category slugs: %v", err)
+	}
+	if err := dedupeProductSlugs(db); err != nil {
+		log.Fatalf("Failed to deduplicate product slugs: %v", err)
+	}
+	if err := dedupeInvoiceCodes(db); err != nil {
+		log.Fatalf("Failed to deduplicate invoice codes: %v", err)
+	}
+	if err := dedupeCouponCodes(db); err != nil {
+		log.Fatalf("Failed to deduplicate coupon codes: %v", err)
+	}
+
 	// Create tables
 	tables := []interface{}{
 		&entities.User{},
@@ -17,7 +30,19 @@ func RunMigration(db *gorm.DB) {
 		&entities.StorePhoto{},
 		&entities.Product{},
 		&entities.FotoProduk{},
+		&entities.ProductSlugHistory{},
+		&entities.ProductVariantOption{},
+		&entities.ProductVariantOptionValue{},
+		&entities.ProductVariant{},
+		&entities.ProductPriceStockHistory{},
+		&entities.StockMovement{},
+		&entities.StockSubscription{},
+		&entities.Warehouse{},
+		&entities.WarehouseStock{},
+		&entities.WarehouseTransfer{},
+		&entities.InvoiceSequence{},
 		&entities.Trx{},
+		&entities.StoreOrder{},
 		&entities.TrxDetail{},
 		&entities.ProductLog{},
 		&entities.KeranjangBelanja{},
@@ -25,9 +50,23 @@ func RunMigration(db *gorm.DB) {
 		&entities.ProductReview{},
 		&entities.Notification{},
 		&entities.ProductPromo{},
+		&entities.ProductPromoTier{},
+		&entities.TransactionPromo{},
 		&entities.DiskonProduk{},
 		&entities.Order{},
 		&entities.ProductCoupon{},
+		&entities.CouponRedemption{},
+		&entities.FlashSale{},
+		&entities.FlashSaleItem{},
+		&entities.FlashSalePurchase{},
+		&entities.StoreVoucher{},
+		&entities.VoucherClaim{},
+		&entities.Reseller{},
+		&entities.ReferralLink{},
+		&entities.Commission{},
+		&entities.ShippingRate{},
+		&entities.Shipment{},
+		&entities.ShipmentCheckpoint{},
 	}
 
 	// Run migrations for all tables
@@ -38,6 +77,10 @@ func RunMigration(db *gorm.DB) {
 		}
 	}
 
+	if err := relaxPromoProduct(db); err != nil {
+		log.Fatalf("Failed to allow store-wide promos: %v", err)
+	}
+
 	// Create log_produk table with custom SQL
 	err := createLogProdukTable(db)
 	if err != nil {
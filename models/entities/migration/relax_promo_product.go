@@ -0,0 +1,25 @@
+package migration
+
+import (
+	"mini-project-evermos/models/entities"
+
+	"gorm.io/gorm"
+)
+
+// relaxPromoProduct lets product_promos.id_produk be NULL for store-wide
+// promos. AutoMigrate never drops a NOT NULL from an existing column.
+func relaxPromoProduct(db *gorm.DB) error {
+	columns, err := db.Migrator().ColumnTypes(&entities.ProductPromo{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "id_produk" {
+			continue
+		}
+		if nullable, ok := column.Nullable(); ok && !nullable {
+			return db.Migrator().AlterColumn(&entities.ProductPromo{}, "IDProduk")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// Stock movement kinds
+const (
+	StockMovementOpening      = "opening"
+	StockMovementSale         = "sale"
+	StockMovementCancellation = "cancellation"
+	StockMovementReturn       = "return"
+	StockMovementAdjustment   = "adjustment"
+	StockMovementImport       = "import"
+)
+
+// StockMovement is one entry of the stock ledger of a product, or of one of its
+// variants when IDVarian is set. Jumlah is signed: sales are negative, returns
+// and cancellations positive. The sum of Jumlah is the current stock. Rows are
+// never updated or deleted.
+type StockMovement struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	IDProduk    uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian    *uint      `json:"id_varian" gorm:"column:id_varian;index"`
+	IDGudang    *uint      `json:"id_gudang" gorm:"column:id_gudang;index"` // Warehouse the stock moved in, nil for stores without warehouses
+	Jenis       string     `json:"jenis" gorm:"column:jenis;size:20;not null;index"`
+	Jumlah      int        `json:"jumlah" gorm:"column:jumlah;not null"`
+	StokSebelum int        `json:"stok_sebelum" gorm:"column:stok_sebelum;not null"`
+	StokSesudah int        `json:"stok_sesudah" gorm:"column:stok_sesudah;not null"`
+	IDUser      *uint      `json:"id_user" gorm:"column:id_user"` // Nil for changes made by the system
+	Referensi   string     `json:"referensi" gorm:"column:referensi;size:100;index"`
+	Catatan     string     `json:"catatan" gorm:"column:catatan;size:255"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+func (StockMovement) TableName() string {
+	return "produk_mutasi_stok"
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// InvoiceSequence holds the last number issued for one invoice scope, e.g. a day or a store's day
+type InvoiceSequence struct {
+	Scope      string     `json:"scope" gorm:"column:scope;primaryKey;size:50"`
+	NomorAkhir int        `json:"nomor_akhir" gorm:"column:nomor_akhir;not null;default:0"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "nomor_urut_invoice"
+}
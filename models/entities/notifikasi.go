@@ -2,8 +2,18 @@ package entities
 
 import "time"
 
+// Notification kinds; general notifications have none
+const (
+	NotificationLowStock    = "low_stock"
+	NotificationBackInStock = "back_in_stock"
+	NotificationReseller    = "reseller"
+)
+
 type Notification struct {
 	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser    *uint      `json:"id_user" gorm:"column:id_user;index"` // Nil for a notification to every user
+	Jenis     string     `json:"jenis" gorm:"column:jenis;size:30"`
+	IDProduk  *uint      `json:"id_produk" gorm:"column:id_produk"`
 	Pesan     string     `json:"pesan" gorm:"type:text;not null"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// ShippingRate is one row of the local courier rate table. IDKotaTujuan may be
+// "*" to define a flat rate from the origin city to any destination.
+type ShippingRate struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Kurir        string     `json:"kurir" gorm:"column:kurir;size:50;not null;index:idx_tarif_ongkir_rute,unique"`
+	Layanan      string     `json:"layanan" gorm:"column:layanan;size:50;not null;index:idx_tarif_ongkir_rute,unique"`
+	IDKotaAsal   string     `json:"id_kota_asal" gorm:"column:id_kota_asal;size:20;not null;index:idx_tarif_ongkir_rute,unique"`
+	IDKotaTujuan string     `json:"id_kota_tujuan" gorm:"column:id_kota_tujuan;size:20;not null;index:idx_tarif_ongkir_rute,unique"`
+	HargaPerKg   float64    `json:"harga_per_kg" gorm:"column:harga_per_kg;not null"`
+	EstimasiHari string     `json:"estimasi_hari" gorm:"column:estimasi_hari;size:20"`
+	IsActive     bool       `json:"is_active" gorm:"column:aktif;default:true"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+func (ShippingRate) TableName() string {
+	return "tarif_ongkir"
+}
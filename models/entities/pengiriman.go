@@ -0,0 +1,39 @@
+package entities
+
+import "time"
+
+// Shipment is the parcel a seller sends for one trx_detail line
+type Shipment struct {
+	ID          uint                 `json:"id" gorm:"primaryKey"`
+	IDTrx       uint                 `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	IDTrxDetail uint                 `json:"id_trx_detail" gorm:"column:id_trx_detail;not null;uniqueIndex"`
+	Kurir       string               `json:"kurir" gorm:"column:kurir;size:50;not null"`
+	Layanan     string               `json:"layanan" gorm:"column:layanan;size:50"`
+	NoResi      string               `json:"no_resi" gorm:"column:no_resi;size:100;not null;index"`
+	Status      string               `json:"status" gorm:"column:status;size:30;not null"`
+	ShippedAt   time.Time            `json:"shipped_at" gorm:"column:shipped_at"`
+	DeliveredAt *time.Time           `json:"delivered_at" gorm:"column:delivered_at"`
+	Checkpoints []ShipmentCheckpoint `json:"checkpoints" gorm:"foreignKey:IDPengiriman"`
+	CreatedAt   *time.Time           `json:"created_at"`
+	UpdatedAt   *time.Time           `json:"updated_at"`
+}
+
+func (Shipment) TableName() string {
+	return "pengiriman"
+}
+
+// ShipmentCheckpoint is one entry of a shipment's tracking timeline
+type ShipmentCheckpoint struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	IDPengiriman uint       `json:"id_pengiriman" gorm:"column:id_pengiriman;not null;index"`
+	Status       string     `json:"status" gorm:"column:status;size:30;not null"`
+	Keterangan   string     `json:"keterangan" gorm:"column:keterangan;type:text"`
+	Lokasi       string     `json:"lokasi" gorm:"column:lokasi;size:255"`
+	Sumber       string     `json:"sumber" gorm:"column:sumber;size:20;not null"` // seller or courier
+	Waktu        time.Time  `json:"waktu" gorm:"column:waktu;not null"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+func (ShipmentCheckpoint) TableName() string {
+	return "pengiriman_riwayat"
+}
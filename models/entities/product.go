@@ -8,24 +8,54 @@ import (
 
 type Product struct {
 	gorm.Model
-	ID            uint    `gorm:"primaryKey"`
-	NamaProduk    string  `gorm:"size:255;not null"`
-	Slug          string  `gorm:"size:255;not null"`
-	HargaReseller string  `gorm:"size:255;not null"`
-	HargaKonsumen string  `gorm:"size:255;not null"`
-	HargaOriginal string  `gorm:"size:255;not null"`
-	Stok          int     `gorm:"not null"`
-	Deskripsi     *string `gorm:"type:text;default:null"`
-	IDToko        uint    `gorm:"not null"`
-	IDCategory    uint    `gorm:"not null"`
-	CreatedAt     *time.Time
-	UpdatedAt     *time.Time
-	Store         Store           `gorm:"foreignKey:IDToko;references:ID"`
-	Category      Category        `gorm:"foreignKey:IDCategory;references:ID"`
-	FotoProduk    []FotoProduk    `json:"foto_produk" gorm:"foreignKey:IDProduk"`
-	Reviews       []ProductReview `json:"reviews" gorm:"foreignKey:IDProduk"`
-	Promos        []ProductPromo  `json:"promos" gorm:"foreignKey:IDProduk"`
-	Coupons       []ProductCoupon `json:"coupons" gorm:"foreignKey:IDProduk"`
+	ID               uint       `gorm:"primaryKey"`
+	NamaProduk       string     `gorm:"size:255;not null;index:idx_produk_nama_fulltext,class:FULLTEXT;index:idx_produk_fulltext,class:FULLTEXT,priority:1"`
+	Slug             string     `gorm:"size:255;not null;uniqueIndex:idx_produk_slug"`
+	SKU              *string    `gorm:"column:sku;size:100;uniqueIndex:idx_produk_toko_sku,priority:2"` // Seller's own code, unique per store
+	HargaReseller    string     `gorm:"size:255;not null"`
+	HargaKonsumen    string     `gorm:"size:255;not null"`
+	HargaOriginal    string     `gorm:"size:255;not null"`
+	MinOrderReseller int        `gorm:"column:min_order_reseller;not null;default:0"` // Units a reseller must order to get HargaReseller, 0 for no minimum
+	Stok             int        `gorm:"not null"`
+	BatasStok        *int       `gorm:"column:batas_stok"`  // Low-stock threshold; the store owner is notified when the stock falls to it
+	Berat            int        `gorm:"not null;default:0"` // Weight in grams
+	Panjang          int        `gorm:"not null;default:0"` // Dimensions in centimeters
+	Lebar            int        `gorm:"not null;default:0"`
+	Tinggi           int        `gorm:"not null;default:0"`
+	Deskripsi        *string    `gorm:"type:text;default:null;index:idx_produk_fulltext,class:FULLTEXT,priority:2"`
+	IDToko           uint       `gorm:"not null;uniqueIndex:idx_produk_toko_sku,priority:1"`
+	IDCategory       uint       `gorm:"not null"`
+	Status           string     `gorm:"size:20;not null;default:published;index"`
+	JadwalTerbit     *time.Time // Scheduled publish time of a draft
+	AlasanBlokir     string     `gorm:"size:500"` // Reason given by the admin who banned the product
+	CreatedAt        *time.Time
+	UpdatedAt        *time.Time
+	Store            Store                  `gorm:"foreignKey:IDToko;references:ID"`
+	Category         Category               `gorm:"foreignKey:IDCategory;references:ID"`
+	FotoProduk       []FotoProduk           `json:"foto_produk" gorm:"foreignKey:IDProduk"`
+	Reviews          []ProductReview        `json:"reviews" gorm:"foreignKey:IDProduk"`
+	Promos           []ProductPromo         `json:"promos" gorm:"foreignKey:IDProduk"`
+	Coupons          []ProductCoupon        `json:"coupons" gorm:"foreignKey:IDProduk"`
+	Diskon           []DiskonProduk         `json:"diskon" gorm:"foreignKey:ProductID"`
+	VariantOptions   []ProductVariantOption `json:"opsi_varian" gorm:"foreignKey:IDProduk"`
+	Variants         []ProductVariant       `json:"varian" gorm:"foreignKey:IDProduk"`
+}
+
+// Product statuses
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+	ProductStatusBanned    = "banned"
+)
+
+// IsPublished reports whether buyers can see and buy the product at now,
+// counting a draft whose scheduled publish time has passed as published
+func (product Product) IsPublished(now time.Time) bool {
+	if product.Status == ProductStatusPublished {
+		return true
+	}
+	return product.Status == ProductStatusDraft && product.JadwalTerbit != nil && !product.JadwalTerbit.After(now)
 }
 
 func (Product) TableName() string {
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// ProductSlugHistory keeps the previous slugs of a renamed product so old URLs can redirect
+type ProductSlugHistory struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	IDProduk  uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	Slug      string     `json:"slug" gorm:"size:255;not null;uniqueIndex"`
+	CreatedAt *time.Time `json:"created_at"`
+	Product   Product    `json:"-" gorm:"foreignKey:IDProduk"`
+}
+
+func (ProductSlugHistory) TableName() string {
+	return "produk_slug_riwayat"
+}
@@ -2,17 +2,75 @@ package entities
 
 import "time"
 
+// Promotion rule kinds. A promo without a kind is only a text shown with the product.
+const (
+	PromoBuyGet       = "beli_gratis"   // Buy BeliJumlah, get GratisJumlah more of the product free
+	PromoBundle       = "bundling"      // BeliJumlah units of the product for HargaPaket
+	PromoTiered       = "grosir"        // A percentage off by quantity, see ProductPromoTier
+	PromoFreeShipping = "gratis_ongkir" // The store's shipping fee, up to MaksOngkir
+)
+
 type ProductPromo struct {
-	ID        uint64     `json:"id" gorm:"primaryKey;autoIncrement;type:bigint unsigned"`
-	IDToko    uint64     `json:"id_toko" gorm:"column:id_toko;not null;type:bigint unsigned"`
-	IDProduk  uint       `json:"id_produk" gorm:"column:id_produk;not null;type:int unsigned"`
-	Promo     string     `json:"promo" gorm:"column:promo;type:text;not null"`
-	Store     Store      `json:"store" gorm:"foreignKey:IDToko;references:ID"`
-	Product   Product    `json:"product" gorm:"foreignKey:IDProduk;references:ID"`
-	CreatedAt *time.Time `json:"created_at" gorm:"<-:create"`
-	UpdatedAt *time.Time `json:"updated_at" gorm:"<-:create;autoUpdateTime"`
+	ID           uint64             `json:"id" gorm:"primaryKey;autoIncrement;type:bigint unsigned"`
+	IDToko       uint64             `json:"id_toko" gorm:"column:id_toko;not null;type:bigint unsigned"`
+	IDProduk     *uint              `json:"id_produk" gorm:"column:id_produk;type:int unsigned"` // Nil for a store-wide free shipping promo
+	Promo        string             `json:"promo" gorm:"column:promo;type:text;not null"`
+	Jenis        string             `json:"jenis" gorm:"column:jenis;size:20;not null;default:''"`
+	BeliJumlah   int                `json:"beli_jumlah" gorm:"column:beli_jumlah;default:0"`
+	GratisJumlah int                `json:"gratis_jumlah" gorm:"column:gratis_jumlah;default:0"`
+	HargaPaket   float64            `json:"harga_paket" gorm:"column:harga_paket;default:0"`
+	MinBelanja   float64            `json:"min_belanja" gorm:"column:min_belanja;default:0"` // Spend needed for free shipping
+	MaksOngkir   float64            `json:"maks_ongkir" gorm:"column:maks_ongkir;default:0"` // Cap of free shipping, 0 for none
+	MulaiPada    *time.Time         `json:"mulai_pada" gorm:"column:mulai_pada;index"`
+	BerakhirPada *time.Time         `json:"berakhir_pada" gorm:"column:berakhir_pada;index"`
+	Aktif        bool               `json:"aktif" gorm:"column:aktif;not null;default:false"`
+	Tingkat      []ProductPromoTier `json:"tingkat" gorm:"foreignKey:IDPromo"`
+	Store        Store              `json:"store" gorm:"foreignKey:IDToko;references:ID"`
+	Product      Product            `json:"product" gorm:"foreignKey:IDProduk;references:ID"`
+	CreatedAt    *time.Time         `json:"created_at" gorm:"<-:create"`
+	UpdatedAt    *time.Time         `json:"updated_at" gorm:"<-:create;autoUpdateTime"`
 }
 
 func (ProductPromo) TableName() string {
 	return "product_promos"
 }
+
+// IsRunning reports whether the promo is evaluated at checkout at now
+func (promo ProductPromo) IsRunning(now time.Time) bool {
+	if promo.Jenis == "" || !promo.Aktif {
+		return false
+	}
+	if promo.MulaiPada != nil && promo.MulaiPada.After(now) {
+		return false
+	}
+	return promo.BerakhirPada == nil || promo.BerakhirPada.After(now)
+}
+
+// ProductPromoTier is one step of a tiered quantity discount
+type ProductPromoTier struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	IDPromo   uint64  `json:"id_promo" gorm:"column:id_promo;not null;index;type:bigint unsigned"`
+	MinJumlah int     `json:"min_jumlah" gorm:"column:min_jumlah;not null"`
+	Persen    float64 `json:"persen" gorm:"column:persen;not null"`
+}
+
+func (ProductPromoTier) TableName() string {
+	return "product_promo_tingkat"
+}
+
+// TransactionPromo records a promo applied at checkout, to a line or to a store's shipping
+type TransactionPromo struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	IDTrx       uint       `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	IDTrxToko   *uint      `json:"id_trx_toko" gorm:"column:id_trx_toko;index"`
+	IDTrxDetail *uint      `json:"id_trx_detail" gorm:"column:id_trx_detail;index"` // Nil for free shipping
+	IDPromo     uint64     `json:"id_promo" gorm:"column:id_promo;not null;index;type:bigint unsigned"`
+	Promo       string     `json:"promo" gorm:"column:promo;type:text"`
+	Jenis       string     `json:"jenis" gorm:"column:jenis;size:20;not null"`
+	Diskon      float64    `json:"diskon" gorm:"column:diskon;not null"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+func (TransactionPromo) TableName() string {
+	return "trx_promo"
+}
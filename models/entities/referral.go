@@ -0,0 +1,61 @@
+package entities
+
+import "time"
+
+// Commission statuses. A commission is pending until its store order is
+// delivered, and cancelled with the store order.
+const (
+	CommissionPending   = "tertunda"
+	CommissionSettled   = "cair"
+	CommissionCancelled = "batal"
+)
+
+// ReferralLink is a code a reseller shares to earn commission, for one
+// product or, without IDProduk, for everything the buyer orders
+type ReferralLink struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	IDUser    uint       `json:"id_user" gorm:"column:id_user;not null;index"` // The reseller
+	IDProduk  *uint      `json:"id_produk" gorm:"column:id_produk;index"`
+	Kode      string     `json:"kode" gorm:"column:kode;size:20;not null;uniqueIndex"`
+	Klik      int        `json:"klik" gorm:"column:klik;not null;default:0"`
+	User      User       `json:"user" gorm:"foreignKey:IDUser"`
+	Product   Product    `json:"product" gorm:"foreignKey:IDProduk"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func (ReferralLink) TableName() string {
+	return "referral"
+}
+
+// Covers reports whether an order line of productID earns commission through the link
+func (link ReferralLink) Covers(productID uint) bool {
+	return link.IDProduk == nil || *link.IDProduk == productID
+}
+
+// Commission is a reseller's earning on one transaction line bought through
+// their referral: the margin between the line's price and the reseller price
+type Commission struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	IDUser        uint         `json:"id_user" gorm:"column:id_user;not null;index"` // The reseller
+	IDReferral    uint         `json:"id_referral" gorm:"column:id_referral;not null;index"`
+	IDTrx         uint         `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	IDTrxToko     *uint        `json:"id_trx_toko" gorm:"column:id_trx_toko;index"`
+	IDTrxDetail   uint         `json:"id_trx_detail" gorm:"column:id_trx_detail;not null;uniqueIndex"`
+	KodeInvoice   string       `json:"kode_invoice" gorm:"column:kode_invoice;size:100"` // Of the store order
+	IDProduk      uint         `json:"id_produk" gorm:"column:id_produk;not null"`
+	NamaProduk    string       `json:"nama_produk" gorm:"column:nama_produk;size:255"`
+	Kuantitas     int          `json:"kuantitas" gorm:"column:kuantitas;not null"`
+	HargaJual     float64      `json:"harga_jual" gorm:"column:harga_jual;not null"`         // Line total the buyer was charged before discounts
+	HargaReseller float64      `json:"harga_reseller" gorm:"column:harga_reseller;not null"` // Unit reseller price from the product log
+	Komisi        float64      `json:"komisi" gorm:"column:komisi;not null"`
+	Status        string       `json:"status" gorm:"column:status;size:20;not null;default:tertunda;index"`
+	DicairkanPada *time.Time   `json:"dicairkan_pada" gorm:"column:dicairkan_pada"`
+	Referral      ReferralLink `json:"referral" gorm:"foreignKey:IDReferral"`
+	CreatedAt     *time.Time   `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+}
+
+func (Commission) TableName() string {
+	return "komisi_reseller"
+}
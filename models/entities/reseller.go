@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// Reseller application statuses. A rejected or revoked user may apply again.
+const (
+	ResellerPending  = "menunggu"
+	ResellerApproved = "disetujui"
+	ResellerRejected = "ditolak"
+	ResellerRevoked  = "dicabut"
+)
+
+// Reseller is a user's application for the reseller tier, one per user.
+// While it is approved the user buys at the products' reseller prices.
+type Reseller struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	IDUser         uint       `json:"id_user" gorm:"column:id_user;not null;uniqueIndex"`
+	NamaUsaha      string     `json:"nama_usaha" gorm:"column:nama_usaha;size:255"`
+	Alasan         string     `json:"alasan" gorm:"column:alasan;type:text"`
+	Status         string     `json:"status" gorm:"column:status;size:20;not null;default:menunggu;index"`
+	Catatan        string     `json:"catatan" gorm:"column:catatan;size:500"` // Admin's reason for a rejection or revocation
+	DiputuskanOleh *uint      `json:"diputuskan_oleh" gorm:"column:diputuskan_oleh"`
+	DiputuskanPada *time.Time `json:"diputuskan_pada" gorm:"column:diputuskan_pada"`
+	User           User       `json:"user" gorm:"foreignKey:IDUser"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
+
+func (Reseller) TableName() string {
+	return "reseller"
+}
+
+// IsApproved reports whether the user currently buys at reseller prices
+func (reseller Reseller) IsApproved() bool {
+	return reseller.Status == ResellerApproved
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// Price and stock change sources
+const (
+	PriceStockSourceBulk = "bulk"
+)
+
+// ProductPriceStockHistory records one change of the stock or prices of a
+// product, or of one of its variants when IDVarian is set. Fields that did not
+// change are left nil. Rows are never updated or deleted.
+type ProductPriceStockHistory struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	IDProduk          uint       `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	IDVarian          *uint      `json:"id_varian" gorm:"column:id_varian;index"`
+	IDUser            uint       `json:"id_user" gorm:"column:id_user;not null"`
+	Sumber            string     `json:"sumber" gorm:"column:sumber;size:30;not null"`
+	StokLama          *int       `json:"stok_lama" gorm:"column:stok_lama"`
+	StokBaru          *int       `json:"stok_baru" gorm:"column:stok_baru"`
+	HargaKonsumenLama *string    `json:"harga_konsumen_lama" gorm:"column:harga_konsumen_lama;size:255"`
+	HargaKonsumenBaru *string    `json:"harga_konsumen_baru" gorm:"column:harga_konsumen_baru;size:255"`
+	HargaResellerLama *string    `json:"harga_reseller_lama" gorm:"column:harga_reseller_lama;size:255"`
+	HargaResellerBaru *string    `json:"harga_reseller_baru" gorm:"column:harga_reseller_baru;size:255"`
+	CreatedAt         *time.Time `json:"created_at"`
+}
+
+func (ProductPriceStockHistory) TableName() string {
+	return "produk_riwayat_harga_stok"
+}
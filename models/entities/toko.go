@@ -14,6 +14,8 @@ type Store struct {
 	NamaToko      string       `json:"nama_toko" gorm:"column:nama_toko;not null"`
 	DeskripsiToko string       `json:"deskripsi_toko" gorm:"column:deskripsi_toko"`
 	UrlFoto       string       `json:"url_foto" gorm:"column:url_foto"`
+	IDProvinsi    string       `json:"id_provinsi" gorm:"column:id_provinsi"`
+	IDKota        string       `json:"id_kota" gorm:"column:id_kota"` // Origin city for shipping
 	FotoToko      []StorePhoto `json:"foto_toko" gorm:"foreignKey:IdToko"`
 	CreatedAt     *time.Time   `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt     *time.Time   `json:"updated_at" gorm:"column:updated_at"`
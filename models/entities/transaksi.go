@@ -3,16 +3,27 @@ package entities
 import "time"
 
 type Trx struct {
-	ID               uint        `json:"id" gorm:"primaryKey"`
-	IDUser           uint        `json:"user_id" gorm:"column:id_user"` // Updated json tag
-	AlamatPengiriman uint        `json:"alamat_pengiriman"`
-	HargaTotal       float64     `json:"harga_total"`
-	KodeInvoice      string      `json:"kode_invoice"`
-	MethodBayar      string      `json:"method_bayar"`
-	Address          Address     `json:"address" gorm:"foreignKey:AlamatPengiriman"`
-	TrxDetail        []TrxDetail `json:"trx_detail" gorm:"foreignKey:IDTrx"`
-	CreatedAt        *time.Time  `json:"created_at"`
-	UpdatedAt        *time.Time  `json:"updated_at"`
+	ID               uint               `json:"id" gorm:"primaryKey"`
+	IDUser           uint               `json:"user_id" gorm:"column:id_user"` // Updated json tag
+	AlamatPengiriman uint               `json:"alamat_pengiriman"`
+	HargaTotal       float64            `json:"harga_total"`
+	OngkosKirim      float64            `json:"ongkos_kirim" gorm:"column:ongkos_kirim;default:0"`
+	Diskon           float64            `json:"diskon" gorm:"column:diskon;default:0"`               // Promo and coupon discounts on the lines, already taken off HargaTotal
+	DiskonOngkir     float64            `json:"diskon_ongkir" gorm:"column:diskon_ongkir;default:0"` // Free shipping, already taken off HargaTotal
+	KodeKupon        string             `json:"kode_kupon" gorm:"column:kode_kupon;size:50"`
+	IDReseller       *uint              `json:"id_reseller" gorm:"column:id_reseller;index"` // Reseller whose referral the buyer came through
+	KodeReferral     string             `json:"kode_referral" gorm:"column:kode_referral;size:20"`
+	Kurir            string             `json:"kurir" gorm:"column:kurir;size:50"`
+	LayananKirim     string             `json:"layanan_kirim" gorm:"column:layanan_kirim;size:50"`
+	KodeInvoice      string             `json:"kode_invoice" gorm:"column:kode_invoice;size:100;uniqueIndex"`
+	MethodBayar      string             `json:"method_bayar"`
+	Address          Address            `json:"address" gorm:"foreignKey:AlamatPengiriman"`
+	TrxDetail        []TrxDetail        `json:"trx_detail" gorm:"foreignKey:IDTrx"`
+	StoreOrders      []StoreOrder       `json:"store_orders" gorm:"foreignKey:IDTrx"`
+	Promos           []TransactionPromo `json:"promos" gorm:"foreignKey:IDTrx"`
+	Vouchers         []VoucherClaim     `json:"vouchers" gorm:"foreignKey:IDTrx"`
+	CreatedAt        *time.Time         `json:"created_at"`
+	UpdatedAt        *time.Time         `json:"updated_at"`
 }
 
 func (Trx) TableName() string {
@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+// StoreOrder is the part of a checkout sold by one store, with its own invoice, shipping and status
+type StoreOrder struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	IDTrx        uint        `json:"id_trx" gorm:"column:id_trx;not null;index"`
+	IDToko       uint        `json:"id_toko" gorm:"column:id_toko;not null;index"`
+	KodeInvoice  string      `json:"kode_invoice" gorm:"column:kode_invoice;size:100;not null;uniqueIndex"`
+	Subtotal     float64     `json:"subtotal" gorm:"column:subtotal;not null"`
+	OngkosKirim  float64     `json:"ongkos_kirim" gorm:"column:ongkos_kirim;default:0"`
+	Diskon       float64     `json:"diskon" gorm:"column:diskon;default:0"` // Promo and coupon discounts on this store's lines
+	DiskonOngkir float64     `json:"diskon_ongkir" gorm:"column:diskon_ongkir;default:0"`
+	HargaTotal   float64     `json:"harga_total" gorm:"column:harga_total;not null"`
+	Kurir        string      `json:"kurir" gorm:"column:kurir;size:50"`
+	LayananKirim string      `json:"layanan_kirim" gorm:"column:layanan_kirim;size:50"`
+	Status       string      `json:"status" gorm:"column:status;size:30;not null;default:pending"`
+	Store        Store       `json:"store" gorm:"foreignKey:IDToko"`
+	Transaction  Trx         `json:"transaction" gorm:"foreignKey:IDTrx"`
+	TrxDetail    []TrxDetail `json:"trx_detail" gorm:"foreignKey:IDTrxToko"`
+	CreatedAt    *time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time  `json:"updated_at"`
+}
+
+func (StoreOrder) TableName() string {
+	return "trx_toko"
+}
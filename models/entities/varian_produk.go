@@ -0,0 +1,66 @@
+package entities
+
+import "time"
+
+// ProductVariantOption is an axis a product varies on, e.g. "Ukuran" or "Warna"
+type ProductVariantOption struct {
+	ID        uint                        `json:"id" gorm:"primaryKey"`
+	IDProduk  uint                        `json:"id_produk" gorm:"column:id_produk;not null;index"`
+	Nama      string                      `json:"nama" gorm:"column:nama;size:100;not null"`
+	Urutan    int                         `json:"urutan" gorm:"column:urutan;not null;default:0"`
+	Values    []ProductVariantOptionValue `json:"nilai" gorm:"foreignKey:IDOpsi"`
+	CreatedAt *time.Time                  `json:"created_at"`
+	UpdatedAt *time.Time                  `json:"updated_at"`
+}
+
+func (ProductVariantOption) TableName() string {
+	return "produk_opsi_varian"
+}
+
+type ProductVariantOptionValue struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	IDOpsi    uint       `json:"id_opsi" gorm:"column:id_opsi;not null;index"`
+	Nilai     string     `json:"nilai" gorm:"column:nilai;size:100;not null"`
+	Urutan    int        `json:"urutan" gorm:"column:urutan;not null;default:0"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func (ProductVariantOptionValue) TableName() string {
+	return "produk_opsi_varian_nilai"
+}
+
+// ProductVariant is one combination of option values with its own SKU, price and stock.
+// Empty prices fall back to the product's prices.
+type ProductVariant struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	IDProduk       uint        `json:"id_produk" gorm:"column:id_produk;not null;uniqueIndex:idx_produk_varian_kombinasi"`
+	SKU            string      `json:"sku" gorm:"column:sku;size:100;not null;uniqueIndex"`
+	Kombinasi      string      `json:"kombinasi" gorm:"column:kombinasi;size:255;not null"`
+	KunciKombinasi string      `json:"kunci_kombinasi" gorm:"column:kunci_kombinasi;size:255;not null;uniqueIndex:idx_produk_varian_kombinasi"`
+	HargaKonsumen  *string     `json:"harga_konsumen" gorm:"column:harga_konsumen;size:255"`
+	HargaReseller  *string     `json:"harga_reseller" gorm:"column:harga_reseller;size:255"`
+	Stok           int         `json:"stok" gorm:"column:stok;not null;default:0"`
+	IDFotoProduk   *uint       `json:"id_foto_produk" gorm:"column:id_foto_produk"`
+	IsActive       bool        `json:"aktif" gorm:"column:aktif;not null;default:true"`
+	FotoProduk     *FotoProduk `json:"foto_produk" gorm:"foreignKey:IDFotoProduk"`
+	CreatedAt      *time.Time  `json:"created_at"`
+	UpdatedAt      *time.Time  `json:"updated_at"`
+}
+
+func (ProductVariant) TableName() string {
+	return "produk_varian"
+}
+
+// PriceFor returns the variant's consumer and reseller prices, falling back to the product's
+func (variant ProductVariant) PriceFor(product Product) (string, string) {
+	hargaKonsumen := product.HargaKonsumen
+	if variant.HargaKonsumen != nil && *variant.HargaKonsumen != "" {
+		hargaKonsumen = *variant.HargaKonsumen
+	}
+	hargaReseller := product.HargaReseller
+	if variant.HargaReseller != nil && *variant.HargaReseller != "" {
+		hargaReseller = *variant.HargaReseller
+	}
+	return hargaKonsumen, hargaReseller
+}
@@ -0,0 +1,67 @@
+package entities
+
+import "time"
+
+// Voucher claim statuses; a claim that was used goes back to available when
+// the store order that used it is cancelled. An available claim whose voucher
+// can no longer be used is shown as expired.
+const (
+	VoucherAvailable = "tersedia"
+	VoucherUsed      = "terpakai"
+	VoucherExpired   = "kedaluwarsa"
+)
+
+// StoreVoucher is a discount a seller offers on their own store's products.
+// Buyers claim it into their voucher wallet before using it at checkout.
+type StoreVoucher struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	IDToko       uint       `json:"id_toko" gorm:"column:id_toko;not null;index"`
+	Kode         string     `json:"kode" gorm:"column:kode;size:50;not null;index"`
+	Nama         string     `json:"nama" gorm:"column:nama;size:255;not null"`
+	Jenis        string     `json:"jenis" gorm:"column:jenis;size:20;not null;default:nominal"` // CouponPercentage or CouponFixed
+	Nilai        float64    `json:"nilai" gorm:"column:nilai;not null"`
+	MinBelanja   float64    `json:"min_belanja" gorm:"column:min_belanja;not null;default:0"` // Spend needed on the store's lines
+	MaksDiskon   float64    `json:"maks_diskon" gorm:"column:maks_diskon;not null;default:0"` // Cap of a percentage voucher, 0 for none
+	Kuota        int        `json:"kuota" gorm:"column:kuota;not null;default:0"`             // Claims allowed, 0 for unlimited
+	Diklaim      int        `json:"diklaim" gorm:"column:diklaim;not null;default:0"`
+	MulaiPada    time.Time  `json:"mulai_pada" gorm:"column:mulai_pada;not null"`
+	BerakhirPada time.Time  `json:"berakhir_pada" gorm:"column:berakhir_pada;not null;index"`
+	Aktif        bool       `json:"aktif" gorm:"column:aktif;not null;default:false"`
+	Store        Store      `json:"store" gorm:"foreignKey:IDToko"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+func (StoreVoucher) TableName() string {
+	return "voucher_toko"
+}
+
+// IsValidAt reports whether the voucher can be used at now
+func (voucher StoreVoucher) IsValidAt(now time.Time) bool {
+	return voucher.Aktif && !voucher.MulaiPada.After(now) && voucher.BerakhirPada.After(now)
+}
+
+// IsClaimableAt reports whether buyers can still claim the voucher at now,
+// which they can before it starts
+func (voucher StoreVoucher) IsClaimableAt(now time.Time) bool {
+	return voucher.Aktif && voucher.BerakhirPada.After(now) && (voucher.Kuota == 0 || voucher.Diklaim < voucher.Kuota)
+}
+
+// VoucherClaim is a store voucher in a buyer's wallet, one per buyer and voucher
+type VoucherClaim struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	IDVoucher   uint         `json:"id_voucher" gorm:"column:id_voucher;not null;uniqueIndex:idx_voucher_klaim_user,priority:1"`
+	IDUser      uint         `json:"id_user" gorm:"column:id_user;not null;uniqueIndex:idx_voucher_klaim_user,priority:2"`
+	Status      string       `json:"status" gorm:"column:status;size:20;not null;default:tersedia"`
+	IDTrx       *uint        `json:"id_trx" gorm:"column:id_trx;index"`
+	IDTrxToko   *uint        `json:"id_trx_toko" gorm:"column:id_trx_toko;index"`
+	Diskon      float64      `json:"diskon" gorm:"column:diskon;not null;default:0"`
+	DipakaiPada *time.Time   `json:"dipakai_pada" gorm:"column:dipakai_pada"`
+	Voucher     StoreVoucher `json:"voucher" gorm:"foreignKey:IDVoucher"`
+	CreatedAt   *time.Time   `json:"created_at"`
+	UpdatedAt   *time.Time   `json:"updated_at"`
+}
+
+func (VoucherClaim) TableName() string {
+	return "voucher_klaim"
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// FlashSaleRequest creates or edits a flash sale campaign. Items are only read
+// when creating; afterwards they are managed one by one.
+type FlashSaleRequest struct {
+	Nama         string                 `json:"nama"`
+	Deskripsi    string                 `json:"deskripsi"`
+	MulaiPada    time.Time              `json:"mulai_pada"`
+	BerakhirPada time.Time              `json:"berakhir_pada"`
+	Aktif        *bool                  `json:"aktif"`
+	Items        []FlashSaleItemRequest `json:"items"`
+}
+
+type FlashSaleItemRequest struct {
+	IDProduk     uint    `json:"id_produk"`
+	IDVarian     *uint   `json:"id_varian"`
+	HargaFlash   float64 `json:"harga_flash"`
+	Kuota        int     `json:"kuota"`
+	BatasPerUser int     `json:"batas_per_user"`
+}
+
+// FlashSaleResponse shows a campaign with a countdown to its start or end,
+// in seconds from WaktuServer
+type FlashSaleResponse struct {
+	ID            uint                    `json:"id"`
+	Nama          string                  `json:"nama"`
+	Deskripsi     string                  `json:"deskripsi"`
+	MulaiPada     time.Time               `json:"mulai_pada"`
+	BerakhirPada  time.Time               `json:"berakhir_pada"`
+	Aktif         bool                    `json:"aktif"`
+	Status        string                  `json:"status"`
+	WaktuServer   time.Time               `json:"waktu_server"`
+	MulaiDalam    int64                   `json:"mulai_dalam"`
+	BerakhirDalam int64                   `json:"berakhir_dalam"`
+	Items         []FlashSaleItemResponse `json:"items"`
+	CreatedAt     *time.Time              `json:"created_at"`
+	UpdatedAt     *time.Time              `json:"updated_at"`
+}
+
+type FlashSaleItemResponse struct {
+	ID           uint    `json:"id"`
+	IDProduk     uint    `json:"id_produk"`
+	IDVarian     *uint   `json:"id_varian"`
+	NamaProduk   string  `json:"nama_produk"`
+	NamaVarian   string  `json:"nama_varian,omitempty"`
+	Slug         string  `json:"slug"`
+	IDToko       uint    `json:"id_toko"`
+	HargaNormal  string  `json:"harga_normal"`
+	HargaFlash   float64 `json:"harga_flash"`
+	Kuota        int     `json:"kuota"`
+	Terjual      int     `json:"terjual"`
+	Sisa         int     `json:"sisa"`
+	BatasPerUser int     `json:"batas_per_user"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// WarehouseRequest creates or edits a warehouse; id_kota is the origin city of its parcels
+type WarehouseRequest struct {
+	Nama       string `json:"nama"`
+	Alamat     string `json:"alamat"`
+	IDProvinsi string `json:"id_provinsi"`
+	IDKota     string `json:"id_kota"`
+	Utama      bool   `json:"utama"`
+	Aktif      *bool  `json:"aktif"`
+}
+
+type WarehouseResponse struct {
+	ID         uint       `json:"id"`
+	IDToko     uint       `json:"id_toko"`
+	Nama       string     `json:"nama"`
+	Alamat     string     `json:"alamat"`
+	IDProvinsi string     `json:"id_provinsi"`
+	IDKota     string     `json:"id_kota"`
+	Utama      bool       `json:"utama"`
+	Aktif      bool       `json:"aktif"`
+	TotalStok  int        `json:"total_stok"`
+	CreatedAt  *time.Time `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+type WarehouseStockResponse struct {
+	IDProduk   uint       `json:"id_produk"`
+	NamaProduk string     `json:"nama_produk"`
+	SKU        string     `json:"sku"`
+	IDVarian   *uint      `json:"id_varian,omitempty"`
+	Stok       int        `json:"stok"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+// WarehouseTransferRequest moves stock between two warehouses of the same store;
+// products with variants need id_varian
+type WarehouseTransferRequest struct {
+	IDGudangAsal   uint   `json:"id_gudang_asal"`
+	IDGudangTujuan uint   `json:"id_gudang_tujuan"`
+	IDProduk       uint   `json:"id_produk"`
+	IDVarian       *uint  `json:"id_varian"`
+	Jumlah         int    `json:"jumlah"`
+	Catatan        string `json:"catatan"`
+}
+
+type WarehouseTransferResponse struct {
+	ID             uint       `json:"id"`
+	IDToko         uint       `json:"id_toko"`
+	IDGudangAsal   uint       `json:"id_gudang_asal"`
+	IDGudangTujuan uint       `json:"id_gudang_tujuan"`
+	IDProduk       uint       `json:"id_produk"`
+	IDVarian       *uint      `json:"id_varian,omitempty"`
+	Jumlah         int        `json:"jumlah"`
+	IDUser         uint       `json:"id_user"`
+	Catatan        string     `json:"catatan,omitempty"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// ProductImportRowResult is the outcome of one data row of an import file
+type ProductImportRowResult struct {
+	Baris    int      `json:"baris"` // Line number in the file, the header is line 1
+	Aksi     string   `json:"aksi"`  // "create" or "update"
+	IDProduk uint     `json:"id_produk,omitempty"`
+	SKU      string   `json:"sku,omitempty"`
+	Slug     string   `json:"slug,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// ProductImportResult reports every row of an import. Nothing is saved unless
+// every row is valid and the import is not a dry run.
+type ProductImportResult struct {
+	DryRun     bool                     `json:"dry_run"`
+	Disimpan   bool                     `json:"disimpan"`
+	TotalBaris int                      `json:"total_baris"`
+	Dibuat     int                      `json:"dibuat"`
+	Diperbarui int                      `json:"diperbarui"`
+	Gagal      int                      `json:"gagal"`
+	Baris      []ProductImportRowResult `json:"baris"`
+}
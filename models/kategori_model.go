@@ -5,12 +5,25 @@ import "time"
 // Request
 type CategoryRequest struct {
 	NamaCategory string `json:"nama_category" binding:"required"`
+	IDParent     *uint  `json:"id_parent"`
+	Slug         string `json:"slug"`
+	Icon         string `json:"icon"`
+	Urutan       int    `json:"urutan"`
 }
 
 // Response
 type CategoryResponse struct {
 	ID           uint       `json:"id"`
+	IDParent     *uint      `json:"id_parent"`
 	NamaCategory string     `json:"nama_category"`
+	Slug         string     `json:"slug"`
+	Icon         string     `json:"icon"`
+	Urutan       int        `json:"urutan"`
 	CreatedAt    *time.Time `json:"created_at"`
 	UpdatedAt    *time.Time `json:"updated_at"`
 }
+
+type CategoryTreeResponse struct {
+	CategoryResponse
+	Children []CategoryTreeResponse `json:"children"`
+}
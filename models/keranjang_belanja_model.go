@@ -3,16 +3,19 @@ package models
 import "time"
 
 type KeranjangBelanjaRequest struct {
-	IDToko       uint `json:"id_toko" form:"id_toko"`
-	IDProduk     uint `json:"id_produk" form:"id_produk"`
-	JumlahProduk int  `json:"jumlah_produk" form:"jumlah_produk"`
+	IDToko       uint  `json:"id_toko" form:"id_toko"`
+	IDProduk     uint  `json:"id_produk" form:"id_produk"`
+	IDVarian     *uint `json:"id_varian" form:"id_varian"`
+	JumlahProduk int   `json:"jumlah_produk" form:"jumlah_produk"`
 }
 
 type KeranjangBelanjaResponse struct {
-	ID           uint `json:"id"`
-	IDToko       uint `json:"id_toko"`
-	IDProduk     uint `json:"id_produk"`
-	JumlahProduk int  `json:"jumlah_produk"`
+	ID           uint                    `json:"id"`
+	IDToko       uint                    `json:"id_toko"`
+	IDProduk     uint                    `json:"id_produk"`
+	IDVarian     *uint                   `json:"id_varian"`
+	JumlahProduk int                     `json:"jumlah_produk"`
+	Varian       *ProductVariantResponse `json:"varian"`
 	Store        struct {
 		ID            uint       `json:"id"`
 		IDUser        uint       `json:"id_user"` // Add this line
@@ -28,6 +31,7 @@ type KeranjangBelanjaResponse struct {
 		Slug          string               `json:"slug"`
 		HargaReseller string               `json:"harga_reseler"`
 		HargaKonsumen string               `json:"harga_konsumen"`
+		HargaCoret    string               `json:"harga_coret,omitempty"` // List price while a discount applies
 		Stok          int                  `json:"stok"`
 		Deskripsi     *string              `json:"deskripsi"`
 		FotoProduk    []FotoProdukResponse `json:"foto_produk"` // Add this field
@@ -46,3 +50,26 @@ type FotoToko struct {
 	CreatedAt *time.Time `json:"created_at"` // Changed back to pointer type
 	UpdatedAt *time.Time `json:"updated_at"` // Changed back to pointer type
 }
+
+// KeranjangBelanjaSummaryResponse prices the cart with the promos running now
+type KeranjangBelanjaSummaryResponse struct {
+	Items        []KeranjangBelanjaSummaryItem `json:"items"`
+	Subtotal     float64                       `json:"subtotal"`
+	Diskon       float64                       `json:"diskon"`
+	Total        float64                       `json:"total"`
+	GratisOngkir []AppliedPromo                `json:"gratis_ongkir"` // Free shipping the cart qualifies for, per store
+}
+
+type KeranjangBelanjaSummaryItem struct {
+	ID           uint           `json:"id"`
+	IDToko       uint           `json:"id_toko"`
+	IDProduk     uint           `json:"id_produk"`
+	IDVarian     *uint          `json:"id_varian"`
+	NamaProduk   string         `json:"nama_produk"`
+	JumlahProduk int            `json:"jumlah_produk"`
+	Harga        float64        `json:"harga"`
+	HargaTotal   float64        `json:"harga_total"`
+	Reseller     bool           `json:"reseller"` // Priced at the reseller price
+	Diskon       float64        `json:"diskon"`
+	Promos       []AppliedPromo `json:"promos"`
+}
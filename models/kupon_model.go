@@ -2,22 +2,82 @@ package models
 
 import "time"
 
+// ProductCouponRequest creates or edits a coupon. jenis is "persen" (default)
+// or "nominal"; cakupan is "produk" (default, needs product_id), "toko" (id_toko),
+// "kategori" (id_category) or "keranjang" for the whole cart.
 type ProductCouponRequest struct {
-	ProductID uint      `json:"product_id"`
-	Code      string    `json:"code"`
-	Discount  float64   `json:"discount"`
-	ValidFrom time.Time `json:"valid_from"`
-	ValidTo   time.Time `json:"valid_to"`
+	ProductID    uint      `json:"product_id"`
+	Code         string    `json:"code"`
+	Jenis        string    `json:"jenis"`
+	Cakupan      string    `json:"cakupan"`
+	IDToko       uint      `json:"id_toko"`
+	IDCategory   uint      `json:"id_category"`
+	Discount     float64   `json:"discount"`
+	MinBelanja   float64   `json:"min_belanja"`
+	MaksDiskon   float64   `json:"maks_diskon"`
+	KuotaTotal   int       `json:"kuota_total"`
+	KuotaPerUser int       `json:"kuota_per_user"`
+	ValidFrom    time.Time `json:"valid_from"`
+	ValidTo      time.Time `json:"valid_to"`
+	IsActive     *bool     `json:"is_active"`
 }
 
 type ProductCouponResponse struct {
-	ID        uint      `json:"id"`
-	ProductID uint      `json:"product_id"`
-	Code      string    `json:"code"`
-	Discount  float64   `json:"discount"`
-	ValidFrom time.Time `json:"valid_from"`
-	ValidTo   time.Time `json:"valid_to"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint      `json:"id"`
+	ProductID    *uint     `json:"product_id"`
+	Code         string    `json:"code"`
+	Jenis        string    `json:"jenis"`
+	Cakupan      string    `json:"cakupan"`
+	IDToko       *uint     `json:"id_toko"`
+	IDCategory   *uint     `json:"id_category"`
+	Discount     float64   `json:"discount"`
+	MinBelanja   float64   `json:"min_belanja"`
+	MaksDiskon   float64   `json:"maks_diskon"`
+	KuotaTotal   int       `json:"kuota_total"`
+	KuotaPerUser int       `json:"kuota_per_user"`
+	Terpakai     int       `json:"terpakai"`
+	ValidFrom    time.Time `json:"valid_from"`
+	ValidTo      time.Time `json:"valid_to"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CouponValidateRequest previews a coupon on the products of a checkout;
+// a bare product_id checks one unit of that product
+type CouponValidateRequest struct {
+	Code      string               `json:"code"`
+	ProductID uint                 `json:"product_id"`
+	Products  []TransactionProduct `json:"products"`
+}
+
+type CouponLineDiscount struct {
+	ProductID uint    `json:"product_id"`
+	VariantID *uint   `json:"variant_id,omitempty"`
+	Diskon    float64 `json:"diskon"`
+}
+
+type CouponQuoteResponse struct {
+	Kupon    ProductCouponResponse `json:"kupon"`
+	Subtotal float64               `json:"subtotal"` // Spend on the lines the coupon covers
+	Diskon   float64               `json:"diskon"`
+	Baris    []CouponLineDiscount  `json:"baris"`
+}
+
+// CouponRedemption is the coupon a checkout uses, redeemed with the transaction
+type CouponRedemption struct {
+	CouponID  uint    `json:"coupon_id"`
+	KodeKupon string  `json:"kode_kupon"`
+	Diskon    float64 `json:"diskon"`
+}
+
+type CouponRedemptionResponse struct {
+	ID        uint       `json:"id"`
+	IDKupon   uint       `json:"id_kupon"`
+	KodeKupon string     `json:"kode_kupon"`
+	IDUser    uint       `json:"id_user"`
+	IDTrx     uint       `json:"id_trx"`
+	Diskon    float64    `json:"diskon"`
+	Status    string     `json:"status"`
+	CreatedAt *time.Time `json:"created_at"`
 }
@@ -11,8 +11,15 @@ type ProductLogProcess struct {
 	Deskripsi     string  `json:"deskripsi"`
 	StoreID       uint    `json:"store_id"`
 	CategoryID    uint    `json:"category_id"`
+	VariantID     *uint   `json:"variant_id"`
+	WarehouseID   *uint   `json:"warehouse_id"`
+	SKU           string  `json:"sku"`
+	NamaVarian    string  `json:"nama_varian"`
 	Kuantitas     int     `json:"kuantitas"`
 	HargaTotal    float64 `json:"harga_total"`
+	Diskon        float64 `json:"diskon"`          // Promo discount plus its share of the coupon
+	FlashSaleItem *uint   `json:"flash_sale_item"` // Flash sale item the line is priced by
+	Reseller      bool    `json:"reseller"`        // Priced at HargaReseller for an approved reseller
 }
 
 type ProductLogDetailResponse struct {
@@ -37,6 +44,9 @@ type ProductLogResponse struct {
 	Slug          string                   `json:"slug"`
 	HargaReseller float64                  `json:"harga_reseller"`
 	HargaKonsumen float64                  `json:"harga_konsumen"`
+	IDVarian      *uint                    `json:"id_varian"`
+	SKU           string                   `json:"sku"`
+	NamaVarian    string                   `json:"nama_varian"`
 	Deskripsi     string                   `json:"deskripsi"`
 	Store         StoreResponse            `json:"store"`
 	Produk        ProductLogDetailResponse `json:"produk"` // Use the new response type
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// StockMovementRequest records a stock change that did not come from an order,
+// e.g. jenis "return" with jumlah 2 or jenis "adjustment" with jumlah -3.
+// Products with variants need id_varian. Stores with warehouses may name the
+// warehouse in id_gudang, otherwise the primary warehouse is used.
+type StockMovementRequest struct {
+	IDVarian  *uint  `json:"id_varian"`
+	IDGudang  *uint  `json:"id_gudang"`
+	Jenis     string `json:"jenis"`
+	Jumlah    int    `json:"jumlah"`
+	Referensi string `json:"referensi"`
+	Catatan   string `json:"catatan"`
+}
+
+type StockMovementResponse struct {
+	ID          uint       `json:"id"`
+	IDProduk    uint       `json:"id_produk"`
+	IDVarian    *uint      `json:"id_varian,omitempty"`
+	IDGudang    *uint      `json:"id_gudang,omitempty"`
+	Jenis       string     `json:"jenis"`
+	Jumlah      int        `json:"jumlah"`
+	StokSebelum int        `json:"stok_sebelum"`
+	StokSesudah int        `json:"stok_sesudah"`
+	IDUser      *uint      `json:"id_user"`
+	Referensi   string     `json:"referensi,omitempty"`
+	Catatan     string     `json:"catatan,omitempty"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// StockBalanceResponse compares the stock of a product or variant with the sum of its ledger
+type StockBalanceResponse struct {
+	IDVarian   *uint  `json:"id_varian,omitempty"`
+	SKU        string `json:"sku,omitempty"`
+	Stok       int    `json:"stok"`
+	StokLedger int    `json:"stok_ledger"`
+	Selisih    int    `json:"selisih"`
+	Sesuai     bool   `json:"sesuai"`
+}
+
+type StockValidationResponse struct {
+	IDProduk uint                   `json:"id_produk"`
+	Sesuai   bool                   `json:"sesuai"`
+	Saldo    []StockBalanceResponse `json:"saldo"`
+}
@@ -8,6 +8,9 @@ type NotificationRequest struct {
 
 type NotificationResponse struct {
 	ID        uint       `json:"id"`
+	IDUser    *uint      `json:"id_user,omitempty"`
+	Jenis     string     `json:"jenis,omitempty"`
+	IDProduk  *uint      `json:"id_produk,omitempty"`
 	Pesan     string     `json:"pesan"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
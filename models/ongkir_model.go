@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type ShippingRateRequest struct {
+	Kurir        string  `json:"kurir"`
+	Layanan      string  `json:"layanan"`
+	IDKotaAsal   string  `json:"id_kota_asal"`
+	IDKotaTujuan string  `json:"id_kota_tujuan"`
+	HargaPerKg   float64 `json:"harga_per_kg"`
+	EstimasiHari string  `json:"estimasi_hari"`
+	IsActive     *bool   `json:"is_active"`
+}
+
+type ShippingRateResponse struct {
+	ID           uint       `json:"id"`
+	Kurir        string     `json:"kurir"`
+	Layanan      string     `json:"layanan"`
+	IDKotaAsal   string     `json:"id_kota_asal"`
+	IDKotaTujuan string     `json:"id_kota_tujuan"`
+	HargaPerKg   float64    `json:"harga_per_kg"`
+	EstimasiHari string     `json:"estimasi_hari"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type ShippingQuoteRequest struct {
+	AlamatPengiriman uint                 `json:"alamat_pengiriman"`
+	Products         []TransactionProduct `json:"products"`
+}
+
+// ShippingQuoteResponse is one selectable courier/service option for a cart
+type ShippingQuoteResponse struct {
+	Kurir        string                `json:"kurir"`
+	Layanan      string                `json:"layanan"`
+	EstimasiHari string                `json:"estimasi_hari"`
+	OngkosKirim  float64               `json:"ongkos_kirim"`
+	Rincian      []ShippingParcelQuote `json:"rincian"`
+}
+
+// ShippingParcelQuote is the fee for the parcel a single store ships from its
+// origin city, or from one of its warehouses when the store has them
+type ShippingParcelQuote struct {
+	IDToko       uint    `json:"id_toko"`
+	IDGudang     *uint   `json:"id_gudang,omitempty"`
+	Baris        []int   `json:"-"` // Indexes of the quoted products in this parcel
+	IDKotaAsal   string  `json:"id_kota_asal"`
+	IDKotaTujuan string  `json:"id_kota_tujuan"`
+	BeratGram    int     `json:"berat_gram"`
+	BeratTagihKg int     `json:"berat_tagih_kg"`
+	HargaPerKg   float64 `json:"harga_per_kg"`
+	OngkosKirim  float64 `json:"ongkos_kirim"`
+}
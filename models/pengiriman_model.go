@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type ShipmentRequest struct {
+	Kurir      string `json:"kurir"`
+	Layanan    string `json:"layanan"`
+	NoResi     string `json:"no_resi"`
+	Keterangan string `json:"keterangan"`
+	Lokasi     string `json:"lokasi"`
+}
+
+type ShipmentCheckpointRequest struct {
+	Status     string     `json:"status"`
+	Keterangan string     `json:"keterangan"`
+	Lokasi     string     `json:"lokasi"`
+	Waktu      *time.Time `json:"waktu"`
+}
+
+type ShipmentCheckpointResponse struct {
+	ID         uint      `json:"id"`
+	Status     string    `json:"status"`
+	Keterangan string    `json:"keterangan"`
+	Lokasi     string    `json:"lokasi"`
+	Sumber     string    `json:"sumber"`
+	Waktu      time.Time `json:"waktu"`
+}
+
+type ShipmentResponse struct {
+	ID          uint                         `json:"id"`
+	IDTrx       uint                         `json:"id_trx"`
+	IDTrxDetail uint                         `json:"id_trx_detail"`
+	Kurir       string                       `json:"kurir"`
+	Layanan     string                       `json:"layanan"`
+	NoResi      string                       `json:"no_resi"`
+	Status      string                       `json:"status"`
+	ShippedAt   time.Time                    `json:"shipped_at"`
+	DeliveredAt *time.Time                   `json:"delivered_at"`
+	Checkpoints []ShipmentCheckpointResponse `json:"checkpoints"`
+	CreatedAt   *time.Time                   `json:"created_at"`
+	UpdatedAt   *time.Time                   `json:"updated_at"`
+}
+
+type TransactionTrackingResponse struct {
+	IDTrx       uint               `json:"id_trx"`
+	KodeInvoice string             `json:"kode_invoice"`
+	Pengiriman  []ShipmentResponse `json:"pengiriman"`
+}
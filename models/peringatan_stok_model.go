@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// LowStockThresholdRequest sets the stock at which the store owner is warned; null turns it off
+type LowStockThresholdRequest struct {
+	BatasStok *int `json:"batas_stok"`
+}
+
+type LowStockThresholdResponse struct {
+	IDProduk  uint `json:"id_produk"`
+	Stok      int  `json:"stok"`
+	BatasStok *int `json:"batas_stok"`
+}
+
+// StockSubscriptionRequest subscribes to a sold-out product; products with variants need id_varian
+type StockSubscriptionRequest struct {
+	IDVarian *uint `json:"id_varian"`
+}
+
+type StockSubscriptionResponse struct {
+	ID             uint       `json:"id"`
+	IDProduk       uint       `json:"id_produk"`
+	NamaProduk     string     `json:"nama_produk,omitempty"`
+	Slug           string     `json:"slug,omitempty"`
+	IDVarian       *uint      `json:"id_varian,omitempty"`
+	DiberitahuPada *time.Time `json:"diberitahu_pada"` // Null while still waiting for stock
+	CreatedAt      *time.Time `json:"created_at"`
+}
@@ -7,35 +7,104 @@ import (
 
 // Request
 type ProductRequest struct {
-	NamaProduk    string                  `json:"nama_produk" form:"nama_produk"`
-	CategoryID    uint                    `json:"category_id" form:"category_id"`
-	StoreID       uint                    `json:"store_id"`
-	HargaReseller string                  `json:"harga_reseller" form:"harga_reseller"`
-	HargaKonsumen string                  `json:"harga_konsumen" form:"harga_konsumen"`
-	Stok          int                     `json:"stok" form:"stok"`
-	Deskripsi     string                  `json:"deskripsi" form:"deskripsi"`
-	PhotoURLs     []interface{}           `json:"photo_urls" form:"photo_urls"` // Changed type to interface{}
-	PhotoIDs      []uint                  `json:"photo_ids" form:"photo_ids"`
-	PhotoFiles    []*multipart.FileHeader `form:"photo_files"`
+	NamaProduk       string                  `json:"nama_produk" form:"nama_produk"`
+	Slug             string                  `json:"slug" form:"slug"`
+	SKU              string                  `json:"sku" form:"sku"`
+	CategoryID       uint                    `json:"category_id" form:"category_id"`
+	StoreID          uint                    `json:"store_id"`
+	HargaReseller    string                  `json:"harga_reseller" form:"harga_reseller"`
+	HargaKonsumen    string                  `json:"harga_konsumen" form:"harga_konsumen"`
+	MinOrderReseller int                     `json:"min_order_reseller" form:"min_order_reseller"`
+	Stok             int                     `json:"stok" form:"stok"`
+	Berat            int                     `json:"berat" form:"berat"`
+	Panjang          int                     `json:"panjang" form:"panjang"`
+	Lebar            int                     `json:"lebar" form:"lebar"`
+	Tinggi           int                     `json:"tinggi" form:"tinggi"`
+	Deskripsi        string                  `json:"deskripsi" form:"deskripsi"`
+	Status           string                  `json:"status" form:"status"`
+	JadwalTerbit     *time.Time              `json:"jadwal_terbit" form:"jadwal_terbit"`
+	PhotoURLs        []interface{}           `json:"photo_urls" form:"photo_urls"` // Changed type to interface{}
+	PhotoIDs         []uint                  `json:"photo_ids" form:"photo_ids"`
+	PhotoFiles       []*multipart.FileHeader `form:"photo_files"`
+}
+
+// ProductStatusRequest moves a product between draft, published and archived.
+// A draft with jadwal_terbit is published automatically at that time.
+type ProductStatusRequest struct {
+	Status       string     `json:"status"`
+	JadwalTerbit *time.Time `json:"jadwal_terbit"`
+}
+
+type ProductBanRequest struct {
+	Alasan string `json:"alasan"`
+}
+
+// ProductViewer is the user looking at a product; the zero value is an anonymous buyer
+type ProductViewer struct {
+	UserID  uint
+	IsAdmin bool
+}
+
+// ProductFilter narrows the product listing; zero values mean "no filter"
+type ProductFilter struct {
+	Keyword     string
+	CategoryID  uint
+	CategoryIDs []uint // CategoryID and all of its descendants, filled by the service
+	StoreID     uint
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     bool
+	MinRating   float64
+	HasDiscount bool
+	Sort        string
+	Status      string // Only for admin and seller listings, which set AnyStatus
+	AnyStatus   bool   // Include products buyers cannot see
 }
 
 // Response
 type ProductResponse struct {
-	ID            uint                          `json:"id"`
-	NamaProduk    string                        `json:"nama_produk"`
-	Slug          string                        `json:"slug"`
-	HargaReseller string                        `json:"harga_reseler"`
-	HargaKonsumen string                        `json:"harga_konsumen"`
-	Stok          int                           `json:"stok"`
-	Deskripsi     *string                       `json:"deskripsi"`
-	Store         StoreResponse                 `json:"toko"`
-	Category      CategoryResponse              `json:"category"`
-	FotoProduk    []FotoProdukResponse          `json:"foto_produk"`
-	Reviews       []SimpleProductReviewResponse `json:"reviews"`
-	Promos        []ProductPromoResponse        `json:"promos"`
-	Coupons       []ProductCouponResponse       `json:"coupons"` // Add this line
-	CreatedAt     *time.Time                    `json:"created_at"`
-	UpdatedAt     *time.Time                    `json:"updated_at"`
+	ID               uint                          `json:"id"`
+	NamaProduk       string                        `json:"nama_produk"`
+	Slug             string                        `json:"slug"`
+	SKU              string                        `json:"sku,omitempty"`
+	HargaReseller    string                        `json:"harga_reseler,omitempty"`
+	HargaKonsumen    string                        `json:"harga_konsumen"`
+	HargaCoret       string                        `json:"harga_coret,omitempty"` // List price while a discount applies
+	HargaAnda        string                        `json:"harga_anda,omitempty"`  // Price the signed-in reseller pays
+	MinOrderReseller int                           `json:"min_order_reseller,omitempty"`
+	Diskon           *ActiveDiscountResponse       `json:"diskon,omitempty"`
+	Stok             int                           `json:"stok"`
+	BatasStok        *int                          `json:"batas_stok,omitempty"`
+	Berat            int                           `json:"berat"`
+	Panjang          int                           `json:"panjang"`
+	Lebar            int                           `json:"lebar"`
+	Tinggi           int                           `json:"tinggi"`
+	Deskripsi        *string                       `json:"deskripsi"`
+	Store            StoreResponse                 `json:"toko"`
+	Category         CategoryResponse              `json:"category"`
+	FotoProduk       []FotoProdukResponse          `json:"foto_produk"`
+	Reviews          []SimpleProductReviewResponse `json:"reviews"`
+	Promos           []ProductPromoResponse        `json:"promos"`
+	Coupons          []ProductCouponResponse       `json:"coupons"` // Add this line
+	Opsi             []VariantOptionResponse       `json:"opsi_varian,omitempty"`
+	Varian           []ProductVariantResponse      `json:"varian,omitempty"`
+	Status           string                        `json:"status"`
+	JadwalTerbit     *time.Time                    `json:"jadwal_terbit,omitempty"`
+	AlasanBlokir     string                        `json:"alasan_blokir,omitempty"`
+	CreatedAt        *time.Time                    `json:"created_at"`
+	UpdatedAt        *time.Time                    `json:"updated_at"`
+}
+
+// ProductSearchResult is a product matched by full-text search with its relevance score
+type ProductSearchResult struct {
+	ProductResponse
+	Relevansi float64 `json:"relevansi"`
+}
+
+// ProductSlugRedirect points an old product slug at the product's current URL
+type ProductSlugRedirect struct {
+	Slug     string `json:"slug"`
+	Location string `json:"location"`
 }
 
 type SimpleProductReviewResponse struct {
@@ -47,3 +116,28 @@ type SimpleProductReviewResponse struct {
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 }
+
+type FacetCount struct {
+	ID     uint   `json:"id"`
+	Nama   string `json:"nama"`
+	Jumlah int64  `json:"jumlah"`
+}
+
+type PriceBucketFacet struct {
+	Label  string   `json:"label"`
+	Min    float64  `json:"min"`
+	Max    *float64 `json:"max"`
+	Jumlah int64    `json:"jumlah"`
+}
+
+type ProductFacets struct {
+	Category     []FacetCount       `json:"category"`
+	Toko         []FacetCount       `json:"toko"`
+	RentangHarga []PriceBucketFacet `json:"rentang_harga"`
+}
+
+// ProductListResponse is a page of products with the facet counts of the whole result set
+type ProductListResponse struct {
+	Pagination
+	Facets ProductFacets `json:"facets"`
+}
@@ -2,17 +2,66 @@ package models
 
 import "time"
 
+// ProductPromoRequest creates or edits a promo. Without jenis the promo is only
+// a text shown with the product; see the Product Promos API for the rule kinds.
 type ProductPromoRequest struct {
-	IDToko   uint   `json:"id_toko" form:"id_toko"`
-	IDProduk uint   `json:"id_produk" form:"id_produk"`
-	Promo    string `json:"promo" form:"promo"`
+	IDToko       uint               `json:"id_toko" form:"id_toko"`
+	IDProduk     uint               `json:"id_produk" form:"id_produk"`
+	Promo        string             `json:"promo" form:"promo"`
+	Jenis        string             `json:"jenis" form:"jenis"`
+	BeliJumlah   int                `json:"beli_jumlah" form:"beli_jumlah"`
+	GratisJumlah int                `json:"gratis_jumlah" form:"gratis_jumlah"`
+	HargaPaket   float64            `json:"harga_paket" form:"harga_paket"`
+	MinBelanja   float64            `json:"min_belanja" form:"min_belanja"`
+	MaksOngkir   float64            `json:"maks_ongkir" form:"maks_ongkir"`
+	Tingkat      []PromoTierRequest `json:"tingkat" form:"tingkat"`
+	MulaiPada    *time.Time         `json:"mulai_pada" form:"mulai_pada"`
+	BerakhirPada *time.Time         `json:"berakhir_pada" form:"berakhir_pada"`
+	Aktif        *bool              `json:"aktif" form:"aktif"`
+}
+
+type PromoTierRequest struct {
+	MinJumlah int     `json:"min_jumlah"`
+	Persen    float64 `json:"persen"`
 }
 
 type ProductPromoResponse struct {
-	ID        uint       `json:"id"`
-	IDToko    uint       `json:"id_toko"`
-	IDProduk  uint       `json:"id_produk"`
-	Promo     string     `json:"promo"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID           uint               `json:"id"`
+	IDToko       uint               `json:"id_toko"`
+	IDProduk     *uint              `json:"id_produk"`
+	Promo        string             `json:"promo"`
+	Jenis        string             `json:"jenis"`
+	BeliJumlah   int                `json:"beli_jumlah,omitempty"`
+	GratisJumlah int                `json:"gratis_jumlah,omitempty"`
+	HargaPaket   float64            `json:"harga_paket,omitempty"`
+	MinBelanja   float64            `json:"min_belanja,omitempty"`
+	MaksOngkir   float64            `json:"maks_ongkir,omitempty"`
+	Tingkat      []PromoTierRequest `json:"tingkat,omitempty"`
+	MulaiPada    *time.Time         `json:"mulai_pada"`
+	BerakhirPada *time.Time         `json:"berakhir_pada"`
+	Aktif        bool               `json:"aktif"`
+	CreatedAt    *time.Time         `json:"created_at"`
+	UpdatedAt    *time.Time         `json:"updated_at"`
+}
+
+// AppliedPromo is a promo the pricing engine applied to a checkout line, or to
+// a store's shipping when Baris is -1
+type AppliedPromo struct {
+	IDPromo  uint    `json:"id_promo"`
+	Promo    string  `json:"promo"`
+	Jenis    string  `json:"jenis"`
+	IDToko   uint    `json:"id_toko"`
+	IDProduk *uint   `json:"id_produk,omitempty"`
+	Baris    int     `json:"-"`
+	Diskon   float64 `json:"diskon"`
+}
+
+// TransactionPromoResponse is a promo applied to a transaction line or store shipping
+type TransactionPromoResponse struct {
+	IDPromo     uint    `json:"id_promo"`
+	Promo       string  `json:"promo"`
+	Jenis       string  `json:"jenis"`
+	IDTrxToko   *uint   `json:"id_trx_toko"`
+	IDTrxDetail *uint   `json:"id_trx_detail"`
+	Diskon      float64 `json:"diskon"`
 }
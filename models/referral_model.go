@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// ReferralLinkRequest creates a reseller's referral link for a product, or
+// without id_produk for everything the buyer orders
+type ReferralLinkRequest struct {
+	IDProduk *uint `json:"id_produk"`
+}
+
+type ReferralLinkResponse struct {
+	ID           uint       `json:"id"`
+	Kode         string     `json:"kode"`
+	Tautan       string     `json:"tautan"` // Shareable link that opens the referral
+	IDProduk     *uint      `json:"id_produk"`
+	NamaProduk   string     `json:"nama_produk,omitempty"`
+	Slug         string     `json:"slug,omitempty"`
+	NamaReseller string     `json:"nama_reseller,omitempty"`
+	Klik         int        `json:"klik"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+// ReferralAttribution is the reseller a checkout is credited to, with the
+// commission of each line by index
+type ReferralAttribution struct {
+	IDReferral uint      `json:"id_referral"`
+	IDUser     uint      `json:"id_user"`
+	Kode       string    `json:"kode"`
+	Komisi     []float64 `json:"komisi"`
+}
+
+type CommissionResponse struct {
+	ID            uint       `json:"id"`
+	IDTrx         uint       `json:"id_trx"`
+	IDTrxToko     *uint      `json:"id_trx_toko"`
+	IDTrxDetail   uint       `json:"id_trx_detail"`
+	KodeInvoice   string     `json:"kode_invoice"`
+	KodeReferral  string     `json:"kode_referral"`
+	IDProduk      uint       `json:"id_produk"`
+	NamaProduk    string     `json:"nama_produk"`
+	Kuantitas     int        `json:"kuantitas"`
+	HargaJual     float64    `json:"harga_jual"`
+	HargaReseller float64    `json:"harga_reseller"`
+	Komisi        float64    `json:"komisi"`
+	Status        string     `json:"status"`
+	DicairkanPada *time.Time `json:"dicairkan_pada"`
+	CreatedAt     *time.Time `json:"created_at"`
+}
+
+// EarningsResponse is a reseller's commission ledger with its totals
+type EarningsResponse struct {
+	Tertunda float64              `json:"tertunda"`
+	Cair     float64              `json:"cair"`
+	Riwayat  []CommissionResponse `json:"riwayat"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ResellerApplyRequest applies for the reseller tier
+type ResellerApplyRequest struct {
+	NamaUsaha string `json:"nama_usaha"`
+	Alasan    string `json:"alasan"`
+}
+
+// ResellerDecisionRequest carries the admin's note on a rejection or revocation
+type ResellerDecisionRequest struct {
+	Catatan string `json:"catatan"`
+}
+
+type ResellerResponse struct {
+	ID             uint       `json:"id"`
+	IDUser         uint       `json:"id_user"`
+	NamaUser       string     `json:"nama_user"`
+	Email          string     `json:"email"`
+	NamaUsaha      string     `json:"nama_usaha"`
+	Alasan         string     `json:"alasan"`
+	Status         string     `json:"status"`
+	Catatan        string     `json:"catatan,omitempty"`
+	DiputuskanPada *time.Time `json:"diputuskan_pada"`
+	CreatedAt      *time.Time `json:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
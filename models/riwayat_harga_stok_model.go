@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// BulkPriceStockItem targets a product by id_produk, or by sku: the product's own
+// SKU in the store or a variant's SKU. Only the fields that are sent change.
+type BulkPriceStockItem struct {
+	IDProduk      uint     `json:"id_produk"`
+	SKU           string   `json:"sku"`
+	Stok          *int     `json:"stok"`
+	HargaKonsumen *float64 `json:"harga_konsumen"`
+	HargaReseller *float64 `json:"harga_reseller"`
+}
+
+// BulkPriceStockRequest updates many products at once. In "atomic" mode (the
+// default) nothing changes unless every item is valid; in "partial" mode every
+// valid item is applied on its own.
+type BulkPriceStockRequest struct {
+	StoreID uint                 `json:"store_id"` // Admins only; sellers always update their own store
+	Mode    string               `json:"mode"`
+	Items   []BulkPriceStockItem `json:"items"`
+}
+
+type BulkPriceStockItemResult struct {
+	Index    int                        `json:"index"`
+	IDProduk uint                       `json:"id_produk,omitempty"`
+	IDVarian *uint                      `json:"id_varian,omitempty"`
+	SKU      string                     `json:"sku,omitempty"`
+	Berhasil bool                       `json:"berhasil"`
+	Error    string                     `json:"error,omitempty"`
+	Riwayat  *PriceStockHistoryResponse `json:"riwayat,omitempty"` // Nil when nothing changed
+}
+
+type BulkPriceStockResult struct {
+	Mode     string                     `json:"mode"`
+	Berhasil int                        `json:"berhasil"`
+	Gagal    int                        `json:"gagal"`
+	Items    []BulkPriceStockItemResult `json:"items"`
+}
+
+type PriceStockHistoryResponse struct {
+	ID                uint       `json:"id"`
+	IDProduk          uint       `json:"id_produk"`
+	IDVarian          *uint      `json:"id_varian,omitempty"`
+	IDUser            uint       `json:"id_user"`
+	Sumber            string     `json:"sumber"`
+	StokLama          *int       `json:"stok_lama,omitempty"`
+	StokBaru          *int       `json:"stok_baru,omitempty"`
+	HargaKonsumenLama *string    `json:"harga_konsumen_lama,omitempty"`
+	HargaKonsumenBaru *string    `json:"harga_konsumen_baru,omitempty"`
+	HargaResellerLama *string    `json:"harga_reseller_lama,omitempty"`
+	HargaResellerBaru *string    `json:"harga_reseller_baru,omitempty"`
+	CreatedAt         *time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+type SearchSuggestionItem struct {
+	Tipe string `json:"tipe"`
+	ID   uint   `json:"id"`
+	Nama string `json:"nama"`
+}
+
+type SearchSuggestionResponse struct {
+	Query      string                 `json:"query"`
+	Produk     []SearchSuggestionItem `json:"produk"`
+	Category   []SearchSuggestionItem `json:"category"`
+	Toko       []SearchSuggestionItem `json:"toko"`
+	DidYouMean string                 `json:"did_you_mean,omitempty"`
+}
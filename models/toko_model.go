@@ -8,9 +8,11 @@ import (
 // Response
 type StoreResponse struct {
 	ID            uint               `json:"id"`
-	IDUser        uint               `json:"id_user"` // Add this line
+	IDUser        uint               `json:"id_user,omitempty"` // Add this line
 	NamaToko      string             `json:"nama_toko"`
 	DeskripsiToko string             `json:"deskripsi_toko"`
+	IDProvinsi    string             `json:"id_provinsi"`
+	IDKota        string             `json:"id_kota"`
 	FotoToko      []FotoTokoResponse `json:"foto_toko"`
 	CreatedAt     *time.Time         `json:"created_at"`
 	UpdatedAt     *time.Time         `json:"updated_at"`
@@ -27,6 +29,8 @@ type StoreProcess struct {
 	UserID        uint
 	NamaToko      string
 	DeskripsiToko string
+	IDProvinsi    string
+	IDKota        string
 	URL           string
 	Photo         string
 	IdFoto        uint `json:"id_foto"`
@@ -51,9 +55,11 @@ type StorePaginationResponse struct {
 // StoreDetailResponse is used for pagination response
 type StoreDetailResponse struct {
 	ID            uint             `json:"id"`
-	IDUser        uint             `json:"id_user"` // Add this line
+	IDUser        uint             `json:"id_user,omitempty"` // Add this line
 	NamaToko      string           `json:"nama_toko"`
 	DeskripsiToko string           `json:"deskripsi_toko"`
+	IDProvinsi    string           `json:"id_provinsi"`
+	IDKota        string           `json:"id_kota"`
 	FotoToko      []StorePhotoData `json:"foto_toko"`
 	CreatedAt     *time.Time       `json:"created_at"`
 	UpdatedAt     *time.Time       `json:"updated_at"`
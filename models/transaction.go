@@ -5,11 +5,17 @@ import (
 )
 
 type Transaction struct {
-	UserID           uint   `json:"user_id"`
-	AlamatPengiriman uint   `json:"alamat_pengiriman"`
-	HargaTotal       int    `json:"harga_total"`
-	KodeInvoice      string `json:"kode_invoice"`
-	MethodBayar      string `json:"method_bayar"`
+	UserID           uint    `json:"user_id"`
+	AlamatPengiriman uint    `json:"alamat_pengiriman"`
+	HargaTotal       int     `json:"harga_total"`
+	OngkosKirim      float64 `json:"ongkos_kirim"`
+	Diskon           float64 `json:"diskon"`
+	DiskonOngkir     float64 `json:"diskon_ongkir"`
+	KodeKupon        string  `json:"kode_kupon"`
+	Kurir            string  `json:"kurir"`
+	LayananKirim     string  `json:"layanan_kirim"`
+	KodeInvoice      string  `json:"kode_invoice"`
+	MethodBayar      string  `json:"method_bayar"`
 }
 
 type TransactionRequest struct {
@@ -17,11 +23,17 @@ type TransactionRequest struct {
 	AlamatPengiriman uint                 `json:"alamat_pengiriman"`
 	HargaTotal       float64              `json:"harga_total"`
 	MethodBayar      string               `json:"method_bayar"`
+	Kurir            string               `json:"kurir"`
+	Layanan          string               `json:"layanan"`
 	Products         []TransactionProduct `json:"products"`
+	KodeKupon        string               `json:"kode_kupon"`
+	VoucherToko      []uint               `json:"voucher_toko"` // Claimed store vouchers, one per store
+	KodeReferral     string               `json:"kode_referral"`
 }
 
 type TransactionProduct struct {
 	ProductID uint    `json:"product_id"`
+	VariantID *uint   `json:"variant_id"`
 	Quantity  int     `json:"quantity"`
 	Price     float64 `json:"price"`
 }
@@ -32,20 +44,35 @@ type TransactionUpdateRequest struct {
 }
 
 type TransactionResponse struct {
-	ID                 uint                `json:"id"`
-	UserID             uint                `json:"user_id"`
-	HargaTotal         float64             `json:"harga_total"`
-	KodeInvoice        string              `json:"kode_invoice"`
-	MethodBayar        string              `json:"method_bayar"`
-	Address            AddressResponse     `json:"address"`
-	TransactionDetails []TransactionDetail `json:"transaction_details,omitempty"`
-	CreatedAt          time.Time           `json:"created_at"`
-	UpdatedAt          time.Time           `json:"updated_at"`
+	ID                 uint                         `json:"id"`
+	UserID             uint                         `json:"user_id"`
+	HargaTotal         float64                      `json:"harga_total"`
+	OngkosKirim        float64                      `json:"ongkos_kirim"`
+	Diskon             float64                      `json:"diskon"`
+	DiskonOngkir       float64                      `json:"diskon_ongkir"`
+	KodeKupon          string                       `json:"kode_kupon,omitempty"`
+	Promos             []TransactionPromoResponse   `json:"promos,omitempty"`
+	VoucherToko        []TransactionVoucherResponse `json:"voucher_toko,omitempty"`
+	KodeReferral       string                       `json:"kode_referral,omitempty"`
+	Kurir              string                       `json:"kurir"`
+	LayananKirim       string                       `json:"layanan_kirim"`
+	KodeInvoice        string                       `json:"kode_invoice"`
+	MethodBayar        string                       `json:"method_bayar"`
+	Address            AddressResponse              `json:"address"`
+	TransactionDetails []TransactionDetail          `json:"transaction_details,omitempty"`
+	StoreOrders        []StoreOrderResponse         `json:"store_orders,omitempty"`
+	CreatedAt          time.Time                    `json:"created_at"`
+	UpdatedAt          time.Time                    `json:"updated_at"`
 }
 
 type TransactionProcessData struct {
-	Transaction Transaction         `json:"transaction"`
-	LogProduct  []ProductLogProcess `json:"log_product"`
+	Transaction Transaction          `json:"transaction"`
+	LogProduct  []ProductLogProcess  `json:"log_product"`
+	StoreOrders []StoreOrderProcess  `json:"store_orders"`
+	Coupon      *CouponRedemption    `json:"coupon"`
+	Promos      []AppliedPromo       `json:"promos"`
+	Vouchers    []StoreVoucherUse    `json:"vouchers"`
+	Referral    *ReferralAttribution `json:"referral"`
 }
 
 type TransactionDetail struct {
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// StoreOrderProcess carries the per-store values computed at checkout
+type StoreOrderProcess struct {
+	StoreID      uint    `json:"store_id"`
+	KodeInvoice  string  `json:"kode_invoice"`
+	OngkosKirim  float64 `json:"ongkos_kirim"`
+	DiskonOngkir float64 `json:"diskon_ongkir"`
+	Kurir        string  `json:"kurir"`
+	LayananKirim string  `json:"layanan_kirim"`
+}
+
+type StoreOrderStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type StoreOrderItemResponse struct {
+	ID            uint    `json:"id"`
+	IDLogProduk   uint    `json:"id_log_produk"`
+	NamaProduk    string  `json:"nama_produk"`
+	IDGudang      *uint   `json:"id_gudang,omitempty"` // Warehouse to pick the line from
+	Kuantitas     int     `json:"kuantitas"`
+	HargaTotal    float64 `json:"harga_total"`
+	Diskon        float64 `json:"diskon"`
+	Reseller      bool    `json:"reseller"` // Sold at the reseller price
+	ProductStatus string  `json:"product_status"`
+}
+
+type StoreOrderResponse struct {
+	ID           uint                     `json:"id"`
+	IDTrx        uint                     `json:"id_trx"`
+	IDToko       uint                     `json:"id_toko"`
+	NamaToko     string                   `json:"nama_toko"`
+	KodeInvoice  string                   `json:"kode_invoice"`
+	Subtotal     float64                  `json:"subtotal"`
+	OngkosKirim  float64                  `json:"ongkos_kirim"`
+	Diskon       float64                  `json:"diskon"`
+	DiskonOngkir float64                  `json:"diskon_ongkir"`
+	HargaTotal   float64                  `json:"harga_total"`
+	Kurir        string                   `json:"kurir"`
+	LayananKirim string                   `json:"layanan_kirim"`
+	Status       string                   `json:"status"`
+	Items        []StoreOrderItemResponse `json:"items"`
+	CreatedAt    *time.Time               `json:"created_at"`
+	UpdatedAt    *time.Time               `json:"updated_at"`
+}
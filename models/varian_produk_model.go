@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type VariantOptionInput struct {
+	Nama  string   `json:"nama"`
+	Nilai []string `json:"nilai"`
+}
+
+// VariantOptionsRequest replaces a product's options and regenerates its variant combinations
+type VariantOptionsRequest struct {
+	Opsi []VariantOptionInput `json:"opsi"`
+}
+
+type VariantUpdateRequest struct {
+	SKU           string  `json:"sku"`
+	HargaKonsumen *string `json:"harga_konsumen"`
+	HargaReseller *string `json:"harga_reseller"`
+	Stok          *int    `json:"stok"`
+	IDFotoProduk  *uint   `json:"id_foto_produk"`
+	IsActive      *bool   `json:"aktif"`
+}
+
+type VariantOptionResponse struct {
+	ID     uint     `json:"id"`
+	Nama   string   `json:"nama"`
+	Urutan int      `json:"urutan"`
+	Nilai  []string `json:"nilai"`
+}
+
+type ProductVariantResponse struct {
+	ID            uint       `json:"id"`
+	IDProduk      uint       `json:"id_produk"`
+	SKU           string     `json:"sku"`
+	Kombinasi     string     `json:"kombinasi"`
+	HargaKonsumen string     `json:"harga_konsumen"`
+	HargaCoret    string     `json:"harga_coret,omitempty"` // List price while a discount applies
+	HargaReseller string     `json:"harga_reseler,omitempty"`
+	HargaAnda     string     `json:"harga_anda,omitempty"` // Price the signed-in reseller pays
+	Stok          int        `json:"stok"`
+	IDFotoProduk  *uint      `json:"id_foto_produk"`
+	URLFoto       string     `json:"url_foto"`
+	IsActive      bool       `json:"aktif"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+type ProductVariantsResponse struct {
+	IDProduk uint                     `json:"id_produk"`
+	Opsi     []VariantOptionResponse  `json:"opsi"`
+	Varian   []ProductVariantResponse `json:"varian"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// StoreVoucherRequest creates or edits a store voucher; jenis is persen or nominal
+type StoreVoucherRequest struct {
+	IDToko       uint       `json:"id_toko"`
+	Kode         string     `json:"kode"`
+	Nama         string     `json:"nama"`
+	Jenis        string     `json:"jenis"`
+	Nilai        float64    `json:"nilai"`
+	MinBelanja   float64    `json:"min_belanja"`
+	MaksDiskon   float64    `json:"maks_diskon"`
+	Kuota        int        `json:"kuota"`
+	MulaiPada    *time.Time `json:"mulai_pada"`
+	BerakhirPada time.Time  `json:"berakhir_pada"`
+	Aktif        *bool      `json:"aktif"`
+}
+
+type StoreVoucherResponse struct {
+	ID           uint       `json:"id"`
+	IDToko       uint       `json:"id_toko"`
+	NamaToko     string     `json:"nama_toko"`
+	Kode         string     `json:"kode"`
+	Nama         string     `json:"nama"`
+	Jenis        string     `json:"jenis"`
+	Nilai        float64    `json:"nilai"`
+	MinBelanja   float64    `json:"min_belanja"`
+	MaksDiskon   float64    `json:"maks_diskon"`
+	Kuota        int        `json:"kuota"`
+	Diklaim      int        `json:"diklaim"`
+	MulaiPada    time.Time  `json:"mulai_pada"`
+	BerakhirPada time.Time  `json:"berakhir_pada"`
+	Aktif        bool       `json:"aktif"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+// VoucherClaimResponse is a voucher in the buyer's wallet. Status is tersedia,
+// terpakai, or kedaluwarsa for an unused voucher that can no longer be used.
+type VoucherClaimResponse struct {
+	ID          uint                 `json:"id"`
+	Status      string               `json:"status"`
+	IDTrx       *uint                `json:"id_trx"`
+	IDTrxToko   *uint                `json:"id_trx_toko"`
+	Diskon      float64              `json:"diskon"`
+	DipakaiPada *time.Time           `json:"dipakai_pada"`
+	Voucher     StoreVoucherResponse `json:"voucher"`
+	CreatedAt   *time.Time           `json:"created_at"`
+}
+
+// StoreVoucherUse is a claimed voucher a checkout uses on one store's lines
+type StoreVoucherUse struct {
+	ClaimID   uint    `json:"claim_id"`
+	VoucherID uint    `json:"voucher_id"`
+	IDToko    uint    `json:"id_toko"`
+	Kode      string  `json:"kode"`
+	Diskon    float64 `json:"diskon"`
+}
+
+// TransactionVoucherResponse is a store voucher used by a transaction
+type TransactionVoucherResponse struct {
+	IDVoucher uint    `json:"id_voucher"`
+	Kode      string  `json:"kode"`
+	IDToko    uint    `json:"id_toko"`
+	IDTrxToko *uint   `json:"id_trx_toko"`
+	Diskon    float64 `json:"diskon"`
+}
@@ -14,7 +14,7 @@ type WishlistRepository interface {
 	Insert(wishlist entities.Wishlist) (entities.Wishlist, error)
 	Delete(id uint) error
 	ValidateProduct(productID uint) (entities.Product, error)
-	Update(id uint, storeID uint, productID uint) error
+	Update(id uint, storeID uint, productID uint, variantID *uint) error
 	ClearAll(userID uint) ([]entities.Wishlist, error)
 }
 
@@ -39,9 +39,11 @@ func (repository *wishlistRepositoryImpl) FindAll(userID uint) ([]entities.Wishl
 		}).
 		Preload("Store.FotoToko").
 		Preload("Product").
+		Preload("Product.Diskon", activeDiscounts).
 		Preload("Product.Category").
 		Preload("Product.FotoProduk").
 		Preload("Product.Store").
+		Preload("Variant.FotoProduk").
 		Find(&wishlists).Error
 
 	if err != nil {
@@ -74,9 +76,11 @@ func (repository *wishlistRepositoryImpl) FindById(id uint) (entities.Wishlist,
 		}).
 		Preload("Store.FotoToko").
 		Preload("Product").
+		Preload("Product.Diskon", activeDiscounts).
 		Preload("Product.Category").
 		Preload("Product.FotoProduk").
 		Preload("Product.Store").
+		Preload("Variant.FotoProduk").
 		First(&wishlist, id).Error
 
 	// Update UrlFoto if FotoToko exists
@@ -105,12 +109,13 @@ func (repository *wishlistRepositoryImpl) ValidateProduct(productID uint) (entit
 	return product, err
 }
 
-func (repository *wishlistRepositoryImpl) Update(id uint, storeID uint, productID uint) error {
+func (repository *wishlistRepositoryImpl) Update(id uint, storeID uint, productID uint, variantID *uint) error {
 	return repository.database.Model(&entities.Wishlist{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"id_toko":    storeID,
 			"id_produk":  productID,
+			"id_varian":  variantID,
 			"updated_at": time.Now(),
 		}).Error
 }
@@ -126,9 +131,11 @@ func (repository *wishlistRepositoryImpl) ClearAll(userID uint) ([]entities.Wish
 		}).
 		Preload("Store.FotoToko").
 		Preload("Product").
+		Preload("Product.Diskon", activeDiscounts).
 		Preload("Product.Category").
 		Preload("Product.FotoProduk").
 		Preload("Product.Store").
+		Preload("Variant.FotoProduk").
 		Find(&wishlists).Error
 
 	if err != nil {
@@ -1,20 +1,20 @@
 package repositories
 
 import (
-	"fmt"
-	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
-	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type DiskonProdukRepository interface {
-	ApplyDiscount(productID uint, discountPercent string) (models.DiskonProdukResponse, error)
-	GetById(id uint) (models.DiskonProdukResponse, error)
-	GetAll() ([]models.DiskonProdukResponse, error)
-	UpdateDiscount(id uint, productID uint, discountPercent string) (models.DiskonProdukResponse, error)
-	DeleteDiscount(id uint) (models.DiskonProdukResponse, error) // Add this line
+	Create(diskon entities.DiskonProduk) (entities.DiskonProduk, error)
+	GetById(id uint) (entities.DiskonProduk, error)
+	GetAll(openEnded bool) ([]entities.DiskonProduk, error)
+	Update(diskon entities.DiskonProduk) (entities.DiskonProduk, error)
+	DeleteDiscount(id uint) error
+	RefreshStatuses(now time.Time) (int64, error)
+	RestoreLegacyPrices() (int64, error)
 }
 
 type diskonProdukRepositoryImpl struct {
@@ -25,242 +25,94 @@ func NewDiskonProdukRepository(database *gorm.DB) DiskonProdukRepository {
 	return &diskonProdukRepositoryImpl{database}
 }
 
-func (repository *diskonProdukRepositoryImpl) ApplyDiscount(productID uint, discountPercent string) (models.DiskonProdukResponse, error) {
-	// Start transaction
-	tx := repository.database.Begin()
+// activeDiscountCond matches the discounts that apply at the given time, see entities.DiskonProduk.IsActive
+const activeDiscountCond = "mulai_pada <= ? AND (berakhir_pada IS NULL OR berakhir_pada > ?)"
 
-	// First get the product
-	var product entities.Product
-	if err := tx.First(&product, productID).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Check if this is the first discount
-	if product.HargaOriginal == "" {
-		// Store the original price if this is the first discount
-		product.HargaOriginal = product.HargaKonsumen
-		if err := tx.Save(&product).Error; err != nil {
-			tx.Rollback()
-			return models.DiskonProdukResponse{}, err
-		}
-	}
-
-	// Use original price for discount calculation
-	originalPrice, err := strconv.ParseFloat(product.HargaOriginal, 64)
-	if err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Convert discount percentage to float
-	discountPercentFloat, err := strconv.ParseFloat(discountPercent, 64)
-	if err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Calculate new price based on original price
-	discountAmount := originalPrice * (discountPercentFloat / 100)
-	newPrice := originalPrice - discountAmount
-	newPriceStr := fmt.Sprintf("%.0f", newPrice)
-
-	// Create new discount record
-	diskon := entities.DiskonProduk{
-		ProductID:     productID,
-		HargaKonsumen: discountPercent + "%", // Store the percentage
-	}
-
-	if err := tx.Create(&diskon).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Update product's current price with the new discounted price
-	if err := tx.Model(&product).Update("harga_konsumen", newPriceStr).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return models.DiskonProdukResponse{}, err
-	}
+// activeDiscounts preloads only the discounts that apply now
+func activeDiscounts(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Where(activeDiscountCond, now, now)
+}
 
-	return models.DiskonProdukResponse{
-		ID:            diskon.ID,
-		ProductID:     diskon.ProductID,
-		HargaKonsumen: diskon.HargaKonsumen,
-		CreatedAt:     diskon.CreatedAt,
-		UpdatedAt:     diskon.UpdatedAt,
-	}, nil
+func (repository *diskonProdukRepositoryImpl) Create(diskon entities.DiskonProduk) (entities.DiskonProduk, error) {
+	err := repository.database.Create(&diskon).Error
+	return diskon, err
 }
 
-func (repository *diskonProdukRepositoryImpl) GetById(id uint) (models.DiskonProdukResponse, error) {
+func (repository *diskonProdukRepositoryImpl) GetById(id uint) (entities.DiskonProduk, error) {
 	var diskon entities.DiskonProduk
-
 	err := repository.database.First(&diskon, id).Error
-	if err != nil {
-		return models.DiskonProdukResponse{}, err
-	}
-
-	return models.DiskonProdukResponse{
-		ID:            diskon.ID,
-		ProductID:     diskon.ProductID,
-		HargaKonsumen: diskon.HargaKonsumen,
-		CreatedAt:     diskon.CreatedAt,
-		UpdatedAt:     diskon.UpdatedAt,
-	}, nil
+	return diskon, err
 }
 
-func (repository *diskonProdukRepositoryImpl) GetAll() ([]models.DiskonProdukResponse, error) {
+// GetAll lists the discounts, newest first. openEnded keeps only those without
+// an end, which are the legacy ones restored by RestoreLegacyPrices.
+func (repository *diskonProdukRepositoryImpl) GetAll(openEnded bool) ([]entities.DiskonProduk, error) {
 	var diskons []entities.DiskonProduk
 
-	// Add Order("created_at DESC") to get newest records first
-	err := repository.database.Order("created_at DESC").Find(&diskons).Error
-	if err != nil {
-		return nil, err
-	}
-
-	var responses []models.DiskonProdukResponse
-	for _, diskon := range diskons {
-		responses = append(responses, models.DiskonProdukResponse{
-			ID:            diskon.ID,
-			ProductID:     diskon.ProductID,
-			HargaKonsumen: diskon.HargaKonsumen,
-			CreatedAt:     diskon.CreatedAt,
-			UpdatedAt:     diskon.UpdatedAt,
-		})
+	query := repository.database.Order("created_at DESC")
+	if openEnded {
+		query = query.Where("berakhir_pada IS NULL")
 	}
-
-	return responses, nil
+	err := query.Find(&diskons).Error
+	return diskons, err
 }
 
-func (repository *diskonProdukRepositoryImpl) UpdateDiscount(id uint, productID uint, discountPercent string) (models.DiskonProdukResponse, error) {
-	// Start transaction
-	tx := repository.database.Begin()
+func (repository *diskonProdukRepositoryImpl) Update(diskon entities.DiskonProduk) (entities.DiskonProduk, error) {
+	err := repository.database.Save(&diskon).Error
+	return diskon, err
+}
 
-	// First get the product
-	var product entities.Product
-	if err := tx.First(&product, productID).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
+func (repository *diskonProdukRepositoryImpl) DeleteDiscount(id uint) error {
+	return repository.database.Delete(&entities.DiskonProduk{}, id).Error
+}
 
-	// Use HargaOriginal if it exists, otherwise use current HargaKonsumen
-	originalPrice, err := strconv.ParseFloat(product.HargaOriginal, 64)
-	if err != nil {
-		// If HargaOriginal is empty or invalid, use current HargaKonsumen
-		originalPrice, err = strconv.ParseFloat(product.HargaKonsumen, 64)
-		if err != nil {
-			tx.Rollback()
-			return models.DiskonProdukResponse{}, err
+// RefreshStatuses stores the status every discount has at now and returns how
+// many changed. Prices follow the schedule either way.
+func (repository *diskonProdukRepositoryImpl) RefreshStatuses(now time.Time) (int64, error) {
+	var changed int64
+	err := repository.database.Transaction(func(tx *gorm.DB) error {
+		active := tx.Model(&entities.DiskonProduk{}).
+			Where(activeDiscountCond, now, now).
+			Where("status <> ?", entities.DiscountActive).
+			Updates(map[string]interface{}{"status": entities.DiscountActive, "updated_at": now})
+		if active.Error != nil {
+			return active.Error
 		}
-		// Store the original price if not already stored
-		product.HargaOriginal = product.HargaKonsumen
-		if err := tx.Save(&product).Error; err != nil {
-			tx.Rollback()
-			return models.DiskonProdukResponse{}, err
+		expired := tx.Model(&entities.DiskonProduk{}).
+			Where("berakhir_pada <= ? AND status <> ?", now, entities.DiscountExpired).
+			Updates(map[string]interface{}{"status": entities.DiscountExpired, "updated_at": now})
+		if expired.Error != nil {
+			return expired.Error
 		}
-	}
-
-	// Convert discount percentage to float
-	discountPercentFloat, err := strconv.ParseFloat(discountPercent, 64)
-	if err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Calculate new price based on original price
-	discountAmount := originalPrice * (discountPercentFloat / 100)
-	newPrice := originalPrice - discountAmount
-	newPriceStr := fmt.Sprintf("%.0f", newPrice)
-
-	// Update existing discount record
-	var diskon entities.DiskonProduk
-	if err := tx.First(&diskon, id).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	diskon.ProductID = productID
-	diskon.HargaKonsumen = discountPercent + "%"
-
-	if err := tx.Save(&diskon).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Update product's price
-	if err := tx.Model(&product).Update("harga_konsumen", newPriceStr).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return models.DiskonProdukResponse{}, err
-	}
-
-	return models.DiskonProdukResponse{
-		ID:            diskon.ID,
-		ProductID:     diskon.ProductID,
-		HargaKonsumen: diskon.HargaKonsumen,
-		CreatedAt:     diskon.CreatedAt,
-		UpdatedAt:     diskon.UpdatedAt,
-	}, nil
+		changed = active.RowsAffected + expired.RowsAffected
+		return nil
+	})
+	return changed, err
 }
 
-func (repository *diskonProdukRepositoryImpl) DeleteDiscount(id uint) (models.DiskonProdukResponse, error) {
-	// Start transaction
-	tx := repository.database.Begin()
-
-	// First get the discount record
-	var diskon entities.DiskonProduk
-	if err := tx.First(&diskon, id).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Get the product to restore original price
-	var product entities.Product
-	if err := tx.First(&product, diskon.ProductID).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Restore original price if it exists
-	if product.HargaOriginal != "" {
-		if err := tx.Model(&product).Update("harga_konsumen", product.HargaOriginal).Error; err != nil {
-			tx.Rollback()
-			return models.DiskonProdukResponse{}, err
-		}
-		// Clear the original price
-		if err := tx.Model(&product).Update("harga_original", "").Error; err != nil {
-			tx.Rollback()
-			return models.DiskonProdukResponse{}, err
+// RestoreLegacyPrices undoes discounts made before they were computed at read
+// time. Those overwrote produk.harga_konsumen and kept the list price in
+// harga_original; the product gets its list price back and the discount its
+// percentage and start, so the price buyers see stays the same. The legacy
+// rows hold no end date to restore, so the discounts keep running until the
+// seller or an admin ends them.
+func (repository *diskonProdukRepositoryImpl) RestoreLegacyPrices() (int64, error) {
+	var restored int64
+	err := repository.database.Transaction(func(tx *gorm.DB) error {
+		products := tx.Exec(
+			`UPDATE produk SET harga_konsumen = harga_original, harga_original = ''
+			WHERE harga_original <> ''
+			AND EXISTS (SELECT 1 FROM diskon_produk d WHERE d.id_produk = produk.id AND d.mulai_pada IS NULL)`,
+		)
+		if products.Error != nil {
+			return products.Error
 		}
-	}
-
-	// Create response before deleting the record
-	response := models.DiskonProdukResponse{
-		ID:            diskon.ID,
-		ProductID:     diskon.ProductID,
-		HargaKonsumen: diskon.HargaKonsumen,
-		CreatedAt:     diskon.CreatedAt,
-		UpdatedAt:     diskon.UpdatedAt,
-	}
-
-	// Delete the discount record
-	if err := tx.Delete(&diskon).Error; err != nil {
-		tx.Rollback()
-		return models.DiskonProdukResponse{}, err
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return models.DiskonProdukResponse{}, err
-	}
-
-	return response, nil
+		restored = products.RowsAffected
+		return tx.Exec(
+			`UPDATE diskon_produk SET persen = CAST(REPLACE(harga_konsumen, '%', '') AS DECIMAL(5,2)), mulai_pada = created_at
+			WHERE mulai_pada IS NULL`,
+		).Error
+	})
+	return restored, err
 }
@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FlashSaleRepository interface {
+	FindAll() ([]entities.FlashSale, error)
+	FindCurrent(now time.Time) ([]entities.FlashSale, error)
+	FindById(id uint) (entities.FlashSale, error)
+	Create(sale entities.FlashSale) (entities.FlashSale, error)
+	Update(sale entities.FlashSale) (entities.FlashSale, error)
+	Delete(id uint) error
+	FindItem(saleID uint, itemID uint) (entities.FlashSaleItem, error)
+	FindLiveItems(productID uint, now time.Time) ([]entities.FlashSaleItem, error)
+	ItemTaken(saleID uint, productID uint, variantID *uint, exceptID uint) (bool, error)
+	CreateItem(item entities.FlashSaleItem) (entities.FlashSaleItem, error)
+	UpdateItem(item entities.FlashSaleItem) (entities.FlashSaleItem, error)
+	DeleteItem(id uint) error
+	CountReserved(itemID uint, userID uint) (int, error)
+}
+
+type flashSaleRepositoryImpl struct {
+	database *gorm.DB
+}
+
+func NewFlashSaleRepository(database *gorm.DB) FlashSaleRepository {
+	return &flashSaleRepositoryImpl{database}
+}
+
+func (r *flashSaleRepositoryImpl) preloadItems(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id asc") }).
+		Preload("Items.Product").
+		Preload("Items.Variant")
+}
+
+func (r *flashSaleRepositoryImpl) FindAll() ([]entities.FlashSale, error) {
+	var sales []entities.FlashSale
+	err := r.preloadItems(r.database).Order("mulai_pada desc").Find(&sales).Error
+	return sales, err
+}
+
+// FindCurrent finds the active sales that have not ended at now, soonest first
+func (r *flashSaleRepositoryImpl) FindCurrent(now time.Time) ([]entities.FlashSale, error) {
+	var sales []entities.FlashSale
+	err := r.preloadItems(r.database).
+		Where("aktif = ? AND berakhir_pada > ?", true, now).
+		Order("mulai_pada asc, id asc").
+		Find(&sales).Error
+	return sales, err
+}
+
+func (r *flashSaleRepositoryImpl) FindById(id uint) (entities.FlashSale, error) {
+	var sale entities.FlashSale
+	err := r.preloadItems(r.database).First(&sale, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return sale, errors.New("flash sale not found")
+	}
+	return sale, err
+}
+
+func (r *flashSaleRepositoryImpl) Create(sale entities.FlashSale) (entities.FlashSale, error) {
+	if err := r.database.Omit("Items.Product", "Items.Variant", "Items.FlashSale").Create(&sale).Error; err != nil {
+		return entities.FlashSale{}, err
+	}
+	return r.FindById(sale.ID)
+}
+
+func (r *flashSaleRepositoryImpl) Update(sale entities.FlashSale) (entities.FlashSale, error) {
+	if err := r.database.Omit("Items", "created_at").Save(&sale).Error; err != nil {
+		return entities.FlashSale{}, err
+	}
+	return r.FindById(sale.ID)
+}
+
+// Delete removes a sale and its items. A sale that has sold anything is kept
+// with the transactions; deactivate it instead.
+func (r *flashSaleRepositoryImpl) Delete(id uint) error {
+	return r.database.Transaction(func(tx *gorm.DB) error {
+		var sold int64
+		if err := tx.Model(&entities.FlashSalePurchase{}).
+			Where("id_flash_sale_produk IN (?)", tx.Model(&entities.FlashSaleItem{}).Select("id").Where("id_flash_sale = ?", id)).
+			Count(&sold).Error; err != nil {
+			return err
+		}
+		if sold > 0 {
+			return errors.New("flash sale has purchases; deactivate it instead")
+		}
+		if err := tx.Where("id_flash_sale = ?", id).Delete(&entities.FlashSaleItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.FlashSale{}, id).Error
+	})
+}
+
+func (r *flashSaleRepositoryImpl) FindItem(saleID uint, itemID uint) (entities.FlashSaleItem, error) {
+	var item entities.FlashSaleItem
+	err := r.database.Preload("Product").Preload("Variant").
+		Where("id = ? AND id_flash_sale = ?", itemID, saleID).
+		First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return item, errors.New("flash sale item not found")
+	}
+	return item, err
+}
+
+// FindLiveItems finds the items of a product in the sales that are live at now
+func (r *flashSaleRepositoryImpl) FindLiveItems(productID uint, now time.Time) ([]entities.FlashSaleItem, error) {
+	var items []entities.FlashSaleItem
+	err := r.database.
+		Joins("FlashSale").
+		Where("flash_sale_produk.id_produk = ?", productID).
+		Where("FlashSale.aktif = ? AND FlashSale.mulai_pada <= ? AND FlashSale.berakhir_pada > ?", true, now, now).
+		Order("flash_sale_produk.id asc").
+		Find(&items).Error
+	return items, err
+}
+
+// ItemTaken reports whether the sale already has the product or variant
+func (r *flashSaleRepositoryImpl) ItemTaken(saleID uint, productID uint, variantID *uint, exceptID uint) (bool, error) {
+	query := r.database.Model(&entities.FlashSaleItem{}).
+		Where("id_flash_sale = ? AND id_produk = ? AND id <> ?", saleID, productID, exceptID)
+	if variantID == nil {
+		query = query.Where("id_varian IS NULL")
+	} else {
+		query = query.Where("id_varian = ?", *variantID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *flashSaleRepositoryImpl) CreateItem(item entities.FlashSaleItem) (entities.FlashSaleItem, error) {
+	if err := r.database.Omit("Product", "Variant", "FlashSale").Create(&item).Error; err != nil {
+		return entities.FlashSaleItem{}, err
+	}
+	return r.FindItem(item.IDFlashSale, item.ID)
+}
+
+// UpdateItem saves the terms of an item. Terjual is only changed by checkouts,
+// and the quota cannot go below it.
+func (r *flashSaleRepositoryImpl) UpdateItem(item entities.FlashSaleItem) (entities.FlashSaleItem, error) {
+	result := r.database.Model(&entities.FlashSaleItem{}).
+		Where("id = ? AND terjual <= ?", item.ID, item.Kuota).
+		Updates(map[string]interface{}{
+			"harga_flash":    item.HargaFlash,
+			"kuota":          item.Kuota,
+			"batas_per_user": item.BatasPerUser,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return entities.FlashSaleItem{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return entities.FlashSaleItem{}, errors.New("kuota cannot be less than the units already sold")
+	}
+	return r.FindItem(item.IDFlashSale, item.ID)
+}
+
+func (r *flashSaleRepositoryImpl) DeleteItem(id uint) error {
+	return r.database.Transaction(func(tx *gorm.DB) error {
+		var sold int64
+		if err := tx.Model(&entities.FlashSalePurchase{}).Where("id_flash_sale_produk = ?", id).Count(&sold).Error; err != nil {
+			return err
+		}
+		if sold > 0 {
+			return errors.New("flash sale item has purchases and cannot be removed")
+		}
+		return tx.Delete(&entities.FlashSaleItem{}, id).Error
+	})
+}
+
+// CountReserved is how many units of an item the user holds in checkouts that
+// were not cancelled
+func (r *flashSaleRepositoryImpl) CountReserved(itemID uint, userID uint) (int, error) {
+	var reserved int
+	err := r.database.Model(&entities.FlashSalePurchase{}).
+		Select("COALESCE(SUM(jumlah), 0)").
+		Where("id_flash_sale_produk = ? AND id_user = ? AND status = ?", itemID, userID, entities.FlashSaleReserved).
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+// reserveFlashSales takes the units of a checkout's flash sale lines off their
+// quotas, booked to buyerID, the signed-in buyer. Each item row and the buyer's
+// purchases of it are locked while its quota and the buyer's limit are checked,
+// in id order so concurrent checkouts do not deadlock; the checkout fails when
+// a sale ended or sold out meanwhile.
+func reserveFlashSales(tx *gorm.DB, buyerID uint, purchases []entities.FlashSalePurchase) error {
+	sort.SliceStable(purchases, func(i, j int) bool {
+		return purchases[i].IDFlashSaleItem < purchases[j].IDFlashSaleItem
+	})
+	now := time.Now()
+	for _, purchase := range purchases {
+		purchase.IDUser = buyerID
+		var item entities.FlashSaleItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("FlashSale").Preload("Product").
+			First(&item, purchase.IDFlashSaleItem).Error; err != nil {
+			return errors.New("flash sale item is no longer available")
+		}
+		if !item.FlashSale.IsLive(now) {
+			return fmt.Errorf("flash sale for %s is not live", item.Product.NamaProduk)
+		}
+		if purchase.Jumlah > item.Sisa() {
+			return fmt.Errorf("flash sale for %s has only %d left", item.Product.NamaProduk, item.Sisa())
+		}
+		if item.BatasPerUser > 0 {
+			// A locking read sees the purchases committed since the checkout
+			// began, which a plain read of its snapshot would miss, and locks
+			// the buyer's purchase rows until the checkout ends
+			var reserved int
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Model(&entities.FlashSalePurchase{}).
+				Select("COALESCE(SUM(jumlah), 0)").
+				Where("id_flash_sale_produk = ? AND id_user = ? AND status = ?", item.ID, purchase.IDUser, entities.FlashSaleReserved).
+				Scan(&reserved).Error; err != nil {
+				return err
+			}
+			if reserved+purchase.Jumlah > item.BatasPerUser {
+				return fmt.Errorf("flash sale for %s is limited to %d per buyer", item.Product.NamaProduk, item.BatasPerUser)
+			}
+		}
+
+		if err := tx.Model(&item).UpdateColumn("terjual", gorm.Expr("terjual + ?", purchase.Jumlah)).Error; err != nil {
+			return err
+		}
+		purchase.Status = entities.FlashSaleReserved
+		if err := tx.Create(&purchase).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseFlashSales gives the units of cancelled checkout lines back to their
+// quotas. column is id_trx or id_trx_toko.
+func releaseFlashSales(tx *gorm.DB, column string, id uint) error {
+	var purchases []entities.FlashSalePurchase
+	if err := tx.Where(column+" = ? AND status = ?", id, entities.FlashSaleReserved).Find(&purchases).Error; err != nil {
+		return err
+	}
+	for _, purchase := range purchases {
+		if err := tx.Model(&entities.FlashSaleItem{}).
+			Where("id = ?", purchase.IDFlashSaleItem).
+			UpdateColumn("terjual", gorm.Expr("GREATEST(terjual - ?, 0)", purchase.Jumlah)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&purchase).Update("status", entities.FlashSaleReleased).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WarehouseRepository interface {
+	FindByStore(storeID uint) ([]entities.Warehouse, error)
+	FindActiveByStores(storeIDs []uint) ([]entities.Warehouse, error)
+	FindById(id uint) (entities.Warehouse, error)
+	Create(warehouse entities.Warehouse) (entities.Warehouse, error)
+	Update(warehouse entities.Warehouse) (entities.Warehouse, error)
+	TotalStock(id uint) (int, error)
+	FindStocks(warehouseIDs []uint, productIDs []uint) ([]entities.WarehouseStock, error)
+	Transfer(transfer entities.WarehouseTransfer) (entities.WarehouseTransfer, error)
+	FindTransfers(storeID uint, limit int, offset int) ([]entities.WarehouseTransfer, int64, error)
+}
+
+type warehouseRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepositoryImpl{db}
+}
+
+func (r *warehouseRepositoryImpl) FindByStore(storeID uint) ([]entities.Warehouse, error) {
+	var warehouses []entities.Warehouse
+	err := r.db.Where("id_toko = ?", storeID).Order("utama desc, id asc").Find(&warehouses).Error
+	return warehouses, err
+}
+
+// FindActiveByStores lists the warehouses that can ship, the primary one of each store first
+func (r *warehouseRepositoryImpl) FindActiveByStores(storeIDs []uint) ([]entities.Warehouse, error) {
+	var warehouses []entities.Warehouse
+	if len(storeIDs) == 0 {
+		return warehouses, nil
+	}
+	err := r.db.Where("id_toko IN ? AND aktif = ?", storeIDs, true).Order("utama desc, id asc").Find(&warehouses).Error
+	return warehouses, err
+}
+
+func (r *warehouseRepositoryImpl) FindById(id uint) (entities.Warehouse, error) {
+	var warehouse entities.Warehouse
+	err := r.db.First(&warehouse, id).Error
+	return warehouse, err
+}
+
+// Create adds a warehouse. The first warehouse of a store becomes its primary
+// one and takes over all the stock the store had so far.
+func (r *warehouseRepositoryImpl) Create(warehouse entities.Warehouse) (entities.Warehouse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the store so two first warehouses cannot both take the stock
+		var store entities.Store
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&store, warehouse.IDToko).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&entities.Warehouse{}).Where("id_toko = ?", warehouse.IDToko).Count(&count).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		first := count == 0
+		if first {
+			warehouse.Utama = true
+			warehouse.Aktif = true
+		} else if warehouse.Utama {
+			if err := clearPrimaryWarehouse(tx, warehouse.IDToko, now); err != nil {
+				return err
+			}
+		}
+		warehouse.CreatedAt = &now
+		warehouse.UpdatedAt = &now
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+		if !first {
+			return nil
+		}
+
+		if err := tx.Exec(
+			`INSERT INTO gudang_stok (id_gudang, id_produk, stok, updated_at)
+			SELECT ?, p.id, p.stok, ? FROM produk p
+			WHERE p.id_toko = ? AND p.stok <> 0
+			AND NOT EXISTS (SELECT 1 FROM produk_varian v WHERE v.id_produk = p.id AND v.aktif = ?)`,
+			warehouse.ID, now, warehouse.IDToko, true,
+		).Error; err != nil {
+			return err
+		}
+		return tx.Exec(
+			`INSERT INTO gudang_stok (id_gudang, id_produk, id_varian, stok, updated_at)
+			SELECT ?, v.id_produk, v.id, v.stok, ? FROM produk_varian v
+			JOIN produk p ON p.id = v.id_produk
+			WHERE p.id_toko = ? AND v.stok <> 0`,
+			warehouse.ID, now, warehouse.IDToko,
+		).Error
+	})
+	return warehouse, err
+}
+
+// Update saves the warehouse; making it the primary one demotes the previous primary
+func (r *warehouseRepositoryImpl) Update(warehouse entities.Warehouse) (entities.Warehouse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if warehouse.Utama {
+			if err := clearPrimaryWarehouse(tx, warehouse.IDToko, now); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&entities.Warehouse{}).Where("id = ?", warehouse.ID).Updates(map[string]interface{}{
+			"nama":        warehouse.Nama,
+			"alamat":      warehouse.Alamat,
+			"id_provinsi": warehouse.IDProvinsi,
+			"id_kota":     warehouse.IDKota,
+			"utama":       warehouse.Utama,
+			"aktif":       warehouse.Aktif,
+			"updated_at":  now,
+		}).Error
+	})
+	if err != nil {
+		return entities.Warehouse{}, err
+	}
+	return r.FindById(warehouse.ID)
+}
+
+// TotalStock sums the stock held in a warehouse
+func (r *warehouseRepositoryImpl) TotalStock(id uint) (int, error) {
+	var total int
+	err := r.db.Model(&entities.WarehouseStock{}).
+		Where("id_gudang = ?", id).
+		Select("COALESCE(SUM(stok), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// FindStocks lists the stock rows of the warehouses, limited to productIDs when given
+func (r *warehouseRepositoryImpl) FindStocks(warehouseIDs []uint, productIDs []uint) ([]entities.WarehouseStock, error) {
+	var stocks []entities.WarehouseStock
+	if len(warehouseIDs) == 0 {
+		return stocks, nil
+	}
+	query := r.db.
+		Preload("Product", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, nama_produk, slug, sku")
+		}).
+		Where("id_gudang IN ?", warehouseIDs)
+	if len(productIDs) > 0 {
+		query = query.Where("id_produk IN ?", productIDs)
+	}
+	err := query.Order("id_produk asc, id_varian asc").Find(&stocks).Error
+	return stocks, err
+}
+
+// Transfer moves stock from one warehouse to another; the product's stock stays the same
+func (r *warehouseRepositoryImpl) Transfer(transfer entities.WarehouseTransfer) (entities.WarehouseTransfer, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the product or variant, the same row a stock movement locks first
+		var product entities.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, nama_produk").First(&product, transfer.IDProduk).Error; err != nil {
+			return err
+		}
+		if transfer.IDVarian != nil {
+			var variant entities.ProductVariant
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&variant, *transfer.IDVarian).Error; err != nil {
+				return err
+			}
+		}
+
+		var from, to entities.Warehouse
+		if err := tx.First(&from, transfer.IDGudangAsal).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&to, transfer.IDGudangTujuan).Error; err != nil {
+			return err
+		}
+		if err := shiftWarehouseStock(tx, from, product.ID, transfer.IDVarian, -transfer.Jumlah, product.NamaProduk); err != nil {
+			return err
+		}
+		if err := shiftWarehouseStock(tx, to, product.ID, transfer.IDVarian, transfer.Jumlah, product.NamaProduk); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.CreatedAt = &now
+		return tx.Create(&transfer).Error
+	})
+	return transfer, err
+}
+
+func (r *warehouseRepositoryImpl) FindTransfers(storeID uint, limit int, offset int) ([]entities.WarehouseTransfer, int64, error) {
+	var transfers []entities.WarehouseTransfer
+	var total int64
+
+	query := r.db.Model(&entities.WarehouseTransfer{}).Where("id_toko = ?", storeID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id desc").Limit(limit).Offset(offset).Find(&transfers).Error
+	return transfers, total, err
+}
+
+func clearPrimaryWarehouse(tx *gorm.DB, storeID uint, now time.Time) error {
+	return tx.Model(&entities.Warehouse{}).
+		Where("id_toko = ? AND utama = ?", storeID, true).
+		Updates(map[string]interface{}{"utama": false, "updated_at": now}).Error
+}
+
+// moveWarehouseStock applies movement.Jumlah to the warehouse it names, or to
+// the primary warehouse of the product's store, and records which one it was.
+// Stores without warehouses keep a single stock and are left alone.
+func moveWarehouseStock(tx *gorm.DB, movement *entities.StockMovement) error {
+	var product entities.Product
+	if err := tx.Select("id, id_toko, nama_produk").First(&product, movement.IDProduk).Error; err != nil {
+		return err
+	}
+
+	var warehouse entities.Warehouse
+	query := tx.Where("id_toko = ?", product.IDToko)
+	if movement.IDGudang != nil {
+		query = query.Where("id = ?", *movement.IDGudang)
+	} else {
+		query = query.Where("utama = ?", true)
+	}
+	err := query.First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if movement.IDGudang != nil {
+			return errors.New("warehouse not found in the product's store")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := shiftWarehouseStock(tx, warehouse, product.ID, movement.IDVarian, movement.Jumlah, product.NamaProduk); err != nil {
+		return err
+	}
+	movement.IDGudang = &warehouse.ID
+	return nil
+}
+
+// shiftWarehouseStock adds delta to the stock of a product or variant in one
+// warehouse. Callers lock the product or variant first, so the row is created
+// at most once.
+func shiftWarehouseStock(tx *gorm.DB, warehouse entities.Warehouse, productID uint, variantID *uint, delta int, name string) error {
+	var stock entities.WarehouseStock
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id_gudang = ? AND id_produk = ?", warehouse.ID, productID)
+	if variantID != nil {
+		query = query.Where("id_varian = ?", *variantID)
+	} else {
+		query = query.Where("id_varian IS NULL")
+	}
+	err := query.First(&stock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		stock = entities.WarehouseStock{IDGudang: warehouse.ID, IDProduk: productID, IDVarian: variantID}
+	} else if err != nil {
+		return err
+	}
+
+	after := stock.Stok + delta
+	if after < 0 {
+		return fmt.Errorf("insufficient stock for %s in warehouse %s, %d left", name, warehouse.Nama, stock.Stok)
+	}
+	now := time.Now()
+	if stock.ID == 0 {
+		stock.Stok = after
+		stock.UpdatedAt = &now
+		return tx.Omit("Product").Create(&stock).Error
+	}
+	return tx.Model(&entities.WarehouseStock{}).
+		Where("id = ?", stock.ID).
+		Updates(map[string]interface{}{"stok": after, "updated_at": now}).Error
+}
+
+// resetWarehouseProductStock puts the stock of a product that no longer has
+// active variants back in its primary warehouse when the product's own
+// warehouse rows went stale while the stock was kept in the variants
+func resetWarehouseProductStock(tx *gorm.DB, productID uint) error {
+	var product entities.Product
+	if err := tx.Select("id, id_toko, stok").First(&product, productID).Error; err != nil {
+		return err
+	}
+	var primary entities.Warehouse
+	err := tx.Where("id_toko = ? AND utama = ?", product.IDToko, true).First(&primary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var total int
+	if err := tx.Model(&entities.WarehouseStock{}).
+		Where("id_produk = ? AND id_varian IS NULL", productID).
+		Select("COALESCE(SUM(stok), 0)").
+		Scan(&total).Error; err != nil {
+		return err
+	}
+	if total == product.Stok {
+		return nil
+	}
+
+	if err := tx.Model(&entities.WarehouseStock{}).
+		Where("id_produk = ? AND id_varian IS NULL", productID).
+		Updates(map[string]interface{}{"stok": 0, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	return shiftWarehouseStock(tx, primary, productID, nil, product.Stok, "")
+}
@@ -10,6 +10,9 @@ import (
 type CategoryRepository interface {
 	FindAll() ([]entities.Category, error)
 	FindById(id uint) (entities.Category, error)
+	FindBySlug(slug string) (entities.Category, error)
+	SlugExists(slug string, excludeID uint) (bool, error)
+	HasChildren(id uint) (bool, error)
 	Insert(category entities.Category) (entities.Category, error)
 	Update(id uint, category entities.Category) (entities.Category, error)
 	Destroy(id uint) (bool, error)
@@ -25,7 +28,7 @@ func NewCategoryRepository(database *gorm.DB) CategoryRepository {
 
 func (repository *categoryRepositoryImpl) FindAll() ([]entities.Category, error) {
 	var categories []entities.Category
-	err := repository.database.Order("id desc").Find(&categories).Error
+	err := repository.database.Order("urutan asc, id desc").Find(&categories).Error
 	if err != nil {
 		return categories, err
 	}
@@ -41,6 +44,29 @@ func (repository *categoryRepositoryImpl) FindById(id uint) (entities.Category,
 	return category, nil
 }
 
+func (repository *categoryRepositoryImpl) FindBySlug(slug string) (entities.Category, error) {
+	var category entities.Category
+	err := repository.database.Where("slug = ?", slug).First(&category).Error
+	return category, err
+}
+
+// SlugExists also checks deleted categories, since they still hold the unique slug
+func (repository *categoryRepositoryImpl) SlugExists(slug string, excludeID uint) (bool, error) {
+	var count int64
+	err := repository.database.Unscoped().Model(&entities.Category{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repository *categoryRepositoryImpl) HasChildren(id uint) (bool, error) {
+	var count int64
+	err := repository.database.Model(&entities.Category{}).
+		Where("id_parent = ?", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (repository *categoryRepositoryImpl) Insert(category entities.Category) (entities.Category, error) {
 	err := repository.database.Create(&category).Error
 	return category, err
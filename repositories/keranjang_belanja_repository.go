@@ -4,6 +4,7 @@ import (
 	"errors"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -27,7 +28,7 @@ func NewKeranjangBelanjaRepository(db *gorm.DB) KeranjangBelanjaRepository {
 
 func (repository *keranjangBelanjaRepositoryImpl) FindAll() ([]entities.KeranjangBelanja, error) {
 	var keranjangBelanja []entities.KeranjangBelanja
-	err := repository.db.Order("id desc").Preload("Store.FotoToko").Preload("Product").Preload("Product.FotoProduk").Find(&keranjangBelanja).Error
+	err := repository.db.Order("id desc").Preload("Store.FotoToko").Preload("Product").Preload("Product.Diskon", activeDiscounts).Preload("Product.FotoProduk").Preload("Variant.FotoProduk").Find(&keranjangBelanja).Error
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +52,9 @@ func (repository *keranjangBelanjaRepositoryImpl) FindById(id uint) (entities.Ke
 		}).
 		Preload("Store.FotoToko").
 		Preload("Product").
+		Preload("Product.Diskon", activeDiscounts).
 		Preload("Product.FotoProduk").
+		Preload("Variant.FotoProduk").
 		First(&keranjangBelanja, id).Error
 	if err != nil {
 		return keranjangBelanja, err
@@ -77,10 +80,14 @@ func (repository *keranjangBelanjaRepositoryImpl) Create(input models.KeranjangB
 	if err := repository.db.First(&product, input.IDProduk).Error; err != nil {
 		return entities.KeranjangBelanja{}, errors.New("product not found")
 	}
+	if !product.IsPublished(time.Now()) {
+		return entities.KeranjangBelanja{}, errors.New("product is not available")
+	}
 
 	keranjangBelanja := entities.KeranjangBelanja{
 		IDToko:   input.IDToko,
 		IDProduk: input.IDProduk,
+		IDVarian: input.IDVarian,
 	}
 
 	err := repository.db.Create(&keranjangBelanja).Error
@@ -93,6 +100,7 @@ func (repository *keranjangBelanjaRepositoryImpl) Create(input models.KeranjangB
 	err = repository.db.
 		Preload("Store.FotoToko").
 		Preload("Product").
+		Preload("Product.Diskon", activeDiscounts).
 		Preload("Product.FotoProduk").
 		First(&completeKeranjang, keranjangBelanja.ID).Error
 	if err != nil {
@@ -125,10 +133,14 @@ func (repository *keranjangBelanjaRepositoryImpl) Update(id uint, input models.K
 	if err := repository.db.First(&product, input.IDProduk).Error; err != nil {
 		return entities.KeranjangBelanja{}, errors.New("product not found")
 	}
+	if !product.IsPublished(time.Now()) {
+		return entities.KeranjangBelanja{}, errors.New("product is not available")
+	}
 
 	// Update the cart item
 	keranjangBelanja.IDToko = input.IDToko
 	keranjangBelanja.IDProduk = input.IDProduk
+	keranjangBelanja.IDVarian = input.IDVarian
 	keranjangBelanja.JumlahProduk = input.JumlahProduk
 
 	err := repository.db.Save(&keranjangBelanja).Error
@@ -4,21 +4,25 @@ import (
 	"fmt"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
-	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductCouponRepository interface {
-	FindAll() ([]models.ProductCouponResponse, error)
-	FindById(id uint) (models.ProductCouponResponse, error)
-	FindByCode(code string) (models.ProductCouponResponse, error)
-	FindByProduct(productId uint) ([]models.ProductCouponResponse, error)
-	Create(coupon models.ProductCouponRequest) (models.ProductCouponResponse, error)
-	Update(id uint, coupon models.ProductCouponRequest) (models.ProductCouponResponse, error)
-	Delete(id uint) (models.ProductCouponResponse, error) // Changed return type
-	ValidateCoupon(code string, productId uint) (models.ProductCouponResponse, error)
+	FindAll() ([]entities.ProductCoupon, error)
+	FindById(id uint) (entities.ProductCoupon, error)
+	FindByCode(code string) (entities.ProductCoupon, error)
+	FindByProduct(productId uint) ([]entities.ProductCoupon, error)
+	CodeTaken(code string, exceptID uint) (bool, error)
+	Create(coupon entities.ProductCoupon) (entities.ProductCoupon, error)
+	Update(coupon entities.ProductCoupon) (entities.ProductCoupon, error)
+	Delete(id uint) (entities.ProductCoupon, error)
+	CountRedemptions(couponID uint, userID uint) (int64, error)
+	FindRedemptions(couponID uint) ([]entities.CouponRedemption, error)
+	RestoreLegacyPrices() (int64, error)
 }
 
 type productCouponRepositoryImpl struct {
@@ -29,277 +33,146 @@ func NewProductCouponRepository(db *gorm.DB) ProductCouponRepository {
 	return &productCouponRepositoryImpl{db}
 }
 
-func (r *productCouponRepositoryImpl) FindAll() ([]models.ProductCouponResponse, error) {
+func (r *productCouponRepositoryImpl) FindAll() ([]entities.ProductCoupon, error) {
 	var coupons []entities.ProductCoupon
-	if err := r.db.
-		Order("id DESC"). // Add this line to order by ID descending
-		Preload("Product").
-		Find(&coupons).Error; err != nil {
-		return nil, err
-	}
-
-	var responses []models.ProductCouponResponse
-	for _, coupon := range coupons {
-		responses = append(responses, mapCouponToResponse(coupon))
-	}
-	return responses, nil
+	err := r.db.Order("id DESC").Find(&coupons).Error
+	return coupons, err
 }
 
-func (r *productCouponRepositoryImpl) FindById(id uint) (models.ProductCouponResponse, error) {
+func (r *productCouponRepositoryImpl) FindById(id uint) (entities.ProductCoupon, error) {
 	var coupon entities.ProductCoupon
-	if err := r.db.
-		Preload("Product").
-		Preload("Product.Store").
-		Preload("Product.Store.FotoToko").
-		Preload("Product.Category").
-		Preload("Product.FotoProduk").
-		Preload("Product.Reviews").
-		Preload("Product.Promos").
-		First(&coupon, id).Error; err != nil {
-		return models.ProductCouponResponse{}, err
-	}
-	return mapCouponToResponse(coupon), nil
+	err := r.db.First(&coupon, id).Error
+	return coupon, err
 }
 
-func (r *productCouponRepositoryImpl) FindByCode(code string) (models.ProductCouponResponse, error) {
+// FindByCode finds a coupon by its code, ignoring case. Codes are unique and
+// stored in upper case.
+func (r *productCouponRepositoryImpl) FindByCode(code string) (entities.ProductCoupon, error) {
 	var coupon entities.ProductCoupon
-	if err := r.db.
-		Preload("Product").
-		Preload("Product.Store").
-		Preload("Product.Store.FotoToko").
-		Preload("Product.Category").
-		Preload("Product.FotoProduk").
-		Preload("Product.Reviews").
-		Preload("Product.Promos").
-		Where("kode_kupon = ?", code).
-		First(&coupon).Error; err != nil {
-		return models.ProductCouponResponse{}, err
-	}
-	return mapCouponToResponse(coupon), nil
+	err := r.db.Where("kode_kupon = ?", strings.ToUpper(strings.TrimSpace(code))).First(&coupon).Error
+	return coupon, err
 }
 
-func (r *productCouponRepositoryImpl) FindByProduct(productId uint) ([]models.ProductCouponResponse, error) {
+func (r *productCouponRepositoryImpl) FindByProduct(productId uint) ([]entities.ProductCoupon, error) {
 	var coupons []entities.ProductCoupon
-	if err := r.db.
-		Preload("Product").
-		Preload("Product.Store").
-		Preload("Product.Store.FotoToko").
-		Preload("Product.Category").
-		Preload("Product.FotoProduk").
-		Preload("Product.Reviews").
-		Preload("Product.Promos").
-		Where("id_produk = ?", productId).
-		Find(&coupons).Error; err != nil {
-		return nil, err
-	}
-
-	var responses []models.ProductCouponResponse
-	for _, coupon := range coupons {
-		responses = append(responses, mapCouponToResponse(coupon))
-	}
-	return responses, nil
+	err := r.db.Where("id_produk = ?", productId).Order("id DESC").Find(&coupons).Error
+	return coupons, err
 }
 
-func (r *productCouponRepositoryImpl) Create(request models.ProductCouponRequest) (models.ProductCouponResponse, error) {
-	tx := r.db.Begin()
-
-	// Get product to update price
-	var product entities.Product
-	if err := tx.First(&product, request.ProductID).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Store original price if not already stored
-	if product.HargaOriginal == "" {
-		// Update the product with the original price
-		if err := tx.Model(&product).Updates(map[string]interface{}{
-			"harga_original": product.HargaKonsumen,
-		}).Error; err != nil {
-			tx.Rollback()
-			return models.ProductCouponResponse{}, err
-		}
-	}
-
-	// Delete all existing coupons for this product
-	if err := tx.Where("id_produk = ?", request.ProductID).Delete(&entities.ProductCoupon{}).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Calculate new discounted price based on original price or current price
-	originalPrice := product.HargaOriginal
-	if originalPrice == "" {
-		originalPrice = product.HargaKonsumen
-	}
-	price, _ := strconv.ParseFloat(originalPrice, 64)
-	discountedPrice := price - (price * request.Discount / 100)
-
-	// Create new coupon
-	now := time.Now()
-	coupon := entities.ProductCoupon{
-		IDProduk:  request.ProductID,
-		Code:      request.Code,
-		Discount:  request.Discount,
-		ValidFrom: request.ValidFrom,
-		ValidTo:   request.ValidTo,
-		IsActive:  true,
-		CreatedAt: &now,
-		UpdatedAt: &now,
-	}
-
-	if err := tx.Create(&coupon).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
+func (r *productCouponRepositoryImpl) CodeTaken(code string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.ProductCoupon{}).
+		Where("UPPER(kode_kupon) = UPPER(?) AND id <> ?", code, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
 
-	// Update product with discounted price
-	if err := tx.Model(&product).Updates(map[string]interface{}{
-		"harga_konsumen": fmt.Sprintf("%.0f", discountedPrice),
-		"updated_at":     now,
-	}).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
+func (r *productCouponRepositoryImpl) Create(coupon entities.ProductCoupon) (entities.ProductCoupon, error) {
+	err := r.db.Create(&coupon).Error
+	return coupon, err
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return models.ProductCouponResponse{}, err
+// Update saves the coupon's terms; the usage count is only changed by checkouts
+func (r *productCouponRepositoryImpl) Update(coupon entities.ProductCoupon) (entities.ProductCoupon, error) {
+	if err := r.db.Omit("terpakai", "created_at").Save(&coupon).Error; err != nil {
+		return entities.ProductCoupon{}, err
 	}
-
 	return r.FindById(coupon.ID)
 }
 
-func (r *productCouponRepositoryImpl) Update(id uint, request models.ProductCouponRequest) (models.ProductCouponResponse, error) {
-	// Start transaction
-	tx := r.db.Begin()
-
-	// Get existing coupon
-	var existingCoupon entities.ProductCoupon
-	if err := tx.First(&existingCoupon, id).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Get product to update price
-	var product entities.Product
-	if err := tx.First(&product, request.ProductID).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
+// Delete removes the coupon; its redemptions stay with the transactions that used it
+func (r *productCouponRepositoryImpl) Delete(id uint) (entities.ProductCoupon, error) {
+	coupon, err := r.FindById(id)
+	if err != nil {
+		return entities.ProductCoupon{}, err
 	}
-
-	// Calculate new discounted price
-	originalPriceKonsumen, _ := strconv.ParseFloat(product.HargaKonsumen, 64)
-	discountedPriceKonsumen := originalPriceKonsumen - (originalPriceKonsumen * request.Discount / 100)
-
-	now := time.Now()
-	// Use correct column names that match the database
-	updates := map[string]interface{}{
-		"id_produk":      request.ProductID,
-		"kode_kupon":     request.Code,
-		"diskon":         request.Discount,
-		"berlaku_dari":   request.ValidFrom,
-		"berlaku_sampai": request.ValidTo,
-		"updated_at":     now,
-	}
-
-	// Update the coupon
-	if err := tx.Model(&existingCoupon).Updates(updates).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
+	if err := r.db.Delete(&coupon).Error; err != nil {
+		return entities.ProductCoupon{}, err
 	}
+	return coupon, nil
+}
 
-	// Update product price
-	if err := tx.Model(&product).Updates(map[string]interface{}{
-		"harga_konsumen": fmt.Sprintf("%.0f", discountedPriceKonsumen),
-		"updated_at":     now,
-	}).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
+// CountRedemptions counts the redemptions of a coupon by a user that still count against its limit
+func (r *productCouponRepositoryImpl) CountRedemptions(couponID uint, userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&entities.CouponRedemption{}).
+		Where("id_kupon = ? AND id_user = ? AND status = ?", couponID, userID, entities.CouponRedeemed).
+		Count(&count).Error
+	return count, err
+}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
+func (r *productCouponRepositoryImpl) FindRedemptions(couponID uint) ([]entities.CouponRedemption, error) {
+	var redemptions []entities.CouponRedemption
+	err := r.db.Where("id_kupon = ?", couponID).Order("id DESC").Find(&redemptions).Error
+	return redemptions, err
+}
 
-	return r.FindById(id)
+// RestoreLegacyPrices undoes coupons made before they were applied at checkout.
+// Those overwrote produk.harga_konsumen and kept the list price in
+// harga_original; legacy discounts have been restored by then, so any price
+// left in harga_original was put there by a coupon.
+func (r *productCouponRepositoryImpl) RestoreLegacyPrices() (int64, error) {
+	result := r.db.Exec(`UPDATE produk SET harga_konsumen = harga_original, harga_original = '' WHERE harga_original <> ''`)
+	return result.RowsAffected, result.Error
 }
 
-func (r *productCouponRepositoryImpl) Delete(id uint) (models.ProductCouponResponse, error) {
-	tx := r.db.Begin()
+// redeemCoupon records the coupon used by a checkout inside its transaction,
+// for buyerID, the signed-in buyer. The coupon row and the buyer's redemptions
+// stay locked until the checkout commits, so concurrent checkouts cannot both
+// take the last use.
+func redeemCoupon(tx *gorm.DB, buyerID uint, redemption entities.CouponRedemption) error {
+	redemption.IDUser = buyerID
 
-	// Get the coupon with product information
 	var coupon entities.ProductCoupon
-	if err := tx.Preload("Product").First(&coupon, id).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Store the response before deletion
-	response := mapCouponToResponse(coupon)
-
-	// Get the complete product information
-	var product entities.Product
-	if err := tx.First(&product, coupon.IDProduk).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Delete the coupon
-	if err := tx.Delete(&coupon).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// Count remaining active coupons
-	var remainingCoupons int64
-	if err := tx.Model(&entities.ProductCoupon{}).
-		Where("id_produk = ? AND aktif = ? AND id != ?", coupon.IDProduk, true, id).
-		Count(&remainingCoupons).Error; err != nil {
-		tx.Rollback()
-		return models.ProductCouponResponse{}, err
-	}
-
-	// If no more active coupons exist, restore original price
-	if remainingCoupons == 0 && product.HargaOriginal != "" {
-		// Update product with original price
-		if err := tx.Model(&product).Updates(map[string]interface{}{
-			"harga_konsumen": product.HargaOriginal,
-			"updated_at":     time.Now(),
-		}).Error; err != nil {
-			tx.Rollback()
-			return models.ProductCouponResponse{}, err
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, redemption.IDKupon).Error; err != nil {
+		return fmt.Errorf("coupon %s is no longer available", redemption.KodeKupon)
+	}
+	if !coupon.IsValidAt(time.Now()) {
+		return fmt.Errorf("coupon %s is not valid now", coupon.Code)
+	}
+	if coupon.KuotaTotal > 0 && coupon.Terpakai >= coupon.KuotaTotal {
+		return fmt.Errorf("coupon %s has been used up", coupon.Code)
+	}
+	if coupon.KuotaPerUser > 0 {
+		// A locking read sees the redemptions committed since the checkout
+		// began, which a plain read of its snapshot would miss
+		var used int64
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Model(&entities.CouponRedemption{}).
+			Where("id_kupon = ? AND id_user = ? AND status = ?", coupon.ID, redemption.IDUser, entities.CouponRedeemed).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if int(used) >= coupon.KuotaPerUser {
+			return fmt.Errorf("you have already used coupon %s %d times", coupon.Code, coupon.KuotaPerUser)
 		}
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return models.ProductCouponResponse{}, err
+	if err := tx.Model(&coupon).UpdateColumn("terpakai", gorm.Expr("terpakai + 1")).Error; err != nil {
+		return err
 	}
-
-	return response, nil
+	redemption.Status = entities.CouponRedeemed
+	return tx.Create(&redemption).Error
 }
 
-func (r *productCouponRepositoryImpl) ValidateCoupon(code string, productId uint) (models.ProductCouponResponse, error) {
-	var coupon entities.ProductCoupon
-	now := time.Now()
-
-	err := r.db.
-		Preload("Product").
-		Preload("Product.Store").
-		Preload("Product.Store.FotoToko").
-		Preload("Product.Category").
-		Preload("Product.FotoProduk").
-		Preload("Product.Reviews").
-		Preload("Product.Promos").
-		Where("kode_kupon = ? AND id_produk = ? AND aktif = ? AND berlaku_dari <= ? AND berlaku_sampai >= ?",
-			code, productId, true, now, now).
-		First(&coupon).Error
-	if err != nil {
-		return models.ProductCouponResponse{}, err
+// releaseCoupons gives back the coupon uses of a transaction, e.g. once all of
+// its store orders are cancelled
+func releaseCoupons(tx *gorm.DB, trxID uint) error {
+	var redemptions []entities.CouponRedemption
+	if err := tx.Where("id_trx = ? AND status = ?", trxID, entities.CouponRedeemed).Find(&redemptions).Error; err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		if err := tx.Model(&entities.ProductCoupon{}).
+			Where("id = ? AND terpakai > 0", redemption.IDKupon).
+			UpdateColumn("terpakai", gorm.Expr("terpakai - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&redemption).Update("status", entities.CouponReleased).Error; err != nil {
+			return err
+		}
 	}
-
-	return mapCouponToResponse(coupon), nil
+	return nil
 }
 
 func mapCouponToResponse(coupon entities.ProductCoupon) models.ProductCouponResponse {
@@ -312,14 +185,23 @@ func mapCouponToResponse(coupon entities.ProductCoupon) models.ProductCouponResp
 	}
 
 	return models.ProductCouponResponse{
-		ID:        coupon.ID,
-		ProductID: coupon.IDProduk,
-		Code:      coupon.Code,
-		Discount:  coupon.Discount,
-		ValidFrom: coupon.ValidFrom,
-		ValidTo:   coupon.ValidTo,
-		IsActive:  coupon.IsActive,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		ID:           coupon.ID,
+		ProductID:    coupon.IDProduk,
+		Code:         coupon.Code,
+		Jenis:        coupon.Jenis,
+		Cakupan:      coupon.Cakupan,
+		IDToko:       coupon.IDToko,
+		IDCategory:   coupon.IDCategory,
+		Discount:     coupon.Discount,
+		MinBelanja:   coupon.MinBelanja,
+		MaksDiskon:   coupon.MaksDiskon,
+		KuotaTotal:   coupon.KuotaTotal,
+		KuotaPerUser: coupon.KuotaPerUser,
+		Terpakai:     coupon.Terpakai,
+		ValidFrom:    coupon.ValidFrom,
+		ValidTo:      coupon.ValidTo,
+		IsActive:     coupon.IsActive,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 	}
 }
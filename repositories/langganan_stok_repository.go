@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StockSubscriptionRepository interface {
+	FindPending(userID uint, productID uint, variantID *uint) (entities.StockSubscription, error)
+	FindByUser(userID uint) ([]entities.StockSubscription, error)
+	Create(subscription entities.StockSubscription) (entities.StockSubscription, error)
+	DeletePending(userID uint, productID uint, variantID *uint) (int64, error)
+}
+
+type stockSubscriptionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStockSubscriptionRepository(db *gorm.DB) StockSubscriptionRepository {
+	return &stockSubscriptionRepositoryImpl{db}
+}
+
+// pendingSubscriptions matches the subscriptions of a product, or of one of its
+// variants, that have not been notified yet
+func pendingSubscriptions(db *gorm.DB, productID uint, variantID *uint) *gorm.DB {
+	query := db.Model(&entities.StockSubscription{}).
+		Where("id_produk = ? AND diberitahu_pada IS NULL", productID)
+	if variantID != nil {
+		return query.Where("id_varian = ?", *variantID)
+	}
+	return query.Where("id_varian IS NULL")
+}
+
+func (r *stockSubscriptionRepositoryImpl) FindPending(userID uint, productID uint, variantID *uint) (entities.StockSubscription, error) {
+	var subscription entities.StockSubscription
+	err := pendingSubscriptions(r.db, productID, variantID).Where("id_user = ?", userID).First(&subscription).Error
+	return subscription, err
+}
+
+func (r *stockSubscriptionRepositoryImpl) FindByUser(userID uint) ([]entities.StockSubscription, error) {
+	var subscriptions []entities.StockSubscription
+	err := r.db.
+		Preload("Product", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, nama_produk, slug, stok")
+		}).
+		Where("id_user = ?", userID).
+		Order("id desc").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *stockSubscriptionRepositoryImpl) Create(subscription entities.StockSubscription) (entities.StockSubscription, error) {
+	now := time.Now()
+	subscription.CreatedAt = &now
+	err := r.db.Omit("Product").Create(&subscription).Error
+	return subscription, err
+}
+
+func (r *stockSubscriptionRepositoryImpl) DeletePending(userID uint, productID uint, variantID *uint) (int64, error) {
+	result := pendingSubscriptions(r.db, productID, variantID).Where("id_user = ?", userID).Delete(&entities.StockSubscription{})
+	return result.RowsAffected, result.Error
+}
+
+// notifyStockChange runs in the transaction that recorded movement. It tells
+// the store owner when the product's stock falls to its low-stock threshold,
+// and the subscribers once the product or variant is back in stock.
+func notifyStockChange(tx *gorm.DB, movement entities.StockMovement) error {
+	var product entities.Product
+	if err := tx.Preload("Store").First(&product, movement.IDProduk).Error; err != nil {
+		return err
+	}
+
+	// The product's stock before and after; a variant counts only while active
+	before, after := movement.StokSebelum, movement.StokSesudah
+	var variant entities.ProductVariant
+	if movement.IDVarian != nil {
+		if err := tx.First(&variant, *movement.IDVarian).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.ProductVariant{}).
+			Where("id_produk = ? AND aktif = ?", product.ID, true).
+			Select("COALESCE(SUM(stok), 0)").
+			Scan(&after).Error; err != nil {
+			return err
+		}
+		before = after
+		if variant.IsActive {
+			before = after - movement.Jumlah
+		}
+	}
+
+	now := time.Now()
+	var notifications []entities.Notification
+	if product.BatasStok != nil && before > *product.BatasStok && after <= *product.BatasStok {
+		message := fmt.Sprintf("%s is running low, %d left", product.NamaProduk, after)
+		if after == 0 {
+			message = fmt.Sprintf("%s is out of stock", product.NamaProduk)
+		}
+		notifications = append(notifications, entities.Notification{
+			IDUser:    &product.Store.IDUser,
+			Jenis:     entities.NotificationLowStock,
+			IDProduk:  &product.ID,
+			Pesan:     message,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		})
+	}
+
+	var subscriptions []entities.StockSubscription
+	if before <= 0 && after > 0 {
+		if err := pendingSubscriptions(tx, product.ID, nil).Find(&subscriptions).Error; err != nil {
+			return err
+		}
+	}
+	if movement.IDVarian != nil && variant.IsActive && movement.StokSebelum <= 0 && movement.StokSesudah > 0 {
+		var variantSubscriptions []entities.StockSubscription
+		if err := pendingSubscriptions(tx, product.ID, movement.IDVarian).Find(&variantSubscriptions).Error; err != nil {
+			return err
+		}
+		subscriptions = append(subscriptions, variantSubscriptions...)
+	}
+
+	var fired []uint
+	for _, subscription := range subscriptions {
+		name := product.NamaProduk
+		if subscription.IDVarian != nil {
+			name = fmt.Sprintf("%s (%s)", product.NamaProduk, variant.Kombinasi)
+		}
+		userID := subscription.IDUser
+		notifications = append(notifications, entities.Notification{
+			IDUser:    &userID,
+			Jenis:     entities.NotificationBackInStock,
+			IDProduk:  &product.ID,
+			Pesan:     fmt.Sprintf("%s is back in stock", name),
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		})
+		fired = append(fired, subscription.ID)
+	}
+
+	if len(notifications) > 0 {
+		if err := tx.Create(&notifications).Error; err != nil {
+			return err
+		}
+	}
+	if len(fired) > 0 {
+		return tx.Model(&entities.StockSubscription{}).Where("id IN ?", fired).Update("diberitahu_pada", now).Error
+	}
+	return nil
+}
@@ -33,6 +33,9 @@ func (repository *productLogRepositoryImpl) Insert(input models.ProductLogProces
 		Deskripsi:     &input.Deskripsi,
 		IDToko:        input.StoreID,
 		IDCategory:    input.CategoryID,
+		IDVarian:      input.VariantID,
+		SKU:           input.SKU,
+		NamaVarian:    input.NamaVarian,
 	}
 
 	err := repository.db.
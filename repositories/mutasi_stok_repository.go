@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockMovementRepository interface {
+	Record(movement entities.StockMovement) (entities.StockMovement, error)
+	FindByProductId(productID uint, variantID *uint, kind string, limit int, offset int) ([]entities.StockMovement, int64, error)
+	SumByProduct(productID uint) (int, map[uint]int, error)
+	OpenBalances() (int64, error)
+}
+
+type stockMovementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStockMovementRepository(db *gorm.DB) StockMovementRepository {
+	return &stockMovementRepositoryImpl{db}
+}
+
+// Record applies a manual movement such as a return or an adjustment
+func (r *stockMovementRepositoryImpl) Record(movement entities.StockMovement) (entities.StockMovement, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = moveStock(tx, movement)
+		return err
+	})
+	return movement, err
+}
+
+func (r *stockMovementRepositoryImpl) FindByProductId(productID uint, variantID *uint, kind string, limit int, offset int) ([]entities.StockMovement, int64, error) {
+	var movements []entities.StockMovement
+	var total int64
+
+	query := r.db.Model(&entities.StockMovement{}).Where("id_produk = ?", productID)
+	if variantID != nil {
+		query = query.Where("id_varian = ?", *variantID)
+	}
+	if kind != "" {
+		query = query.Where("jenis = ?", kind)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id desc").Limit(limit).Offset(offset).Find(&movements).Error
+	return movements, total, err
+}
+
+// SumByProduct derives the stock of a product and of each of its variants from the ledger
+func (r *stockMovementRepositoryImpl) SumByProduct(productID uint) (int, map[uint]int, error) {
+	var rows []struct {
+		IDVarian *uint `gorm:"column:id_varian"`
+		Total    int   `gorm:"column:total"`
+	}
+	err := r.db.Model(&entities.StockMovement{}).
+		Select("id_varian, COALESCE(SUM(jumlah), 0) AS total").
+		Where("id_produk = ?", productID).
+		Group("id_varian").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, nil, err
+	}
+
+	product := 0
+	variants := map[uint]int{}
+	for _, row := range rows {
+		if row.IDVarian == nil {
+			product = row.Total
+		} else {
+			variants[*row.IDVarian] = row.Total
+		}
+	}
+	return product, variants, nil
+}
+
+// OpenBalances records an opening movement for the stock that existed before
+// the ledger, so every product and variant without movements starts from its
+// current stock. Products with active variants keep their stock in the variants.
+func (r *stockMovementRepositoryImpl) OpenBalances() (int64, error) {
+	now := time.Now()
+	var opened int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		products := tx.Exec(
+			`INSERT INTO produk_mutasi_stok (id_produk, jenis, jumlah, stok_sebelum, stok_sesudah, referensi, catatan, created_at)
+			SELECT p.id, ?, p.stok, 0, p.stok, '', 'opening balance', ? FROM produk p
+			WHERE p.stok <> 0
+			AND NOT EXISTS (SELECT 1 FROM produk_varian v WHERE v.id_produk = p.id AND v.aktif = ?)
+			AND NOT EXISTS (SELECT 1 FROM produk_mutasi_stok m WHERE m.id_produk = p.id AND m.id_varian IS NULL)`,
+			entities.StockMovementOpening, now, true,
+		)
+		if products.Error != nil {
+			return products.Error
+		}
+		variants := tx.Exec(
+			`INSERT INTO produk_mutasi_stok (id_produk, id_varian, jenis, jumlah, stok_sebelum, stok_sesudah, referensi, catatan, created_at)
+			SELECT v.id_produk, v.id, ?, v.stok, 0, v.stok, '', 'opening balance', ? FROM produk_varian v
+			WHERE v.stok <> 0
+			AND NOT EXISTS (SELECT 1 FROM produk_mutasi_stok m WHERE m.id_varian = v.id)`,
+			entities.StockMovementOpening, now,
+		)
+		if variants.Error != nil {
+			return variants.Error
+		}
+		opened = products.RowsAffected + variants.RowsAffected
+		return nil
+	})
+	return opened, err
+}
+
+// moveStock adds movement.Jumlah to the stock of the product, or of its variant,
+// inside tx and appends the movement to the ledger. Stock never goes below zero.
+func moveStock(tx *gorm.DB, movement entities.StockMovement) (entities.StockMovement, error) {
+	var stok int
+	var name string
+	var target *gorm.DB
+	if movement.IDVarian != nil {
+		var variant entities.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, *movement.IDVarian).Error; err != nil {
+			return movement, err
+		}
+		if variant.IDProduk != movement.IDProduk {
+			return movement, errors.New("variant does not belong to the product")
+		}
+		stok, name = variant.Stok, variant.SKU
+		target = tx.Model(&entities.ProductVariant{}).Where("id = ?", variant.ID)
+	} else {
+		var product entities.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, movement.IDProduk).Error; err != nil {
+			return movement, err
+		}
+		stok, name = product.Stok, product.NamaProduk
+		target = tx.Model(&entities.Product{}).Where("id = ?", product.ID)
+	}
+
+	after := stok + movement.Jumlah
+	if after < 0 {
+		return movement, fmt.Errorf("insufficient stock for %s, %d left", name, stok)
+	}
+	if err := target.Updates(map[string]interface{}{"stok": after, "updated_at": time.Now()}).Error; err != nil {
+		return movement, err
+	}
+	if movement.IDVarian != nil {
+		if err := syncProductStock(tx, movement.IDProduk); err != nil {
+			return movement, err
+		}
+	}
+	return logStockMovement(tx, movement, stok, after)
+}
+
+// logStockMovement appends a movement for a stock that was already set from
+// before to after, e.g. by an edit of the product, moves it in the store's
+// warehouse and sends the stock alerts it triggers. Nothing is logged when the
+// stock did not change.
+func logStockMovement(tx *gorm.DB, movement entities.StockMovement, before int, after int) (entities.StockMovement, error) {
+	if before == after {
+		return movement, nil
+	}
+	movement.Jumlah = after - before
+	if err := moveWarehouseStock(tx, &movement); err != nil {
+		return movement, err
+	}
+	movement, err := appendStockMovement(tx, movement, before, after)
+	if err != nil {
+		return movement, err
+	}
+	return movement, notifyStockChange(tx, movement)
+}
+
+// appendStockMovement only writes the ledger entry, for corrections of the
+// ledger itself that did not change the stock
+func appendStockMovement(tx *gorm.DB, movement entities.StockMovement, before int, after int) (entities.StockMovement, error) {
+	now := time.Now()
+	movement.Jumlah = after - before
+	movement.StokSebelum = before
+	movement.StokSesudah = after
+	movement.CreatedAt = &now
+	err := tx.Create(&movement).Error
+	return movement, err
+}
+
+// hasActiveVariants reports whether the stock of a product is kept in its variants
+func hasActiveVariants(tx *gorm.DB, productID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&entities.ProductVariant{}).
+		Where("id_produk = ? AND aktif = ?", productID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// stockActor is the user recorded on a movement; zero means the system
+func stockActor(userID uint) *uint {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
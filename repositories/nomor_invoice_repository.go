@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceSequenceRepository interface {
+	Next(scope string) (int, error)
+}
+
+type invoiceSequenceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewInvoiceSequenceRepository(db *gorm.DB) InvoiceSequenceRepository {
+	return &invoiceSequenceRepositoryImpl{db}
+}
+
+// Next increments and returns the counter of a scope. It runs in its own
+// transaction, so a checkout that fails later leaves a gap instead of a duplicate.
+func (r *invoiceSequenceRepositoryImpl) Next(scope string) (int, error) {
+	tx := r.db.Begin()
+
+	now := time.Now()
+	sequence := entities.InvoiceSequence{Scope: scope, CreatedAt: &now, UpdatedAt: &now}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// Row lock serialises concurrent checkouts on the same scope
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ?", scope).
+		First(&sequence).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	next := sequence.NomorAkhir + 1
+	if err := tx.Model(&entities.InvoiceSequence{}).
+		Where("scope = ?", scope).
+		Updates(map[string]interface{}{"nomor_akhir": next, "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	return next, nil
+}
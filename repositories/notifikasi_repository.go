@@ -8,7 +8,7 @@ import (
 )
 
 type NotificationRepository interface {
-	FindAll() ([]entities.Notification, error)
+	FindForUser(userID uint) ([]entities.Notification, error)
 	FindById(id uint) (entities.Notification, error)
 	Create(notification entities.Notification) (entities.Notification, error)
 	Update(id uint, notification entities.Notification) (entities.Notification, error)
@@ -23,9 +23,10 @@ func NewNotificationRepository(db *gorm.DB) NotificationRepository {
 	return &notificationRepositoryImpl{db}
 }
 
-func (r *notificationRepositoryImpl) FindAll() ([]entities.Notification, error) {
+// FindForUser lists the notifications sent to every user and those sent to userID
+func (r *notificationRepositoryImpl) FindForUser(userID uint) ([]entities.Notification, error) {
 	var notifications []entities.Notification
-	err := r.db.Order("created_at desc").Find(&notifications).Error
+	err := r.db.Where("id_user IS NULL OR id_user = ?", userID).Order("created_at desc").Find(&notifications).Error
 	return notifications, err
 }
 
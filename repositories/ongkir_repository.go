@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShippingRateRepository interface {
+	FindAll() ([]entities.ShippingRate, error)
+	FindById(id uint) (entities.ShippingRate, error)
+	FindByRoute(originCityID string, destinationCityID string) ([]entities.ShippingRate, error)
+	Create(rate entities.ShippingRate) (entities.ShippingRate, error)
+	Update(id uint, rate entities.ShippingRate) (entities.ShippingRate, error)
+	Delete(id uint) error
+}
+
+type shippingRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewShippingRateRepository(db *gorm.DB) ShippingRateRepository {
+	return &shippingRateRepositoryImpl{db}
+}
+
+func (r *shippingRateRepositoryImpl) FindAll() ([]entities.ShippingRate, error) {
+	var rates []entities.ShippingRate
+	err := r.db.Order("kurir, layanan, id_kota_asal, id_kota_tujuan").Find(&rates).Error
+	return rates, err
+}
+
+func (r *shippingRateRepositoryImpl) FindById(id uint) (entities.ShippingRate, error) {
+	var rate entities.ShippingRate
+	err := r.db.First(&rate, id).Error
+	return rate, err
+}
+
+// FindByRoute returns active rates for the route, including "*" wildcard destinations
+func (r *shippingRateRepositoryImpl) FindByRoute(originCityID string, destinationCityID string) ([]entities.ShippingRate, error) {
+	var rates []entities.ShippingRate
+	err := r.db.
+		Where("id_kota_asal = ? AND id_kota_tujuan IN ? AND aktif = ?", originCityID, []string{destinationCityID, "*"}, true).
+		Order("kurir, layanan").
+		Find(&rates).Error
+	return rates, err
+}
+
+func (r *shippingRateRepositoryImpl) Create(rate entities.ShippingRate) (entities.ShippingRate, error) {
+	now := time.Now()
+	rate.CreatedAt = &now
+	rate.UpdatedAt = &now
+	err := r.db.Create(&rate).Error
+	return rate, err
+}
+
+func (r *shippingRateRepositoryImpl) Update(id uint, rate entities.ShippingRate) (entities.ShippingRate, error) {
+	updates := map[string]interface{}{
+		"kurir":          rate.Kurir,
+		"layanan":        rate.Layanan,
+		"id_kota_asal":   rate.IDKotaAsal,
+		"id_kota_tujuan": rate.IDKotaTujuan,
+		"harga_per_kg":   rate.HargaPerKg,
+		"estimasi_hari":  rate.EstimasiHari,
+		"aktif":          rate.IsActive,
+		"updated_at":     time.Now(),
+	}
+	if err := r.db.Model(&entities.ShippingRate{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return entities.ShippingRate{}, err
+	}
+	return r.FindById(id)
+}
+
+func (r *shippingRateRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&entities.ShippingRate{}, id).Error
+}
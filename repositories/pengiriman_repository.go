@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShipmentRepository interface {
+	FindById(id uint) (entities.Shipment, error)
+	FindByTrxDetailId(trxDetailID uint) (entities.Shipment, error)
+	FindByTrxId(trxID uint) ([]entities.Shipment, error)
+	Create(shipment entities.Shipment, checkpoint entities.ShipmentCheckpoint) (entities.Shipment, error)
+	AddCheckpoints(shipment entities.Shipment, checkpoints []entities.ShipmentCheckpoint) (entities.Shipment, error)
+}
+
+type shipmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &shipmentRepositoryImpl{db}
+}
+
+func preloadCheckpoints(db *gorm.DB) *gorm.DB {
+	return db.Order("waktu asc, id asc")
+}
+
+func (r *shipmentRepositoryImpl) FindById(id uint) (entities.Shipment, error) {
+	var shipment entities.Shipment
+	err := r.db.Preload("Checkpoints", preloadCheckpoints).First(&shipment, id).Error
+	return shipment, err
+}
+
+func (r *shipmentRepositoryImpl) FindByTrxDetailId(trxDetailID uint) (entities.Shipment, error) {
+	var shipment entities.Shipment
+	err := r.db.
+		Preload("Checkpoints", preloadCheckpoints).
+		Where("id_trx_detail = ?", trxDetailID).
+		First(&shipment).Error
+	return shipment, err
+}
+
+func (r *shipmentRepositoryImpl) FindByTrxId(trxID uint) ([]entities.Shipment, error) {
+	var shipments []entities.Shipment
+	err := r.db.
+		Preload("Checkpoints", preloadCheckpoints).
+		Where("id_trx = ?", trxID).
+		Order("id asc").
+		Find(&shipments).Error
+	return shipments, err
+}
+
+// Create ships a trx_detail line. Its store order must be processing, or
+// shipped when another of its lines went first, and is moved to shipped.
+func (r *shipmentRepositoryImpl) Create(shipment entities.Shipment, checkpoint entities.ShipmentCheckpoint) (entities.Shipment, error) {
+	tx := r.db.Begin()
+
+	now := time.Now()
+	if err := shipStoreOrder(tx, shipment.IDTrxDetail, now); err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	shipment.CreatedAt = &now
+	shipment.UpdatedAt = &now
+	if err := tx.Create(&shipment).Error; err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	checkpoint.IDPengiriman = shipment.ID
+	checkpoint.CreatedAt = &now
+	if err := tx.Create(&checkpoint).Error; err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	if err := tx.Exec("UPDATE trx_detail SET product_status = ? WHERE id = ?", shipment.Status, shipment.IDTrxDetail).Error; err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.Shipment{}, err
+	}
+
+	return r.FindById(shipment.ID)
+}
+
+// shipStoreOrder locks the store order of a trx_detail line and moves it from
+// processing to shipped. Lines of checkouts made before store orders have none.
+func shipStoreOrder(tx *gorm.DB, trxDetailID uint, now time.Time) error {
+	var detail entities.TrxDetail
+	if err := tx.Select("id, id_trx_toko").First(&detail, trxDetailID).Error; err != nil {
+		return err
+	}
+	if detail.IDTrxToko == nil {
+		return nil
+	}
+
+	var storeOrder entities.StoreOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id, status").
+		First(&storeOrder, *detail.IDTrxToko).Error; err != nil {
+		return err
+	}
+	switch storeOrder.Status {
+	case "processing":
+		return tx.Model(&entities.StoreOrder{}).
+			Where("id = ?", storeOrder.ID).
+			Updates(map[string]interface{}{"status": "shipped", "updated_at": now}).Error
+	case "shipped":
+		return nil
+	default:
+		return fmt.Errorf("store order is %s, only processing orders can be shipped", storeOrder.Status)
+	}
+}
+
+// AddCheckpoints appends checkpoints and moves the shipment and its trx_detail line to the given status
+func (r *shipmentRepositoryImpl) AddCheckpoints(shipment entities.Shipment, checkpoints []entities.ShipmentCheckpoint) (entities.Shipment, error) {
+	tx := r.db.Begin()
+
+	now := time.Now()
+	for _, checkpoint := range checkpoints {
+		checkpoint.IDPengiriman = shipment.ID
+		checkpoint.CreatedAt = &now
+		if err := tx.Create(&checkpoint).Error; err != nil {
+			tx.Rollback()
+			return entities.Shipment{}, err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"status":       shipment.Status,
+		"delivered_at": shipment.DeliveredAt,
+		"updated_at":   now,
+	}
+	if err := tx.Model(&entities.Shipment{}).Where("id = ?", shipment.ID).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	if err := tx.Exec("UPDATE trx_detail SET product_status = ? WHERE id = ?", shipment.Status, shipment.IDTrxDetail).Error; err != nil {
+		tx.Rollback()
+		return entities.Shipment{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.Shipment{}, err
+	}
+
+	return r.FindById(shipment.ID)
+}
@@ -9,22 +9,34 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/gosimple/slug"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
-	FindAllPagination(pagination responder.Pagination) (responder.Pagination, error)
+	FindAllPagination(pagination responder.Pagination, filter models.ProductFilter) (responder.Pagination, error)
+	FindFacets(filter models.ProductFilter) (models.ProductFacets, error)
 	FindById(id uint) (models.ProductResponse, error)
-	Insert(product models.ProductRequest) (models.ProductResponse, error)
-	Update(id uint, product models.ProductRequest) (models.ProductResponse, error)
+	FindIdBySlug(slug string) (uint, error)
+	FindSlugHistory(slug string) (entities.ProductSlugHistory, error)
+	SlugTaken(slug string, productID uint) (bool, error)
+	SKUTaken(storeID uint, sku string, productID uint) (bool, error)
+	FindBySKU(storeID uint, sku string) (entities.Product, error)
+	Insert(product models.ProductRequest, actorID uint) (models.ProductResponse, error)
+	Update(id uint, product models.ProductRequest, actorID uint) (models.ProductResponse, error)
 	Destroy(id uint) (bool, error)
 	FindByCategory(categoryID string) ([]models.ProductResponse, error)
-	SearchProducts(query string) ([]models.ProductResponse, error)
+	SearchProducts(pagination responder.Pagination, match string, booleanMode bool) (responder.Pagination, error)
 	FindRelatedProducts(id uint) ([]models.ProductResponse, error)
 	SaveProductPhoto(photo entities.FotoProduk) (entities.FotoProduk, error)
 	GetProductPhoto(id uint) (entities.FotoProduk, error)
 	SaveProductPhotos(productID uint, photoURLs []interface{}) error
+	FindWithStoreByIds(ids []uint) ([]entities.Product, error)
+	UpdateStatus(id uint, status string, publishAt *time.Time, banReason string) error
+	PublishDue(now time.Time) ([]entities.Product, error)
+	FindByStore(storeID uint) ([]entities.Product, error)
+	ImportProducts(products []entities.Product, photoURLs [][]string, stockSet []bool, actorID uint) error
+	UpdateLowStockThreshold(id uint, threshold *int) error
 }
 
 type productRepositoryImpl struct {
@@ -35,19 +47,101 @@ func NewProductRepository(database *gorm.DB) ProductRepository {
 	return &productRepositoryImpl{database}
 }
 
-func (repository *productRepositoryImpl) FindAllPagination(request responder.Pagination) (responder.Pagination, error) {
+// SQL expressions used by the product filters, sorts and facets
+const (
+	// productPriceExpr is the price buyers pay now, see entities.Product.ActiveDiscount
+	productPriceExpr  = "(CAST(produk.harga_konsumen AS DECIMAL(15,2)) * (100 - " + productDiscountExpr + ") / 100)"
+	productRatingExpr = "(SELECT COALESCE(AVG(product_reviews.rating), 0) FROM product_reviews WHERE product_reviews.id_produk = produk.id)"
+	productSoldExpr   = "(SELECT COALESCE(SUM(trx_detail.kuantitas), 0) FROM trx_detail JOIN log_produk ON log_produk.id = trx_detail.id_log_produk WHERE log_produk.id_produk = produk.id)"
+
+	// productDiscountExpr is the percentage of the discount that applies now, 0 without one
+	productDiscountExpr = "COALESCE((SELECT d.persen FROM diskon_produk d WHERE d.id_produk = produk.id AND d.mulai_pada <= NOW() AND (d.berakhir_pada IS NULL OR d.berakhir_pada > NOW()) ORDER BY d.mulai_pada DESC, d.id DESC LIMIT 1), 0)"
+
+	// productPublishedCond matches what buyers can see, see entities.Product.IsPublished
+	productPublishedCond = "(produk.status = 'published' OR (produk.status = 'draft' AND produk.jadwal_terbit <= ?))"
+)
+
+// productSorts maps the accepted sort keys to their ORDER BY clause
+var productSorts = map[string]string{
+	"terbaru":    "produk.id DESC",
+	"harga_asc":  productPriceExpr + " ASC, produk.id DESC",
+	"harga_desc": productPriceExpr + " DESC, produk.id DESC",
+	"terlaris":   productSoldExpr + " DESC, produk.id DESC",
+	"rating":     productRatingExpr + " DESC, produk.id DESC",
+}
+
+// productPriceBuckets are the ranges reported in the price facet; the last one is open-ended
+var productPriceBuckets = []struct {
+	label string
+	min   float64
+	max   float64
+}{
+	{"< Rp 50.000", 0, 50000},
+	{"Rp 50.000 - Rp 100.000", 50000, 100000},
+	{"Rp 100.000 - Rp 250.000", 100000, 250000},
+	{"Rp 250.000 - Rp 500.000", 250000, 500000},
+	{"Rp 500.000 - Rp 1.000.000", 500000, 1000000},
+	{"> Rp 1.000.000", 1000000, 0},
+}
+
+func applyProductFilter(query *gorm.DB, filter models.ProductFilter) *gorm.DB {
+	if !filter.AnyStatus {
+		query = query.Where(productPublishedCond, time.Now())
+	}
+	if filter.Status != "" {
+		query = query.Where("produk.status = ?", filter.Status)
+	}
+	if filter.Keyword != "" {
+		query = query.Where("(produk.nama_produk LIKE ? OR produk.deskripsi LIKE ?)",
+			"%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("produk.id_category IN ?", filter.CategoryIDs)
+	} else if filter.CategoryID != 0 {
+		query = query.Where("produk.id_category = ?", filter.CategoryID)
+	}
+	if filter.StoreID != 0 {
+		query = query.Where("produk.id_toko = ?", filter.StoreID)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where(productPriceExpr+" >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where(productPriceExpr+" <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock {
+		query = query.Where("produk.stok > 0")
+	}
+	if filter.MinRating > 0 {
+		query = query.Where(productRatingExpr+" >= ?", filter.MinRating)
+	}
+	if filter.HasDiscount {
+		query = query.Where(productDiscountExpr + " > 0")
+	}
+	return query
+}
+
+func (repository *productRepositoryImpl) FindAllPagination(request responder.Pagination, filter models.ProductFilter) (responder.Pagination, error) {
 	var products []entities.Product
 	var totalRows int64
-	query := repository.database.Model(&entities.Product{})
-	// Add search condition if keyword is present
-	if request.Keyword != "" {
-		query = query.Where("nama_produk LIKE ? OR deskripsi LIKE ?",
-			"%"+request.Keyword+"%", "%"+request.Keyword+"%")
-	}
-	// Count total rows with search condition
-	query.Count(&totalRows)
+
+	if filter.Sort == "" {
+		filter.Sort = "terbaru"
+	}
+	order, ok := productSorts[filter.Sort]
+	if !ok {
+		return responder.Pagination{}, fmt.Errorf("unknown sort %q, use terbaru, harga_asc, harga_desc, terlaris or rating", filter.Sort)
+	}
+
+	// Count total rows matching the filter
+	err := applyProductFilter(repository.database.Model(&entities.Product{}), filter).
+		Count(&totalRows).Error
+	if err != nil {
+		return responder.Pagination{}, err
+	}
+
 	// Build main query with all relations
-	query = repository.database.Model(&entities.Product{}).
+	query := repository.database.Model(&entities.Product{}).
 		Preload("Store", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id, id_user, nama_toko, deskripsi_toko, created_at, updated_at") // Added id_user
 		}).
@@ -57,15 +151,11 @@ func (repository *productRepositoryImpl) FindAllPagination(request responder.Pag
 		Preload("Reviews").
 		Preload("Reviews.Store").
 		Preload("Promos").
+		Preload("Promos.Tingkat", promoTierOrder).
+		Preload("Diskon", activeDiscounts).
 		Preload("Promos.Store")
-	// Apply search condition to main query
-	if request.Keyword != "" {
-		query = query.Where("nama_produk LIKE ? OR deskripsi LIKE ?",
-			"%"+request.Keyword+"%", "%"+request.Keyword+"%")
-	}
-	// Apply pagination
-	err := query.
-		Order("id desc").
+	err = applyProductFilter(query, filter).
+		Order(order).
 		Limit(request.Limit).
 		Offset(request.GetOffset()).
 		Find(&products).Error
@@ -84,6 +174,78 @@ func (repository *productRepositoryImpl) FindAllPagination(request responder.Pag
 	return request, nil
 }
 
+// FindFacets counts the products matching filter per category, store and price range.
+// Each facet ignores its own filter so the other values stay selectable.
+func (repository *productRepositoryImpl) FindFacets(filter models.ProductFilter) (models.ProductFacets, error) {
+	facets := models.ProductFacets{
+		Category:     []models.FacetCount{},
+		Toko:         []models.FacetCount{},
+		RentangHarga: []models.PriceBucketFacet{},
+	}
+
+	categoryFilter := filter
+	categoryFilter.CategoryID = 0
+	categoryFilter.CategoryIDs = nil
+	err := applyProductFilter(repository.database.Model(&entities.Product{}), categoryFilter).
+		Select("produk.id_category AS id, category.nama_category AS nama, COUNT(*) AS jumlah").
+		Joins("JOIN category ON category.id = produk.id_category").
+		Group("produk.id_category, category.nama_category").
+		Order("jumlah DESC, nama ASC").
+		Scan(&facets.Category).Error
+	if err != nil {
+		return models.ProductFacets{}, err
+	}
+
+	storeFilter := filter
+	storeFilter.StoreID = 0
+	err = applyProductFilter(repository.database.Model(&entities.Product{}), storeFilter).
+		Select("produk.id_toko AS id, toko.nama_toko AS nama, COUNT(*) AS jumlah").
+		Joins("JOIN toko ON toko.id = produk.id_toko").
+		Group("produk.id_toko, toko.nama_toko").
+		Order("jumlah DESC, nama ASC").
+		Scan(&facets.Toko).Error
+	if err != nil {
+		return models.ProductFacets{}, err
+	}
+
+	// Bucket index per product, e.g. CASE WHEN price < 50000 THEN 0 ... ELSE 5 END
+	bucketExpr := "CASE"
+	for i, bucket := range productPriceBuckets[:len(productPriceBuckets)-1] {
+		bucketExpr += fmt.Sprintf(" WHEN %s < %.0f THEN %d", productPriceExpr, bucket.max, i)
+	}
+	bucketExpr += fmt.Sprintf(" ELSE %d END", len(productPriceBuckets)-1)
+
+	var bucketCounts []struct {
+		Bucket int
+		Jumlah int64
+	}
+	priceFilter := filter
+	priceFilter.MinPrice = nil
+	priceFilter.MaxPrice = nil
+	err = applyProductFilter(repository.database.Model(&entities.Product{}), priceFilter).
+		Select(bucketExpr + " AS bucket, COUNT(*) AS jumlah").
+		Group("bucket").
+		Scan(&bucketCounts).Error
+	if err != nil {
+		return models.ProductFacets{}, err
+	}
+
+	counts := make(map[int]int64, len(bucketCounts))
+	for _, count := range bucketCounts {
+		counts[count.Bucket] = count.Jumlah
+	}
+	for i, bucket := range productPriceBuckets {
+		facet := models.PriceBucketFacet{Label: bucket.label, Min: bucket.min, Jumlah: counts[i]}
+		if bucket.max > 0 {
+			max := bucket.max
+			facet.Max = &max
+		}
+		facets.RentangHarga = append(facets.RentangHarga, facet)
+	}
+
+	return facets, nil
+}
+
 func (repository *productRepositoryImpl) FindById(id uint) (models.ProductResponse, error) {
 	var product entities.Product
 
@@ -97,6 +259,8 @@ func (repository *productRepositoryImpl) FindById(id uint) (models.ProductRespon
 		Preload("Reviews").
 		Preload("Reviews.Store").
 		Preload("Promos").
+		Preload("Promos.Tingkat", promoTierOrder).
+		Preload("Diskon", activeDiscounts).
 		Preload("Promos.Store").
 		Preload("Coupons"). // Add this line
 		First(&product, id)
@@ -108,22 +272,94 @@ func (repository *productRepositoryImpl) FindById(id uint) (models.ProductRespon
 	return mapProductToResponse(product), nil
 }
 
-func (repository *productRepositoryImpl) Insert(input models.ProductRequest) (models.ProductResponse, error) {
+func (repository *productRepositoryImpl) FindIdBySlug(slug string) (uint, error) {
+	var product entities.Product
+	err := repository.database.Select("id").Where("slug = ?", slug).First(&product).Error
+	return product.ID, err
+}
+
+func (repository *productRepositoryImpl) FindSlugHistory(slug string) (entities.ProductSlugHistory, error) {
+	var history entities.ProductSlugHistory
+	err := repository.database.Where("slug = ?", slug).First(&history).Error
+	return history, err
+}
+
+// SlugTaken reports whether slug belongs to another product, either as its
+// current slug (deleted products included) or as one it redirects from
+func (repository *productRepositoryImpl) SlugTaken(slug string, productID uint) (bool, error) {
+	var count int64
+	err := repository.database.Unscoped().Model(&entities.Product{}).
+		Where("slug = ? AND id <> ?", slug, productID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = repository.database.Model(&entities.ProductSlugHistory{}).
+		Where("slug = ? AND id_produk <> ?", slug, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SKUTaken reports whether another product of the store uses sku
+func (repository *productRepositoryImpl) SKUTaken(storeID uint, sku string, productID uint) (bool, error) {
+	var count int64
+	err := repository.database.Unscoped().Model(&entities.Product{}).
+		Where("id_toko = ? AND sku = ? AND id <> ?", storeID, sku, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repository *productRepositoryImpl) FindBySKU(storeID uint, sku string) (entities.Product, error) {
+	var product entities.Product
+	err := repository.database.Where("id_toko = ? AND sku = ?", storeID, sku).First(&product).Error
+	return product, err
+}
+
+// productSKU stores an empty SKU as NULL so it does not collide with other products without one
+func productSKU(sku string) *string {
+	if sku == "" {
+		return nil
+	}
+	return &sku
+}
+
+// Insert creates the product and opens its stock ledger with the initial stock
+func (repository *productRepositoryImpl) Insert(input models.ProductRequest, actorID uint) (models.ProductResponse, error) {
 	now := time.Now()
 	product := entities.Product{
-		NamaProduk:    input.NamaProduk,
-		IDToko:        input.StoreID,
-		IDCategory:    input.CategoryID,
-		HargaReseller: input.HargaReseller,
-		HargaKonsumen: input.HargaKonsumen,
-		Stok:          input.Stok,
-		Deskripsi:     &input.Deskripsi,
-		Slug:          slug.Make(input.NamaProduk),
-		CreatedAt:     &now,
-		UpdatedAt:     &now,
-	}
-
-	err := repository.database.Create(&product).Error
+		NamaProduk:       input.NamaProduk,
+		IDToko:           input.StoreID,
+		IDCategory:       input.CategoryID,
+		HargaReseller:    input.HargaReseller,
+		HargaKonsumen:    input.HargaKonsumen,
+		MinOrderReseller: input.MinOrderReseller,
+		Stok:             input.Stok,
+		Berat:            input.Berat,
+		Panjang:          input.Panjang,
+		Lebar:            input.Lebar,
+		Tinggi:           input.Tinggi,
+		Deskripsi:        &input.Deskripsi,
+		Slug:             input.Slug,
+		SKU:              productSKU(input.SKU),
+		Status:           input.Status,
+		JadwalTerbit:     input.JadwalTerbit,
+		CreatedAt:        &now,
+		UpdatedAt:        &now,
+	}
+
+	err := repository.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		_, err := logStockMovement(tx, entities.StockMovement{
+			IDProduk: product.ID,
+			Jenis:    entities.StockMovementOpening,
+			IDUser:   stockActor(actorID),
+			Catatan:  "initial stock",
+		}, 0, product.Stok)
+		return err
+	})
 	if err != nil {
 		return models.ProductResponse{}, err
 	}
@@ -156,6 +392,7 @@ func (repository *productRepositoryImpl) Insert(input models.ProductRequest) (mo
 		Preload("Store.FotoToko").
 		Preload("Category").
 		Preload("FotoProduk").
+		Preload("Diskon", activeDiscounts).
 		First(&completeProduct, product.ID).Error
 
 	if err != nil {
@@ -165,13 +402,14 @@ func (repository *productRepositoryImpl) Insert(input models.ProductRequest) (mo
 	return mapProductToResponse(completeProduct), nil
 }
 
-func (repository *productRepositoryImpl) Update(id uint, input models.ProductRequest) (models.ProductResponse, error) {
+// Update saves the product; a change of stock is recorded in the ledger as an adjustment
+func (repository *productRepositoryImpl) Update(id uint, input models.ProductRequest, actorID uint) (models.ProductResponse, error) {
 	// Start transaction
 	tx := repository.database.Begin()
 
 	// First verify the product exists and get its data
 	var existingProduct entities.Product
-	if err := tx.Preload("FotoProduk").First(&existingProduct, id).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("FotoProduk").First(&existingProduct, id).Error; err != nil {
 		tx.Rollback()
 		return models.ProductResponse{}, fmt.Errorf("product not found: %v", err)
 	}
@@ -179,15 +417,44 @@ func (repository *productRepositoryImpl) Update(id uint, input models.ProductReq
 	// Update product fields
 	now := time.Now()
 	updates := map[string]interface{}{
-		"nama_produk":    input.NamaProduk,
-		"slug":           slug.Make(input.NamaProduk),
-		"harga_reseller": input.HargaReseller,
-		"harga_konsumen": input.HargaKonsumen,
-		"stok":           input.Stok,
-		"deskripsi":      &input.Deskripsi,
-		"id_category":    input.CategoryID,
-		"id_toko":        input.StoreID,
-		"updated_at":     &now,
+		"nama_produk":        input.NamaProduk,
+		"slug":               input.Slug,
+		"sku":                productSKU(input.SKU),
+		"harga_reseller":     input.HargaReseller,
+		"harga_konsumen":     input.HargaKonsumen,
+		"min_order_reseller": input.MinOrderReseller,
+		"stok":               input.Stok,
+		"berat":              input.Berat,
+		"panjang":            input.Panjang,
+		"lebar":              input.Lebar,
+		"tinggi":             input.Tinggi,
+		"deskripsi":          &input.Deskripsi,
+		"id_category":        input.CategoryID,
+		"id_toko":            input.StoreID,
+		"updated_at":         &now,
+	}
+
+	if err := moveProductSlug(tx, id, existingProduct.Slug, input.Slug, now); err != nil {
+		tx.Rollback()
+		return models.ProductResponse{}, err
+	}
+
+	// The stock of a product with variants is the sum of its variants' stock
+	hasVariants, err := hasActiveVariants(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return models.ProductResponse{}, err
+	}
+	if hasVariants {
+		delete(updates, "stok")
+	} else if _, err := logStockMovement(tx, entities.StockMovement{
+		IDProduk: id,
+		Jenis:    entities.StockMovementAdjustment,
+		IDUser:   stockActor(actorID),
+		Catatan:  "product edited",
+	}, existingProduct.Stok, input.Stok); err != nil {
+		tx.Rollback()
+		return models.ProductResponse{}, err
 	}
 
 	// Update the product
@@ -233,7 +500,7 @@ func (repository *productRepositoryImpl) Update(id uint, input models.ProductReq
 
 	// Fetch updated product with all relationships
 	var updatedProduct entities.Product
-	err := repository.database.
+	err = repository.database.
 		Preload("Store").
 		Preload("Store.FotoToko").
 		Preload("Category").
@@ -241,6 +508,8 @@ func (repository *productRepositoryImpl) Update(id uint, input models.ProductReq
 		Preload("Reviews").
 		Preload("Reviews.Store").
 		Preload("Promos").
+		Preload("Promos.Tingkat", promoTierOrder).
+		Preload("Diskon", activeDiscounts).
 		Preload("Promos.Store").
 		First(&updatedProduct, id).Error
 
@@ -270,8 +539,11 @@ func (repository *productRepositoryImpl) FindByCategory(categoryID string) ([]mo
 		Preload("Reviews").
 		Preload("Reviews.Store").
 		Preload("Promos").
+		Preload("Promos.Tingkat", promoTierOrder).
+		Preload("Diskon", activeDiscounts).
 		Preload("Promos.Store").
 		Where("id_category = ?", categoryID).
+		Where(productPublishedCond, time.Now()).
 		Order("id desc"). // Add this line to sort by newest first
 		Find(&products).Error
 
@@ -286,31 +558,87 @@ func (repository *productRepositoryImpl) FindByCategory(categoryID string) ([]mo
 	return responses, nil
 }
 
-func (repository *productRepositoryImpl) SearchProducts(query string) ([]models.ProductResponse, error) {
-	var products []entities.Product
+// SearchProducts runs a FULLTEXT search and returns a page of products ranked by
+// relevance. Matches in the product name weigh twice as much as the description.
+func (repository *productRepositoryImpl) SearchProducts(request responder.Pagination, match string, booleanMode bool) (responder.Pagination, error) {
+	mode := "IN NATURAL LANGUAGE MODE"
+	if booleanMode {
+		mode = "IN BOOLEAN MODE"
+	}
+	matchAll := "MATCH(produk.nama_produk, produk.deskripsi) AGAINST (? " + mode + ")"
+	matchName := "MATCH(produk.nama_produk) AGAINST (? " + mode + ")"
 
-	err := repository.database.
-		Preload("Store").
-		Preload("Store.FotoToko").
-		Preload("Category").
-		Preload("FotoProduk").
-		Preload("Reviews").
-		Preload("Reviews.Store").
-		Preload("Promos").
-		Preload("Promos.Store").
-		Where("LOWER(nama_produk) LIKE LOWER(?)", "%"+query+"%").
-		Order("id desc"). // Add this line to sort by newest first
-		Find(&products).Error
+	now := time.Now()
+	var totalRows int64
+	err := repository.database.Model(&entities.Product{}).
+		Where(matchAll, match).
+		Where(productPublishedCond, now).
+		Count(&totalRows).Error
+	if err != nil {
+		return responder.Pagination{}, err
+	}
 
+	var hits []struct {
+		ID        uint
+		Relevansi float64
+	}
+	err = repository.database.Model(&entities.Product{}).
+		Select("produk.id, 2 * "+matchName+" + "+matchAll+" AS relevansi", match, match).
+		Where(matchAll, match).
+		Where(productPublishedCond, now).
+		Order("relevansi DESC, produk.id DESC").
+		Limit(request.Limit).
+		Offset(request.GetOffset()).
+		Scan(&hits).Error
 	if err != nil {
-		return nil, err
+		return responder.Pagination{}, err
 	}
 
-	var responses []models.ProductResponse
-	for _, product := range products {
-		responses = append(responses, mapProductToResponse(product))
+	results := []models.ProductSearchResult{}
+	if len(hits) > 0 {
+		ids := make([]uint, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.ID
+		}
+
+		var products []entities.Product
+		err = repository.database.
+			Preload("Store").
+			Preload("Store.FotoToko").
+			Preload("Category").
+			Preload("FotoProduk").
+			Preload("Reviews").
+			Preload("Reviews.Store").
+			Preload("Promos").
+			Preload("Promos.Tingkat", promoTierOrder).
+			Preload("Diskon", activeDiscounts).
+			Preload("Promos.Store").
+			Where("id IN ?", ids).
+			Find(&products).Error
+		if err != nil {
+			return responder.Pagination{}, err
+		}
+
+		byID := make(map[uint]entities.Product, len(products))
+		for _, product := range products {
+			byID[product.ID] = product
+		}
+		// Keep the relevance order of the first query
+		for _, hit := range hits {
+			if product, ok := byID[hit.ID]; ok {
+				results = append(results, models.ProductSearchResult{
+					ProductResponse: mapProductToResponse(product),
+					Relevansi:       hit.Relevansi,
+				})
+			}
+		}
 	}
-	return responses, nil
+
+	request.Rows = results
+	request.TotalRows = totalRows
+	request.TotalPages = int(math.Ceil(float64(totalRows) / float64(request.Limit)))
+
+	return request, nil
 }
 
 func (repository *productRepositoryImpl) FindRelatedProducts(id uint) ([]models.ProductResponse, error) {
@@ -332,8 +660,11 @@ func (repository *productRepositoryImpl) FindRelatedProducts(id uint) ([]models.
 		Preload("Reviews").
 		Preload("Reviews.Store").
 		Preload("Promos").
+		Preload("Promos.Tingkat", promoTierOrder).
+		Preload("Diskon", activeDiscounts).
 		Preload("Promos.Store").
 		Where("id_category = ? AND id != ?", currentProduct.IDCategory, id).
+		Where(productPublishedCond, time.Now()).
 		Order("id desc"). // Add this line to sort by newest first
 		Limit(5).
 		Find(&products).Error
@@ -387,13 +718,7 @@ func (repository *productRepositoryImpl) SaveProductPhotos(productID uint, photo
 			}
 		} else if urlStr, ok := urlData.(string); ok {
 			// If it's just a URL string
-			photo := entities.FotoProduk{
-				IDProduk: productID,
-				PhotoURL: urlStr,
-				Photo:    filepath.Base(urlStr),
-				FileName: filepath.Base(urlStr),
-				FileType: filepath.Ext(urlStr),
-			}
+			photo := photoFromURL(productID, urlStr)
 			if err := repository.database.Create(&photo).Error; err != nil {
 				return err
 			}
@@ -402,6 +727,184 @@ func (repository *productRepositoryImpl) SaveProductPhotos(productID uint, photo
 	return nil
 }
 
+// moveProductSlug keeps the old slug so its URL redirects to the renamed product,
+// and reclaims the new slug if it was one of the product's earlier slugs
+func moveProductSlug(tx *gorm.DB, id uint, oldSlug string, newSlug string, now time.Time) error {
+	if oldSlug == "" || oldSlug == newSlug {
+		return nil
+	}
+	history := entities.ProductSlugHistory{IDProduk: id, Slug: oldSlug, CreatedAt: &now}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+	return tx.Where("id_produk = ? AND slug = ?", id, newSlug).Delete(&entities.ProductSlugHistory{}).Error
+}
+
+func photoFromURL(productID uint, url string) entities.FotoProduk {
+	return entities.FotoProduk{
+		IDProduk: productID,
+		PhotoURL: url,
+		Photo:    filepath.Base(url),
+		FileName: filepath.Base(url),
+		FileType: filepath.Ext(url),
+	}
+}
+
+// FindByStore loads every product of a store in any status with its category and photos
+func (repository *productRepositoryImpl) FindByStore(storeID uint) ([]entities.Product, error) {
+	var products []entities.Product
+	err := repository.database.
+		Preload("Category").
+		Preload("FotoProduk").
+		Where("id_toko = ?", storeID).
+		Order("id asc").
+		Find(&products).Error
+	return products, err
+}
+
+// ImportProducts creates the products without an ID and updates the others in
+// one transaction. A non-empty photoURLs[i] replaces the photos of products[i],
+// and an existing product's stock is only set when stockSet[i] is true.
+// The IDs of created products are written back into products.
+func (repository *productRepositoryImpl) ImportProducts(products []entities.Product, photoURLs [][]string, stockSet []bool, actorID uint) error {
+	now := time.Now()
+	return repository.database.Transaction(func(tx *gorm.DB) error {
+		for i := range products {
+			product := &products[i]
+			movement := entities.StockMovement{
+				Jenis:     entities.StockMovementImport,
+				IDUser:    stockActor(actorID),
+				Referensi: "import",
+			}
+			if product.ID == 0 {
+				product.CreatedAt = &now
+				product.UpdatedAt = &now
+				if err := tx.Omit(clause.Associations).Create(product).Error; err != nil {
+					return fmt.Errorf("failed to create %s: %v", product.NamaProduk, err)
+				}
+				movement.IDProduk = product.ID
+				if _, err := logStockMovement(tx, movement, 0, product.Stok); err != nil {
+					return err
+				}
+			} else {
+				var existing entities.Product
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, slug, stok").First(&existing, product.ID).Error; err != nil {
+					return fmt.Errorf("product %d not found: %v", product.ID, err)
+				}
+				if err := moveProductSlug(tx, product.ID, existing.Slug, product.Slug, now); err != nil {
+					return err
+				}
+				updates := map[string]interface{}{
+					"nama_produk":    product.NamaProduk,
+					"slug":           product.Slug,
+					"sku":            product.SKU,
+					"id_category":    product.IDCategory,
+					"harga_konsumen": product.HargaKonsumen,
+					"harga_reseller": product.HargaReseller,
+					"stok":           product.Stok,
+					"berat":          product.Berat,
+					"panjang":        product.Panjang,
+					"lebar":          product.Lebar,
+					"tinggi":         product.Tinggi,
+					"deskripsi":      product.Deskripsi,
+					"status":         product.Status,
+					"jadwal_terbit":  product.JadwalTerbit,
+					"updated_at":     &now,
+				}
+
+				// The stock of a product with variants is the sum of its variants'
+				// stock, and a row without stok leaves the stock as sales left it
+				hasVariants, err := hasActiveVariants(tx, product.ID)
+				if err != nil {
+					return err
+				}
+				if hasVariants || !stockSet[i] {
+					delete(updates, "stok")
+				} else {
+					movement.IDProduk = product.ID
+					if _, err := logStockMovement(tx, movement, existing.Stok, product.Stok); err != nil {
+						return err
+					}
+				}
+				if err := tx.Model(&entities.Product{}).Where("id = ?", product.ID).Updates(updates).Error; err != nil {
+					return fmt.Errorf("failed to update %s: %v", product.NamaProduk, err)
+				}
+			}
+
+			if len(photoURLs[i]) == 0 {
+				continue
+			}
+			if err := tx.Delete(&entities.FotoProduk{}, "id_produk = ?", product.ID).Error; err != nil {
+				return err
+			}
+			for _, url := range photoURLs[i] {
+				photo := photoFromURL(product.ID, url)
+				photo.CreatedAt = &now
+				photo.UpdatedAt = &now
+				if err := tx.Create(&photo).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (repository *productRepositoryImpl) UpdateLowStockThreshold(id uint, threshold *int) error {
+	return repository.database.Model(&entities.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"batas_stok": threshold, "updated_at": time.Now()}).Error
+}
+
+// FindWithStoreByIds loads raw product rows with their store, used for weight and origin lookups
+func (repository *productRepositoryImpl) FindWithStoreByIds(ids []uint) ([]entities.Product, error) {
+	var products []entities.Product
+	err := repository.database.
+		Preload("Store").
+		Where("id IN ?", ids).
+		Find(&products).Error
+	return products, err
+}
+
+// UpdateStatus sets the lifecycle status, the scheduled publish time and the ban reason of a product
+func (repository *productRepositoryImpl) UpdateStatus(id uint, status string, publishAt *time.Time, banReason string) error {
+	now := time.Now()
+	return repository.database.Model(&entities.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"jadwal_terbit": publishAt,
+			"alasan_blokir": banReason,
+			"updated_at":    &now,
+		}).Error
+}
+
+// PublishDue publishes the drafts whose scheduled publish time has passed and returns them
+func (repository *productRepositoryImpl) PublishDue(now time.Time) ([]entities.Product, error) {
+	var products []entities.Product
+	err := repository.database.Transaction(func(tx *gorm.DB) error {
+		err := tx.Select("id, nama_produk").
+			Where("status = ? AND jadwal_terbit <= ?", entities.ProductStatusDraft, now).
+			Find(&products).Error
+		if err != nil || len(products) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(products))
+		for i, product := range products {
+			ids[i] = product.ID
+		}
+		return tx.Model(&entities.Product{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":        entities.ProductStatusPublished,
+				"jadwal_terbit": nil,
+				"updated_at":    &now,
+			}).Error
+	})
+	return products, err
+}
+
 // Helper function to map simplified review response
 func mapSimplifiedReviewResponse(review entities.ProductReview) models.SimpleProductReviewResponse {
 	return models.SimpleProductReviewResponse{
@@ -465,14 +968,7 @@ func mapProductToResponse(product entities.Product) models.ProductResponse {
 
 	var promoResponses []models.ProductPromoResponse
 	for _, promo := range product.Promos {
-		promoResponses = append(promoResponses, models.ProductPromoResponse{
-			ID:        uint(promo.ID),
-			IDToko:    uint(promo.IDToko),
-			IDProduk:  uint(promo.IDProduk),
-			Promo:     promo.Promo,
-			CreatedAt: promo.CreatedAt,
-			UpdatedAt: promo.UpdatedAt,
-		})
+		promoResponses = append(promoResponses, mapPromoToResponse(promo))
 	}
 
 	var couponResponses []models.ProductCouponResponse
@@ -491,27 +987,63 @@ func mapProductToResponse(product entities.Product) models.ProductResponse {
 		UpdatedAt:     product.Store.UpdatedAt,
 	}
 
+	var sku string
+	if product.SKU != nil {
+		sku = *product.SKU
+	}
+
+	// Buyers pay the discounted price, the list price is shown struck through
+	hargaKonsumen := product.HargaKonsumen
+	var hargaCoret string
+	var diskon *models.ActiveDiscountResponse
+	if active := product.ActiveDiscount(time.Now()); active != nil {
+		hargaCoret = product.HargaKonsumen
+		hargaKonsumen = active.Apply(product.HargaKonsumen)
+		diskon = &models.ActiveDiscountResponse{
+			ID:           active.ID,
+			Persen:       active.Persen,
+			MulaiPada:    active.MulaiPada,
+			BerakhirPada: active.BerakhirPada,
+		}
+	}
+
 	return models.ProductResponse{
-		ID:            product.ID,
-		NamaProduk:    product.NamaProduk,
-		Slug:          product.Slug,
-		HargaReseller: product.HargaReseller,
-		HargaKonsumen: product.HargaKonsumen,
-		Stok:          product.Stok,
-		Deskripsi:     product.Deskripsi,
-		Store:         storeResponse, // Use the new store response with photos
+		ID:               product.ID,
+		NamaProduk:       product.NamaProduk,
+		Slug:             product.Slug,
+		SKU:              sku,
+		HargaReseller:    product.HargaReseller,
+		HargaKonsumen:    hargaKonsumen,
+		HargaCoret:       hargaCoret,
+		Diskon:           diskon,
+		MinOrderReseller: product.MinOrderReseller,
+		Stok:             product.Stok,
+		Berat:            product.Berat,
+		Panjang:          product.Panjang,
+		Lebar:            product.Lebar,
+		Tinggi:           product.Tinggi,
+		Deskripsi:        product.Deskripsi,
+		Store:            storeResponse, // Use the new store response with photos
 		Category: models.CategoryResponse{
 			ID:           product.Category.ID,
+			IDParent:     product.Category.IDParent,
 			NamaCategory: product.Category.NamaCategory,
+			Slug:         product.Category.Slug,
+			Icon:         product.Category.Icon,
+			Urutan:       product.Category.Urutan,
 			CreatedAt:    product.Category.CreatedAt,
 			UpdatedAt:    product.Category.UpdatedAt,
 		},
-		FotoProduk: fotoProdukResponses,
-		Reviews:    reviewResponses,
-		Promos:     promoResponses,
-		Coupons:    couponResponses, // Add this line
-		CreatedAt:  product.CreatedAt,
-		UpdatedAt:  product.UpdatedAt,
+		FotoProduk:   fotoProdukResponses,
+		Reviews:      reviewResponses,
+		Promos:       promoResponses,
+		Coupons:      couponResponses, // Add this line
+		Status:       product.Status,
+		JadwalTerbit: product.JadwalTerbit,
+		AlasanBlokir: product.AlasanBlokir,
+		BatasStok:    product.BatasStok,
+		CreatedAt:    product.CreatedAt,
+		UpdatedAt:    product.UpdatedAt,
 	}
 }
 
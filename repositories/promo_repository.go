@@ -12,8 +12,9 @@ type ProductPromoRepository interface {
 	FindAll() ([]entities.ProductPromo, error)
 	FindById(id uint64) (entities.ProductPromo, error)
 	FindByProductId(productId uint32) ([]entities.ProductPromo, error)
-	Insert(promo models.ProductPromoRequest, storeId uint64) (entities.ProductPromo, error)
-	Update(promo models.ProductPromoRequest, id uint64) (entities.ProductPromo, error)
+	FindRunning(productIDs []uint, storeIDs []uint, now time.Time) ([]entities.ProductPromo, error)
+	Insert(promo entities.ProductPromo) (entities.ProductPromo, error)
+	Update(promo entities.ProductPromo) (entities.ProductPromo, error)
 	Delete(id uint64) error
 	DeleteAll() error
 }
@@ -28,70 +29,126 @@ func NewProductPromoRepository(db *gorm.DB) ProductPromoRepository {
 
 func (r *productPromoRepositoryImpl) FindAll() ([]entities.ProductPromo, error) {
 	var promos []entities.ProductPromo
-	err := r.db.Order("id desc").Preload("Store").Preload("Product").Find(&promos).Error
+	err := r.db.Order("id desc").Preload("Store").Preload("Product").Preload("Tingkat", promoTierOrder).Find(&promos).Error
 	return promos, err
 }
 
 func (r *productPromoRepositoryImpl) FindById(id uint64) (entities.ProductPromo, error) {
 	var promo entities.ProductPromo
-	err := r.db.Order("id desc").Preload("Store").Preload("Product").First(&promo, id).Error
+	err := r.db.Order("id desc").Preload("Store").Preload("Product").Preload("Tingkat", promoTierOrder).First(&promo, id).Error
 	return promo, err
 }
 
 func (r *productPromoRepositoryImpl) FindByProductId(productId uint32) ([]entities.ProductPromo, error) {
 	var promos []entities.ProductPromo
-	err := r.db.Order("id desc").Preload("Store").Preload("Product").Where("id_produk = ?", productId).Find(&promos).Error
+	err := r.db.Order("id desc").Preload("Store").Preload("Product").Preload("Tingkat", promoTierOrder).Where("id_produk = ?", productId).Find(&promos).Error
 	return promos, err
 }
 
-func (r *productPromoRepositoryImpl) Insert(promo models.ProductPromoRequest, storeId uint64) (entities.ProductPromo, error) {
-	now := time.Now()
-	newPromo := entities.ProductPromo{
-		IDToko:    storeId,
-		IDProduk:  promo.IDProduk,
-		Promo:     promo.Promo,
-		CreatedAt: &now,
-		UpdatedAt: &now,
+// FindRunning finds the rule promos that apply at now to the given products,
+// and the store-wide ones of the given stores
+func (r *productPromoRepositoryImpl) FindRunning(productIDs []uint, storeIDs []uint, now time.Time) ([]entities.ProductPromo, error) {
+	var promos []entities.ProductPromo
+	if len(productIDs) == 0 && len(storeIDs) == 0 {
+		return promos, nil
 	}
-
-	err := r.db.Create(&newPromo).Error
-	if err != nil {
-		return entities.ProductPromo{}, err
+	if len(productIDs) == 0 {
+		productIDs = []uint{0}
 	}
-
-	var completePromo entities.ProductPromo
-	err = r.db.Preload("Store").Preload("Product").First(&completePromo, newPromo.ID).Error
-	return completePromo, err
+	if len(storeIDs) == 0 {
+		storeIDs = []uint{0}
+	}
+	err := r.db.
+		Preload("Tingkat", promoTierOrder).
+		Where("jenis <> '' AND aktif = ?", true).
+		Where("(mulai_pada IS NULL OR mulai_pada <= ?) AND (berakhir_pada IS NULL OR berakhir_pada > ?)", now, now).
+		Where("id_produk IN ? OR (id_produk IS NULL AND id_toko IN ?)", productIDs, storeIDs).
+		Order("id asc").
+		Find(&promos).Error
+	return promos, err
 }
 
-func (r *productPromoRepositoryImpl) Update(promo models.ProductPromoRequest, id uint64) (entities.ProductPromo, error) {
-	var existingPromo entities.ProductPromo
+func (r *productPromoRepositoryImpl) Insert(promo entities.ProductPromo) (entities.ProductPromo, error) {
 	now := time.Now()
+	promo.CreatedAt = &now
+	promo.UpdatedAt = &now
 
-	err := r.db.First(&existingPromo, id).Error
-	if err != nil {
+	if err := r.db.Omit("Store", "Product").Create(&promo).Error; err != nil {
 		return entities.ProductPromo{}, err
 	}
+	return r.FindById(promo.ID)
+}
 
-	existingPromo.Promo = promo.Promo
-	existingPromo.IDToko = uint64(promo.IDToko)
-	existingPromo.IDProduk = promo.IDProduk
-	existingPromo.UpdatedAt = &now
-
-	err = r.db.Save(&existingPromo).Error
+// Update saves the promo and replaces its tiers
+func (r *productPromoRepositoryImpl) Update(promo entities.ProductPromo) (entities.ProductPromo, error) {
+	now := time.Now()
+	promo.UpdatedAt = &now
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_promo = ?", promo.ID).Delete(&entities.ProductPromoTier{}).Error; err != nil {
+			return err
+		}
+		for i := range promo.Tingkat {
+			promo.Tingkat[i].ID = 0
+			promo.Tingkat[i].IDPromo = promo.ID
+		}
+		if len(promo.Tingkat) > 0 {
+			if err := tx.Create(&promo.Tingkat).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Store", "Product", "Tingkat").Save(&promo).Error
+	})
 	if err != nil {
 		return entities.ProductPromo{}, err
 	}
-
-	var updatedPromo entities.ProductPromo
-	err = r.db.Preload("Store").Preload("Product").First(&updatedPromo, id).Error
-	return updatedPromo, err
+	return r.FindById(promo.ID)
 }
 
 func (r *productPromoRepositoryImpl) Delete(id uint64) error {
-	return r.db.Delete(&entities.ProductPromo{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_promo = ?", id).Delete(&entities.ProductPromoTier{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.ProductPromo{}, id).Error
+	})
 }
 
 func (r *productPromoRepositoryImpl) DeleteAll() error {
-	return r.db.Exec("DELETE FROM product_promos").Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_promo_tingkat").Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM product_promos").Error
+	})
+}
+
+func promoTierOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("min_jumlah asc")
+}
+
+// mapPromoToResponse maps a promo shown with its product
+func mapPromoToResponse(promo entities.ProductPromo) models.ProductPromoResponse {
+	var tingkat []models.PromoTierRequest
+	for _, tier := range promo.Tingkat {
+		tingkat = append(tingkat, models.PromoTierRequest{MinJumlah: tier.MinJumlah, Persen: tier.Persen})
+	}
+	return models.ProductPromoResponse{
+		ID:           uint(promo.ID),
+		IDToko:       uint(promo.IDToko),
+		IDProduk:     promo.IDProduk,
+		Promo:        promo.Promo,
+		Jenis:        promo.Jenis,
+		BeliJumlah:   promo.BeliJumlah,
+		GratisJumlah: promo.GratisJumlah,
+		HargaPaket:   promo.HargaPaket,
+		MinBelanja:   promo.MinBelanja,
+		MaksOngkir:   promo.MaksOngkir,
+		Tingkat:      tingkat,
+		MulaiPada:    promo.MulaiPada,
+		BerakhirPada: promo.BerakhirPada,
+		Aktif:        promo.Aktif,
+		CreatedAt:    promo.CreatedAt,
+		UpdatedAt:    promo.UpdatedAt,
+	}
 }
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"errors"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReferralRepository interface {
+	FindByCode(kode string) (entities.ReferralLink, error)
+	FindByUser(userID uint) ([]entities.ReferralLink, error)
+	FindUserLink(userID uint, productID *uint) (entities.ReferralLink, error)
+	CodeTaken(kode string) (bool, error)
+	Create(link entities.ReferralLink) (entities.ReferralLink, error)
+	CountClick(id uint) error
+	FindCommissions(userID uint, status string) ([]entities.Commission, error)
+}
+
+type referralRepositoryImpl struct {
+	database *gorm.DB
+}
+
+func NewReferralRepository(database *gorm.DB) ReferralRepository {
+	return &referralRepositoryImpl{database}
+}
+
+func (r *referralRepositoryImpl) FindByCode(kode string) (entities.ReferralLink, error) {
+	var link entities.ReferralLink
+	err := r.database.Preload("User").Preload("Product").Where("kode = ?", kode).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return link, errors.New("referral code not found")
+	}
+	return link, err
+}
+
+// FindByUser lists the reseller's links, newest first
+func (r *referralRepositoryImpl) FindByUser(userID uint) ([]entities.ReferralLink, error) {
+	var links []entities.ReferralLink
+	err := r.database.Preload("Product").Where("id_user = ?", userID).Order("id desc").Find(&links).Error
+	return links, err
+}
+
+// FindUserLink finds the reseller's link for a product, or their general link
+// when productID is nil
+func (r *referralRepositoryImpl) FindUserLink(userID uint, productID *uint) (entities.ReferralLink, error) {
+	var link entities.ReferralLink
+	query := r.database.Preload("Product").Where("id_user = ?", userID)
+	if productID == nil {
+		query = query.Where("id_produk IS NULL")
+	} else {
+		query = query.Where("id_produk = ?", *productID)
+	}
+	err := query.First(&link).Error
+	return link, err
+}
+
+func (r *referralRepositoryImpl) CodeTaken(kode string) (bool, error) {
+	var count int64
+	err := r.database.Model(&entities.ReferralLink{}).Where("kode = ?", kode).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *referralRepositoryImpl) Create(link entities.ReferralLink) (entities.ReferralLink, error) {
+	if err := r.database.Omit("User", "Product").Create(&link).Error; err != nil {
+		return entities.ReferralLink{}, err
+	}
+	return r.FindByCode(link.Kode)
+}
+
+func (r *referralRepositoryImpl) CountClick(id uint) error {
+	return r.database.Model(&entities.ReferralLink{}).Where("id = ?", id).
+		UpdateColumn("klik", gorm.Expr("klik + 1")).Error
+}
+
+// FindCommissions lists the reseller's ledger, newest first
+func (r *referralRepositoryImpl) FindCommissions(userID uint, status string) ([]entities.Commission, error) {
+	var commissions []entities.Commission
+	query := r.database.Preload("Referral").Where("id_user = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id desc").Find(&commissions).Error
+	return commissions, err
+}
+
+// settleCommissions settles the pending commissions of a delivered store order
+func settleCommissions(tx *gorm.DB, storeOrderID uint) error {
+	return tx.Model(&entities.Commission{}).
+		Where("id_trx_toko = ? AND status = ?", storeOrderID, entities.CommissionPending).
+		Updates(map[string]interface{}{
+			"status":         entities.CommissionSettled,
+			"dicairkan_pada": time.Now(),
+		}).Error
+}
+
+// releaseCommissions cancels the pending commissions of cancelled store
+// orders. column is id_trx or id_trx_toko.
+func releaseCommissions(tx *gorm.DB, column string, id uint) error {
+	return tx.Model(&entities.Commission{}).
+		Where(column+" = ? AND status = ?", id, entities.CommissionPending).
+		Update("status", entities.CommissionCancelled).Error
+}
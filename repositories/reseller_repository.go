@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ResellerRepository interface {
+	FindById(id uint) (entities.Reseller, error)
+	FindByUser(userID uint) (entities.Reseller, error)
+	FindAll(status string) ([]entities.Reseller, error)
+	Apply(reseller entities.Reseller) (entities.Reseller, error)
+	Decide(id uint, from string, to string, adminID uint, catatan string) (entities.Reseller, error)
+}
+
+type resellerRepositoryImpl struct {
+	database *gorm.DB
+}
+
+func NewResellerRepository(database *gorm.DB) ResellerRepository {
+	return &resellerRepositoryImpl{database}
+}
+
+func (r *resellerRepositoryImpl) FindById(id uint) (entities.Reseller, error) {
+	var reseller entities.Reseller
+	err := r.database.Preload("User").First(&reseller, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reseller, errors.New("reseller application not found")
+	}
+	return reseller, err
+}
+
+// FindByUser finds the user's application; gorm.ErrRecordNotFound means the
+// user never applied
+func (r *resellerRepositoryImpl) FindByUser(userID uint) (entities.Reseller, error) {
+	var reseller entities.Reseller
+	err := r.database.Preload("User").Where("id_user = ?", userID).First(&reseller).Error
+	return reseller, err
+}
+
+// FindAll lists the applications, oldest first so the queue is reviewed in order
+func (r *resellerRepositoryImpl) FindAll(status string) ([]entities.Reseller, error) {
+	var resellers []entities.Reseller
+	query := r.database.Preload("User")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at asc, id asc").Find(&resellers).Error
+	return resellers, err
+}
+
+// Apply saves a new application, or puts a rejected or revoked one back in
+// the queue with the new details
+func (r *resellerRepositoryImpl) Apply(reseller entities.Reseller) (entities.Reseller, error) {
+	reseller.Status = entities.ResellerPending
+	reseller.Catatan = ""
+	reseller.DiputuskanOleh = nil
+	reseller.DiputuskanPada = nil
+	if err := r.database.Omit("User").Save(&reseller).Error; err != nil {
+		return entities.Reseller{}, err
+	}
+	return r.FindById(reseller.ID)
+}
+
+// Decide moves an application from one status to another and notifies the
+// user. The row is locked, so two admins cannot decide the same application.
+func (r *resellerRepositoryImpl) Decide(id uint, from string, to string, adminID uint, catatan string) (entities.Reseller, error) {
+	err := r.database.Transaction(func(tx *gorm.DB) error {
+		var reseller entities.Reseller
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reseller, id).Error; err != nil {
+			return errors.New("reseller application not found")
+		}
+		if reseller.Status != from {
+			return fmt.Errorf("application is %s, not %s", reseller.Status, from)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&reseller).Updates(map[string]interface{}{
+			"status":          to,
+			"catatan":         catatan,
+			"diputuskan_oleh": adminID,
+			"diputuskan_pada": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var message string
+		switch to {
+		case entities.ResellerApproved:
+			message = "Your reseller application has been approved; reseller prices now apply to your orders"
+		case entities.ResellerRejected:
+			message = "Your reseller application has been rejected"
+		default:
+			message = "Your reseller status has been revoked"
+		}
+		if catatan != "" {
+			message += ": " + catatan
+		}
+		return tx.Create(&entities.Notification{
+			IDUser:    &reseller.IDUser,
+			Jenis:     entities.NotificationReseller,
+			Pesan:     message,
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return entities.Reseller{}, err
+	}
+	return r.FindById(id)
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"errors"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceStockHistoryRepository interface {
+	Apply(changes []entities.ProductPriceStockHistory) ([]entities.ProductPriceStockHistory, error)
+	FindByProductId(productID uint, limit int, offset int) ([]entities.ProductPriceStockHistory, int64, error)
+}
+
+type priceStockHistoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPriceStockHistoryRepository(db *gorm.DB) PriceStockHistoryRepository {
+	return &priceStockHistoryRepositoryImpl{db}
+}
+
+// Apply writes the new stock and prices of each change in one transaction and
+// records it in the history, and a stock change in the stock ledger. The old
+// values are read from the locked row, and fields that already hold the new
+// value are dropped from the change. The returned slice matches changes; an
+// entry with ID 0 changed nothing.
+func (r *priceStockHistoryRepositoryImpl) Apply(changes []entities.ProductPriceStockHistory) ([]entities.ProductPriceStockHistory, error) {
+	applied := make([]entities.ProductPriceStockHistory, len(changes))
+	now := time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i, change := range changes {
+			var stok int
+			var hargaKonsumen, hargaReseller string
+			var target *gorm.DB
+			if change.IDVarian != nil {
+				var variant entities.ProductVariant
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&variant, *change.IDVarian).Error; err != nil {
+					return err
+				}
+				if variant.IDProduk != change.IDProduk {
+					return errors.New("variant does not belong to the product")
+				}
+				stok = variant.Stok
+				if variant.HargaKonsumen != nil {
+					hargaKonsumen = *variant.HargaKonsumen
+				}
+				if variant.HargaReseller != nil {
+					hargaReseller = *variant.HargaReseller
+				}
+				target = tx.Model(&entities.ProductVariant{}).Where("id = ?", variant.ID)
+			} else {
+				var product entities.Product
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, change.IDProduk).Error; err != nil {
+					return err
+				}
+				stok = product.Stok
+				hargaKonsumen = product.HargaKonsumen
+				hargaReseller = product.HargaReseller
+				target = tx.Model(&entities.Product{}).Where("id = ?", product.ID)
+			}
+
+			updates := map[string]interface{}{}
+			if change.StokBaru != nil && *change.StokBaru != stok {
+				change.StokLama = &stok
+				updates["stok"] = *change.StokBaru
+			} else {
+				change.StokBaru = nil
+			}
+			if change.HargaKonsumenBaru != nil && *change.HargaKonsumenBaru != hargaKonsumen {
+				change.HargaKonsumenLama = &hargaKonsumen
+				updates["harga_konsumen"] = *change.HargaKonsumenBaru
+			} else {
+				change.HargaKonsumenBaru = nil
+			}
+			if change.HargaResellerBaru != nil && *change.HargaResellerBaru != hargaReseller {
+				change.HargaResellerLama = &hargaReseller
+				updates["harga_reseller"] = *change.HargaResellerBaru
+			} else {
+				change.HargaResellerBaru = nil
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			updates["updated_at"] = &now
+			if err := target.Updates(updates).Error; err != nil {
+				return err
+			}
+			if change.StokBaru != nil {
+				if _, err := logStockMovement(tx, entities.StockMovement{
+					IDProduk:  change.IDProduk,
+					IDVarian:  change.IDVarian,
+					Jenis:     entities.StockMovementAdjustment,
+					IDUser:    stockActor(change.IDUser),
+					Referensi: change.Sumber,
+				}, stok, *change.StokBaru); err != nil {
+					return err
+				}
+			}
+			if change.IDVarian != nil && change.StokBaru != nil {
+				if err := syncProductStock(tx, change.IDProduk); err != nil {
+					return err
+				}
+			}
+
+			change.CreatedAt = &now
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+			applied[i] = change
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (r *priceStockHistoryRepositoryImpl) FindByProductId(productID uint, limit int, offset int) ([]entities.ProductPriceStockHistory, int64, error) {
+	var histories []entities.ProductPriceStockHistory
+	var total int64
+
+	query := r.db.Model(&entities.ProductPriceStockHistory{}).Where("id_produk = ?", productID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id desc").Limit(limit).Offset(offset).Find(&histories).Error
+	return histories, total, err
+}
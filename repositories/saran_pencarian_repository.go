@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SearchSuggestionRepository interface {
+	FindAll() ([]models.SearchSuggestionItem, error)
+}
+
+type searchSuggestionRepositoryImpl struct {
+	database *gorm.DB
+}
+
+func NewSearchSuggestionRepository(database *gorm.DB) SearchSuggestionRepository {
+	return &searchSuggestionRepositoryImpl{database}
+}
+
+// FindAll lists the names of every published product, category and store
+func (repository *searchSuggestionRepositoryImpl) FindAll() ([]models.SearchSuggestionItem, error) {
+	var items []models.SearchSuggestionItem
+
+	sources := []struct {
+		query     *gorm.DB
+		selectSQL string
+	}{
+		{repository.database.Model(&entities.Product{}).Where(productPublishedCond, time.Now()), "'produk' AS tipe, id, nama_produk AS nama"},
+		{repository.database.Model(&entities.Category{}), "'category' AS tipe, id, nama_category AS nama"},
+		{repository.database.Model(&entities.Store{}), "'toko' AS tipe, id, nama_toko AS nama"},
+	}
+	for _, source := range sources {
+		var rows []models.SearchSuggestionItem
+		err := source.query.Select(source.selectSQL).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, rows...)
+	}
+
+	return items, nil
+}
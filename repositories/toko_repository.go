@@ -47,7 +47,7 @@ func (repository *storeRepositoryImpl) FindAllPagination(pagination responder.Pa
 
 	// Add Preload for FotoToko and ensure proper ordering
 	err = query.
-		Select("id, id_user, nama_toko, deskripsi_toko, url_foto, created_at, updated_at"). // Add this line to ensure id_user is selected
+		Select("id, id_user, nama_toko, deskripsi_toko, url_foto, id_provinsi, id_kota, created_at, updated_at"). // Add this line to ensure id_user is selected
 		Preload("FotoToko", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC")
 		}).
@@ -69,7 +69,7 @@ func (repository *storeRepositoryImpl) FindById(id uint) (entities.Store, []enti
 
 	// Get the store with eager loading of photos
 	err := repository.database.
-		Select("id, id_user, nama_toko, deskripsi_toko, url_foto, created_at, updated_at"). // Make sure id_user is selected
+		Select("id, id_user, nama_toko, deskripsi_toko, url_foto, id_provinsi, id_kota, created_at, updated_at"). // Make sure id_user is selected
 		Preload("FotoToko", func(db *gorm.DB) *gorm.DB {
 			return db.Order("created_at DESC").Limit(1) // Only get the latest photo
 		}).
@@ -111,6 +111,8 @@ func (repository *storeRepositoryImpl) Update(id uint, store entities.Store, pho
 		"nama_toko":      store.NamaToko,
 		"deskripsi_toko": store.DeskripsiToko,
 		"url_foto":       store.UrlFoto,
+		"id_provinsi":    store.IDProvinsi,
+		"id_kota":        store.IDKota,
 	}
 
 	if err := tx.Model(&entities.Store{}).Where("id = ?", id).Updates(updates).Error; err != nil {
@@ -155,6 +157,8 @@ func (repository *storeRepositoryImpl) Insert(store entities.Store, photoURLs []
 		NamaToko:      store.NamaToko,
 		DeskripsiToko: store.DeskripsiToko,
 		UrlFoto:       store.UrlFoto,
+		IDProvinsi:    store.IDProvinsi,
+		IDKota:        store.IDKota,
 	}
 
 	if err := tx.Create(&storeToCreate).Error; err != nil {
@@ -1,21 +1,25 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/models/responder"
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository interface {
 	FindAllPagination(pagination responder.Pagination) (responder.Pagination, error)
 	FindById(id uint) (entities.Trx, error)
+	FindByInvoiceCode(code string) (entities.Trx, error)
 	Insert(transaction models.TransactionProcessData) (uint, error)
 	Update(transaction entities.Trx) (entities.Trx, error)
-	Delete(id uint) error
+	Delete(id uint, actorID uint) error
 }
 
 type transactionRepositoryImpl struct {
@@ -58,13 +62,20 @@ func (repository *transactionRepositoryImpl) FindAllPagination(pagination respon
 		}
 
 		response := models.TransactionResponse{
-			ID:          trx.ID,
-			UserID:      trx.IDUser,
-			HargaTotal:  trx.HargaTotal,
-			KodeInvoice: trx.KodeInvoice,
-			MethodBayar: trx.MethodBayar,
-			CreatedAt:   createdAt,
-			UpdatedAt:   updatedAt,
+			ID:           trx.ID,
+			UserID:       trx.IDUser,
+			HargaTotal:   trx.HargaTotal,
+			OngkosKirim:  trx.OngkosKirim,
+			Diskon:       trx.Diskon,
+			DiskonOngkir: trx.DiskonOngkir,
+			KodeKupon:    trx.KodeKupon,
+			KodeReferral: trx.KodeReferral,
+			Kurir:        trx.Kurir,
+			LayananKirim: trx.LayananKirim,
+			KodeInvoice:  trx.KodeInvoice,
+			MethodBayar:  trx.MethodBayar,
+			CreatedAt:    createdAt,
+			UpdatedAt:    updatedAt,
 			Address: models.AddressResponse{
 				ID:           trx.Address.ID,
 				IDUser:       trx.IDUser,
@@ -101,19 +112,38 @@ func (repository *transactionRepositoryImpl) FindById(id uint) (entities.Trx, er
 	err := repository.database.
 		Order("id desc").   // Add ordering here
 		Preload("Address"). // Make sure we're preloading the Address
+		Preload("TrxDetail").
+		Preload("TrxDetail.ProductLog").
+		Preload("StoreOrders").
+		Preload("StoreOrders.Store", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, id_user, nama_toko")
+		}).
+		Preload("StoreOrders.TrxDetail").
+		Preload("StoreOrders.TrxDetail.ProductLog").
+		Preload("Promos").
+		Preload("Vouchers.Voucher").
 		Where("id = ?", id).
 		First(&transaction).Error
 
-	// Map the IDUser to the response
-	if err == nil {
-		transaction.IDUser = transaction.IDUser // Ensure IDUser is properly set
-	}
-
 	fmt.Printf("Retrieved transaction with address: %+v\n", transaction)
 
 	return transaction, err
 }
 
+func (repository *transactionRepositoryImpl) FindByInvoiceCode(code string) (entities.Trx, error) {
+	var transaction entities.Trx
+
+	err := repository.database.Where("kode_invoice = ?", code).First(&transaction).Error
+	if err == gorm.ErrRecordNotFound {
+		// Fall back to the invoice code of a store order
+		err = repository.database.
+			Where("id = (?)", repository.database.Model(&entities.StoreOrder{}).Select("id_trx").Where("kode_invoice = ?", code)).
+			First(&transaction).Error
+	}
+
+	return transaction, err
+}
+
 func (repository *transactionRepositoryImpl) Insert(transaction models.TransactionProcessData) (uint, error) {
 	tx := repository.database.Begin()
 
@@ -123,9 +153,19 @@ func (repository *transactionRepositoryImpl) Insert(transaction models.Transacti
 		IDUser:           transaction.Transaction.UserID, // Make sure this matches your DB column
 		AlamatPengiriman: transaction.Transaction.AlamatPengiriman,
 		HargaTotal:       float64(transaction.Transaction.HargaTotal),
+		OngkosKirim:      transaction.Transaction.OngkosKirim,
+		Diskon:           transaction.Transaction.Diskon,
+		DiskonOngkir:     transaction.Transaction.DiskonOngkir,
+		KodeKupon:        transaction.Transaction.KodeKupon,
+		Kurir:            transaction.Transaction.Kurir,
+		LayananKirim:     transaction.Transaction.LayananKirim,
 		KodeInvoice:      transaction.Transaction.KodeInvoice,
 		MethodBayar:      transaction.Transaction.MethodBayar,
 	}
+	if transaction.Referral != nil {
+		transaction_insert.IDReseller = &transaction.Referral.IDUser
+		transaction_insert.KodeReferral = transaction.Referral.Kode
+	}
 
 	// Debug the entity before saving
 	fmt.Printf("Repository: Transaction entity before create: %+v\n", transaction_insert)
@@ -141,29 +181,185 @@ func (repository *transactionRepositoryImpl) Insert(transaction models.Transacti
 		return 0, fmt.Errorf("failed to load address: %w", err)
 	}
 
-	for _, v := range transaction.LogProduct {
+	// One store order per store, totals summed from that store's lines less
+	// their promo and coupon discounts, plus shipping less free shipping
+	storeOrderIDs := map[uint]uint{}
+	storeOrderCodes := map[uint]string{}
+	for _, storeOrder := range transaction.StoreOrders {
+		var subtotal, diskon float64
+		for _, v := range transaction.LogProduct {
+			if v.StoreID == storeOrder.StoreID {
+				subtotal += v.HargaTotal
+				diskon += v.Diskon
+			}
+		}
+
+		store_order := &entities.StoreOrder{
+			IDTrx:        transaction_insert.ID,
+			IDToko:       storeOrder.StoreID,
+			KodeInvoice:  storeOrder.KodeInvoice,
+			Subtotal:     subtotal,
+			OngkosKirim:  storeOrder.OngkosKirim,
+			Diskon:       diskon,
+			DiskonOngkir: storeOrder.DiskonOngkir,
+			HargaTotal:   subtotal - diskon + storeOrder.OngkosKirim - storeOrder.DiskonOngkir,
+			Kurir:        storeOrder.Kurir,
+			LayananKirim: storeOrder.LayananKirim,
+			Status:       "pending",
+		}
+		if err := tx.Create(store_order).Error; err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to create store order: %w", err)
+		}
+		storeOrderIDs[storeOrder.StoreID] = store_order.ID
+		storeOrderCodes[storeOrder.StoreID] = store_order.KodeInvoice
+	}
+
+	detailIDs := make([]uint, len(transaction.LogProduct))
+	var flashSales []entities.FlashSalePurchase
+	for i, v := range transaction.LogProduct {
 		log_product := &entities.ProductLog{
 			IDProduk:      v.ProductID,
 			NamaProduk:    v.NamaProduk,
 			Slug:          v.Slug,
-			HargaReseller: fmt.Sprintf("%.2f", v.HargaReseller),
-			HargaKonsumen: fmt.Sprintf("%.2f", v.HargaKonsumen),
+			HargaReseller: v.HargaReseller,
+			HargaKonsumen: v.HargaKonsumen,
 			Deskripsi:     &v.Deskripsi,
 			IDToko:        v.StoreID,
 			IDCategory:    v.CategoryID,
+			IDVarian:      v.VariantID,
+			SKU:           v.SKU,
+			NamaVarian:    v.NamaVarian,
 		}
 		if err := tx.Create(log_product).Error; err != nil {
 			tx.Rollback()
 			return 0, err
 		}
 
-		if err := tx.Create(&entities.TrxDetail{
+		// Take the stock from the warehouse the line was allocated to; the
+		// checkout fails when there is not enough left
+		sale, err := moveStock(tx, entities.StockMovement{
+			IDProduk:  v.ProductID,
+			IDVarian:  v.VariantID,
+			IDGudang:  v.WarehouseID,
+			Jenis:     entities.StockMovementSale,
+			Jumlah:    -v.Kuantitas,
+			IDUser:    stockActor(transaction.Transaction.UserID),
+			Referensi: storeOrderCodes[v.StoreID],
+		})
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		var storeOrderID *uint
+		if id, ok := storeOrderIDs[v.StoreID]; ok {
+			storeOrderID = &id
+		}
+
+		detail := &entities.TrxDetail{
 			IDTrx:       transaction_insert.ID,
+			IDTrxToko:   storeOrderID,
 			IDLogProduk: log_product.ID,
+			IDVarian:    v.VariantID,
 			IDToko:      v.StoreID,
+			IDGudang:    sale.IDGudang,
 			Kuantitas:   v.Kuantitas,
 			HargaTotal:  float64(v.HargaTotal),
-		}).Error; err != nil {
+			Diskon:      v.Diskon,
+			Reseller:    v.Reseller,
+		}
+		if err := tx.Create(detail).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		detailIDs[i] = detail.ID
+
+		if v.FlashSaleItem != nil {
+			flashSales = append(flashSales, entities.FlashSalePurchase{
+				IDFlashSaleItem: *v.FlashSaleItem,
+				IDTrx:           transaction_insert.ID,
+				IDTrxToko:       storeOrderID,
+				IDTrxDetail:     detail.ID,
+				Jumlah:          v.Kuantitas,
+			})
+		}
+	}
+
+	// Reserve the flash sale units; the checkout fails when a sale sold out meanwhile
+	if err := reserveFlashSales(tx, transaction.Transaction.UserID, flashSales); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// Record the promos applied, against their line or the store order whose
+	// shipping they paid
+	for _, promo := range transaction.Promos {
+		applied := entities.TransactionPromo{
+			IDTrx:   transaction_insert.ID,
+			IDPromo: uint64(promo.IDPromo),
+			Promo:   promo.Promo,
+			Jenis:   promo.Jenis,
+			Diskon:  promo.Diskon,
+		}
+		if id, ok := storeOrderIDs[promo.IDToko]; ok {
+			applied.IDTrxToko = &id
+		}
+		if promo.Baris >= 0 {
+			applied.IDTrxDetail = &detailIDs[promo.Baris]
+		}
+		if err := tx.Create(&applied).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	// Credit the reseller the buyer came through with the commission of each
+	// line, pending until its store order is delivered
+	if transaction.Referral != nil {
+		for i, v := range transaction.LogProduct {
+			if transaction.Referral.Komisi[i] <= 0 {
+				continue
+			}
+			hargaReseller, _ := strconv.ParseFloat(v.HargaReseller, 64)
+			commission := entities.Commission{
+				IDUser:        transaction.Referral.IDUser,
+				IDReferral:    transaction.Referral.IDReferral,
+				IDTrx:         transaction_insert.ID,
+				IDTrxDetail:   detailIDs[i],
+				KodeInvoice:   storeOrderCodes[v.StoreID],
+				IDProduk:      v.ProductID,
+				NamaProduk:    v.NamaProduk,
+				Kuantitas:     v.Kuantitas,
+				HargaJual:     v.HargaTotal,
+				HargaReseller: hargaReseller,
+				Komisi:        transaction.Referral.Komisi[i],
+				Status:        entities.CommissionPending,
+			}
+			if id, ok := storeOrderIDs[v.StoreID]; ok {
+				commission.IDTrxToko = &id
+			}
+			if err := tx.Omit("Referral").Create(&commission).Error; err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+		}
+	}
+
+	// Use the store vouchers out of the buyer's wallet
+	if err := useVoucherClaims(tx, transaction.Vouchers, transaction.Transaction.UserID, transaction_insert.ID, storeOrderIDs); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// Redeem the coupon last; the checkout fails when its limits were reached meanwhile
+	if transaction.Coupon != nil {
+		if err := redeemCoupon(tx, transaction.Transaction.UserID, entities.CouponRedemption{
+			IDKupon:   transaction.Coupon.CouponID,
+			KodeKupon: transaction.Coupon.KodeKupon,
+			IDTrx:     transaction_insert.ID,
+			Diskon:    transaction.Coupon.Diskon,
+		}); err != nil {
 			tx.Rollback()
 			return 0, err
 		}
@@ -181,15 +377,72 @@ func (repository *transactionRepositoryImpl) Update(transaction entities.Trx) (e
 	return transaction, err
 }
 
-func (repository *transactionRepositoryImpl) Delete(id uint) error {
+// Delete removes the transaction. A transaction with shipped or delivered
+// store orders cannot be deleted; its pending and processing store orders give
+// back the stock their sale took, like a cancellation does.
+func (repository *transactionRepositoryImpl) Delete(id uint, actorID uint) error {
 	tx := repository.database.Begin()
 
+	var storeOrders []entities.StoreOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id, kode_invoice, status").
+		Where("id_trx = ?", id).
+		Find(&storeOrders).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, storeOrder := range storeOrders {
+		if storeOrder.Status == "shipped" || storeOrder.Status == "delivered" {
+			tx.Rollback()
+			return errors.New("transaction has shipped store orders and cannot be deleted")
+		}
+	}
+	for _, storeOrder := range storeOrders {
+		if storeOrder.Status != "pending" && storeOrder.Status != "processing" {
+			continue
+		}
+		if err := reverseSales(tx, storeOrder.KodeInvoice, actorID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	// First delete related records in trx_detail
 	if err := tx.Where("id_trx = ?", id).Delete(&entities.TrxDetail{}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	if err := tx.Where("id_trx = ?", id).Delete(&entities.StoreOrder{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("id_trx = ?", id).Delete(&entities.TransactionPromo{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := releaseCoupons(tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := releaseFlashSales(tx, "id_trx", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := releaseVoucherClaims(tx, "id_trx", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := releaseCommissions(tx, "id_trx", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Then delete the transaction
 	if err := tx.Delete(&entities.Trx{}, id).Error; err != nil {
 		tx.Rollback()
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"errors"
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StoreOrderRepository interface {
+	FindById(id uint) (entities.StoreOrder, error)
+	FindByTrxId(trxID uint) ([]entities.StoreOrder, error)
+	FindByStoreId(storeID uint, status string) ([]entities.StoreOrder, error)
+	UpdateStatus(id uint, from string, status string, actorID uint) (entities.StoreOrder, error)
+}
+
+type storeOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStoreOrderRepository(db *gorm.DB) StoreOrderRepository {
+	return &storeOrderRepositoryImpl{db}
+}
+
+func (r *storeOrderRepositoryImpl) preload() *gorm.DB {
+	return r.db.
+		Preload("Store", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, id_user, nama_toko")
+		}).
+		Preload("Transaction").
+		Preload("TrxDetail").
+		Preload("TrxDetail.ProductLog")
+}
+
+func (r *storeOrderRepositoryImpl) FindById(id uint) (entities.StoreOrder, error) {
+	var storeOrder entities.StoreOrder
+	err := r.preload().First(&storeOrder, id).Error
+	return storeOrder, err
+}
+
+func (r *storeOrderRepositoryImpl) FindByTrxId(trxID uint) ([]entities.StoreOrder, error) {
+	var storeOrders []entities.StoreOrder
+	err := r.preload().Where("id_trx = ?", trxID).Order("id asc").Find(&storeOrders).Error
+	return storeOrders, err
+}
+
+func (r *storeOrderRepositoryImpl) FindByStoreId(storeID uint, status string) ([]entities.StoreOrder, error) {
+	var storeOrders []entities.StoreOrder
+	query := r.preload().Where("id_toko = ?", storeID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id desc").Find(&storeOrders).Error
+	return storeOrders, err
+}
+
+// UpdateStatus saves the status; a cancelled order gives back the stock its sale
+// took, its flash sale units and its store voucher, and the coupon of the
+// checkout once all of its store orders are cancelled. The order must still be
+// in the from status, so two requests racing on it cannot both go through.
+func (r *storeOrderRepositoryImpl) UpdateStatus(id uint, from string, status string, actorID uint) (entities.StoreOrder, error) {
+	now := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.StoreOrder{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(map[string]interface{}{"status": status, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("store order status has changed, please reload it")
+		}
+		if status == "delivered" {
+			return settleCommissions(tx, id)
+		}
+		if status != "cancelled" {
+			return nil
+		}
+
+		var storeOrder entities.StoreOrder
+		if err := tx.Select("id, id_trx, kode_invoice").First(&storeOrder, id).Error; err != nil {
+			return err
+		}
+		if err := reverseSales(tx, storeOrder.KodeInvoice, actorID); err != nil {
+			return err
+		}
+
+		if err := releaseFlashSales(tx, "id_trx_toko", storeOrder.ID); err != nil {
+			return err
+		}
+		if err := releaseVoucherClaims(tx, "id_trx_toko", storeOrder.ID); err != nil {
+			return err
+		}
+		if err := releaseCommissions(tx, "id_trx_toko", storeOrder.ID); err != nil {
+			return err
+		}
+
+		var open int64
+		if err := tx.Model(&entities.StoreOrder{}).
+			Where("id_trx = ? AND status <> ?", storeOrder.IDTrx, "cancelled").
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return nil
+		}
+		return releaseCoupons(tx, storeOrder.IDTrx)
+	})
+	if err != nil {
+		return entities.StoreOrder{}, err
+	}
+	return r.FindById(id)
+}
+
+// reverseSales gives back the stock the sale of a store order took, recorded
+// as cancellation movements. Orders placed before the stock ledger took no
+// stock and have no sales to reverse.
+func reverseSales(tx *gorm.DB, kodeInvoice string, actorID uint) error {
+	var sales []entities.StockMovement
+	if err := tx.Where("referensi = ? AND jenis = ?", kodeInvoice, entities.StockMovementSale).
+		Order("id asc").
+		Find(&sales).Error; err != nil {
+		return err
+	}
+	for _, sale := range sales {
+		if _, err := moveStock(tx, entities.StockMovement{
+			IDProduk:  sale.IDProduk,
+			IDVarian:  sale.IDVarian,
+			IDGudang:  sale.IDGudang,
+			Jenis:     entities.StockMovementCancellation,
+			Jumlah:    -sale.Jumlah,
+			IDUser:    stockActor(actorID),
+			Referensi: kodeInvoice,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
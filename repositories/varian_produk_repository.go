@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"mini-project-evermos/models/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductVariantRepository interface {
+	FindOptionsByProductId(productID uint) ([]entities.ProductVariantOption, error)
+	FindByProductId(productID uint, activeOnly bool) ([]entities.ProductVariant, error)
+	FindById(id uint) (entities.ProductVariant, error)
+	FindBySKU(sku string) (entities.ProductVariant, error)
+	ReplaceOptions(productID uint, options []entities.ProductVariantOption, variants []entities.ProductVariant, actorID uint) error
+	Update(variant entities.ProductVariant, actorID uint) (entities.ProductVariant, error)
+	HasActiveVariants(productID uint) (bool, error)
+}
+
+type productVariantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) ProductVariantRepository {
+	return &productVariantRepositoryImpl{db}
+}
+
+func (r *productVariantRepositoryImpl) FindOptionsByProductId(productID uint) ([]entities.ProductVariantOption, error) {
+	var options []entities.ProductVariantOption
+	err := r.db.
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan asc")
+		}).
+		Where("id_produk = ?", productID).
+		Order("urutan asc").
+		Find(&options).Error
+	return options, err
+}
+
+func (r *productVariantRepositoryImpl) FindByProductId(productID uint, activeOnly bool) ([]entities.ProductVariant, error) {
+	var variants []entities.ProductVariant
+	query := r.db.Preload("FotoProduk").Where("id_produk = ?", productID)
+	if activeOnly {
+		query = query.Where("aktif = ?", true)
+	}
+	err := query.Order("id asc").Find(&variants).Error
+	return variants, err
+}
+
+func (r *productVariantRepositoryImpl) FindById(id uint) (entities.ProductVariant, error) {
+	var variant entities.ProductVariant
+	err := r.db.Preload("FotoProduk").First(&variant, id).Error
+	return variant, err
+}
+
+func (r *productVariantRepositoryImpl) FindBySKU(sku string) (entities.ProductVariant, error) {
+	var variant entities.ProductVariant
+	err := r.db.Where("sku = ?", sku).First(&variant).Error
+	return variant, err
+}
+
+// ReplaceOptions swaps the product's options and saves the regenerated variants.
+// Variants missing from the new combinations are deactivated rather than deleted
+// because carts, wishlists and transactions may still reference them.
+func (r *productVariantRepositoryImpl) ReplaceOptions(productID uint, options []entities.ProductVariantOption, variants []entities.ProductVariant, actorID uint) error {
+	tx := r.db.Begin()
+
+	var optionIDs []uint
+	if err := tx.Model(&entities.ProductVariantOption{}).Where("id_produk = ?", productID).Pluck("id", &optionIDs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(optionIDs) > 0 {
+		if err := tx.Where("id_opsi IN ?", optionIDs).Delete(&entities.ProductVariantOptionValue{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Where("id IN ?", optionIDs).Delete(&entities.ProductVariantOption{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, option := range options {
+		option.CreatedAt = &now
+		option.UpdatedAt = &now
+		for i := range option.Values {
+			option.Values[i].CreatedAt = &now
+			option.Values[i].UpdatedAt = &now
+		}
+		if err := tx.Create(&option).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Model(&entities.ProductVariant{}).
+		Where("id_produk = ?", productID).
+		Updates(map[string]interface{}{"aktif": false, "updated_at": now}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, variant := range variants {
+		variant.IsActive = true
+		variant.UpdatedAt = &now
+		if variant.ID == 0 {
+			variant.CreatedAt = &now
+			if err := tx.Create(&variant).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+			continue
+		}
+		if err := tx.Model(&entities.ProductVariant{}).
+			Where("id = ?", variant.ID).
+			Updates(map[string]interface{}{
+				"aktif":           true,
+				"kombinasi":       variant.Kombinasi,
+				"kunci_kombinasi": variant.KunciKombinasi,
+				"updated_at":      now,
+			}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := syncProductStock(tx, productID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := reopenProductLedger(tx, productID, actorID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Update saves the variant; a change of stock is recorded in the ledger as an adjustment
+func (r *productVariantRepositoryImpl) Update(variant entities.ProductVariant, actorID uint) (entities.ProductVariant, error) {
+	tx := r.db.Begin()
+
+	var existing entities.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, variant.ID).Error; err != nil {
+		tx.Rollback()
+		return entities.ProductVariant{}, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"sku":            variant.SKU,
+		"harga_konsumen": variant.HargaKonsumen,
+		"harga_reseller": variant.HargaReseller,
+		"stok":           variant.Stok,
+		"id_foto_produk": variant.IDFotoProduk,
+		"aktif":          variant.IsActive,
+		"updated_at":     now,
+	}
+	if err := tx.Model(&entities.ProductVariant{}).Where("id = ?", variant.ID).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return entities.ProductVariant{}, err
+	}
+
+	if _, err := logStockMovement(tx, entities.StockMovement{
+		IDProduk: variant.IDProduk,
+		IDVarian: &variant.ID,
+		Jenis:    entities.StockMovementAdjustment,
+		IDUser:   stockActor(actorID),
+		Catatan:  "variant edited",
+	}, existing.Stok, variant.Stok); err != nil {
+		tx.Rollback()
+		return entities.ProductVariant{}, err
+	}
+
+	if err := syncProductStock(tx, variant.IDProduk); err != nil {
+		tx.Rollback()
+		return entities.ProductVariant{}, err
+	}
+	if err := reopenProductLedger(tx, variant.IDProduk, actorID); err != nil {
+		tx.Rollback()
+		return entities.ProductVariant{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.ProductVariant{}, err
+	}
+	return r.FindById(variant.ID)
+}
+
+func (r *productVariantRepositoryImpl) HasActiveVariants(productID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.ProductVariant{}).
+		Where("id_produk = ? AND aktif = ?", productID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// syncProductStock keeps produk.stok equal to the stock of its active variants
+// so listings that only read the product stay correct
+func syncProductStock(tx *gorm.DB, productID uint) error {
+	var count int64
+	if err := tx.Model(&entities.ProductVariant{}).
+		Where("id_produk = ? AND aktif = ?", productID, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	return tx.Exec(
+		"UPDATE produk SET stok = (SELECT COALESCE(SUM(stok), 0) FROM produk_varian WHERE id_produk = ? AND aktif = ?) WHERE id = ?",
+		productID, true, productID,
+	).Error
+}
+
+// reopenProductLedger brings the product's own ledger back in line with its
+// stock once it has no active variants left. While it had variants the stock
+// was kept in the variants' ledgers instead.
+func reopenProductLedger(tx *gorm.DB, productID uint, actorID uint) error {
+	hasVariants, err := hasActiveVariants(tx, productID)
+	if err != nil || hasVariants {
+		return err
+	}
+	if err := resetWarehouseProductStock(tx, productID); err != nil {
+		return err
+	}
+
+	var product entities.Product
+	if err := tx.Select("id, stok").First(&product, productID).Error; err != nil {
+		return err
+	}
+	var ledger int
+	if err := tx.Model(&entities.StockMovement{}).
+		Where("id_produk = ? AND id_varian IS NULL", productID).
+		Select("COALESCE(SUM(jumlah), 0)").
+		Scan(&ledger).Error; err != nil {
+		return err
+	}
+	if ledger == product.Stok {
+		return nil
+	}
+	_, err = appendStockMovement(tx, entities.StockMovement{
+		IDProduk: productID,
+		Jenis:    entities.StockMovementAdjustment,
+		IDUser:   stockActor(actorID),
+		Catatan:  "variants removed",
+	}, ledger, product.Stok)
+	return err
+}
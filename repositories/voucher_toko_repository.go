@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoreVoucherRepository interface {
+	FindById(id uint) (entities.StoreVoucher, error)
+	FindByStore(storeID uint) ([]entities.StoreVoucher, error)
+	FindClaimable(storeID uint, now time.Time) ([]entities.StoreVoucher, error)
+	CodeTaken(storeID uint, kode string, exceptID uint) (bool, error)
+	Create(voucher entities.StoreVoucher) (entities.StoreVoucher, error)
+	Update(voucher entities.StoreVoucher) (entities.StoreVoucher, error)
+	Delete(id uint) error
+	Claim(voucherID uint, userID uint) (entities.VoucherClaim, error)
+	FindClaim(voucherID uint, userID uint) (entities.VoucherClaim, error)
+	FindClaims(userID uint) ([]entities.VoucherClaim, error)
+}
+
+type storeVoucherRepositoryImpl struct {
+	database *gorm.DB
+}
+
+func NewStoreVoucherRepository(database *gorm.DB) StoreVoucherRepository {
+	return &storeVoucherRepositoryImpl{database}
+}
+
+func (r *storeVoucherRepositoryImpl) FindById(id uint) (entities.StoreVoucher, error) {
+	var voucher entities.StoreVoucher
+	err := r.database.Preload("Store").First(&voucher, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return voucher, errors.New("voucher not found")
+	}
+	return voucher, err
+}
+
+func (r *storeVoucherRepositoryImpl) FindByStore(storeID uint) ([]entities.StoreVoucher, error) {
+	var vouchers []entities.StoreVoucher
+	err := r.database.Preload("Store").Where("id_toko = ?", storeID).Order("id desc").Find(&vouchers).Error
+	return vouchers, err
+}
+
+// FindClaimable finds the store's vouchers buyers can claim at now, ending soonest first
+func (r *storeVoucherRepositoryImpl) FindClaimable(storeID uint, now time.Time) ([]entities.StoreVoucher, error) {
+	var vouchers []entities.StoreVoucher
+	err := r.database.Preload("Store").
+		Where("id_toko = ? AND aktif = ? AND berakhir_pada > ?", storeID, true, now).
+		Where("kuota = 0 OR diklaim < kuota").
+		Order("berakhir_pada asc, id asc").
+		Find(&vouchers).Error
+	return vouchers, err
+}
+
+// CodeTaken reports whether another voucher of the store has the code, ignoring case
+func (r *storeVoucherRepositoryImpl) CodeTaken(storeID uint, kode string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.database.Model(&entities.StoreVoucher{}).
+		Where("id_toko = ? AND UPPER(kode) = ? AND id <> ?", storeID, strings.ToUpper(kode), exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *storeVoucherRepositoryImpl) Create(voucher entities.StoreVoucher) (entities.StoreVoucher, error) {
+	if err := r.database.Omit("Store").Create(&voucher).Error; err != nil {
+		return entities.StoreVoucher{}, err
+	}
+	return r.FindById(voucher.ID)
+}
+
+// Update saves the terms of a voucher; Diklaim is only changed by claims
+func (r *storeVoucherRepositoryImpl) Update(voucher entities.StoreVoucher) (entities.StoreVoucher, error) {
+	if err := r.database.Omit("Store", "diklaim", "created_at").Save(&voucher).Error; err != nil {
+		return entities.StoreVoucher{}, err
+	}
+	return r.FindById(voucher.ID)
+}
+
+// Delete removes a voucher nobody has claimed yet
+func (r *storeVoucherRepositoryImpl) Delete(id uint) error {
+	return r.database.Transaction(func(tx *gorm.DB) error {
+		var claims int64
+		if err := tx.Model(&entities.VoucherClaim{}).Where("id_voucher = ?", id).Count(&claims).Error; err != nil {
+			return err
+		}
+		if claims > 0 {
+			return errors.New("voucher has been claimed; deactivate it instead")
+		}
+		return tx.Delete(&entities.StoreVoucher{}, id).Error
+	})
+}
+
+// Claim puts a voucher into the buyer's wallet. The voucher row is locked while
+// its quota is checked, so it is never claimed more than Kuota times.
+func (r *storeVoucherRepositoryImpl) Claim(voucherID uint, userID uint) (entities.VoucherClaim, error) {
+	var claim entities.VoucherClaim
+	err := r.database.Transaction(func(tx *gorm.DB) error {
+		var voucher entities.StoreVoucher
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&voucher, voucherID).Error; err != nil {
+			return errors.New("voucher not found")
+		}
+		if !voucher.Aktif || !voucher.BerakhirPada.After(time.Now()) {
+			return fmt.Errorf("voucher %s can no longer be claimed", voucher.Kode)
+		}
+		if voucher.Kuota > 0 && voucher.Diklaim >= voucher.Kuota {
+			return fmt.Errorf("voucher %s has run out", voucher.Kode)
+		}
+
+		var claimed int64
+		if err := tx.Model(&entities.VoucherClaim{}).
+			Where("id_voucher = ? AND id_user = ?", voucherID, userID).
+			Count(&claimed).Error; err != nil {
+			return err
+		}
+		if claimed > 0 {
+			return fmt.Errorf("you have already claimed voucher %s", voucher.Kode)
+		}
+
+		if err := tx.Model(&voucher).UpdateColumn("diklaim", gorm.Expr("diklaim + 1")).Error; err != nil {
+			return err
+		}
+		claim = entities.VoucherClaim{IDVoucher: voucherID, IDUser: userID, Status: entities.VoucherAvailable}
+		return tx.Omit("Voucher").Create(&claim).Error
+	})
+	if err != nil {
+		return entities.VoucherClaim{}, err
+	}
+	return r.FindClaim(voucherID, userID)
+}
+
+func (r *storeVoucherRepositoryImpl) FindClaim(voucherID uint, userID uint) (entities.VoucherClaim, error) {
+	var claim entities.VoucherClaim
+	err := r.database.Preload("Voucher.Store").
+		Where("id_voucher = ? AND id_user = ?", voucherID, userID).
+		First(&claim).Error
+	return claim, err
+}
+
+// FindClaims lists the buyer's wallet, newest claim first
+func (r *storeVoucherRepositoryImpl) FindClaims(userID uint) ([]entities.VoucherClaim, error) {
+	var claims []entities.VoucherClaim
+	err := r.database.Preload("Voucher.Store").
+		Where("id_user = ?", userID).
+		Order("id desc").
+		Find(&claims).Error
+	return claims, err
+}
+
+// useVoucherClaims marks the wallet vouchers of a checkout used by it. Each
+// claim row is locked and checked again against the buyer's wallet; the
+// checkout fails when a voucher is not the buyer's, was used elsewhere or
+// stopped being valid meanwhile.
+func useVoucherClaims(tx *gorm.DB, uses []models.StoreVoucherUse, buyerID uint, trxID uint, storeOrderIDs map[uint]uint) error {
+	now := time.Now()
+	for _, use := range uses {
+		var claim entities.VoucherClaim
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, use.ClaimID).Error; err != nil || claim.IDUser != buyerID {
+			return fmt.Errorf("voucher %s is not in your wallet", use.Kode)
+		}
+		if claim.Status != entities.VoucherAvailable {
+			return fmt.Errorf("voucher %s has already been used", use.Kode)
+		}
+		var voucher entities.StoreVoucher
+		if err := tx.First(&voucher, claim.IDVoucher).Error; err != nil || !voucher.IsValidAt(now) {
+			return fmt.Errorf("voucher %s is not valid now", use.Kode)
+		}
+
+		updates := map[string]interface{}{
+			"status":       entities.VoucherUsed,
+			"id_trx":       trxID,
+			"diskon":       use.Diskon,
+			"dipakai_pada": now,
+		}
+		if id, ok := storeOrderIDs[use.IDToko]; ok {
+			updates["id_trx_toko"] = id
+		}
+		if err := tx.Model(&claim).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseVoucherClaims puts the vouchers used by cancelled store orders back
+// into their buyers' wallets. column is id_trx or id_trx_toko.
+func releaseVoucherClaims(tx *gorm.DB, column string, id uint) error {
+	return tx.Model(&entities.VoucherClaim{}).
+		Where(column+" = ? AND status = ?", id, entities.VoucherUsed).
+		Updates(map[string]interface{}{
+			"status":       entities.VoucherAvailable,
+			"id_trx":       nil,
+			"id_trx_toko":  nil,
+			"diskon":       0,
+			"dipakai_pada": nil,
+		}).Error
+}
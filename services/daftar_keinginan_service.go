@@ -21,17 +21,20 @@ type wishlistServiceImpl struct {
 	repository        repositories.WishlistRepository
 	storeRepository   repositories.StoreRepository
 	productRepository repositories.ProductRepository
+	variantRepository repositories.ProductVariantRepository
 }
 
 func NewWishlistService(
 	repository *repositories.WishlistRepository,
 	storeRepository *repositories.StoreRepository,
 	productRepository *repositories.ProductRepository,
+	variantRepository *repositories.ProductVariantRepository,
 ) WishlistService {
 	return &wishlistServiceImpl{
 		repository:        *repository,
 		storeRepository:   *storeRepository,
 		productRepository: *productRepository,
+		variantRepository: *variantRepository,
 	}
 }
 
@@ -72,10 +75,20 @@ func (service *wishlistServiceImpl) Create(input models.WishlistRequest, userID
 		return models.WishlistResponse{}, errors.New("product not found")
 	}
 
+	// A wishlist may point at the whole product or at one specific variant
+	if input.VariantID != nil && *input.VariantID != 0 {
+		if _, err := resolveVariant(service.variantRepository, input.ProductID, input.VariantID); err != nil {
+			return models.WishlistResponse{}, err
+		}
+	} else {
+		input.VariantID = nil
+	}
+
 	// Create wishlist
 	wishlist := entities.Wishlist{
 		IDToko:   input.StoreID,
 		IDProduk: input.ProductID,
+		IDVarian: input.VariantID,
 	}
 
 	result, err := service.repository.Insert(wishlist)
@@ -119,8 +132,16 @@ func (service *wishlistServiceImpl) Update(id uint, input models.WishlistRequest
 		return models.WishlistResponse{}, errors.New("store not found")
 	}
 
+	if input.VariantID != nil && *input.VariantID != 0 {
+		if _, err := resolveVariant(service.variantRepository, input.ProductID, input.VariantID); err != nil {
+			return models.WishlistResponse{}, err
+		}
+	} else {
+		input.VariantID = nil
+	}
+
 	// Update the wishlist directly
-	err = service.repository.Update(id, input.StoreID, input.ProductID)
+	err = service.repository.Update(id, input.StoreID, input.ProductID, input.VariantID)
 	if err != nil {
 		return models.WishlistResponse{}, err
 	}
@@ -155,6 +176,8 @@ func formatWishlistResponse(wishlist entities.Wishlist) models.WishlistResponse
 		ID:       wishlist.ID,
 		IDToko:   wishlist.IDToko,
 		IDProduk: wishlist.IDProduk,
+		IDVarian: wishlist.IDVarian,
+		Varian:   toVariantSummary(wishlist.Variant, wishlist.Product),
 		Store: models.StoreResponse{
 			ID:            wishlist.Store.ID,
 			IDUser:        wishlist.Store.IDUser,
@@ -194,6 +217,10 @@ func formatWishlistResponse(wishlist entities.Wishlist) models.WishlistResponse
 	response.Product.Slug = wishlist.Product.Slug
 	response.Product.HargaReseller = wishlist.Product.HargaReseller
 	response.Product.HargaKonsumen = wishlist.Product.HargaKonsumen
+	if diskon := wishlist.Product.ActiveDiscount(time.Now()); diskon != nil {
+		response.Product.HargaCoret = wishlist.Product.HargaKonsumen
+		response.Product.HargaKonsumen = diskon.Apply(wishlist.Product.HargaKonsumen)
+	}
 	response.Product.Stok = wishlist.Product.Stok
 	response.Product.Deskripsi = wishlist.Product.Deskripsi
 	response.Product.FotoProduk = fotoProdukResponses // Add this line
@@ -238,12 +265,7 @@ func formatProductResponse(product entities.Product) models.ProductResponse {
 			CreatedAt: product.Store.CreatedAt,
 			UpdatedAt: product.Store.UpdatedAt,
 		},
-		Category: models.CategoryResponse{
-			ID:           product.Category.ID,
-			NamaCategory: product.Category.NamaCategory,
-			CreatedAt:    product.Category.CreatedAt,
-			UpdatedAt:    product.Category.UpdatedAt,
-		},
+		Category:   mapCategoryToResponse(product.Category),
 		FotoProduk: fotoProdukResponses,
 		CreatedAt:  product.CreatedAt,
 		UpdatedAt:  product.UpdatedAt,
@@ -124,14 +124,7 @@ func mapToSimpleProductResponse(product entities.Product) models.SimpleProductRe
 	// Map promos
 	var promoResponses []models.ProductPromoResponse
 	for _, promo := range product.Promos {
-		promoResponses = append(promoResponses, models.ProductPromoResponse{
-			ID:        uint(promo.ID),
-			IDToko:    uint(promo.IDToko),
-			IDProduk:  uint(promo.IDProduk),
-			Promo:     promo.Promo,
-			CreatedAt: promo.CreatedAt,
-			UpdatedAt: promo.UpdatedAt,
-		})
+		promoResponses = append(promoResponses, toProductPromoResponse(promo))
 	}
 
 	return models.SimpleProductResponse{
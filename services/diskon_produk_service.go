@@ -1,42 +1,202 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DiskonProdukService interface {
-	ApplyDiscount(input models.DiskonProdukRequest) (models.DiskonProdukResponse, error)
+	ApplyDiscount(userId uint, isAdmin bool, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error)
 	GetById(id uint) (models.DiskonProdukResponse, error)
-	GetAll() ([]models.DiskonProdukResponse, error)
-	UpdateDiscount(id uint, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error)
-	DeleteDiscount(id uint) (models.DiskonProdukResponse, error)
+	GetAll(openEnded bool) ([]models.DiskonProdukResponse, error)
+	UpdateDiscount(userId uint, isAdmin bool, id uint, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error)
+	DeleteDiscount(userId uint, isAdmin bool, id uint) (models.DiskonProdukResponse, error)
+	RefreshStatuses() (int64, error)
+	RestoreLegacyPrices() (int64, error)
 }
 
 type diskonProdukServiceImpl struct {
-	repository repositories.DiskonProdukRepository
+	repository  repositories.DiskonProdukRepository
+	productRepo repositories.ProductRepository
 }
 
-func NewDiskonProdukService(repository repositories.DiskonProdukRepository) DiskonProdukService {
-	return &diskonProdukServiceImpl{repository}
+func NewDiskonProdukService(repository repositories.DiskonProdukRepository, productRepo repositories.ProductRepository) DiskonProdukService {
+	return &diskonProdukServiceImpl{repository: repository, productRepo: productRepo}
 }
 
-func (service *diskonProdukServiceImpl) ApplyDiscount(input models.DiskonProdukRequest) (models.DiskonProdukResponse, error) {
-	return service.repository.ApplyDiscount(input.ProductID, input.HargaKonsumen)
+// ApplyDiscount schedules a discount. The product row is not changed: the
+// discounted price is worked out whenever the product is read.
+func (service *diskonProdukServiceImpl) ApplyDiscount(userId uint, isAdmin bool, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error) {
+	if err := service.checkOwner(userId, isAdmin, input.ProductID); err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	diskon, err := buildDiscount(input, time.Now())
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+
+	created, err := service.repository.Create(diskon)
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	return toDiskonProdukResponse(created), nil
 }
 
 func (service *diskonProdukServiceImpl) GetById(id uint) (models.DiskonProdukResponse, error) {
-	return service.repository.GetById(id)
+	diskon, err := service.repository.GetById(id)
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	return toDiskonProdukResponse(diskon), nil
+}
+
+// GetAll lists the discounts; openEnded keeps only the legacy ones that never expire
+func (service *diskonProdukServiceImpl) GetAll(openEnded bool) ([]models.DiskonProdukResponse, error) {
+	diskons, err := service.repository.GetAll(openEnded)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []models.DiskonProdukResponse
+	for _, diskon := range diskons {
+		responses = append(responses, toDiskonProdukResponse(diskon))
+	}
+	return responses, nil
+}
+
+// UpdateDiscount reschedules a discount; an expired discount can be extended
+func (service *diskonProdukServiceImpl) UpdateDiscount(userId uint, isAdmin bool, id uint, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error) {
+	existing, err := service.repository.GetById(id)
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	if err := service.checkOwner(userId, isAdmin, existing.ProductID); err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	if input.ProductID != 0 && input.ProductID != existing.ProductID {
+		return models.DiskonProdukResponse{}, errors.New("a discount cannot be moved to another product")
+	}
+	input.ProductID = existing.ProductID
+	if input.MulaiPada == nil {
+		input.MulaiPada = existing.MulaiPada
+	}
+
+	diskon, err := buildDiscount(input, time.Now())
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	diskon.ID = existing.ID
+	diskon.CreatedAt = existing.CreatedAt
+
+	updated, err := service.repository.Update(diskon)
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	return toDiskonProdukResponse(updated), nil
 }
 
-func (service *diskonProdukServiceImpl) GetAll() ([]models.DiskonProdukResponse, error) {
-	return service.repository.GetAll()
+// DeleteDiscount removes a discount; the product is back at its list price at once
+func (service *diskonProdukServiceImpl) DeleteDiscount(userId uint, isAdmin bool, id uint) (models.DiskonProdukResponse, error) {
+	diskon, err := service.repository.GetById(id)
+	if err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	if err := service.checkOwner(userId, isAdmin, diskon.ProductID); err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+
+	if err := service.repository.DeleteDiscount(id); err != nil {
+		return models.DiskonProdukResponse{}, err
+	}
+	return toDiskonProdukResponse(diskon), nil
+}
+
+// RefreshStatuses persists the activation and expiry of discounts; prices
+// follow the schedule without it
+func (service *diskonProdukServiceImpl) RefreshStatuses() (int64, error) {
+	return service.repository.RefreshStatuses(time.Now())
+}
+
+// RestoreLegacyPrices gives products discounted the old way their list price back
+func (service *diskonProdukServiceImpl) RestoreLegacyPrices() (int64, error) {
+	return service.repository.RestoreLegacyPrices()
+}
+
+func (service *diskonProdukServiceImpl) checkOwner(userId uint, isAdmin bool, productID uint) error {
+	product, err := service.productRepo.FindById(productID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+func buildDiscount(input models.DiskonProdukRequest, now time.Time) (entities.DiskonProduk, error) {
+	label := strings.TrimSuffix(strings.TrimSpace(input.HargaKonsumen), "%")
+	persen, err := strconv.ParseFloat(label, 64)
+	if err != nil || persen <= 0 || persen >= 100 {
+		return entities.DiskonProduk{}, errors.New("harga_konsumen must be a percentage between 0 and 100, e.g. \"10\"")
+	}
+	if input.BerakhirPada == nil {
+		return entities.DiskonProduk{}, errors.New("berakhir_pada is required")
+	}
+
+	mulai := now
+	if input.MulaiPada != nil {
+		mulai = *input.MulaiPada
+	}
+	if !input.BerakhirPada.After(mulai) {
+		return entities.DiskonProduk{}, errors.New("berakhir_pada must be after mulai_pada")
+	}
+	if !input.BerakhirPada.After(now) {
+		return entities.DiskonProduk{}, errors.New("berakhir_pada must be in the future")
+	}
+
+	diskon := entities.DiskonProduk{
+		ProductID:     input.ProductID,
+		HargaKonsumen: fmt.Sprintf("%s%%", label),
+		Persen:        persen,
+		MulaiPada:     &mulai,
+		BerakhirPada:  input.BerakhirPada,
+	}
+	diskon.Status = diskon.StatusAt(now)
+	return diskon, nil
 }
 
-func (service *diskonProdukServiceImpl) UpdateDiscount(id uint, input models.DiskonProdukRequest) (models.DiskonProdukResponse, error) {
-	return service.repository.UpdateDiscount(id, input.ProductID, input.HargaKonsumen)
+func toDiskonProdukResponse(diskon entities.DiskonProduk) models.DiskonProdukResponse {
+	return models.DiskonProdukResponse{
+		ID:            diskon.ID,
+		ProductID:     diskon.ProductID,
+		HargaKonsumen: diskon.HargaKonsumen,
+		Persen:        diskon.Persen,
+		MulaiPada:     diskon.MulaiPada,
+		BerakhirPada:  diskon.BerakhirPada,
+		Status:        diskon.Status,
+		CreatedAt:     diskon.CreatedAt,
+		UpdatedAt:     diskon.UpdatedAt,
+	}
 }
 
-func (service *diskonProdukServiceImpl) DeleteDiscount(id uint) (models.DiskonProdukResponse, error) {
-	return service.repository.DeleteDiscount(id)
+// pricingBase rebuilds the list prices and the active discount of a product
+// response, so its variants are priced the same way as the product
+func pricingBase(product models.ProductResponse) entities.Product {
+	base := entities.Product{HargaKonsumen: product.HargaKonsumen, HargaReseller: product.HargaReseller}
+	if product.Diskon != nil {
+		base.HargaKonsumen = product.HargaCoret
+		base.Diskon = []entities.DiskonProduk{{
+			ID:           product.Diskon.ID,
+			Persen:       product.Diskon.Persen,
+			MulaiPada:    product.Diskon.MulaiPada,
+			BerakhirPada: product.Diskon.BerakhirPada,
+		}}
+	}
+	return base
 }
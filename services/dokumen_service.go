@@ -0,0 +1,294 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/pdf"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page layout in points
+const (
+	documentMarginLeft  = 40.0
+	documentMarginRight = pdf.PageWidth - 40
+	documentBottom      = pdf.PageHeight - 60
+)
+
+type DocumentService interface {
+	TransactionInvoice(userID uint, trxID uint) ([]byte, string, error)
+	PackingSlip(userID uint, storeOrderID uint) ([]byte, string, error)
+}
+
+type documentServiceImpl struct {
+	transactionRepository repositories.TransactionRepository
+	storeOrderRepository  repositories.StoreOrderRepository
+	userRepository        repositories.UserRepository
+	regionService         RegionService
+}
+
+func NewDocumentService(
+	transactionRepository repositories.TransactionRepository,
+	storeOrderRepository repositories.StoreOrderRepository,
+	userRepository repositories.UserRepository,
+	regionService RegionService,
+) DocumentService {
+	return &documentServiceImpl{
+		transactionRepository: transactionRepository,
+		storeOrderRepository:  storeOrderRepository,
+		userRepository:        userRepository,
+		regionService:         regionService,
+	}
+}
+
+// TransactionInvoice renders the buyer's invoice of a transaction
+func (s *documentServiceImpl) TransactionInvoice(userID uint, trxID uint) ([]byte, string, error) {
+	transaction, err := s.transactionRepository.FindById(trxID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepository.FindById(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !user.IsAdmin && transaction.IDUser != userID {
+		return nil, "", errors.New("forbidden")
+	}
+
+	buyer, err := s.userRepository.FindById(transaction.IDUser)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get buyer: %v", err)
+	}
+
+	document := pdf.New()
+	y := documentHeader(document, "INVOICE", transaction.KodeInvoice, transaction.CreatedAt)
+
+	document.Text(documentMarginLeft, y, 10, true, "Pembeli")
+	document.Text(300, y, 10, true, "Dikirim ke")
+	left := []string{buyer.Nama, buyer.Email, buyer.Notelp, "Pembayaran: " + transaction.MethodBayar}
+	right := s.addressLines(transaction.Address)
+	y = documentColumns(document, y+14, left, right)
+
+	// Line items
+	y += 10
+	columns := []float64{330, 410, 480, documentMarginRight}
+	drawRow := func(y float64, bold bool, cells ...string) {
+		document.Text(documentMarginLeft, y, 9, bold, pdf.Truncate(cells[0], columns[0]-60-documentMarginLeft, 9, bold))
+		for i, cell := range cells[1:] {
+			document.TextRight(columns[i], y, 9, bold, cell)
+		}
+	}
+	header := []string{"Produk", "Qty", "Harga", "Diskon", "Subtotal"}
+	drawRow(y, true, header...)
+	document.Line(documentMarginLeft, y+5, documentMarginRight, y+5)
+	y += 18
+
+	var subtotal, discount float64
+	for _, detail := range transaction.TrxDetail {
+		if y > documentBottom {
+			document.AddPage()
+			y = 60
+			drawRow(y, true, header...)
+			document.Line(documentMarginLeft, y+5, documentMarginRight, y+5)
+			y += 18
+		}
+
+		listPrice := detail.ProductLog.HargaKonsumen
+		if detail.Reseller {
+			listPrice = detail.ProductLog.HargaReseller
+		}
+		unitPrice, _ := strconv.ParseFloat(listPrice, 64)
+		gross := unitPrice * float64(detail.Kuantitas)
+		priceDiscount := math.Max(gross-detail.HargaTotal, 0)
+		lineDiscount := priceDiscount + detail.Diskon
+		subtotal += detail.HargaTotal + priceDiscount
+		discount += lineDiscount
+
+		drawRow(y, false,
+			detail.ProductLog.NamaProduk,
+			strconv.Itoa(detail.Kuantitas),
+			formatRupiah(unitPrice),
+			formatRupiah(lineDiscount),
+			formatRupiah(detail.HargaTotal-detail.Diskon),
+		)
+		y += 16
+	}
+	document.Line(documentMarginLeft, y-8, documentMarginRight, y-8)
+
+	if y > documentBottom-70 {
+		document.AddPage()
+		y = 60
+	}
+	shippingLabel := "Ongkos Kirim"
+	if transaction.Kurir != "" {
+		shippingLabel += " (" + strings.TrimSpace(strings.ToUpper(transaction.Kurir)+" "+transaction.LayananKirim) + ")"
+	}
+	discountLabel := "Diskon"
+	var discountCodes []string
+	for _, claim := range transaction.Vouchers {
+		discountCodes = append(discountCodes, "voucher "+claim.Voucher.Kode)
+	}
+	if transaction.KodeKupon != "" {
+		discountCodes = append(discountCodes, "kupon "+transaction.KodeKupon)
+	}
+	if len(discountCodes) > 0 {
+		discountLabel += " (" + strings.Join(discountCodes, ", ") + ")"
+	}
+	totals := [][2]string{
+		{"Subtotal", formatRupiah(subtotal)},
+		{discountLabel, formatRupiah(-discount)},
+		{shippingLabel, formatRupiah(transaction.OngkosKirim)},
+	}
+	if transaction.DiskonOngkir > 0 {
+		totals = append(totals, [2]string{"Gratis Ongkir", formatRupiah(-transaction.DiskonOngkir)})
+	}
+	for _, total := range totals {
+		document.Text(330, y, 9, false, total[0])
+		document.TextRight(documentMarginRight, y, 9, false, total[1])
+		y += 14
+	}
+	document.Text(330, y+2, 11, true, "Total")
+	document.TextRight(documentMarginRight, y+2, 11, true, formatRupiah(transaction.HargaTotal))
+
+	return document.Bytes(), documentFilename(transaction.KodeInvoice), nil
+}
+
+// PackingSlip renders the slip a seller puts in the parcel of one store order
+func (s *documentServiceImpl) PackingSlip(userID uint, storeOrderID uint) ([]byte, string, error) {
+	storeOrder, err := s.storeOrderRepository.FindById(storeOrderID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if storeOrder.Store.IDUser != userID {
+		user, err := s.userRepository.FindById(userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if !user.IsAdmin {
+			return nil, "", errors.New("forbidden")
+		}
+	}
+
+	transaction, err := s.transactionRepository.FindById(storeOrder.IDTrx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	document := pdf.New()
+	y := documentHeader(document, "PACKING SLIP", storeOrder.KodeInvoice, storeOrder.CreatedAt)
+
+	document.Text(documentMarginLeft, y, 10, true, "Pengirim")
+	document.Text(300, y, 10, true, "Penerima")
+	left := []string{
+		storeOrder.Store.NamaToko,
+		"Kurir: " + strings.TrimSpace(strings.ToUpper(storeOrder.Kurir)+" "+storeOrder.LayananKirim),
+		"Invoice transaksi: " + transaction.KodeInvoice,
+	}
+	right := s.addressLines(transaction.Address)
+	y = documentColumns(document, y+14, left, right)
+
+	y += 10
+	header := func(y float64) {
+		document.Text(documentMarginLeft, y, 9, true, "No")
+		document.Text(70, y, 9, true, "Produk")
+		document.TextRight(documentMarginRight, y, 9, true, "Qty")
+		document.Line(documentMarginLeft, y+5, documentMarginRight, y+5)
+	}
+	header(y)
+	y += 18
+
+	totalQuantity := 0
+	for i, detail := range storeOrder.TrxDetail {
+		if y > documentBottom {
+			document.AddPage()
+			y = 60
+			header(y)
+			y += 18
+		}
+		document.Text(documentMarginLeft, y, 9, false, strconv.Itoa(i+1))
+		document.Text(70, y, 9, false, pdf.Truncate(detail.ProductLog.NamaProduk, documentMarginRight-130, 9, false))
+		document.TextRight(documentMarginRight, y, 9, false, strconv.Itoa(detail.Kuantitas))
+		totalQuantity += detail.Kuantitas
+		y += 16
+	}
+	document.Line(documentMarginLeft, y-8, documentMarginRight, y-8)
+	document.Text(70, y+2, 10, true, "Total barang")
+	document.TextRight(documentMarginRight, y+2, 10, true, strconv.Itoa(totalQuantity))
+
+	return document.Bytes(), documentFilename(storeOrder.KodeInvoice), nil
+}
+
+func (s *documentServiceImpl) addressLines(address entities.Address) []string {
+	lines := []string{address.NamaPenerima, address.NoTelp, address.DetailAlamat}
+
+	var region []string
+	if city, err := s.regionService.GetCity(address.IDKota); err == nil && city != nil {
+		region = append(region, city.Name)
+	}
+	if province, err := s.regionService.GetProvince(address.IDProvinsi); err == nil && province != nil {
+		region = append(region, province.Name)
+	}
+	if len(region) > 0 {
+		lines = append(lines, strings.Join(region, ", "))
+	}
+	return lines
+}
+
+// documentHeader draws the title block and returns the y where the body starts
+func documentHeader(document *pdf.Document, title string, code string, createdAt *time.Time) float64 {
+	document.Text(documentMarginLeft, 60, 20, true, title)
+	document.TextRight(documentMarginRight, 52, 10, true, code)
+	if createdAt != nil {
+		document.TextRight(documentMarginRight, 66, 9, false, "Tanggal: "+createdAt.Format("02-01-2006 15:04"))
+	}
+	document.Line(documentMarginLeft, 78, documentMarginRight, 78)
+	return 100
+}
+
+// documentColumns draws two blocks of lines side by side and returns the y below the longer one
+func documentColumns(document *pdf.Document, y float64, left []string, right []string) float64 {
+	rows := len(left)
+	if len(right) > rows {
+		rows = len(right)
+	}
+	for i := 0; i < rows; i++ {
+		if i < len(left) {
+			document.Text(documentMarginLeft, y, 9, false, pdf.Truncate(left[i], 240, 9, false))
+		}
+		if i < len(right) {
+			document.Text(300, y, 9, false, pdf.Truncate(right[i], documentMarginRight-300, 9, false))
+		}
+		y += 13
+	}
+	return y
+}
+
+func documentFilename(code string) string {
+	return strings.ReplaceAll(code, "/", "-") + ".pdf"
+}
+
+// formatRupiah formats an amount as "Rp 1.250.000"
+func formatRupiah(amount float64) string {
+	digits := strconv.FormatInt(int64(math.Round(amount)), 10)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte('.')
+		}
+		out.WriteRune(digit)
+	}
+
+	if negative {
+		return "-Rp " + out.String()
+	}
+	return "Rp " + out.String()
+}
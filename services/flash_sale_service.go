@@ -0,0 +1,337 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FlashSaleService interface {
+	GetCurrent() ([]models.FlashSaleResponse, error)
+	GetAll() ([]models.FlashSaleResponse, error)
+	GetById(id uint) (models.FlashSaleResponse, error)
+	Create(request models.FlashSaleRequest) (models.FlashSaleResponse, error)
+	Update(id uint, request models.FlashSaleRequest) (models.FlashSaleResponse, error)
+	Delete(id uint) error
+	AddItem(saleID uint, request models.FlashSaleItemRequest) (models.FlashSaleResponse, error)
+	UpdateItem(saleID uint, itemID uint, request models.FlashSaleItemRequest) (models.FlashSaleResponse, error)
+	DeleteItem(saleID uint, itemID uint) (models.FlashSaleResponse, error)
+	Apply(userId uint, lines []models.ProductLogProcess) error
+}
+
+type flashSaleServiceImpl struct {
+	repository        repositories.FlashSaleRepository
+	productRepository repositories.ProductRepository
+	variantRepository repositories.ProductVariantRepository
+}
+
+func NewFlashSaleService(
+	repository repositories.FlashSaleRepository,
+	productRepository repositories.ProductRepository,
+	variantRepository repositories.ProductVariantRepository,
+) FlashSaleService {
+	return &flashSaleServiceImpl{repository, productRepository, variantRepository}
+}
+
+// GetCurrent lists the live and upcoming sales, soonest first
+func (s *flashSaleServiceImpl) GetCurrent() ([]models.FlashSaleResponse, error) {
+	now := time.Now()
+	sales, err := s.repository.FindCurrent(now)
+	if err != nil {
+		return nil, err
+	}
+	responses := []models.FlashSaleResponse{}
+	for _, sale := range sales {
+		responses = append(responses, toFlashSaleResponse(sale, now))
+	}
+	return responses, nil
+}
+
+func (s *flashSaleServiceImpl) GetAll() ([]models.FlashSaleResponse, error) {
+	now := time.Now()
+	sales, err := s.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	responses := []models.FlashSaleResponse{}
+	for _, sale := range sales {
+		responses = append(responses, toFlashSaleResponse(sale, now))
+	}
+	return responses, nil
+}
+
+func (s *flashSaleServiceImpl) GetById(id uint) (models.FlashSaleResponse, error) {
+	sale, err := s.repository.FindById(id)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return toFlashSaleResponse(sale, time.Now()), nil
+}
+
+func (s *flashSaleServiceImpl) Create(request models.FlashSaleRequest) (models.FlashSaleResponse, error) {
+	sale, err := buildFlashSale(entities.FlashSale{Aktif: true}, request)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+
+	seen := map[string]bool{}
+	for _, itemRequest := range request.Items {
+		item, err := s.buildItem(entities.FlashSaleItem{}, itemRequest)
+		if err != nil {
+			return models.FlashSaleResponse{}, err
+		}
+		key := fmt.Sprintf("%d/0", item.IDProduk)
+		if item.IDVarian != nil {
+			key = fmt.Sprintf("%d/%d", item.IDProduk, *item.IDVarian)
+		}
+		if seen[key] {
+			return models.FlashSaleResponse{}, fmt.Errorf("product %d is listed twice", item.IDProduk)
+		}
+		seen[key] = true
+		sale.Items = append(sale.Items, item)
+	}
+
+	created, err := s.repository.Create(sale)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return toFlashSaleResponse(created, time.Now()), nil
+}
+
+func (s *flashSaleServiceImpl) Update(id uint, request models.FlashSaleRequest) (models.FlashSaleResponse, error) {
+	existing, err := s.repository.FindById(id)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	sale, err := buildFlashSale(existing, request)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+
+	updated, err := s.repository.Update(sale)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return toFlashSaleResponse(updated, time.Now()), nil
+}
+
+func (s *flashSaleServiceImpl) Delete(id uint) error {
+	if _, err := s.repository.FindById(id); err != nil {
+		return err
+	}
+	return s.repository.Delete(id)
+}
+
+func (s *flashSaleServiceImpl) AddItem(saleID uint, request models.FlashSaleItemRequest) (models.FlashSaleResponse, error) {
+	if _, err := s.repository.FindById(saleID); err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	item, err := s.buildItem(entities.FlashSaleItem{IDFlashSale: saleID}, request)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	taken, err := s.repository.ItemTaken(saleID, item.IDProduk, item.IDVarian, 0)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	if taken {
+		return models.FlashSaleResponse{}, fmt.Errorf("product %d is already in this flash sale", item.IDProduk)
+	}
+
+	if _, err := s.repository.CreateItem(item); err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return s.GetById(saleID)
+}
+
+// UpdateItem changes the price, quota and limit of an item; its product stays
+func (s *flashSaleServiceImpl) UpdateItem(saleID uint, itemID uint, request models.FlashSaleItemRequest) (models.FlashSaleResponse, error) {
+	existing, err := s.repository.FindItem(saleID, itemID)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	request.IDProduk, request.IDVarian = existing.IDProduk, existing.IDVarian
+	item, err := s.buildItem(existing, request)
+	if err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+
+	if _, err := s.repository.UpdateItem(item); err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return s.GetById(saleID)
+}
+
+func (s *flashSaleServiceImpl) DeleteItem(saleID uint, itemID uint) (models.FlashSaleResponse, error) {
+	if _, err := s.repository.FindItem(saleID, itemID); err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	if err := s.repository.DeleteItem(itemID); err != nil {
+		return models.FlashSaleResponse{}, err
+	}
+	return s.GetById(saleID)
+}
+
+// Apply prices the checkout lines of products on a live flash sale at the sale
+// price. An item of the line's variant wins over one of the whole product, and
+// the cheaper one over the other. A line only takes a sale price below what it
+// already costs, e.g. at the reseller, variant or discounted price. The quota and the buyer's limit are checked
+// here and again, under a lock, when the transaction is saved.
+func (s *flashSaleServiceImpl) Apply(userId uint, lines []models.ProductLogProcess) error {
+	now := time.Now()
+	for i, line := range lines {
+		items, err := s.repository.FindLiveItems(line.ProductID, now)
+		if err != nil {
+			return err
+		}
+
+		var best *entities.FlashSaleItem
+		for n := range items {
+			item := &items[n]
+			if item.IDVarian != nil && (line.VariantID == nil || *item.IDVarian != *line.VariantID) {
+				continue
+			}
+			if best == nil ||
+				(item.IDVarian != nil && best.IDVarian == nil) ||
+				((item.IDVarian == nil) == (best.IDVarian == nil) && item.HargaFlash < best.HargaFlash) {
+				best = item
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if best.HargaFlash*float64(line.Kuantitas) >= line.HargaTotal {
+			continue
+		}
+
+		if line.Kuantitas > best.Sisa() {
+			return fmt.Errorf("flash sale for %s has only %d left", line.NamaProduk, best.Sisa())
+		}
+		if best.BatasPerUser > 0 {
+			reserved, err := s.repository.CountReserved(best.ID, userId)
+			if err != nil {
+				return err
+			}
+			if reserved+line.Kuantitas > best.BatasPerUser {
+				return fmt.Errorf("flash sale for %s is limited to %d per buyer", line.NamaProduk, best.BatasPerUser)
+			}
+		}
+
+		itemID := best.ID
+		lines[i].FlashSaleItem = &itemID
+		lines[i].Reseller = false
+		lines[i].HargaKonsumen = strconv.FormatFloat(best.HargaFlash, 'f', 0, 64)
+		lines[i].HargaTotal = best.HargaFlash * float64(line.Kuantitas)
+	}
+	return nil
+}
+
+// buildFlashSale applies the campaign fields of a request to sale
+func buildFlashSale(sale entities.FlashSale, request models.FlashSaleRequest) (entities.FlashSale, error) {
+	sale.Nama = strings.TrimSpace(request.Nama)
+	if sale.Nama == "" {
+		return entities.FlashSale{}, errors.New("nama is required")
+	}
+	if request.MulaiPada.IsZero() || request.BerakhirPada.IsZero() {
+		return entities.FlashSale{}, errors.New("mulai_pada and berakhir_pada are required")
+	}
+	if !request.BerakhirPada.After(request.MulaiPada) {
+		return entities.FlashSale{}, errors.New("berakhir_pada must be after mulai_pada")
+	}
+	sale.Deskripsi = request.Deskripsi
+	sale.MulaiPada, sale.BerakhirPada = request.MulaiPada, request.BerakhirPada
+	if request.Aktif != nil {
+		sale.Aktif = *request.Aktif
+	}
+	return sale, nil
+}
+
+// buildItem applies an item request to item. The sale price must be below the
+// product's or variant's regular price.
+func (s *flashSaleServiceImpl) buildItem(item entities.FlashSaleItem, request models.FlashSaleItemRequest) (entities.FlashSaleItem, error) {
+	product, err := s.productRepository.FindById(request.IDProduk)
+	if err != nil {
+		return entities.FlashSaleItem{}, err
+	}
+
+	regular := product.HargaKonsumen
+	item.IDProduk, item.IDVarian = product.ID, nil
+	if request.IDVarian != nil && *request.IDVarian != 0 {
+		variant, err := s.variantRepository.FindById(*request.IDVarian)
+		if err != nil {
+			return entities.FlashSaleItem{}, err
+		}
+		if variant.IDProduk != product.ID {
+			return entities.FlashSaleItem{}, fmt.Errorf("variant %d does not belong to product %d", variant.ID, product.ID)
+		}
+		regular, _ = variant.PriceFor(pricingBase(product))
+		variantID := variant.ID
+		item.IDVarian = &variantID
+	}
+
+	if request.HargaFlash <= 0 {
+		return entities.FlashSaleItem{}, errors.New("harga_flash must be greater than 0")
+	}
+	if price, err := strconv.ParseFloat(regular, 64); err == nil && request.HargaFlash >= price {
+		return entities.FlashSaleItem{}, fmt.Errorf("harga_flash must be below the regular price of %s", formatRupiah(price))
+	}
+	if request.Kuota < 1 {
+		return entities.FlashSaleItem{}, errors.New("kuota must be at least 1")
+	}
+	if request.BatasPerUser < 0 {
+		return entities.FlashSaleItem{}, errors.New("batas_per_user cannot be negative")
+	}
+	item.HargaFlash, item.Kuota, item.BatasPerUser = request.HargaFlash, request.Kuota, request.BatasPerUser
+	return item, nil
+}
+
+func toFlashSaleResponse(sale entities.FlashSale, now time.Time) models.FlashSaleResponse {
+	response := models.FlashSaleResponse{
+		ID:           sale.ID,
+		Nama:         sale.Nama,
+		Deskripsi:    sale.Deskripsi,
+		MulaiPada:    sale.MulaiPada,
+		BerakhirPada: sale.BerakhirPada,
+		Aktif:        sale.Aktif,
+		Status:       sale.StatusAt(now),
+		WaktuServer:  now,
+		Items:        []models.FlashSaleItemResponse{},
+		CreatedAt:    sale.CreatedAt,
+		UpdatedAt:    sale.UpdatedAt,
+	}
+	switch response.Status {
+	case entities.FlashSaleUpcoming:
+		response.MulaiDalam = int64(sale.MulaiPada.Sub(now).Seconds())
+		response.BerakhirDalam = int64(sale.BerakhirPada.Sub(now).Seconds())
+	case entities.FlashSaleLive:
+		response.BerakhirDalam = int64(sale.BerakhirPada.Sub(now).Seconds())
+	}
+
+	for _, item := range sale.Items {
+		itemResponse := models.FlashSaleItemResponse{
+			ID:           item.ID,
+			IDProduk:     item.IDProduk,
+			IDVarian:     item.IDVarian,
+			NamaProduk:   item.Product.NamaProduk,
+			Slug:         item.Product.Slug,
+			IDToko:       item.Product.IDToko,
+			HargaNormal:  item.Product.HargaKonsumen,
+			HargaFlash:   item.HargaFlash,
+			Kuota:        item.Kuota,
+			Terjual:      item.Terjual,
+			Sisa:         item.Sisa(),
+			BatasPerUser: item.BatasPerUser,
+		}
+		if item.Variant != nil && item.Variant.ID != 0 {
+			itemResponse.NamaVarian = item.Variant.Kombinasi
+			itemResponse.HargaNormal, _ = item.Variant.PriceFor(item.Product)
+		}
+		response.Items = append(response.Items, itemResponse)
+	}
+	return response
+}
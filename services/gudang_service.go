@@ -0,0 +1,432 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strings"
+)
+
+type WarehouseService interface {
+	GetAll(userId uint, isAdmin bool, storeID uint) ([]models.WarehouseResponse, error)
+	GetById(userId uint, isAdmin bool, id uint) (models.WarehouseResponse, error)
+	Create(userId uint, input models.WarehouseRequest) (models.WarehouseResponse, error)
+	Update(userId uint, isAdmin bool, id uint, input models.WarehouseRequest) (models.WarehouseResponse, error)
+	GetStock(userId uint, isAdmin bool, id uint) ([]models.WarehouseStockResponse, error)
+	Transfer(userId uint, isAdmin bool, input models.WarehouseTransferRequest) (models.WarehouseTransferResponse, error)
+	GetTransfers(userId uint, limit int, page int) (models.Pagination, error)
+}
+
+type warehouseServiceImpl struct {
+	repository  repositories.WarehouseRepository
+	storeRepo   repositories.StoreRepository
+	productRepo repositories.ProductRepository
+	variantRepo repositories.ProductVariantRepository
+}
+
+func NewWarehouseService(
+	repository repositories.WarehouseRepository,
+	storeRepo repositories.StoreRepository,
+	productRepo repositories.ProductRepository,
+	variantRepo repositories.ProductVariantRepository,
+) WarehouseService {
+	return &warehouseServiceImpl{
+		repository:  repository,
+		storeRepo:   storeRepo,
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+	}
+}
+
+// GetAll lists the seller's warehouses; admins pick the store with storeID
+func (s *warehouseServiceImpl) GetAll(userId uint, isAdmin bool, storeID uint) ([]models.WarehouseResponse, error) {
+	if !isAdmin || storeID == 0 {
+		store, err := s.storeRepo.FindByUserId(userId)
+		if err != nil {
+			return nil, errors.New("you do not have a store")
+		}
+		storeID = store.ID
+	}
+
+	warehouses, err := s.repository.FindByStore(storeID)
+	if err != nil {
+		return nil, err
+	}
+	responses := []models.WarehouseResponse{}
+	for _, warehouse := range warehouses {
+		response, err := s.toResponse(warehouse)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func (s *warehouseServiceImpl) GetById(userId uint, isAdmin bool, id uint) (models.WarehouseResponse, error) {
+	warehouse, err := s.findOwned(userId, isAdmin, id)
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+	return s.toResponse(warehouse)
+}
+
+// Create adds a warehouse to the seller's store. The first one becomes the
+// primary warehouse and takes over the stock the store already had.
+func (s *warehouseServiceImpl) Create(userId uint, input models.WarehouseRequest) (models.WarehouseResponse, error) {
+	store, err := s.storeRepo.FindByUserId(userId)
+	if err != nil {
+		return models.WarehouseResponse{}, errors.New("you do not have a store")
+	}
+	if err := validateWarehouse(input); err != nil {
+		return models.WarehouseResponse{}, err
+	}
+
+	aktif := true
+	if input.Aktif != nil {
+		aktif = *input.Aktif
+	}
+	if input.Utama && !aktif {
+		return models.WarehouseResponse{}, errors.New("the primary warehouse must be active")
+	}
+
+	warehouse, err := s.repository.Create(entities.Warehouse{
+		IDToko:     store.ID,
+		Nama:       strings.TrimSpace(input.Nama),
+		Alamat:     strings.TrimSpace(input.Alamat),
+		IDProvinsi: input.IDProvinsi,
+		IDKota:     input.IDKota,
+		Utama:      input.Utama,
+		Aktif:      aktif,
+	})
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+	return s.toResponse(warehouse)
+}
+
+// Update edits a warehouse. A store always keeps one active primary warehouse,
+// and a warehouse can only be deactivated once its stock was moved out.
+func (s *warehouseServiceImpl) Update(userId uint, isAdmin bool, id uint, input models.WarehouseRequest) (models.WarehouseResponse, error) {
+	warehouse, err := s.findOwned(userId, isAdmin, id)
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+	if err := validateWarehouse(input); err != nil {
+		return models.WarehouseResponse{}, err
+	}
+
+	aktif := warehouse.Aktif
+	if input.Aktif != nil {
+		aktif = *input.Aktif
+	}
+	if warehouse.Utama && !input.Utama {
+		return models.WarehouseResponse{}, errors.New("make another warehouse primary instead")
+	}
+	if input.Utama && !aktif {
+		return models.WarehouseResponse{}, errors.New("the primary warehouse must be active")
+	}
+	if warehouse.Aktif && !aktif {
+		total, err := s.repository.TotalStock(id)
+		if err != nil {
+			return models.WarehouseResponse{}, err
+		}
+		if total > 0 {
+			return models.WarehouseResponse{}, fmt.Errorf("warehouse still holds %d items, transfer them before deactivating it", total)
+		}
+	}
+
+	warehouse.Nama = strings.TrimSpace(input.Nama)
+	warehouse.Alamat = strings.TrimSpace(input.Alamat)
+	warehouse.IDProvinsi = input.IDProvinsi
+	warehouse.IDKota = input.IDKota
+	warehouse.Utama = input.Utama
+	warehouse.Aktif = aktif
+	updated, err := s.repository.Update(warehouse)
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+	return s.toResponse(updated)
+}
+
+// GetStock lists the stock held in a warehouse per product and variant
+func (s *warehouseServiceImpl) GetStock(userId uint, isAdmin bool, id uint) ([]models.WarehouseStockResponse, error) {
+	if _, err := s.findOwned(userId, isAdmin, id); err != nil {
+		return nil, err
+	}
+	stocks, err := s.repository.FindStocks([]uint{id}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.WarehouseStockResponse{}
+	for _, stock := range stocks {
+		var sku string
+		if stock.Product.SKU != nil {
+			sku = *stock.Product.SKU
+		}
+		responses = append(responses, models.WarehouseStockResponse{
+			IDProduk:   stock.IDProduk,
+			NamaProduk: stock.Product.NamaProduk,
+			SKU:        sku,
+			IDVarian:   stock.IDVarian,
+			Stok:       stock.Stok,
+			UpdatedAt:  stock.UpdatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// Transfer moves stock between two warehouses of the same store
+func (s *warehouseServiceImpl) Transfer(userId uint, isAdmin bool, input models.WarehouseTransferRequest) (models.WarehouseTransferResponse, error) {
+	if input.Jumlah <= 0 {
+		return models.WarehouseTransferResponse{}, errors.New("jumlah must be greater than zero")
+	}
+	if input.IDGudangAsal == input.IDGudangTujuan {
+		return models.WarehouseTransferResponse{}, errors.New("id_gudang_asal and id_gudang_tujuan must differ")
+	}
+	if len(input.Catatan) > 255 {
+		return models.WarehouseTransferResponse{}, errors.New("catatan is too long")
+	}
+
+	from, err := s.findOwned(userId, isAdmin, input.IDGudangAsal)
+	if err != nil {
+		return models.WarehouseTransferResponse{}, err
+	}
+	to, err := s.findOwned(userId, isAdmin, input.IDGudangTujuan)
+	if err != nil {
+		return models.WarehouseTransferResponse{}, err
+	}
+	if from.IDToko != to.IDToko {
+		return models.WarehouseTransferResponse{}, errors.New("both warehouses must belong to the same store")
+	}
+	if !to.Aktif {
+		return models.WarehouseTransferResponse{}, fmt.Errorf("warehouse %s is not active", to.Nama)
+	}
+
+	product, err := s.productRepo.FindById(input.IDProduk)
+	if err != nil {
+		return models.WarehouseTransferResponse{}, err
+	}
+	if product.Store.ID != from.IDToko {
+		return models.WarehouseTransferResponse{}, errors.New("the product does not belong to the warehouses' store")
+	}
+	variant, err := resolveVariant(s.variantRepo, product.ID, input.IDVarian)
+	if err != nil {
+		return models.WarehouseTransferResponse{}, err
+	}
+
+	transfer := entities.WarehouseTransfer{
+		IDToko:         from.IDToko,
+		IDGudangAsal:   from.ID,
+		IDGudangTujuan: to.ID,
+		IDProduk:       product.ID,
+		Jumlah:         input.Jumlah,
+		IDUser:         userId,
+		Catatan:        strings.TrimSpace(input.Catatan),
+	}
+	if variant != nil {
+		transfer.IDVarian = &variant.ID
+	}
+
+	transfer, err = s.repository.Transfer(transfer)
+	if err != nil {
+		return models.WarehouseTransferResponse{}, err
+	}
+	return toWarehouseTransferResponse(transfer), nil
+}
+
+// GetTransfers lists the transfers of the seller's store, newest first
+func (s *warehouseServiceImpl) GetTransfers(userId uint, limit int, page int) (models.Pagination, error) {
+	store, err := s.storeRepo.FindByUserId(userId)
+	if err != nil {
+		return models.Pagination{}, errors.New("you do not have a store")
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	transfers, total, err := s.repository.FindTransfers(store.ID, limit, (page-1)*limit)
+	if err != nil {
+		return models.Pagination{}, err
+	}
+
+	rows := []models.WarehouseTransferResponse{}
+	for _, transfer := range transfers {
+		rows = append(rows, toWarehouseTransferResponse(transfer))
+	}
+	return models.Pagination{
+		Limit:      limit,
+		Page:       page,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		Rows:       rows,
+	}, nil
+}
+
+func (s *warehouseServiceImpl) findOwned(userId uint, isAdmin bool, id uint) (entities.Warehouse, error) {
+	warehouse, err := s.repository.FindById(id)
+	if err != nil {
+		return entities.Warehouse{}, err
+	}
+	if isAdmin {
+		return warehouse, nil
+	}
+	store, err := s.storeRepo.FindByUserId(userId)
+	if err != nil || store.ID != warehouse.IDToko {
+		return entities.Warehouse{}, errors.New("forbidden")
+	}
+	return warehouse, nil
+}
+
+func (s *warehouseServiceImpl) toResponse(warehouse entities.Warehouse) (models.WarehouseResponse, error) {
+	total, err := s.repository.TotalStock(warehouse.ID)
+	if err != nil {
+		return models.WarehouseResponse{}, err
+	}
+	return models.WarehouseResponse{
+		ID:         warehouse.ID,
+		IDToko:     warehouse.IDToko,
+		Nama:       warehouse.Nama,
+		Alamat:     warehouse.Alamat,
+		IDProvinsi: warehouse.IDProvinsi,
+		IDKota:     warehouse.IDKota,
+		Utama:      warehouse.Utama,
+		Aktif:      warehouse.Aktif,
+		TotalStok:  total,
+		CreatedAt:  warehouse.CreatedAt,
+		UpdatedAt:  warehouse.UpdatedAt,
+	}, nil
+}
+
+func validateWarehouse(input models.WarehouseRequest) error {
+	name := strings.TrimSpace(input.Nama)
+	if name == "" || input.IDKota == "" {
+		return errors.New("nama and id_kota are required")
+	}
+	if len(name) > 100 || len(input.Alamat) > 255 {
+		return errors.New("nama or alamat is too long")
+	}
+	return nil
+}
+
+// allocateWarehouses picks the warehouse each checkout line ships from. A store
+// ships all its lines from one warehouse when one holds enough of everything,
+// the primary warehouse first; otherwise each line goes to the first warehouse
+// that holds all of it. Lines of stores without warehouses get nil.
+func allocateWarehouses(repository repositories.WarehouseRepository, items []models.TransactionProduct, products map[uint]entities.Product) ([]*entities.Warehouse, error) {
+	allocation := make([]*entities.Warehouse, len(items))
+
+	var storeIDs, productIDs []uint
+	storeLines := map[uint][]int{}
+	for i, item := range items {
+		storeID := products[item.ProductID].IDToko
+		if _, ok := storeLines[storeID]; !ok {
+			storeIDs = append(storeIDs, storeID)
+		}
+		storeLines[storeID] = append(storeLines[storeID], i)
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	warehouses, err := repository.FindActiveByStores(storeIDs)
+	if err != nil || len(warehouses) == 0 {
+		return allocation, err
+	}
+	var warehouseIDs []uint
+	storeWarehouses := map[uint][]entities.Warehouse{}
+	for _, warehouse := range warehouses {
+		warehouseIDs = append(warehouseIDs, warehouse.ID)
+		storeWarehouses[warehouse.IDToko] = append(storeWarehouses[warehouse.IDToko], warehouse)
+	}
+	stocks, err := repository.FindStocks(warehouseIDs, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type stockKey struct{ warehouse, product, variant uint }
+	keyFor := func(warehouseID uint, item models.TransactionProduct) stockKey {
+		key := stockKey{warehouse: warehouseID, product: item.ProductID}
+		if item.VariantID != nil {
+			key.variant = *item.VariantID
+		}
+		return key
+	}
+	available := map[stockKey]int{}
+	for _, stock := range stocks {
+		key := stockKey{warehouse: stock.IDGudang, product: stock.IDProduk}
+		if stock.IDVarian != nil {
+			key.variant = *stock.IDVarian
+		}
+		available[key] = stock.Stok
+	}
+	covers := func(warehouseID uint, lines []int) bool {
+		needed := map[stockKey]int{}
+		for _, line := range lines {
+			needed[keyFor(warehouseID, items[line])] += items[line].Quantity
+		}
+		for key, quantity := range needed {
+			if available[key] < quantity {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, storeID := range storeIDs {
+		candidates := storeWarehouses[storeID]
+		lines := storeLines[storeID]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		whole := false
+		for i := range candidates {
+			if covers(candidates[i].ID, lines) {
+				for _, line := range lines {
+					allocation[line] = &candidates[i]
+				}
+				whole = true
+				break
+			}
+		}
+		if whole {
+			continue
+		}
+
+		for _, line := range lines {
+			for i := range candidates {
+				if covers(candidates[i].ID, []int{line}) {
+					allocation[line] = &candidates[i]
+					available[keyFor(candidates[i].ID, items[line])] -= items[line].Quantity
+					break
+				}
+			}
+			if allocation[line] == nil {
+				product := products[items[line].ProductID]
+				return nil, fmt.Errorf("insufficient stock for %s, no warehouse of %s holds %d", product.NamaProduk, product.Store.NamaToko, items[line].Quantity)
+			}
+		}
+	}
+	return allocation, nil
+}
+
+func toWarehouseTransferResponse(transfer entities.WarehouseTransfer) models.WarehouseTransferResponse {
+	return models.WarehouseTransferResponse{
+		ID:             transfer.ID,
+		IDToko:         transfer.IDToko,
+		IDGudangAsal:   transfer.IDGudangAsal,
+		IDGudangTujuan: transfer.IDGudangTujuan,
+		IDProduk:       transfer.IDProduk,
+		IDVarian:       transfer.IDVarian,
+		Jumlah:         transfer.Jumlah,
+		IDUser:         transfer.IDUser,
+		Catatan:        transfer.Catatan,
+		CreatedAt:      transfer.CreatedAt,
+	}
+}
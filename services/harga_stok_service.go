@@ -0,0 +1,295 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strconv"
+)
+
+// Bulk update modes
+const (
+	BulkModeAtomic  = "atomic"
+	BulkModePartial = "partial"
+)
+
+const maxBulkItems = 1000
+
+type PriceStockService interface {
+	BulkUpdate(userId uint, isAdmin bool, input models.BulkPriceStockRequest) (models.BulkPriceStockResult, error)
+	History(userId uint, isAdmin bool, productID uint, limit int, page int) (models.Pagination, error)
+}
+
+type priceStockServiceImpl struct {
+	historyRepo repositories.PriceStockHistoryRepository
+	productRepo repositories.ProductRepository
+	variantRepo repositories.ProductVariantRepository
+	storeRepo   repositories.StoreRepository
+}
+
+func NewPriceStockService(
+	historyRepo repositories.PriceStockHistoryRepository,
+	productRepo repositories.ProductRepository,
+	variantRepo repositories.ProductVariantRepository,
+	storeRepo repositories.StoreRepository,
+) PriceStockService {
+	return &priceStockServiceImpl{
+		historyRepo: historyRepo,
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+		storeRepo:   storeRepo,
+	}
+}
+
+// BulkUpdate changes the stock and prices of many products and variants. Every
+// item is validated first; in atomic mode any invalid item cancels the whole
+// request, in partial mode the valid items are applied one by one.
+func (s *priceStockServiceImpl) BulkUpdate(userId uint, isAdmin bool, input models.BulkPriceStockRequest) (models.BulkPriceStockResult, error) {
+	if input.Mode == "" {
+		input.Mode = BulkModeAtomic
+	}
+	if input.Mode != BulkModeAtomic && input.Mode != BulkModePartial {
+		return models.BulkPriceStockResult{}, errors.New("mode must be atomic or partial")
+	}
+	if len(input.Items) == 0 {
+		return models.BulkPriceStockResult{}, errors.New("items is required")
+	}
+	if len(input.Items) > maxBulkItems {
+		return models.BulkPriceStockResult{}, fmt.Errorf("at most %d items can be updated at once", maxBulkItems)
+	}
+
+	// Sellers are limited to their own store, admins to store_id when given
+	storeID := input.StoreID
+	if !isAdmin {
+		store, err := s.storeRepo.FindByUserId(userId)
+		if err != nil {
+			return models.BulkPriceStockResult{}, errors.New("you do not have a store")
+		}
+		storeID = store.ID
+	}
+
+	var ids []uint
+	for _, item := range input.Items {
+		if item.IDProduk != 0 {
+			ids = append(ids, item.IDProduk)
+		}
+	}
+	products := map[uint]entities.Product{}
+	if len(ids) > 0 {
+		found, err := s.productRepo.FindWithStoreByIds(ids)
+		if err != nil {
+			return models.BulkPriceStockResult{}, err
+		}
+		for _, product := range found {
+			products[product.ID] = product
+		}
+	}
+
+	result := models.BulkPriceStockResult{Mode: input.Mode, Items: []models.BulkPriceStockItemResult{}}
+	changes := make([]entities.ProductPriceStockHistory, len(input.Items))
+	seen := map[string]int{}
+	for i, item := range input.Items {
+		itemResult := models.BulkPriceStockItemResult{Index: i, IDProduk: item.IDProduk, SKU: item.SKU}
+		change, err := s.resolveItem(item, storeID, products)
+		if err == nil {
+			key := fmt.Sprintf("produk-%d", change.IDProduk)
+			if change.IDVarian != nil {
+				key = fmt.Sprintf("varian-%d", *change.IDVarian)
+			}
+			if previous, ok := seen[key]; ok {
+				err = fmt.Errorf("the same product is also updated by item %d", previous)
+			}
+			seen[key] = i
+		}
+		if err != nil {
+			itemResult.Error = err.Error()
+		} else {
+			change.IDUser = userId
+			change.Sumber = entities.PriceStockSourceBulk
+			changes[i] = change
+			itemResult.IDProduk = change.IDProduk
+			itemResult.IDVarian = change.IDVarian
+		}
+		result.Items = append(result.Items, itemResult)
+	}
+
+	invalid := 0
+	for _, item := range result.Items {
+		if item.Error != "" {
+			invalid++
+		}
+	}
+	if input.Mode == BulkModeAtomic {
+		if invalid > 0 {
+			result.Gagal = invalid
+			return result, nil
+		}
+		applied, err := s.historyRepo.Apply(changes)
+		if err != nil {
+			return models.BulkPriceStockResult{}, err
+		}
+		for i := range result.Items {
+			s.markApplied(&result.Items[i], applied[i])
+		}
+		result.Berhasil = len(result.Items)
+		return result, nil
+	}
+
+	for i := range result.Items {
+		if result.Items[i].Error != "" {
+			result.Gagal++
+			continue
+		}
+		applied, err := s.historyRepo.Apply(changes[i : i+1])
+		if err != nil {
+			result.Items[i].Error = err.Error()
+			result.Gagal++
+			continue
+		}
+		s.markApplied(&result.Items[i], applied[0])
+		result.Berhasil++
+	}
+	return result, nil
+}
+
+// resolveItem finds the product or variant an item targets and turns it into a change
+func (s *priceStockServiceImpl) resolveItem(item models.BulkPriceStockItem, storeID uint, products map[uint]entities.Product) (entities.ProductPriceStockHistory, error) {
+	change := entities.ProductPriceStockHistory{StokBaru: item.Stok}
+	if item.Stok == nil && item.HargaKonsumen == nil && item.HargaReseller == nil {
+		return change, errors.New("nothing to update, send stok, harga_konsumen or harga_reseller")
+	}
+	if item.Stok != nil && *item.Stok < 0 {
+		return change, errors.New("stok cannot be negative")
+	}
+	for _, price := range []*float64{item.HargaKonsumen, item.HargaReseller} {
+		if price != nil && (*price < 0 || math.IsNaN(*price) || math.IsInf(*price, 0)) {
+			return change, errors.New("prices cannot be negative")
+		}
+	}
+	if item.HargaKonsumen != nil {
+		price := formatPrice(*item.HargaKonsumen)
+		change.HargaKonsumenBaru = &price
+	}
+	if item.HargaReseller != nil {
+		price := formatPrice(*item.HargaReseller)
+		change.HargaResellerBaru = &price
+	}
+
+	var product entities.Product
+	switch {
+	case item.IDProduk != 0:
+		found, ok := products[item.IDProduk]
+		if !ok {
+			return change, fmt.Errorf("product %d not found", item.IDProduk)
+		}
+		product = found
+	case item.SKU != "":
+		// A product SKU is only unique within its store; variant SKUs are unique everywhere
+		if storeID != 0 {
+			found, err := s.productRepo.FindBySKU(storeID, item.SKU)
+			if err == nil {
+				product = found
+				break
+			}
+		}
+		variant, err := s.variantRepo.FindBySKU(item.SKU)
+		if err != nil {
+			return change, fmt.Errorf("sku %s not found", item.SKU)
+		}
+		if !variant.IsActive {
+			return change, fmt.Errorf("variant %s is no longer sold", item.SKU)
+		}
+		found, err := s.productRepo.FindWithStoreByIds([]uint{variant.IDProduk})
+		if err != nil || len(found) == 0 {
+			return change, fmt.Errorf("sku %s not found", item.SKU)
+		}
+		product = found[0]
+		change.IDVarian = &variant.ID
+	default:
+		return change, errors.New("id_produk or sku is required")
+	}
+
+	if storeID != 0 && product.IDToko != storeID {
+		return change, errors.New("the product belongs to another store")
+	}
+	change.IDProduk = product.ID
+
+	// The stock of a product with variants is the sum of its variants' stock
+	if change.IDVarian == nil && item.Stok != nil {
+		hasVariants, err := s.variantRepo.HasActiveVariants(product.ID)
+		if err != nil {
+			return change, err
+		}
+		if hasVariants {
+			return change, errors.New("the product has variants, update the stock of each variant by its sku")
+		}
+	}
+	return change, nil
+}
+
+func (s *priceStockServiceImpl) markApplied(item *models.BulkPriceStockItemResult, applied entities.ProductPriceStockHistory) {
+	item.Berhasil = true
+	if applied.ID != 0 {
+		history := toPriceStockHistoryResponse(applied)
+		item.Riwayat = &history
+	}
+}
+
+// History lists the price and stock changes of a product, newest first
+func (s *priceStockServiceImpl) History(userId uint, isAdmin bool, productID uint, limit int, page int) (models.Pagination, error) {
+	product, err := s.productRepo.FindById(productID)
+	if err != nil {
+		return models.Pagination{}, err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return models.Pagination{}, errors.New("forbidden")
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	histories, total, err := s.historyRepo.FindByProductId(productID, limit, (page-1)*limit)
+	if err != nil {
+		return models.Pagination{}, err
+	}
+
+	rows := []models.PriceStockHistoryResponse{}
+	for _, history := range histories {
+		rows = append(rows, toPriceStockHistoryResponse(history))
+	}
+	return models.Pagination{
+		Limit:      limit,
+		Page:       page,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		Rows:       rows,
+	}, nil
+}
+
+func toPriceStockHistoryResponse(history entities.ProductPriceStockHistory) models.PriceStockHistoryResponse {
+	return models.PriceStockHistoryResponse{
+		ID:                history.ID,
+		IDProduk:          history.IDProduk,
+		IDVarian:          history.IDVarian,
+		IDUser:            history.IDUser,
+		Sumber:            history.Sumber,
+		StokLama:          history.StokLama,
+		StokBaru:          history.StokBaru,
+		HargaKonsumenLama: history.HargaKonsumenLama,
+		HargaKonsumenBaru: history.HargaKonsumenBaru,
+		HargaResellerLama: history.HargaResellerLama,
+		HargaResellerBaru: history.HargaResellerBaru,
+		CreatedAt:         history.CreatedAt,
+	}
+}
+
+// formatPrice renders a price the way prices are stored, e.g. 150000 rather than 150000.00
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/spreadsheet"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+)
+
+// productImportColumns are the columns of the import and export files, in export order
+var productImportColumns = []string{
+	"sku", "slug", "nama_produk", "kategori", "harga_konsumen", "harga_reseller",
+	"stok", "berat", "panjang", "lebar", "tinggi", "deskripsi", "status", "foto_url",
+}
+
+// productPhotoSeparator separates the photo URLs of a product in one cell
+const productPhotoSeparator = "|"
+
+const maxImportRows = 5000
+
+type ProductImportService interface {
+	Import(userId uint, isAdmin bool, storeID uint, format string, data []byte, dryRun bool) (models.ProductImportResult, error)
+	Export(userId uint, isAdmin bool, storeID uint, format string) ([]byte, string, error)
+}
+
+type productImportServiceImpl struct {
+	productRepo  repositories.ProductRepository
+	storeRepo    repositories.StoreRepository
+	categoryRepo repositories.CategoryRepository
+	suggestions  SearchSuggestionService
+}
+
+func NewProductImportService(
+	productRepo repositories.ProductRepository,
+	storeRepo repositories.StoreRepository,
+	categoryRepo repositories.CategoryRepository,
+	suggestions SearchSuggestionService,
+) ProductImportService {
+	return &productImportServiceImpl{
+		productRepo:  productRepo,
+		storeRepo:    storeRepo,
+		categoryRepo: categoryRepo,
+		suggestions:  suggestions,
+	}
+}
+
+// Import creates or updates the store's products from a CSV or XLSX file. A row
+// updates the product with the same sku, or else the same slug, of the store and
+// creates a new product otherwise. Blank cells keep the current value on update.
+// Every row is validated first; the rows are saved in a single transaction only
+// when all of them are valid and dryRun is false.
+func (s *productImportServiceImpl) Import(userId uint, isAdmin bool, storeID uint, format string, data []byte, dryRun bool) (models.ProductImportResult, error) {
+	store, err := s.resolveStore(userId, isAdmin, storeID)
+	if err != nil {
+		return models.ProductImportResult{}, err
+	}
+
+	rows, err := spreadsheet.Read(format, data)
+	if err != nil {
+		return models.ProductImportResult{}, err
+	}
+	if len(rows) < 2 {
+		return models.ProductImportResult{}, errors.New("the file has no product rows")
+	}
+	if len(rows)-1 > maxImportRows {
+		return models.ProductImportResult{}, fmt.Errorf("the file has %d rows, the maximum is %d", len(rows)-1, maxImportRows)
+	}
+	columns, err := importColumns(rows[0])
+	if err != nil {
+		return models.ProductImportResult{}, err
+	}
+
+	existing, err := s.productRepo.FindByStore(store.ID)
+	if err != nil {
+		return models.ProductImportResult{}, err
+	}
+	categories, err := s.categoryRepo.FindAll()
+	if err != nil {
+		return models.ProductImportResult{}, err
+	}
+
+	importer := &productImporter{
+		service:    s,
+		storeID:    store.ID,
+		bySKU:      map[string]entities.Product{},
+		bySlug:     map[string]entities.Product{},
+		categories: map[string]uint{},
+		seenSKU:    map[string]int{},
+		seenSlug:   map[string]int{},
+		seenID:     map[uint]int{},
+	}
+	for _, product := range existing {
+		if product.SKU != nil {
+			importer.bySKU[strings.ToLower(*product.SKU)] = product
+		}
+		importer.bySlug[product.Slug] = product
+	}
+	for _, category := range categories {
+		importer.categories[strconv.FormatUint(uint64(category.ID), 10)] = category.ID
+		importer.categories[strings.ToLower(category.Slug)] = category.ID
+		importer.categories[strings.ToLower(strings.TrimSpace(category.NamaCategory))] = category.ID
+	}
+
+	result := models.ProductImportResult{DryRun: dryRun, Baris: []models.ProductImportRowResult{}}
+	var products []entities.Product
+	var photos [][]string
+	var stockSet []bool
+	for i, row := range rows[1:] {
+		values := map[string]string{}
+		for index, column := range columns {
+			if index < len(row) {
+				values[column] = strings.TrimSpace(row[index])
+			}
+		}
+		if isBlankRow(values) {
+			continue
+		}
+
+		product, photoURLs, rowResult, err := importer.row(i+2, values)
+		if err != nil {
+			return models.ProductImportResult{}, err
+		}
+		result.TotalBaris++
+		result.Baris = append(result.Baris, rowResult)
+		switch {
+		case len(rowResult.Errors) > 0:
+			result.Gagal++
+		case rowResult.Aksi == "create":
+			result.Dibuat++
+		default:
+			result.Diperbarui++
+		}
+		products = append(products, product)
+		photos = append(photos, photoURLs)
+		stockSet = append(stockSet, values["stok"] != "")
+	}
+
+	if result.Gagal > 0 || dryRun || len(products) == 0 {
+		return result, nil
+	}
+
+	if err := s.productRepo.ImportProducts(products, photos, stockSet, userId); err != nil {
+		return models.ProductImportResult{}, err
+	}
+	now := time.Now()
+	for i, product := range products {
+		result.Baris[i].IDProduk = product.ID
+		refreshProductSuggestion(s.suggestions, product.ID, product.NamaProduk, product.IsPublished(now))
+	}
+	result.Disimpan = true
+	return result, nil
+}
+
+// Export writes the whole catalog of the store in the import format
+func (s *productImportServiceImpl) Export(userId uint, isAdmin bool, storeID uint, format string) ([]byte, string, error) {
+	if format == "" {
+		format = spreadsheet.FormatCSV
+	}
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		return nil, "", errors.New("format must be csv or xlsx")
+	}
+
+	store, err := s.resolveStore(userId, isAdmin, storeID)
+	if err != nil {
+		return nil, "", err
+	}
+	products, err := s.productRepo.FindByStore(store.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows := [][]string{productImportColumns}
+	for _, product := range products {
+		var sku, deskripsi string
+		if product.SKU != nil {
+			sku = *product.SKU
+		}
+		if product.Deskripsi != nil {
+			deskripsi = *product.Deskripsi
+		}
+		var photoURLs []string
+		for _, photo := range product.FotoProduk {
+			if url := exportedPhotoURL(photo); url != "" {
+				photoURLs = append(photoURLs, url)
+			}
+		}
+
+		rows = append(rows, []string{
+			sku,
+			product.Slug,
+			product.NamaProduk,
+			product.Category.Slug,
+			product.HargaKonsumen,
+			product.HargaReseller,
+			strconv.Itoa(product.Stok),
+			strconv.Itoa(product.Berat),
+			strconv.Itoa(product.Panjang),
+			strconv.Itoa(product.Lebar),
+			strconv.Itoa(product.Tinggi),
+			deskripsi,
+			product.Status,
+			strings.Join(photoURLs, productPhotoSeparator),
+		})
+	}
+
+	content, err := spreadsheet.Write(format, "Produk", rows)
+	if err != nil {
+		return nil, "", err
+	}
+	name := slug.Make(store.NamaToko)
+	if name == "" {
+		name = fmt.Sprintf("toko-%d", store.ID)
+	}
+	filename := fmt.Sprintf("produk-%s-%s.%s", name, time.Now().Format("20060102"), format)
+	return content, filename, nil
+}
+
+// resolveStore picks the store to import into or export: the caller's own store,
+// or for admins any store given by storeID
+func (s *productImportServiceImpl) resolveStore(userId uint, isAdmin bool, storeID uint) (entities.Store, error) {
+	if storeID == 0 {
+		store, err := s.storeRepo.FindByUserId(userId)
+		if err != nil {
+			return entities.Store{}, errors.New("you do not have a store")
+		}
+		return store, nil
+	}
+
+	store, _, err := s.storeRepo.FindById(storeID)
+	if err != nil {
+		return entities.Store{}, err
+	}
+	if !isAdmin && store.IDUser != userId {
+		return entities.Store{}, errors.New("forbidden")
+	}
+	return store, nil
+}
+
+// productImporter validates the rows of one import against the store's
+// catalog and against the rows before them
+type productImporter struct {
+	service    *productImportServiceImpl
+	storeID    uint
+	bySKU      map[string]entities.Product
+	bySlug     map[string]entities.Product
+	categories map[string]uint
+	seenSKU    map[string]int
+	seenSlug   map[string]int
+	seenID     map[uint]int
+}
+
+func (importer *productImporter) row(line int, values map[string]string) (entities.Product, []string, models.ProductImportRowResult, error) {
+	result := models.ProductImportRowResult{Baris: line, Aksi: "create", SKU: values["sku"]}
+	fail := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	// Find the product the row refers to
+	product := entities.Product{IDToko: importer.storeID, Status: entities.ProductStatusDraft}
+	sku := values["sku"]
+	requestedSlug := ""
+	if values["slug"] != "" {
+		requestedSlug = slug.Make(values["slug"])
+		if requestedSlug == "" {
+			fail("slug %q has no letters or digits", values["slug"])
+		}
+	}
+	if len(sku) > 100 {
+		fail("sku is longer than 100 characters")
+	}
+
+	found := false
+	if sku != "" {
+		if previous, ok := importer.seenSKU[strings.ToLower(sku)]; ok {
+			fail("sku %s is also used on line %d", sku, previous)
+		}
+		importer.seenSKU[strings.ToLower(sku)] = line
+		product, found = importer.bySKU[strings.ToLower(sku)]
+	}
+	if !found && requestedSlug != "" {
+		product, found = importer.bySlug[requestedSlug]
+		if found && product.SKU != nil && sku != "" && !strings.EqualFold(*product.SKU, sku) {
+			fail("slug %s belongs to the product with sku %s", requestedSlug, *product.SKU)
+		}
+	}
+	if found {
+		result.Aksi = "update"
+		result.IDProduk = product.ID
+		if previous, ok := importer.seenID[product.ID]; ok {
+			fail("this product is also updated on line %d", previous)
+		}
+		importer.seenID[product.ID] = line
+		if product.Status == entities.ProductStatusBanned {
+			fail("the product is banned and cannot be changed")
+		}
+	} else {
+		product = entities.Product{IDToko: importer.storeID, Status: entities.ProductStatusDraft}
+		for _, column := range []string{"nama_produk", "kategori", "harga_konsumen", "stok"} {
+			if values[column] == "" {
+				fail("%s is required for a new product", column)
+			}
+		}
+	}
+	if sku != "" {
+		product.SKU = &sku
+	}
+	if name := values["nama_produk"]; name != "" {
+		if len([]rune(name)) > 255 {
+			fail("nama_produk is longer than 255 characters")
+		}
+		product.NamaProduk = name
+	}
+	if category := values["kategori"]; category != "" {
+		id, ok := importer.categories[strings.ToLower(category)]
+		if !ok {
+			fail("kategori %q not found", category)
+		}
+		product.IDCategory = id
+	}
+	if price := values["harga_konsumen"]; price != "" {
+		normalized, err := importPrice(price)
+		if err != nil {
+			fail("harga_konsumen: %v", err)
+		}
+		product.HargaKonsumen = normalized
+	}
+	if price := values["harga_reseller"]; price != "" {
+		normalized, err := importPrice(price)
+		if err != nil {
+			fail("harga_reseller: %v", err)
+		}
+		product.HargaReseller = normalized
+	}
+	for _, field := range []struct {
+		column string
+		target *int
+	}{
+		{"stok", &product.Stok},
+		{"berat", &product.Berat},
+		{"panjang", &product.Panjang},
+		{"lebar", &product.Lebar},
+		{"tinggi", &product.Tinggi},
+	} {
+		if values[field.column] == "" {
+			continue
+		}
+		number, err := strconv.Atoi(values[field.column])
+		if err != nil || number < 0 {
+			fail("%s must be a whole number of at least 0", field.column)
+			continue
+		}
+		*field.target = number
+	}
+	if description := values["deskripsi"]; description != "" {
+		product.Deskripsi = &description
+	}
+	if status := values["status"]; status != "" && product.Status != entities.ProductStatusBanned {
+		switch status {
+		case entities.ProductStatusDraft, entities.ProductStatusPublished, entities.ProductStatusArchived:
+			product.Status = status
+			product.JadwalTerbit = nil
+		default:
+			fail("status must be draft, published or archived")
+		}
+	}
+
+	var photoURLs []string
+	for _, url := range strings.Split(values["foto_url"], productPhotoSeparator) {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "/") {
+			fail("foto_url %q must start with http://, https:// or /", url)
+		}
+		photoURLs = append(photoURLs, url)
+	}
+
+	// Settle the slug: keep it on update unless another one is requested
+	if requestedSlug != "" && requestedSlug != product.Slug {
+		taken, err := importer.slugTaken(requestedSlug, product.ID)
+		if err != nil {
+			return entities.Product{}, nil, models.ProductImportRowResult{}, err
+		}
+		if taken {
+			fail("slug %s is already used by another product", requestedSlug)
+		}
+		product.Slug = requestedSlug
+	} else if product.Slug == "" && product.NamaProduk != "" {
+		generated, err := uniqueSlug(product.NamaProduk, "produk", func(candidate string) (bool, error) {
+			return importer.slugTaken(candidate, 0)
+		})
+		if err != nil {
+			return entities.Product{}, nil, models.ProductImportRowResult{}, err
+		}
+		product.Slug = generated
+	}
+	if product.Slug != "" {
+		if previous, ok := importer.seenSlug[product.Slug]; ok {
+			fail("slug %s is also used on line %d", product.Slug, previous)
+		}
+		importer.seenSlug[product.Slug] = line
+	}
+	result.Slug = product.Slug
+
+	// Deleted products keep their sku, so it cannot be given to another product
+	if sku != "" {
+		taken, err := importer.service.productRepo.SKUTaken(importer.storeID, sku, product.ID)
+		if err != nil {
+			return entities.Product{}, nil, models.ProductImportRowResult{}, err
+		}
+		if taken {
+			fail("sku %s is already used by another product of this store", sku)
+		}
+	}
+
+	return product, photoURLs, result, nil
+}
+
+// slugTaken reports whether slug is used by a product other than productID,
+// in the database or on an earlier row of the file
+func (importer *productImporter) slugTaken(candidate string, productID uint) (bool, error) {
+	if _, ok := importer.seenSlug[candidate]; ok {
+		return true, nil
+	}
+	return importer.service.productRepo.SlugTaken(candidate, productID)
+}
+
+// importColumns maps the header row to known column names
+func importColumns(header []string) ([]string, error) {
+	known := map[string]bool{}
+	for _, column := range productImportColumns {
+		known[column] = true
+	}
+
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, cell := range header {
+		column := strings.ToLower(strings.TrimSpace(cell))
+		if column == "" {
+			continue
+		}
+		if !known[column] {
+			return nil, fmt.Errorf("unknown column %q, the columns are %s", cell, strings.Join(productImportColumns, ", "))
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %s appears twice", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	if !seen["sku"] && !seen["slug"] && !seen["nama_produk"] {
+		return nil, errors.New("the file needs a sku, slug or nama_produk column")
+	}
+	return columns, nil
+}
+
+// importPrice validates a price and drops trailing decimals, e.g. "150000.00" becomes "150000"
+func importPrice(value string) (string, error) {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return "", fmt.Errorf("%q is not a valid price", value)
+	}
+	return formatPrice(price), nil
+}
+
+// exportedPhotoURL picks the stored URL of a photo; uploads keep their path in PhotoURL
+func exportedPhotoURL(photo entities.FotoProduk) string {
+	for _, url := range []string{photo.PhotoURL, photo.URL, photo.Photo} {
+		if url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+func isBlankRow(values map[string]string) bool {
+	for _, value := range values {
+		if value != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -1,27 +1,34 @@
 package services
 
 import (
+	"errors"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/search"
+	"strings"
 )
 
 // Contract
 type CategoryService interface {
 	GetAll() ([]models.CategoryResponse, error)
+	GetTree() ([]models.CategoryTreeResponse, error)
 	GetById(id uint) (models.CategoryResponse, error)
+	GetBySlug(slug string) (models.CategoryResponse, error)
 	Create(payload models.CategoryRequest) (models.CategoryResponse, error)
 	Edit(id uint, payload models.CategoryRequest) (models.CategoryResponse, error)
 	Delete(id uint) (models.CategoryResponse, error)
 }
 
 type categoryServiceImpl struct {
-	repository repositories.CategoryRepository
+	repository  repositories.CategoryRepository
+	suggestions SearchSuggestionService
 }
 
-func NewCategoryService(categoryRepository *repositories.CategoryRepository) CategoryService {
+func NewCategoryService(categoryRepository *repositories.CategoryRepository, suggestions SearchSuggestionService) CategoryService {
 	return &categoryServiceImpl{
-		repository: *categoryRepository,
+		repository:  *categoryRepository,
+		suggestions: suggestions,
 	}
 }
 
@@ -36,17 +43,47 @@ func (service *categoryServiceImpl) GetAll() ([]models.CategoryResponse, error)
 	responses := []models.CategoryResponse{}
 
 	for _, category := range categories {
-		response := models.CategoryResponse{
-			ID:           category.ID,
-			NamaCategory: category.NamaCategory,
-			CreatedAt:    category.CreatedAt,
-			UpdatedAt:    category.UpdatedAt,
+		responses = append(responses, mapCategoryToResponse(category))
+	}
+
+	return responses, nil
+}
+
+// GetTree nests every category under its parent, keeping the urutan order
+func (service *categoryServiceImpl) GetTree() ([]models.CategoryTreeResponse, error) {
+	categories, err := service.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	children := map[uint][]entities.Category{}
+	known := map[uint]bool{}
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+	var roots []entities.Category
+	for _, category := range categories {
+		// Categories whose parent was deleted are shown at the top level
+		if category.IDParent == nil || !known[*category.IDParent] {
+			roots = append(roots, category)
+			continue
 		}
+		children[*category.IDParent] = append(children[*category.IDParent], category)
+	}
 
-		responses = append(responses, response)
+	var build func(categories []entities.Category) []models.CategoryTreeResponse
+	build = func(categories []entities.Category) []models.CategoryTreeResponse {
+		nodes := []models.CategoryTreeResponse{}
+		for _, category := range categories {
+			nodes = append(nodes, models.CategoryTreeResponse{
+				CategoryResponse: mapCategoryToResponse(category),
+				Children:         build(children[category.ID]),
+			})
+		}
+		return nodes
 	}
 
-	return responses, nil
+	return build(roots), nil
 }
 
 func (service *categoryServiceImpl) GetById(id uint) (models.CategoryResponse, error) {
@@ -56,56 +93,51 @@ func (service *categoryServiceImpl) GetById(id uint) (models.CategoryResponse, e
 		return models.CategoryResponse{}, err
 	}
 
-	var response = models.CategoryResponse{
-		ID:           category.ID,
-		NamaCategory: category.NamaCategory,
-		CreatedAt:    category.CreatedAt,
-		UpdatedAt:    category.UpdatedAt,
+	return mapCategoryToResponse(category), nil
+}
+
+func (service *categoryServiceImpl) GetBySlug(slug string) (models.CategoryResponse, error) {
+	category, err := service.repository.FindBySlug(slug)
+	if err != nil {
+		return models.CategoryResponse{}, err
 	}
 
-	return response, nil
+	return mapCategoryToResponse(category), nil
 }
 
 func (service *categoryServiceImpl) Create(payload models.CategoryRequest) (models.CategoryResponse, error) {
 	category := entities.Category{}
-	category.NamaCategory = payload.NamaCategory
+	if err := service.apply(&category, payload); err != nil {
+		return models.CategoryResponse{}, err
+	}
 
 	result, err := service.repository.Insert(category)
 	if err != nil {
 		return models.CategoryResponse{}, err
 	}
+	service.suggestions.Put(search.KindCategory, result.ID, result.NamaCategory)
 
-	response := models.CategoryResponse{
-		ID:           result.ID,
-		NamaCategory: result.NamaCategory,
-		CreatedAt:    result.CreatedAt,
-		UpdatedAt:    result.UpdatedAt,
-	}
-	return response, nil
+	return mapCategoryToResponse(result), nil
 }
 
 func (service *categoryServiceImpl) Edit(id uint, payload models.CategoryRequest) (models.CategoryResponse, error) {
 	//check
-	_, err := service.repository.FindById(id)
+	category, err := service.repository.FindById(id)
 	if err != nil {
 		return models.CategoryResponse{}, err
 	}
 
-	category := entities.Category{}
-	category.NamaCategory = payload.NamaCategory
+	if err := service.apply(&category, payload); err != nil {
+		return models.CategoryResponse{}, err
+	}
 
 	result, err := service.repository.Update(id, category)
 	if err != nil {
 		return models.CategoryResponse{}, err
 	}
+	service.suggestions.Put(search.KindCategory, id, result.NamaCategory)
 
-	response := models.CategoryResponse{
-		ID:           result.ID,
-		NamaCategory: result.NamaCategory,
-		CreatedAt:    result.CreatedAt,
-		UpdatedAt:    result.UpdatedAt,
-	}
-	return response, nil
+	return mapCategoryToResponse(result), nil
 }
 
 func (service *categoryServiceImpl) Delete(id uint) (models.CategoryResponse, error) {
@@ -115,16 +147,108 @@ func (service *categoryServiceImpl) Delete(id uint) (models.CategoryResponse, er
 		return models.CategoryResponse{}, err
 	}
 
+	hasChildren, err := service.repository.HasChildren(id)
+	if err != nil {
+		return models.CategoryResponse{}, err
+	}
+	if hasChildren {
+		return models.CategoryResponse{}, errors.New("category still has sub-categories, move or delete them first")
+	}
+
 	_, err = service.repository.Destroy(id)
 	if err != nil {
 		return models.CategoryResponse{}, err
 	}
+	service.suggestions.Remove(search.KindCategory, id)
+
+	return mapCategoryToResponse(category), nil
+}
+
+// apply validates payload and copies it onto category. The slug is generated
+// from the name unless one is given, and kept when the name does not change.
+func (service *categoryServiceImpl) apply(category *entities.Category, payload models.CategoryRequest) error {
+	name := strings.TrimSpace(payload.NamaCategory)
+	if name == "" {
+		return errors.New("nama_category is required")
+	}
+	if payload.Urutan < 0 {
+		return errors.New("urutan cannot be negative")
+	}
+
+	if payload.IDParent != nil {
+		if category.ID != 0 && *payload.IDParent == category.ID {
+			return errors.New("a category cannot be its own parent")
+		}
+		if _, err := service.repository.FindById(*payload.IDParent); err != nil {
+			return errors.New("parent category not found")
+		}
+		if category.ID != 0 {
+			descendants, err := service.descendantIds(category.ID)
+			if err != nil {
+				return err
+			}
+			for _, id := range descendants {
+				if id == *payload.IDParent {
+					return errors.New("a category cannot be moved under one of its sub-categories")
+				}
+			}
+		}
+	}
+
+	source := payload.Slug
+	if source == "" && category.Slug != "" && name == category.NamaCategory {
+		source = category.Slug
+	}
+	if source == "" {
+		source = name
+	}
+	slug, err := uniqueSlug(source, "kategori", func(candidate string) (bool, error) {
+		return service.repository.SlugExists(candidate, category.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	category.NamaCategory = name
+	category.IDParent = payload.IDParent
+	category.Slug = slug
+	category.Icon = payload.Icon
+	category.Urutan = payload.Urutan
+	return nil
+}
 
-	response := models.CategoryResponse{
-		ID:           category.ID,
-		NamaCategory: category.NamaCategory,
-		CreatedAt:    category.CreatedAt,
-		UpdatedAt:    category.UpdatedAt,
+// descendantIds returns the IDs of every category below id
+func (service *categoryServiceImpl) descendantIds(id uint) ([]uint, error) {
+	categories, err := service.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	return categoryDescendants(categories, id), nil
+}
+
+// categoryDescendants walks the parent links breadth first; the visited set
+// guards against cycles left behind by manual edits
+func categoryDescendants(categories []entities.Category, id uint) []uint {
+	children := map[uint][]uint{}
+	for _, category := range categories {
+		if category.IDParent != nil {
+			children[*category.IDParent] = append(children[*category.IDParent], category.ID)
+		}
+	}
+
+	var descendants []uint
+	visited := map[uint]bool{id: true}
+	queue := []uint{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if !visited[child] {
+				visited[child] = true
+				descendants = append(descendants, child)
+				queue = append(queue, child)
+			}
+		}
 	}
-	return response, nil
+	return descendants
 }
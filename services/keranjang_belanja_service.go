@@ -4,6 +4,7 @@ import (
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
+	"strconv"
 	"time" // Add this import
 )
 
@@ -14,15 +15,27 @@ type KeranjangBelanjaService interface {
 	Update(id uint, input models.KeranjangBelanjaRequest) (models.KeranjangBelanjaResponse, error)
 	Delete(id uint) (models.KeranjangBelanjaResponse, error)
 	ClearAll() ([]models.KeranjangBelanjaResponse, error)
+	Summary(userId uint) (models.KeranjangBelanjaSummaryResponse, error)
 }
 
 type keranjangBelanjaServiceImpl struct {
-	repository repositories.KeranjangBelanjaRepository
+	repository        repositories.KeranjangBelanjaRepository
+	variantRepository repositories.ProductVariantRepository
+	promoService      ProductPromoService
+	resellerService   ResellerService
 }
 
-func NewKeranjangBelanjaService(repository *repositories.KeranjangBelanjaRepository) KeranjangBelanjaService {
+func NewKeranjangBelanjaService(
+	repository *repositories.KeranjangBelanjaRepository,
+	variantRepository *repositories.ProductVariantRepository,
+	promoService *ProductPromoService,
+	resellerService *ResellerService,
+) KeranjangBelanjaService {
 	return &keranjangBelanjaServiceImpl{
-		repository: *repository,
+		repository:        *repository,
+		variantRepository: *variantRepository,
+		promoService:      *promoService,
+		resellerService:   *resellerService,
 	}
 }
 
@@ -31,7 +44,9 @@ func (service *keranjangBelanjaServiceImpl) toResponse(kb entities.KeranjangBela
 		ID:           kb.ID,
 		IDToko:       kb.IDToko,
 		IDProduk:     kb.IDProduk,
+		IDVarian:     kb.IDVarian,
 		JumlahProduk: kb.JumlahProduk,
+		Varian:       toVariantSummary(kb.Variant, kb.Product),
 		CreatedAt:    kb.CreatedAt,
 		UpdatedAt:    kb.UpdatedAt,
 	}
@@ -73,6 +88,10 @@ func (service *keranjangBelanjaServiceImpl) toResponse(kb entities.KeranjangBela
 	response.Product.Slug = kb.Product.Slug
 	response.Product.HargaReseller = kb.Product.HargaReseller
 	response.Product.HargaKonsumen = kb.Product.HargaKonsumen
+	if diskon := kb.Product.ActiveDiscount(time.Now()); diskon != nil {
+		response.Product.HargaCoret = kb.Product.HargaKonsumen
+		response.Product.HargaKonsumen = diskon.Apply(kb.Product.HargaKonsumen)
+	}
 	response.Product.Stok = kb.Product.Stok
 	response.Product.Deskripsi = kb.Product.Deskripsi
 
@@ -159,6 +178,10 @@ func (service *keranjangBelanjaServiceImpl) GetById(id uint) (models.KeranjangBe
 }
 
 func (service *keranjangBelanjaServiceImpl) Create(input models.KeranjangBelanjaRequest) (models.KeranjangBelanjaResponse, error) {
+	if _, err := resolveVariant(service.variantRepository, input.IDProduk, input.IDVarian); err != nil {
+		return models.KeranjangBelanjaResponse{}, err
+	}
+
 	keranjangBelanja, err := service.repository.Create(input)
 	if err != nil {
 		return models.KeranjangBelanjaResponse{}, err
@@ -168,6 +191,10 @@ func (service *keranjangBelanjaServiceImpl) Create(input models.KeranjangBelanja
 }
 
 func (service *keranjangBelanjaServiceImpl) Update(id uint, input models.KeranjangBelanjaRequest) (models.KeranjangBelanjaResponse, error) {
+	if _, err := resolveVariant(service.variantRepository, input.IDProduk, input.IDVarian); err != nil {
+		return models.KeranjangBelanjaResponse{}, err
+	}
+
 	keranjangBelanja, err := service.repository.Update(id, input)
 	if err != nil {
 		return models.KeranjangBelanjaResponse{}, err
@@ -208,3 +235,77 @@ func (service *keranjangBelanjaServiceImpl) ClearAll() ([]models.KeranjangBelanj
 
 	return items, nil
 }
+
+// Summary prices the cart lines at their current price, the reseller price for
+// an approved reseller, and runs the promos on them the way checkout does.
+// Shipping is not known yet, so free shipping only tells which stores'
+// shipping the cart qualifies for.
+func (service *keranjangBelanjaServiceImpl) Summary(userId uint) (models.KeranjangBelanjaSummaryResponse, error) {
+	keranjangBelanja, err := service.repository.FindAll()
+	if err != nil {
+		return models.KeranjangBelanjaSummaryResponse{}, err
+	}
+
+	summary := models.KeranjangBelanjaSummaryResponse{
+		Items:        []models.KeranjangBelanjaSummaryItem{},
+		GratisOngkir: []models.AppliedPromo{},
+	}
+	var lines []models.ProductLogProcess
+	for _, kb := range keranjangBelanja {
+		response := service.toResponse(kb)
+		harga, hargaReseller := response.Product.HargaKonsumen, response.Product.HargaReseller
+		if response.Varian != nil {
+			harga, hargaReseller = response.Varian.HargaKonsumen, response.Varian.HargaReseller
+		}
+		price, _ := strconv.ParseFloat(harga, 64)
+
+		summary.Items = append(summary.Items, models.KeranjangBelanjaSummaryItem{
+			ID:           kb.ID,
+			IDToko:       kb.IDToko,
+			IDProduk:     kb.IDProduk,
+			IDVarian:     kb.IDVarian,
+			NamaProduk:   kb.Product.NamaProduk,
+			JumlahProduk: kb.JumlahProduk,
+			Harga:        price,
+			HargaTotal:   price * float64(kb.JumlahProduk),
+			Promos:       []models.AppliedPromo{},
+		})
+		lines = append(lines, models.ProductLogProcess{
+			ProductID:     kb.IDProduk,
+			VariantID:     kb.IDVarian,
+			StoreID:       kb.IDToko,
+			HargaKonsumen: harga,
+			HargaReseller: hargaReseller,
+			Kuantitas:     kb.JumlahProduk,
+			HargaTotal:    price * float64(kb.JumlahProduk),
+		})
+	}
+
+	if err := service.resellerService.PriceLines(userId, lines); err != nil {
+		return models.KeranjangBelanjaSummaryResponse{}, err
+	}
+	for i, line := range lines {
+		if line.Reseller {
+			summary.Items[i].Reseller = true
+			summary.Items[i].Harga = line.HargaTotal / float64(line.Kuantitas)
+			summary.Items[i].HargaTotal = line.HargaTotal
+		}
+		summary.Subtotal += line.HargaTotal
+	}
+
+	promos, _, err := service.promoService.Price(lines, nil)
+	if err != nil {
+		return models.KeranjangBelanjaSummaryResponse{}, err
+	}
+	for _, promo := range promos {
+		if promo.Baris < 0 {
+			summary.GratisOngkir = append(summary.GratisOngkir, promo)
+			continue
+		}
+		summary.Items[promo.Baris].Diskon += promo.Diskon
+		summary.Items[promo.Baris].Promos = append(summary.Items[promo.Baris].Promos, promo)
+		summary.Diskon += promo.Diskon
+	}
+	summary.Total = summary.Subtotal - summary.Diskon
+	return summary, nil
+}
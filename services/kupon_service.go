@@ -1,8 +1,15 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ProductCouponService interface {
@@ -13,45 +20,453 @@ type ProductCouponService interface {
 	Create(request models.ProductCouponRequest) (models.ProductCouponResponse, error)
 	Update(id uint, request models.ProductCouponRequest) (models.ProductCouponResponse, error)
 	Delete(id uint) (models.ProductCouponResponse, error)
-	ValidateCoupon(code string, productId uint) (models.ProductCouponResponse, error)
+	GetRedemptions(id uint) ([]models.CouponRedemptionResponse, error)
+	ValidateCoupon(userId uint, request models.CouponValidateRequest) (models.CouponQuoteResponse, error)
+	Apply(code string, userId uint, lines []models.ProductLogProcess) (models.CouponRedemption, []float64, error)
+	RestoreLegacyPrices() (int64, error)
 }
 
 type productCouponServiceImpl struct {
-	repository repositories.ProductCouponRepository
+	repository         repositories.ProductCouponRepository
+	productRepository  repositories.ProductRepository
+	categoryRepository repositories.CategoryRepository
+	variantRepository  repositories.ProductVariantRepository
 }
 
-func NewProductCouponService(repository repositories.ProductCouponRepository) ProductCouponService {
-	return &productCouponServiceImpl{repository}
+func NewProductCouponService(repository repositories.ProductCouponRepository, productRepository repositories.ProductRepository, categoryRepository repositories.CategoryRepository, variantRepository repositories.ProductVariantRepository) ProductCouponService {
+	return &productCouponServiceImpl{repository, productRepository, categoryRepository, variantRepository}
 }
 
 func (s *productCouponServiceImpl) GetAll() ([]models.ProductCouponResponse, error) {
-	return s.repository.FindAll()
+	coupons, err := s.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	return toProductCouponResponses(coupons), nil
 }
 
 func (s *productCouponServiceImpl) GetById(id uint) (models.ProductCouponResponse, error) {
-	return s.repository.FindById(id)
+	coupon, err := s.repository.FindById(id)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	return toProductCouponResponse(coupon), nil
 }
 
 func (s *productCouponServiceImpl) GetByCode(code string) (models.ProductCouponResponse, error) {
-	return s.repository.FindByCode(code)
+	coupon, err := s.repository.FindByCode(code)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	return toProductCouponResponse(coupon), nil
 }
 
 func (s *productCouponServiceImpl) GetByProduct(productId uint) ([]models.ProductCouponResponse, error) {
-	return s.repository.FindByProduct(productId)
+	coupons, err := s.repository.FindByProduct(productId)
+	if err != nil {
+		return nil, err
+	}
+	return toProductCouponResponses(coupons), nil
 }
 
 func (s *productCouponServiceImpl) Create(request models.ProductCouponRequest) (models.ProductCouponResponse, error) {
-	return s.repository.Create(request)
+	coupon, err := s.buildCoupon(entities.ProductCoupon{IsActive: true}, request)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+
+	created, err := s.repository.Create(coupon)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	return toProductCouponResponse(created), nil
 }
 
 func (s *productCouponServiceImpl) Update(id uint, request models.ProductCouponRequest) (models.ProductCouponResponse, error) {
-	return s.repository.Update(id, request)
+	existing, err := s.repository.FindById(id)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	coupon, err := s.buildCoupon(existing, request)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+
+	updated, err := s.repository.Update(coupon)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	return toProductCouponResponse(updated), nil
 }
 
 func (s *productCouponServiceImpl) Delete(id uint) (models.ProductCouponResponse, error) {
-	return s.repository.Delete(id)
+	coupon, err := s.repository.Delete(id)
+	if err != nil {
+		return models.ProductCouponResponse{}, err
+	}
+	return toProductCouponResponse(coupon), nil
+}
+
+func (s *productCouponServiceImpl) GetRedemptions(id uint) ([]models.CouponRedemptionResponse, error) {
+	if _, err := s.repository.FindById(id); err != nil {
+		return nil, err
+	}
+	redemptions, err := s.repository.FindRedemptions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.CouponRedemptionResponse{}
+	for _, redemption := range redemptions {
+		responses = append(responses, models.CouponRedemptionResponse{
+			ID:        redemption.ID,
+			IDKupon:   redemption.IDKupon,
+			KodeKupon: redemption.KodeKupon,
+			IDUser:    redemption.IDUser,
+			IDTrx:     redemption.IDTrx,
+			Diskon:    redemption.Diskon,
+			Status:    redemption.Status,
+			CreatedAt: redemption.CreatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// ValidateCoupon previews what a coupon takes off the given products, priced
+// like a checkout: the given price, or the product's current price when omitted
+func (s *productCouponServiceImpl) ValidateCoupon(userId uint, request models.CouponValidateRequest) (models.CouponQuoteResponse, error) {
+	items := request.Products
+	if len(items) == 0 && request.ProductID != 0 {
+		items = []models.TransactionProduct{{ProductID: request.ProductID, Quantity: 1}}
+	}
+	if len(items) == 0 {
+		return models.CouponQuoteResponse{}, errors.New("products are required")
+	}
+
+	var lines []models.ProductLogProcess
+	for _, item := range items {
+		product, err := s.productRepository.FindById(item.ProductID)
+		if err != nil {
+			return models.CouponQuoteResponse{}, err
+		}
+		quantity := item.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+		// Priced from the catalog like the checkout, never from the request
+		hargaKonsumen := product.HargaKonsumen
+		if item.VariantID != nil && *item.VariantID != 0 {
+			variant, err := resolveVariant(s.variantRepository, product.ID, item.VariantID)
+			if err != nil {
+				return models.CouponQuoteResponse{}, err
+			}
+			hargaKonsumen, _ = variantPrices(product, *variant, time.Now())
+		}
+		price, err := strconv.ParseFloat(hargaKonsumen, 64)
+		if err != nil {
+			return models.CouponQuoteResponse{}, fmt.Errorf("product %s has an invalid price", product.NamaProduk)
+		}
+		lines = append(lines, models.ProductLogProcess{
+			ProductID:  product.ID,
+			VariantID:  item.VariantID,
+			StoreID:    product.Store.ID,
+			CategoryID: product.Category.ID,
+			Kuantitas:  quantity,
+			HargaTotal: price * float64(quantity),
+		})
+	}
+
+	coupon, err := s.usableCoupon(request.Code, userId)
+	if err != nil {
+		return models.CouponQuoteResponse{}, err
+	}
+	categories, err := s.scopeCategories(coupon)
+	if err != nil {
+		return models.CouponQuoteResponse{}, err
+	}
+	discounts, subtotal, total, err := couponDiscounts(coupon, categories, lines)
+	if err != nil {
+		return models.CouponQuoteResponse{}, err
+	}
+
+	response := models.CouponQuoteResponse{
+		Kupon:    toProductCouponResponse(coupon),
+		Subtotal: subtotal,
+		Diskon:   total,
+		Baris:    []models.CouponLineDiscount{},
+	}
+	for i, line := range lines {
+		if discounts[i] > 0 {
+			response.Baris = append(response.Baris, models.CouponLineDiscount{
+				ProductID: line.ProductID,
+				VariantID: line.VariantID,
+				Diskon:    discounts[i],
+			})
+		}
+	}
+	return response, nil
+}
+
+// Apply works out the coupon of a checkout: the redemption to record with the
+// transaction and the discount of each line. The limits are checked again
+// when the transaction is saved.
+func (s *productCouponServiceImpl) Apply(code string, userId uint, lines []models.ProductLogProcess) (models.CouponRedemption, []float64, error) {
+	coupon, err := s.usableCoupon(code, userId)
+	if err != nil {
+		return models.CouponRedemption{}, nil, err
+	}
+	categories, err := s.scopeCategories(coupon)
+	if err != nil {
+		return models.CouponRedemption{}, nil, err
+	}
+	discounts, _, total, err := couponDiscounts(coupon, categories, lines)
+	if err != nil {
+		return models.CouponRedemption{}, nil, err
+	}
+	return models.CouponRedemption{
+		CouponID:  coupon.ID,
+		KodeKupon: coupon.Code,
+		Diskon:    total,
+	}, discounts, nil
+}
+
+// scopeCategories is the category of a category coupon and every category
+// below it; other coupons have none
+func (s *productCouponServiceImpl) scopeCategories(coupon entities.ProductCoupon) (map[uint]bool, error) {
+	if coupon.Cakupan != entities.CouponScopeCategory || coupon.IDCategory == nil {
+		return nil, nil
+	}
+	categories, err := s.categoryRepository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	scope := map[uint]bool{*coupon.IDCategory: true}
+	for _, id := range categoryDescendants(categories, *coupon.IDCategory) {
+		scope[id] = true
+	}
+	return scope, nil
+}
+
+func (s *productCouponServiceImpl) RestoreLegacyPrices() (int64, error) {
+	return s.repository.RestoreLegacyPrices()
+}
+
+// usableCoupon finds the coupon of a code and checks it can be used by the user now
+func (s *productCouponServiceImpl) usableCoupon(code string, userId uint) (entities.ProductCoupon, error) {
+	code = strings.TrimSpace(code)
+	coupon, err := s.repository.FindByCode(code)
+	if err != nil {
+		return entities.ProductCoupon{}, fmt.Errorf("coupon %s not found", code)
+	}
+	if !coupon.IsValidAt(time.Now()) {
+		return entities.ProductCoupon{}, fmt.Errorf("coupon %s is not valid now", coupon.Code)
+	}
+	if coupon.KuotaTotal > 0 && coupon.Terpakai >= coupon.KuotaTotal {
+		return entities.ProductCoupon{}, fmt.Errorf("coupon %s has been used up", coupon.Code)
+	}
+	if coupon.KuotaPerUser > 0 {
+		used, err := s.repository.CountRedemptions(coupon.ID, userId)
+		if err != nil {
+			return entities.ProductCoupon{}, err
+		}
+		if int(used) >= coupon.KuotaPerUser {
+			return entities.ProductCoupon{}, fmt.Errorf("you have already used coupon %s %d times", coupon.Code, coupon.KuotaPerUser)
+		}
+	}
+	return coupon, nil
 }
 
-func (s *productCouponServiceImpl) ValidateCoupon(code string, productId uint) (models.ProductCouponResponse, error) {
-	return s.repository.ValidateCoupon(code, productId)
+// buildCoupon applies a request to coupon. Only the id of the chosen scope is
+// kept, and the usage count is left alone.
+func (s *productCouponServiceImpl) buildCoupon(coupon entities.ProductCoupon, request models.ProductCouponRequest) (entities.ProductCoupon, error) {
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+	if code == "" {
+		return entities.ProductCoupon{}, errors.New("code is required")
+	}
+	taken, err := s.repository.CodeTaken(code, coupon.ID)
+	if err != nil {
+		return entities.ProductCoupon{}, err
+	}
+	if taken {
+		return entities.ProductCoupon{}, fmt.Errorf("coupon code %s is already used", code)
+	}
+	coupon.Code = code
+
+	coupon.Jenis = request.Jenis
+	if coupon.Jenis == "" {
+		coupon.Jenis = entities.CouponPercentage
+	}
+	switch coupon.Jenis {
+	case entities.CouponPercentage:
+		if request.Discount <= 0 || request.Discount > 100 {
+			return entities.ProductCoupon{}, errors.New("a percentage discount must be between 0 and 100")
+		}
+	case entities.CouponFixed:
+		if request.Discount <= 0 {
+			return entities.ProductCoupon{}, errors.New("discount must be more than 0")
+		}
+	default:
+		return entities.ProductCoupon{}, fmt.Errorf("jenis must be %s or %s", entities.CouponPercentage, entities.CouponFixed)
+	}
+	coupon.Discount = request.Discount
+
+	coupon.Cakupan = request.Cakupan
+	if coupon.Cakupan == "" {
+		coupon.Cakupan = entities.CouponScopeProduct
+	}
+	coupon.IDProduk, coupon.IDToko, coupon.IDCategory = nil, nil, nil
+	switch coupon.Cakupan {
+	case entities.CouponScopeProduct:
+		if request.ProductID == 0 {
+			return entities.ProductCoupon{}, errors.New("product_id is required for a product coupon")
+		}
+		if _, err := s.productRepository.FindById(request.ProductID); err != nil {
+			return entities.ProductCoupon{}, err
+		}
+		productID := request.ProductID
+		coupon.IDProduk = &productID
+	case entities.CouponScopeStore:
+		if request.IDToko == 0 {
+			return entities.ProductCoupon{}, errors.New("id_toko is required for a store coupon")
+		}
+		storeID := request.IDToko
+		coupon.IDToko = &storeID
+	case entities.CouponScopeCategory:
+		if request.IDCategory == 0 {
+			return entities.ProductCoupon{}, errors.New("id_category is required for a category coupon")
+		}
+		categoryID := request.IDCategory
+		coupon.IDCategory = &categoryID
+	case entities.CouponScopeCart:
+	default:
+		return entities.ProductCoupon{}, fmt.Errorf("cakupan must be %s, %s, %s or %s",
+			entities.CouponScopeProduct, entities.CouponScopeStore, entities.CouponScopeCategory, entities.CouponScopeCart)
+	}
+
+	if request.MinBelanja < 0 || request.MaksDiskon < 0 || request.KuotaTotal < 0 || request.KuotaPerUser < 0 {
+		return entities.ProductCoupon{}, errors.New("min_belanja, maks_diskon and quotas cannot be negative")
+	}
+	coupon.MinBelanja = request.MinBelanja
+	coupon.MaksDiskon = request.MaksDiskon
+	coupon.KuotaTotal = request.KuotaTotal
+	coupon.KuotaPerUser = request.KuotaPerUser
+
+	if !request.ValidFrom.IsZero() {
+		coupon.ValidFrom = request.ValidFrom
+	} else if coupon.ValidFrom.IsZero() {
+		coupon.ValidFrom = time.Now()
+	}
+	if !request.ValidTo.After(coupon.ValidFrom) {
+		return entities.ProductCoupon{}, errors.New("valid_to must be after valid_from")
+	}
+	coupon.ValidTo = request.ValidTo
+
+	if request.IsActive != nil {
+		coupon.IsActive = *request.IsActive
+	}
+	return coupon, nil
+}
+
+// couponCovers reports whether a checkout line is in the coupon's scope.
+// categories holds a category coupon's category and its descendants.
+func couponCovers(coupon entities.ProductCoupon, categories map[uint]bool, line models.ProductLogProcess) bool {
+	switch coupon.Cakupan {
+	case entities.CouponScopeProduct:
+		return coupon.IDProduk != nil && *coupon.IDProduk == line.ProductID
+	case entities.CouponScopeStore:
+		return coupon.IDToko != nil && *coupon.IDToko == line.StoreID
+	case entities.CouponScopeCategory:
+		return categories[line.CategoryID]
+	case entities.CouponScopeCart:
+		return true
+	}
+	return false
+}
+
+// couponDiscounts works out what coupon takes off each line, with the spend on
+// the lines it covers and the total discount. Lines are counted after the
+// promo discounts already in their Diskon. Those lines must reach the
+// minimum spend; the discount is spread over them by amount in whole rupiah,
+// the last one taking the rounding.
+func couponDiscounts(coupon entities.ProductCoupon, categories map[uint]bool, lines []models.ProductLogProcess) ([]float64, float64, float64, error) {
+	var covered []int
+	var subtotal float64
+	for i, line := range lines {
+		if couponCovers(coupon, categories, line) && line.HargaTotal > line.Diskon {
+			covered = append(covered, i)
+			subtotal += line.HargaTotal - line.Diskon
+		}
+	}
+	if subtotal <= 0 {
+		return nil, 0, 0, fmt.Errorf("coupon %s does not apply to any product in this order", coupon.Code)
+	}
+	if subtotal < coupon.MinBelanja {
+		return nil, 0, 0, fmt.Errorf("coupon %s needs a spend of at least %s on the products it covers", coupon.Code, formatRupiah(coupon.MinBelanja))
+	}
+
+	total := coupon.Discount
+	if coupon.Jenis == entities.CouponPercentage {
+		total = subtotal * coupon.Discount / 100
+		if coupon.MaksDiskon > 0 && total > coupon.MaksDiskon {
+			total = coupon.MaksDiskon
+		}
+	}
+	total = math.Min(math.Round(total), subtotal)
+	return spreadDiscount(total, lines, covered, subtotal), subtotal, total, nil
+}
+
+// spreadDiscount spreads total over the covered lines by what is left to pay
+// on each, in whole rupiah; the last line takes the rounding. subtotal is what
+// is left to pay on the covered lines together.
+func spreadDiscount(total float64, lines []models.ProductLogProcess, covered []int, subtotal float64) []float64 {
+	discounts := make([]float64, len(lines))
+	remaining := total
+	for n, i := range covered {
+		share := math.Floor(total * (lines[i].HargaTotal - lines[i].Diskon) / subtotal)
+		if n == len(covered)-1 {
+			share = remaining
+		}
+		discounts[i] = share
+		remaining -= share
+	}
+	return discounts
+}
+
+func toProductCouponResponses(coupons []entities.ProductCoupon) []models.ProductCouponResponse {
+	responses := []models.ProductCouponResponse{}
+	for _, coupon := range coupons {
+		responses = append(responses, toProductCouponResponse(coupon))
+	}
+	return responses
+}
+
+func toProductCouponResponse(coupon entities.ProductCoupon) models.ProductCouponResponse {
+	var createdAt, updatedAt time.Time
+	if coupon.CreatedAt != nil {
+		createdAt = *coupon.CreatedAt
+	}
+	if coupon.UpdatedAt != nil {
+		updatedAt = *coupon.UpdatedAt
+	}
+
+	return models.ProductCouponResponse{
+		ID:           coupon.ID,
+		ProductID:    coupon.IDProduk,
+		Code:         coupon.Code,
+		Jenis:        coupon.Jenis,
+		Cakupan:      coupon.Cakupan,
+		IDToko:       coupon.IDToko,
+		IDCategory:   coupon.IDCategory,
+		Discount:     coupon.Discount,
+		MinBelanja:   coupon.MinBelanja,
+		MaksDiskon:   coupon.MaksDiskon,
+		KuotaTotal:   coupon.KuotaTotal,
+		KuotaPerUser: coupon.KuotaPerUser,
+		Terpakai:     coupon.Terpakai,
+		ValidFrom:    coupon.ValidFrom,
+		ValidTo:      coupon.ValidTo,
+		IsActive:     coupon.IsActive,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}
 }
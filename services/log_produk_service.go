@@ -41,7 +41,11 @@ func mapStoreToProductLogResponse(store entities.Store) models.StoreResponse {
 func mapCategoryToResponse(category entities.Category) models.CategoryResponse {
 	return models.CategoryResponse{
 		ID:           category.ID,
+		IDParent:     category.IDParent,
 		NamaCategory: category.NamaCategory,
+		Slug:         category.Slug,
+		Icon:         category.Icon,
+		Urutan:       category.Urutan,
 		CreatedAt:    category.CreatedAt,
 		UpdatedAt:    category.UpdatedAt,
 	}
@@ -77,6 +81,9 @@ func mapProductLogToResponse(productLog entities.ProductLog) models.ProductLogRe
 		Slug:          productLog.Slug,
 		HargaReseller: hargaReseller,
 		HargaKonsumen: hargaKonsumen,
+		IDVarian:      productLog.IDVarian,
+		SKU:           productLog.SKU,
+		NamaVarian:    productLog.NamaVarian,
 		Deskripsi:     *productLog.Deskripsi,
 		Store:         mapStoreToProductLogResponse(productLog.Store),
 		Produk: models.ProductLogDetailResponse{
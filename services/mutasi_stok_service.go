@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strings"
+)
+
+type StockMovementService interface {
+	Record(userId uint, isAdmin bool, productID uint, input models.StockMovementRequest) (models.StockMovementResponse, error)
+	History(userId uint, isAdmin bool, productID uint, variantID *uint, kind string, limit int, page int) (models.Pagination, error)
+	Validate(userId uint, isAdmin bool, productID uint) (models.StockValidationResponse, error)
+	OpenBalances() (int64, error)
+}
+
+type stockMovementServiceImpl struct {
+	repository  repositories.StockMovementRepository
+	productRepo repositories.ProductRepository
+	variantRepo repositories.ProductVariantRepository
+}
+
+func NewStockMovementService(
+	repository repositories.StockMovementRepository,
+	productRepo repositories.ProductRepository,
+	variantRepo repositories.ProductVariantRepository,
+) StockMovementService {
+	return &stockMovementServiceImpl{
+		repository:  repository,
+		productRepo: productRepo,
+		variantRepo: variantRepo,
+	}
+}
+
+// Record adds a manual movement. Sales and cancellations come from orders and
+// cannot be recorded by hand.
+func (s *stockMovementServiceImpl) Record(userId uint, isAdmin bool, productID uint, input models.StockMovementRequest) (models.StockMovementResponse, error) {
+	if err := s.checkOwner(userId, isAdmin, productID); err != nil {
+		return models.StockMovementResponse{}, err
+	}
+
+	input.Referensi = strings.TrimSpace(input.Referensi)
+	switch input.Jenis {
+	case entities.StockMovementAdjustment:
+		if input.Jumlah == 0 {
+			return models.StockMovementResponse{}, errors.New("jumlah cannot be zero")
+		}
+		if strings.TrimSpace(input.Catatan) == "" {
+			return models.StockMovementResponse{}, errors.New("catatan is required to explain an adjustment")
+		}
+	case entities.StockMovementReturn:
+		if input.Jumlah <= 0 {
+			return models.StockMovementResponse{}, errors.New("jumlah of a return must be positive")
+		}
+		if input.Referensi == "" {
+			return models.StockMovementResponse{}, errors.New("referensi is required for a return, e.g. the invoice code")
+		}
+	default:
+		return models.StockMovementResponse{}, errors.New("jenis must be adjustment or return")
+	}
+	if len(input.Referensi) > 100 || len(input.Catatan) > 255 {
+		return models.StockMovementResponse{}, errors.New("referensi or catatan is too long")
+	}
+
+	variant, err := resolveVariant(s.variantRepo, productID, input.IDVarian)
+	if err != nil {
+		return models.StockMovementResponse{}, err
+	}
+	movement := entities.StockMovement{
+		IDProduk:  productID,
+		IDGudang:  input.IDGudang,
+		Jenis:     input.Jenis,
+		Jumlah:    input.Jumlah,
+		IDUser:    &userId,
+		Referensi: input.Referensi,
+		Catatan:   strings.TrimSpace(input.Catatan),
+	}
+	if variant != nil {
+		movement.IDVarian = &variant.ID
+	}
+
+	recorded, err := s.repository.Record(movement)
+	if err != nil {
+		return models.StockMovementResponse{}, err
+	}
+	return toStockMovementResponse(recorded), nil
+}
+
+// History lists the movements of a product and its variants, newest first
+func (s *stockMovementServiceImpl) History(userId uint, isAdmin bool, productID uint, variantID *uint, kind string, limit int, page int) (models.Pagination, error) {
+	if err := s.checkOwner(userId, isAdmin, productID); err != nil {
+		return models.Pagination{}, err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+	movements, total, err := s.repository.FindByProductId(productID, variantID, kind, limit, (page-1)*limit)
+	if err != nil {
+		return models.Pagination{}, err
+	}
+
+	rows := []models.StockMovementResponse{}
+	for _, movement := range movements {
+		rows = append(rows, toStockMovementResponse(movement))
+	}
+	return models.Pagination{
+		Limit:      limit,
+		Page:       page,
+		TotalRows:  total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		Rows:       rows,
+	}, nil
+}
+
+// Validate derives the stock from the ledger and compares it with the stored
+// stock. Products with active variants keep their stock in the variants, so
+// only the variants are compared.
+func (s *stockMovementServiceImpl) Validate(userId uint, isAdmin bool, productID uint) (models.StockValidationResponse, error) {
+	product, err := s.productRepo.FindById(productID)
+	if err != nil {
+		return models.StockValidationResponse{}, err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return models.StockValidationResponse{}, errors.New("forbidden")
+	}
+
+	productLedger, variantLedgers, err := s.repository.SumByProduct(productID)
+	if err != nil {
+		return models.StockValidationResponse{}, err
+	}
+	variants, err := s.variantRepo.FindByProductId(productID, false)
+	if err != nil {
+		return models.StockValidationResponse{}, err
+	}
+
+	result := models.StockValidationResponse{IDProduk: productID, Sesuai: true, Saldo: []models.StockBalanceResponse{}}
+	hasActive := false
+	for _, variant := range variants {
+		hasActive = hasActive || variant.IsActive
+	}
+	if hasActive {
+		for _, variant := range variants {
+			id := variant.ID
+			result.Saldo = append(result.Saldo, stockBalance(&id, variant.SKU, variant.Stok, variantLedgers[variant.ID]))
+		}
+	} else {
+		result.Saldo = append(result.Saldo, stockBalance(nil, product.SKU, product.Stok, productLedger))
+	}
+	for _, balance := range result.Saldo {
+		result.Sesuai = result.Sesuai && balance.Sesuai
+	}
+	return result, nil
+}
+
+// OpenBalances starts the ledger of stock that existed before it
+func (s *stockMovementServiceImpl) OpenBalances() (int64, error) {
+	return s.repository.OpenBalances()
+}
+
+func (s *stockMovementServiceImpl) checkOwner(userId uint, isAdmin bool, productID uint) error {
+	product, err := s.productRepo.FindById(productID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+func stockBalance(variantID *uint, sku string, stok int, ledger int) models.StockBalanceResponse {
+	return models.StockBalanceResponse{
+		IDVarian:   variantID,
+		SKU:        sku,
+		Stok:       stok,
+		StokLedger: ledger,
+		Selisih:    stok - ledger,
+		Sesuai:     stok == ledger,
+	}
+}
+
+func toStockMovementResponse(movement entities.StockMovement) models.StockMovementResponse {
+	return models.StockMovementResponse{
+		ID:          movement.ID,
+		IDProduk:    movement.IDProduk,
+		IDVarian:    movement.IDVarian,
+		IDGudang:    movement.IDGudang,
+		Jenis:       movement.Jenis,
+		Jumlah:      movement.Jumlah,
+		StokSebelum: movement.StokSebelum,
+		StokSesudah: movement.StokSesudah,
+		IDUser:      movement.IDUser,
+		Referensi:   movement.Referensi,
+		Catatan:     movement.Catatan,
+		CreatedAt:   movement.CreatedAt,
+	}
+}
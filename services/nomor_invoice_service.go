@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"mini-project-evermos/repositories"
+	"time"
+)
+
+type InvoiceNumberService interface {
+	NextTransactionCode(at time.Time) (string, error)
+	NextStoreOrderCode(storeID uint, at time.Time) (string, error)
+}
+
+type invoiceNumberServiceImpl struct {
+	repository repositories.InvoiceSequenceRepository
+}
+
+func NewInvoiceNumberService(repository repositories.InvoiceSequenceRepository) InvoiceNumberService {
+	return &invoiceNumberServiceImpl{repository}
+}
+
+// NextTransactionCode issues INV-YYYYMMDD-000001, restarting every day
+func (s *invoiceNumberServiceImpl) NextTransactionCode(at time.Time) (string, error) {
+	day := at.Format("20060102")
+	number, err := s.repository.Next("INV-" + day)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate invoice number: %v", err)
+	}
+	return fmt.Sprintf("INV-%s-%06d", day, number), nil
+}
+
+// NextStoreOrderCode issues INV-YYYYMMDD-T<store>-00001, restarting every day per store
+func (s *invoiceNumberServiceImpl) NextStoreOrderCode(storeID uint, at time.Time) (string, error) {
+	day := at.Format("20060102")
+	number, err := s.repository.Next(fmt.Sprintf("INV-%s-T%d", day, storeID))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate store invoice number: %v", err)
+	}
+	return fmt.Sprintf("INV-%s-T%d-%05d", day, storeID, number), nil
+}
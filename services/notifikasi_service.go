@@ -1,17 +1,18 @@
 package services
 
 import (
+	"errors"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
 )
 
 type NotificationService interface {
-	GetAll() ([]models.NotificationResponse, error)
-	GetById(id uint) (models.NotificationResponse, error)
+	GetAll(userId uint) ([]models.NotificationResponse, error)
+	GetById(id uint, userId uint) (models.NotificationResponse, error)
 	Create(input models.NotificationRequest) (models.NotificationResponse, error)
-	Update(id uint, input models.NotificationRequest) (models.NotificationResponse, error)
-	Delete(id uint) (models.NotificationResponse, error)
+	Update(id uint, userId uint, input models.NotificationRequest) (models.NotificationResponse, error)
+	Delete(id uint, userId uint) (models.NotificationResponse, error)
 }
 
 type notificationServiceImpl struct {
@@ -22,8 +23,9 @@ func NewNotificationService(repository repositories.NotificationRepository) Noti
 	return &notificationServiceImpl{repository}
 }
 
-func (s *notificationServiceImpl) GetAll() ([]models.NotificationResponse, error) {
-	notifications, err := s.repository.FindAll()
+// GetAll lists the notifications for every user and those for userId only
+func (s *notificationServiceImpl) GetAll(userId uint) ([]models.NotificationResponse, error) {
+	notifications, err := s.repository.FindForUser(userId)
 	if err != nil {
 		return nil, err
 	}
@@ -35,8 +37,8 @@ func (s *notificationServiceImpl) GetAll() ([]models.NotificationResponse, error
 	return responses, nil
 }
 
-func (s *notificationServiceImpl) GetById(id uint) (models.NotificationResponse, error) {
-	notification, err := s.repository.FindById(id)
+func (s *notificationServiceImpl) GetById(id uint, userId uint) (models.NotificationResponse, error) {
+	notification, err := s.findForUser(id, userId)
 	if err != nil {
 		return models.NotificationResponse{}, err
 	}
@@ -56,7 +58,11 @@ func (s *notificationServiceImpl) Create(input models.NotificationRequest) (mode
 	return toNotificationResponse(result), nil
 }
 
-func (s *notificationServiceImpl) Update(id uint, input models.NotificationRequest) (models.NotificationResponse, error) {
+func (s *notificationServiceImpl) Update(id uint, userId uint, input models.NotificationRequest) (models.NotificationResponse, error) {
+	if _, err := s.findForUser(id, userId); err != nil {
+		return models.NotificationResponse{}, err
+	}
+
 	notification := entities.Notification{
 		Pesan: input.Pesan,
 	}
@@ -69,9 +75,9 @@ func (s *notificationServiceImpl) Update(id uint, input models.NotificationReque
 	return toNotificationResponse(result), nil
 }
 
-func (s *notificationServiceImpl) Delete(id uint) (models.NotificationResponse, error) {
+func (s *notificationServiceImpl) Delete(id uint, userId uint) (models.NotificationResponse, error) {
 	// Get notification before deleting
-	notification, err := s.repository.FindById(id)
+	notification, err := s.findForUser(id, userId)
 	if err != nil {
 		return models.NotificationResponse{}, err
 	}
@@ -85,9 +91,24 @@ func (s *notificationServiceImpl) Delete(id uint) (models.NotificationResponse,
 	return toNotificationResponse(notification), nil
 }
 
+// findForUser loads a notification unless it was sent to another user
+func (s *notificationServiceImpl) findForUser(id uint, userId uint) (entities.Notification, error) {
+	notification, err := s.repository.FindById(id)
+	if err != nil {
+		return entities.Notification{}, err
+	}
+	if notification.IDUser != nil && *notification.IDUser != userId {
+		return entities.Notification{}, errors.New("forbidden")
+	}
+	return notification, nil
+}
+
 func toNotificationResponse(notification entities.Notification) models.NotificationResponse {
 	return models.NotificationResponse{
 		ID:        notification.ID,
+		IDUser:    notification.IDUser,
+		Jenis:     notification.Jenis,
+		IDProduk:  notification.IDProduk,
 		Pesan:     notification.Pesan,
 		CreatedAt: notification.CreatedAt,
 		UpdatedAt: notification.UpdatedAt,
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"sort"
+	"strings"
+)
+
+// volumetricDivisor converts cm³ to chargeable kilograms, the common courier standard
+const volumetricDivisor = 6000
+
+type ShippingService interface {
+	GetAllRates() ([]models.ShippingRateResponse, error)
+	GetRateById(id uint) (models.ShippingRateResponse, error)
+	CreateRate(input models.ShippingRateRequest) (models.ShippingRateResponse, error)
+	UpdateRate(id uint, input models.ShippingRateRequest) (models.ShippingRateResponse, error)
+	DeleteRate(id uint) (models.ShippingRateResponse, error)
+	Quote(input models.ShippingQuoteRequest) ([]models.ShippingQuoteResponse, error)
+	QuoteFor(input models.ShippingQuoteRequest, kurir string, layanan string) (models.ShippingQuoteResponse, error)
+}
+
+type shippingServiceImpl struct {
+	repository        repositories.ShippingRateRepository
+	productRepository repositories.ProductRepository
+	addressRepository repositories.AddressRepository
+	warehouseRepo     repositories.WarehouseRepository
+}
+
+func NewShippingService(
+	repository repositories.ShippingRateRepository,
+	productRepository repositories.ProductRepository,
+	addressRepository repositories.AddressRepository,
+	warehouseRepo repositories.WarehouseRepository,
+) ShippingService {
+	return &shippingServiceImpl{
+		repository:        repository,
+		productRepository: productRepository,
+		addressRepository: addressRepository,
+		warehouseRepo:     warehouseRepo,
+	}
+}
+
+func (s *shippingServiceImpl) GetAllRates() ([]models.ShippingRateResponse, error) {
+	rates, err := s.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.ShippingRateResponse{}
+	for _, rate := range rates {
+		responses = append(responses, toShippingRateResponse(rate))
+	}
+	return responses, nil
+}
+
+func (s *shippingServiceImpl) GetRateById(id uint) (models.ShippingRateResponse, error) {
+	rate, err := s.repository.FindById(id)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+	return toShippingRateResponse(rate), nil
+}
+
+func (s *shippingServiceImpl) CreateRate(input models.ShippingRateRequest) (models.ShippingRateResponse, error) {
+	rate, err := buildShippingRate(input)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+
+	result, err := s.repository.Create(rate)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+	return toShippingRateResponse(result), nil
+}
+
+func (s *shippingServiceImpl) UpdateRate(id uint, input models.ShippingRateRequest) (models.ShippingRateResponse, error) {
+	if _, err := s.repository.FindById(id); err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+
+	rate, err := buildShippingRate(input)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+
+	result, err := s.repository.Update(id, rate)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+	return toShippingRateResponse(result), nil
+}
+
+func (s *shippingServiceImpl) DeleteRate(id uint) (models.ShippingRateResponse, error) {
+	rate, err := s.repository.FindById(id)
+	if err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return models.ShippingRateResponse{}, err
+	}
+	return toShippingRateResponse(rate), nil
+}
+
+// Quote returns every courier/service that can deliver all items of the cart,
+// cheapest first. Items are grouped per store, or per warehouse of the store,
+// because each ships its own parcel.
+func (s *shippingServiceImpl) Quote(input models.ShippingQuoteRequest) ([]models.ShippingQuoteResponse, error) {
+	if len(input.Products) == 0 {
+		return nil, errors.New("products are required")
+	}
+
+	address, err := s.addressRepository.FindById(input.AlamatPengiriman)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address details: %v", err)
+	}
+	if address.IDKota == "" {
+		return nil, errors.New("shipping address has no city")
+	}
+
+	parcels, err := s.buildParcels(input.Products)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the rates available to every parcel, keyed by courier and service
+	options := map[string]*models.ShippingQuoteResponse{}
+	for i, parcel := range parcels {
+		rates, err := s.repository.FindByRoute(parcel.originCityID, address.IDKota)
+		if err != nil {
+			return nil, err
+		}
+
+		available := map[string]entities.ShippingRate{}
+		for _, rate := range rates {
+			key := shippingOptionKey(rate.Kurir, rate.Layanan)
+			// An exact destination always wins over the "*" flat rate
+			if existing, ok := available[key]; ok && existing.IDKotaTujuan != "*" {
+				continue
+			}
+			available[key] = rate
+		}
+
+		for key, rate := range available {
+			option, ok := options[key]
+			if !ok {
+				if i > 0 {
+					continue
+				}
+				option = &models.ShippingQuoteResponse{
+					Kurir:        rate.Kurir,
+					Layanan:      rate.Layanan,
+					EstimasiHari: rate.EstimasiHari,
+				}
+				options[key] = option
+			}
+
+			billedKg := chargeableKilograms(parcel.grams)
+			fee := float64(billedKg) * rate.HargaPerKg
+			option.OngkosKirim += fee
+			option.Rincian = append(option.Rincian, models.ShippingParcelQuote{
+				IDToko:       parcel.storeID,
+				IDGudang:     parcel.warehouseID,
+				Baris:        parcel.lines,
+				IDKotaAsal:   parcel.originCityID,
+				IDKotaTujuan: address.IDKota,
+				BeratGram:    parcel.grams,
+				BeratTagihKg: billedKg,
+				HargaPerKg:   rate.HargaPerKg,
+				OngkosKirim:  fee,
+			})
+		}
+
+		// Drop options that cannot serve this parcel
+		for key := range options {
+			if _, ok := available[key]; !ok {
+				delete(options, key)
+			}
+		}
+	}
+
+	responses := []models.ShippingQuoteResponse{}
+	for _, option := range options {
+		responses = append(responses, *option)
+	}
+	sort.Slice(responses, func(a, b int) bool {
+		if responses[a].OngkosKirim == responses[b].OngkosKirim {
+			return shippingOptionKey(responses[a].Kurir, responses[a].Layanan) < shippingOptionKey(responses[b].Kurir, responses[b].Layanan)
+		}
+		return responses[a].OngkosKirim < responses[b].OngkosKirim
+	})
+	return responses, nil
+}
+
+// QuoteFor returns the quote for the courier/service chosen at checkout
+func (s *shippingServiceImpl) QuoteFor(input models.ShippingQuoteRequest, kurir string, layanan string) (models.ShippingQuoteResponse, error) {
+	options, err := s.Quote(input)
+	if err != nil {
+		return models.ShippingQuoteResponse{}, err
+	}
+
+	for _, option := range options {
+		if shippingOptionKey(option.Kurir, option.Layanan) == shippingOptionKey(kurir, layanan) {
+			return option, nil
+		}
+	}
+	return models.ShippingQuoteResponse{}, fmt.Errorf("courier %s %s is not available for this address", kurir, layanan)
+}
+
+type shippingParcel struct {
+	storeID      uint
+	warehouseID  *uint
+	originCityID string
+	grams        int
+	lines        []int
+}
+
+// buildParcels groups the items into one parcel per store, or per warehouse
+// for stores that ship from several warehouses
+func (s *shippingServiceImpl) buildParcels(items []models.TransactionProduct) ([]shippingParcel, error) {
+	var ids []uint
+	for _, item := range items {
+		// Weights are multiplied by the quantity
+		if item.Quantity < 1 {
+			return nil, fmt.Errorf("quantity of product %d must be at least 1", item.ProductID)
+		}
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := s.productRepository.FindWithStoreByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	productMap := map[uint]entities.Product{}
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	allocation, err := allocateWarehouses(s.warehouseRepo, items, productMap)
+	if err != nil {
+		return nil, err
+	}
+
+	type parcelKey struct{ store, warehouse uint }
+	var parcels []shippingParcel
+	parcelIndex := map[parcelKey]int{}
+	for i, item := range items {
+		product, ok := productMap[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product with ID %d not found", item.ProductID)
+		}
+
+		key := parcelKey{store: product.IDToko}
+		origin := product.Store.IDKota
+		var warehouseID *uint
+		if warehouse := allocation[i]; warehouse != nil {
+			key.warehouse = warehouse.ID
+			origin = warehouse.IDKota
+			warehouseID = &warehouse.ID
+		}
+		if origin == "" {
+			return nil, fmt.Errorf("store %s has no origin city", product.Store.NamaToko)
+		}
+
+		idx, ok := parcelIndex[key]
+		if !ok {
+			parcels = append(parcels, shippingParcel{
+				storeID:      product.IDToko,
+				warehouseID:  warehouseID,
+				originCityID: origin,
+			})
+			idx = len(parcels) - 1
+			parcelIndex[key] = idx
+		}
+		parcels[idx].grams += chargeableGrams(product) * item.Quantity
+		parcels[idx].lines = append(parcels[idx].lines, i)
+	}
+	return parcels, nil
+}
+
+// chargeableGrams is the larger of the actual and volumetric weight of one unit
+func chargeableGrams(product entities.Product) int {
+	volumetric := product.Panjang * product.Lebar * product.Tinggi * 1000 / volumetricDivisor
+	if volumetric > product.Berat {
+		return volumetric
+	}
+	return product.Berat
+}
+
+// chargeableKilograms rounds a parcel weight up to whole kilograms, minimum 1 kg
+func chargeableKilograms(grams int) int {
+	kg := int(math.Ceil(float64(grams) / 1000))
+	if kg < 1 {
+		return 1
+	}
+	return kg
+}
+
+func shippingOptionKey(kurir string, layanan string) string {
+	return strings.ToLower(kurir) + "|" + strings.ToLower(layanan)
+}
+
+func buildShippingRate(input models.ShippingRateRequest) (entities.ShippingRate, error) {
+	if input.Kurir == "" || input.Layanan == "" {
+		return entities.ShippingRate{}, errors.New("kurir and layanan are required")
+	}
+	if input.IDKotaAsal == "" || input.IDKotaTujuan == "" {
+		return entities.ShippingRate{}, errors.New("id_kota_asal and id_kota_tujuan are required")
+	}
+	if input.HargaPerKg <= 0 {
+		return entities.ShippingRate{}, errors.New("harga_per_kg must be greater than zero")
+	}
+
+	isActive := true
+	if input.IsActive != nil {
+		isActive = *input.IsActive
+	}
+
+	return entities.ShippingRate{
+		Kurir:        strings.ToLower(input.Kurir),
+		Layanan:      strings.ToUpper(input.Layanan),
+		IDKotaAsal:   input.IDKotaAsal,
+		IDKotaTujuan: input.IDKotaTujuan,
+		HargaPerKg:   input.HargaPerKg,
+		EstimasiHari: input.EstimasiHari,
+		IsActive:     isActive,
+	}, nil
+}
+
+func toShippingRateResponse(rate entities.ShippingRate) models.ShippingRateResponse {
+	return models.ShippingRateResponse{
+		ID:           rate.ID,
+		Kurir:        rate.Kurir,
+		Layanan:      rate.Layanan,
+		IDKotaAsal:   rate.IDKotaAsal,
+		IDKotaTujuan: rate.IDKotaTujuan,
+		HargaPerKg:   rate.HargaPerKg,
+		EstimasiHari: rate.EstimasiHari,
+		IsActive:     rate.IsActive,
+		CreatedAt:    rate.CreatedAt,
+		UpdatedAt:    rate.UpdatedAt,
+	}
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"errors"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/courier"
+	"strings"
+	"time"
+)
+
+const (
+	checkpointSourceSeller  = "seller"
+	checkpointSourceCourier = "courier"
+)
+
+type ShipmentService interface {
+	Ship(userID uint, trxDetailID uint, input models.ShipmentRequest) (models.ShipmentResponse, error)
+	AddCheckpoint(userID uint, shipmentID uint, input models.ShipmentCheckpointRequest) (models.ShipmentResponse, error)
+	SyncFromCourier(userID uint, shipmentID uint) (models.ShipmentResponse, error)
+	GetTracking(userID uint, trxID uint) (models.TransactionTrackingResponse, error)
+}
+
+type shipmentServiceImpl struct {
+	repository            repositories.ShipmentRepository
+	trxDetailRepository   repositories.TransactionDetailRepository
+	transactionRepository repositories.TransactionRepository
+	userRepository        repositories.UserRepository
+	couriers              *courier.Registry
+}
+
+func NewShipmentService(
+	repository repositories.ShipmentRepository,
+	trxDetailRepository repositories.TransactionDetailRepository,
+	transactionRepository repositories.TransactionRepository,
+	userRepository repositories.UserRepository,
+	couriers *courier.Registry,
+) ShipmentService {
+	return &shipmentServiceImpl{
+		repository:            repository,
+		trxDetailRepository:   trxDetailRepository,
+		transactionRepository: transactionRepository,
+		userRepository:        userRepository,
+		couriers:              couriers,
+	}
+}
+
+// Ship attaches courier and airway bill number to a trx_detail line owned by the seller's store
+func (s *shipmentServiceImpl) Ship(userID uint, trxDetailID uint, input models.ShipmentRequest) (models.ShipmentResponse, error) {
+	if strings.TrimSpace(input.NoResi) == "" {
+		return models.ShipmentResponse{}, errors.New("no_resi is required")
+	}
+
+	detail, err := s.trxDetailRepository.FindById(trxDetailID)
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+	if err := s.authorizeSeller(userID, detail.Store); err != nil {
+		return models.ShipmentResponse{}, err
+	}
+
+	if _, err := s.repository.FindByTrxDetailId(trxDetailID); err == nil {
+		return models.ShipmentResponse{}, errors.New("transaction detail has already been shipped")
+	}
+
+	// Default to the courier the buyer picked at checkout
+	kurir := input.Kurir
+	if kurir == "" {
+		kurir = detail.Transaction.Kurir
+	}
+	layanan := input.Layanan
+	if layanan == "" {
+		layanan = detail.Transaction.LayananKirim
+	}
+	if kurir == "" {
+		return models.ShipmentResponse{}, errors.New("kurir is required")
+	}
+
+	keterangan := input.Keterangan
+	if keterangan == "" {
+		keterangan = "Paket diserahkan ke kurir"
+	}
+
+	now := time.Now()
+	shipment := entities.Shipment{
+		IDTrx:       detail.IDTrx,
+		IDTrxDetail: detail.ID,
+		Kurir:       strings.ToLower(kurir),
+		Layanan:     strings.ToUpper(layanan),
+		NoResi:      strings.TrimSpace(input.NoResi),
+		Status:      courier.StatusShipped,
+		ShippedAt:   now,
+	}
+	checkpoint := entities.ShipmentCheckpoint{
+		Status:     courier.StatusShipped,
+		Keterangan: keterangan,
+		Lokasi:     input.Lokasi,
+		Sumber:     checkpointSourceSeller,
+		Waktu:      now,
+	}
+
+	result, err := s.repository.Create(shipment, checkpoint)
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+	return toShipmentResponse(result), nil
+}
+
+// AddCheckpoint lets the seller post a tracking update manually
+func (s *shipmentServiceImpl) AddCheckpoint(userID uint, shipmentID uint, input models.ShipmentCheckpointRequest) (models.ShipmentResponse, error) {
+	if !isValidShipmentStatus(input.Status) {
+		return models.ShipmentResponse{}, errors.New("status must be one of shipped, in_transit, delivered")
+	}
+
+	shipment, err := s.findOwnedShipment(userID, shipmentID)
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+	if shipment.Status == courier.StatusDelivered {
+		return models.ShipmentResponse{}, errors.New("shipment has already been delivered")
+	}
+
+	waktu := time.Now()
+	if input.Waktu != nil {
+		waktu = *input.Waktu
+	}
+
+	checkpoint := entities.ShipmentCheckpoint{
+		Status:     input.Status,
+		Keterangan: input.Keterangan,
+		Lokasi:     input.Lokasi,
+		Sumber:     checkpointSourceSeller,
+		Waktu:      waktu,
+	}
+	applyShipmentStatus(&shipment, input.Status, waktu)
+
+	result, err := s.repository.AddCheckpoints(shipment, []entities.ShipmentCheckpoint{checkpoint})
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+	return toShipmentResponse(result), nil
+}
+
+// SyncFromCourier pulls the latest checkpoints from the courier adapter
+func (s *shipmentServiceImpl) SyncFromCourier(userID uint, shipmentID uint) (models.ShipmentResponse, error) {
+	shipment, err := s.findOwnedShipment(userID, shipmentID)
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+
+	result, err := s.sync(shipment)
+	if err != nil {
+		return models.ShipmentResponse{}, err
+	}
+	return toShipmentResponse(result), nil
+}
+
+// GetTracking returns every shipment of a transaction for its buyer, refreshed from the couriers
+func (s *shipmentServiceImpl) GetTracking(userID uint, trxID uint) (models.TransactionTrackingResponse, error) {
+	transaction, err := s.transactionRepository.FindById(trxID)
+	if err != nil {
+		return models.TransactionTrackingResponse{}, err
+	}
+
+	user, err := s.userRepository.FindById(userID)
+	if err != nil {
+		return models.TransactionTrackingResponse{}, err
+	}
+	if !user.IsAdmin && transaction.IDUser != userID {
+		return models.TransactionTrackingResponse{}, errors.New("forbidden")
+	}
+
+	shipments, err := s.repository.FindByTrxId(trxID)
+	if err != nil {
+		return models.TransactionTrackingResponse{}, err
+	}
+
+	response := models.TransactionTrackingResponse{
+		IDTrx:       transaction.ID,
+		KodeInvoice: transaction.KodeInvoice,
+		Pengiriman:  []models.ShipmentResponse{},
+	}
+	for _, shipment := range shipments {
+		if shipment.Status != courier.StatusDelivered {
+			// A courier outage should not hide the timeline we already have
+			if synced, err := s.sync(shipment); err == nil {
+				shipment = synced
+			}
+		}
+		response.Pengiriman = append(response.Pengiriman, toShipmentResponse(shipment))
+	}
+	return response, nil
+}
+
+func (s *shipmentServiceImpl) sync(shipment entities.Shipment) (entities.Shipment, error) {
+	tracker := s.couriers.Get(shipment.Kurir)
+	if tracker == nil {
+		return shipment, errors.New("tracking unavailable for courier " + shipment.Kurir)
+	}
+
+	events, err := tracker.Track(courier.Shipment{
+		Kurir:     shipment.Kurir,
+		Layanan:   shipment.Layanan,
+		NoResi:    shipment.NoResi,
+		ShippedAt: shipment.ShippedAt,
+	})
+	if err != nil {
+		return shipment, err
+	}
+
+	// Skip events already stored from a previous sync
+	seen := map[string]bool{}
+	for _, checkpoint := range shipment.Checkpoints {
+		if checkpoint.Sumber == checkpointSourceCourier {
+			seen[checkpointKey(checkpoint.Status, checkpoint.Keterangan, checkpoint.Waktu)] = true
+		}
+	}
+
+	var checkpoints []entities.ShipmentCheckpoint
+	for _, event := range events {
+		if !isValidShipmentStatus(event.Status) || seen[checkpointKey(event.Status, event.Keterangan, event.Waktu)] {
+			continue
+		}
+		checkpoints = append(checkpoints, entities.ShipmentCheckpoint{
+			Status:     event.Status,
+			Keterangan: event.Keterangan,
+			Lokasi:     event.Lokasi,
+			Sumber:     checkpointSourceCourier,
+			Waktu:      event.Waktu,
+		})
+		if shipment.Status != courier.StatusDelivered {
+			applyShipmentStatus(&shipment, event.Status, event.Waktu)
+		}
+	}
+	if len(checkpoints) == 0 {
+		return shipment, nil
+	}
+
+	return s.repository.AddCheckpoints(shipment, checkpoints)
+}
+
+func (s *shipmentServiceImpl) findOwnedShipment(userID uint, shipmentID uint) (entities.Shipment, error) {
+	shipment, err := s.repository.FindById(shipmentID)
+	if err != nil {
+		return entities.Shipment{}, err
+	}
+
+	detail, err := s.trxDetailRepository.FindById(shipment.IDTrxDetail)
+	if err != nil {
+		return entities.Shipment{}, err
+	}
+	if err := s.authorizeSeller(userID, detail.Store); err != nil {
+		return entities.Shipment{}, err
+	}
+	return shipment, nil
+}
+
+func (s *shipmentServiceImpl) authorizeSeller(userID uint, store entities.Store) error {
+	if store.IDUser == userID {
+		return nil
+	}
+
+	user, err := s.userRepository.FindById(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsAdmin {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+func applyShipmentStatus(shipment *entities.Shipment, status string, waktu time.Time) {
+	shipment.Status = status
+	if status == courier.StatusDelivered {
+		shipment.DeliveredAt = &waktu
+	}
+}
+
+func isValidShipmentStatus(status string) bool {
+	switch status {
+	case courier.StatusShipped, courier.StatusInTransit, courier.StatusDelivered:
+		return true
+	}
+	return false
+}
+
+func checkpointKey(status string, keterangan string, waktu time.Time) string {
+	return status + "|" + keterangan + "|" + waktu.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+func toShipmentResponse(shipment entities.Shipment) models.ShipmentResponse {
+	checkpoints := []models.ShipmentCheckpointResponse{}
+	for _, checkpoint := range shipment.Checkpoints {
+		checkpoints = append(checkpoints, models.ShipmentCheckpointResponse{
+			ID:         checkpoint.ID,
+			Status:     checkpoint.Status,
+			Keterangan: checkpoint.Keterangan,
+			Lokasi:     checkpoint.Lokasi,
+			Sumber:     checkpoint.Sumber,
+			Waktu:      checkpoint.Waktu,
+		})
+	}
+
+	return models.ShipmentResponse{
+		ID:          shipment.ID,
+		IDTrx:       shipment.IDTrx,
+		IDTrxDetail: shipment.IDTrxDetail,
+		Kurir:       shipment.Kurir,
+		Layanan:     shipment.Layanan,
+		NoResi:      shipment.NoResi,
+		Status:      shipment.Status,
+		ShippedAt:   shipment.ShippedAt,
+		DeliveredAt: shipment.DeliveredAt,
+		Checkpoints: checkpoints,
+		CreatedAt:   shipment.CreatedAt,
+		UpdatedAt:   shipment.UpdatedAt,
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"time"
+)
+
+type StockAlertService interface {
+	SetThreshold(userId uint, isAdmin bool, productID uint, input models.LowStockThresholdRequest) (models.LowStockThresholdResponse, error)
+	Subscribe(userId uint, productID uint, input models.StockSubscriptionRequest) (models.StockSubscriptionResponse, error)
+	Unsubscribe(userId uint, productID uint, variantID *uint) error
+	GetMine(userId uint) ([]models.StockSubscriptionResponse, error)
+}
+
+type stockAlertServiceImpl struct {
+	subscriptionRepo repositories.StockSubscriptionRepository
+	productRepo      repositories.ProductRepository
+	variantRepo      repositories.ProductVariantRepository
+}
+
+func NewStockAlertService(
+	subscriptionRepo repositories.StockSubscriptionRepository,
+	productRepo repositories.ProductRepository,
+	variantRepo repositories.ProductVariantRepository,
+) StockAlertService {
+	return &stockAlertServiceImpl{
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+		variantRepo:      variantRepo,
+	}
+}
+
+// SetThreshold sets the low-stock threshold of a product. The owner is warned
+// the next time the stock falls to it, not when it is already below.
+func (s *stockAlertServiceImpl) SetThreshold(userId uint, isAdmin bool, productID uint, input models.LowStockThresholdRequest) (models.LowStockThresholdResponse, error) {
+	product, err := s.productRepo.FindById(productID)
+	if err != nil {
+		return models.LowStockThresholdResponse{}, err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return models.LowStockThresholdResponse{}, errors.New("forbidden")
+	}
+	if input.BatasStok != nil && *input.BatasStok < 0 {
+		return models.LowStockThresholdResponse{}, errors.New("batas_stok cannot be negative")
+	}
+
+	if err := s.productRepo.UpdateLowStockThreshold(productID, input.BatasStok); err != nil {
+		return models.LowStockThresholdResponse{}, err
+	}
+	return models.LowStockThresholdResponse{
+		IDProduk:  productID,
+		Stok:      product.Stok,
+		BatasStok: input.BatasStok,
+	}, nil
+}
+
+// Subscribe asks to be notified once a sold-out product or variant is back in
+// stock. Subscribing twice returns the subscription that is still waiting.
+func (s *stockAlertServiceImpl) Subscribe(userId uint, productID uint, input models.StockSubscriptionRequest) (models.StockSubscriptionResponse, error) {
+	product, err := s.productRepo.FindById(productID)
+	if err != nil {
+		return models.StockSubscriptionResponse{}, err
+	}
+	if !productPublished(product, time.Now()) {
+		return models.StockSubscriptionResponse{}, fmt.Errorf("product %s is not available", product.NamaProduk)
+	}
+
+	variant, err := resolveVariant(s.variantRepo, productID, input.IDVarian)
+	if err != nil {
+		return models.StockSubscriptionResponse{}, err
+	}
+	stock := product.Stok
+	var variantID *uint
+	if variant != nil {
+		stock = variant.Stok
+		variantID = &variant.ID
+	}
+	if stock > 0 {
+		return models.StockSubscriptionResponse{}, errors.New("the product is in stock")
+	}
+
+	subscription, err := s.subscriptionRepo.FindPending(userId, productID, variantID)
+	if err != nil {
+		subscription, err = s.subscriptionRepo.Create(entities.StockSubscription{
+			IDUser:   userId,
+			IDProduk: productID,
+			IDVarian: variantID,
+		})
+		if err != nil {
+			return models.StockSubscriptionResponse{}, err
+		}
+	}
+
+	response := toStockSubscriptionResponse(subscription)
+	response.NamaProduk = product.NamaProduk
+	response.Slug = product.Slug
+	return response, nil
+}
+
+func (s *stockAlertServiceImpl) Unsubscribe(userId uint, productID uint, variantID *uint) error {
+	deleted, err := s.subscriptionRepo.DeletePending(userId, productID, variantID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// GetMine lists the buyer's subscriptions, the ones still waiting and the ones already notified
+func (s *stockAlertServiceImpl) GetMine(userId uint) ([]models.StockSubscriptionResponse, error) {
+	subscriptions, err := s.subscriptionRepo.FindByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []models.StockSubscriptionResponse{}
+	for _, subscription := range subscriptions {
+		response := toStockSubscriptionResponse(subscription)
+		response.NamaProduk = subscription.Product.NamaProduk
+		response.Slug = subscription.Product.Slug
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func toStockSubscriptionResponse(subscription entities.StockSubscription) models.StockSubscriptionResponse {
+	return models.StockSubscriptionResponse{
+		ID:             subscription.ID,
+		IDProduk:       subscription.IDProduk,
+		IDVarian:       subscription.IDVarian,
+		DiberitahuPada: subscription.DiberitahuPada,
+		CreatedAt:      subscription.CreatedAt,
+	}
+}
@@ -7,66 +7,232 @@ import (
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/models/responder" // Updated import path
 	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/search"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type ProductService interface {
-	FindAllPagination(limit int, page int, keyword string) (models.Pagination, error)
+	FindAllPagination(limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error)
+	FindByCategorySlug(slug string, limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error)
+	FindMine(userId uint, limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error)
 	FindById(id uint) (models.ProductResponse, error)
+	FindVisibleById(id uint, viewer models.ProductViewer) (models.ProductResponse, error)
+	FindBySlug(slug string, viewer models.ProductViewer) (models.ProductResponse, string, error)
 	Create(input models.ProductRequest, userId uint) (models.ProductResponse, error)
 	Update(id uint, input models.ProductRequest, userId uint) (models.ProductResponse, error)
 	Delete(id uint, userId uint) (models.ProductResponse, error) // Changed return type
 	FindByCategory(categoryID string) ([]models.ProductResponse, error)
-	SearchProducts(query string) ([]models.ProductResponse, error)
+	SearchProducts(query string, mode string, limit int, page int) (models.Pagination, error)
 	GetRelatedProducts(id uint) ([]models.ProductResponse, error)
 	SaveProductPhoto(photo entities.FotoProduk) (models.FotoProdukResponse, error) // Changed return type
 	GetProductPhoto(id uint) (models.FotoProdukResponse, error)                    // Changed return type
+	UpdateStatus(id uint, userId uint, isAdmin bool, input models.ProductStatusRequest) (models.ProductResponse, error)
+	Ban(id uint, reason string) (models.ProductResponse, error)
+	Unban(id uint) (models.ProductResponse, error)
+	PublishScheduled() (int, error)
 }
 
 type productServiceImpl struct {
 	repository   repositories.ProductRepository
 	storeRepo    repositories.StoreRepository
 	categoryRepo repositories.CategoryRepository
+	variantRepo  repositories.ProductVariantRepository
+	suggestions  SearchSuggestionService
 }
 
 func NewProductService(
 	repository repositories.ProductRepository,
 	storeRepo repositories.StoreRepository,
 	categoryRepo repositories.CategoryRepository,
+	variantRepo repositories.ProductVariantRepository,
+	suggestions SearchSuggestionService,
 ) ProductService {
 	return &productServiceImpl{
 		repository:   repository,
 		storeRepo:    storeRepo,
 		categoryRepo: categoryRepo,
+		variantRepo:  variantRepo,
+		suggestions:  suggestions,
 	}
 }
 
-func (service *productServiceImpl) FindAllPagination(limit int, page int, keyword string) (models.Pagination, error) {
+func (service *productServiceImpl) FindAllPagination(limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error) {
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return models.ProductListResponse{}, errors.New("harga_min cannot be greater than harga_max")
+	}
+	if filter.MinRating < 0 || filter.MinRating > 5 {
+		return models.ProductListResponse{}, errors.New("rating_min must be between 0 and 5")
+	}
+	if filter.Status != "" {
+		if !filter.AnyStatus {
+			return models.ProductListResponse{}, errors.New("only admins and sellers can filter by status")
+		}
+		if !validProductStatus(filter.Status) {
+			return models.ProductListResponse{}, errors.New("status must be draft, published, archived or banned")
+		}
+	}
+
+	// A category includes the products of all its sub-categories
+	if filter.CategoryID != 0 {
+		categories, err := service.categoryRepo.FindAll()
+		if err != nil {
+			return models.ProductListResponse{}, err
+		}
+		filter.CategoryIDs = append([]uint{filter.CategoryID}, categoryDescendants(categories, filter.CategoryID)...)
+	}
+
 	request := responder.Pagination{}
 	request.Limit = limit
 	request.Page = page
-	request.Keyword = keyword
+	request.Keyword = filter.Keyword
 
-	response, err := service.repository.FindAllPagination(request)
+	response, err := service.repository.FindAllPagination(request, filter)
 	if err != nil {
-		return models.Pagination{}, err
+		return models.ProductListResponse{}, err
+	}
+
+	facets, err := service.repository.FindFacets(filter)
+	if err != nil {
+		return models.ProductListResponse{}, err
 	}
 
-	return models.Pagination{
-		Limit:      response.Limit,
-		Page:       response.Page,
-		TotalRows:  response.TotalRows,
-		TotalPages: response.TotalPages,
-		Rows:       response.Rows,
-		Keyword:    response.Keyword,
+	return models.ProductListResponse{
+		Pagination: models.Pagination{
+			Limit:      response.Limit,
+			Page:       response.Page,
+			TotalRows:  response.TotalRows,
+			TotalPages: response.TotalPages,
+			Rows:       response.Rows,
+			Keyword:    response.Keyword,
+		},
+		Facets: facets,
 	}, nil
 }
 
+func (service *productServiceImpl) FindByCategorySlug(slug string, limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error) {
+	category, err := service.categoryRepo.FindBySlug(slug)
+	if err != nil {
+		return models.ProductListResponse{}, err
+	}
+
+	filter.CategoryID = category.ID
+	return service.FindAllPagination(limit, page, filter)
+}
+
+// FindMine lists the products of the user's own store in every status
+func (service *productServiceImpl) FindMine(userId uint, limit int, page int, filter models.ProductFilter) (models.ProductListResponse, error) {
+	store, err := service.storeRepo.FindByUserId(userId)
+	if err != nil {
+		return models.ProductListResponse{}, errors.New("you do not have a store")
+	}
+
+	filter.StoreID = store.ID
+	filter.AnyStatus = true
+	return service.FindAllPagination(limit, page, filter)
+}
+
+// FindBySlug returns the product with slug. When slug is an old slug of a
+// renamed product, it returns the current slug to redirect to instead.
+func (service *productServiceImpl) FindBySlug(slug string, viewer models.ProductViewer) (models.ProductResponse, string, error) {
+	id, err := service.repository.FindIdBySlug(slug)
+	if err == nil {
+		product, err := service.FindVisibleById(id, viewer)
+		return product, "", err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ProductResponse{}, "", err
+	}
+
+	history, err := service.repository.FindSlugHistory(slug)
+	if err != nil {
+		return models.ProductResponse{}, "", err
+	}
+	product, err := service.repository.FindById(history.IDProduk)
+	if err != nil {
+		return models.ProductResponse{}, "", err
+	}
+	if !canViewProduct(product, viewer) {
+		return models.ProductResponse{}, "", gorm.ErrRecordNotFound
+	}
+	return models.ProductResponse{}, product.Slug, nil
+}
+
+// productSlug turns source (or the name when source is empty) into a slug no other product uses
+func (service *productServiceImpl) productSlug(productID uint, source string, name string) (string, error) {
+	if source == "" {
+		source = name
+	}
+	return uniqueSlug(source, "produk", func(candidate string) (bool, error) {
+		return service.repository.SlugTaken(candidate, productID)
+	})
+}
+
+// checkSKU rejects a SKU already used by another product of the store
+func (service *productServiceImpl) checkSKU(storeID uint, sku string, productID uint) error {
+	if sku == "" {
+		return nil
+	}
+	taken, err := service.repository.SKUTaken(storeID, sku, productID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("sku %s is already used by another product of this store", sku)
+	}
+	return nil
+}
+
 func (service *productServiceImpl) FindById(id uint) (models.ProductResponse, error) {
-	return service.repository.FindById(id)
+	product, err := service.repository.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	options, err := service.variantRepo.FindOptionsByProductId(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	variants, err := service.variantRepo.FindByProductId(id, true)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	if len(variants) > 0 {
+		base := pricingBase(product)
+		product.Opsi = toVariantOptionResponses(options)
+		product.Varian = toProductVariantResponses(variants, base)
+	}
+
+	return product, nil
+}
+
+// FindVisibleById returns the product only when viewer may see it: buyers see
+// published products, the store owner and admins see every status
+func (service *productServiceImpl) FindVisibleById(id uint, viewer models.ProductViewer) (models.ProductResponse, error) {
+	product, err := service.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	if !canViewProduct(product, viewer) {
+		return models.ProductResponse{}, fmt.Errorf("product with ID %d not found: %w", id, gorm.ErrRecordNotFound)
+	}
+	return product, nil
 }
 
 func (service *productServiceImpl) Create(input models.ProductRequest, userId uint) (models.ProductResponse, error) {
+	// New products stay hidden until they are published
+	if input.Status == "" {
+		input.Status = entities.ProductStatusDraft
+	}
+	if input.Status != entities.ProductStatusDraft && input.Status != entities.ProductStatusPublished {
+		return models.ProductResponse{}, errors.New("status of a new product must be draft or published")
+	}
+	if err := checkProductSchedule(input.Status, input.JadwalTerbit); err != nil {
+		return models.ProductResponse{}, err
+	}
+
 	// Instead of finding store by user ID, verify the store exists
 	store, _, err := service.storeRepo.FindById(input.StoreID) // Add _ to handle photos return value
 	if err != nil {
@@ -83,8 +249,25 @@ func (service *productServiceImpl) Create(input models.ProductRequest, userId ui
 
 	// Use the store ID from the request
 	input.StoreID = store.ID
+	if err := service.checkSKU(input.StoreID, input.SKU, 0); err != nil {
+		return models.ProductResponse{}, err
+	}
+	if input.MinOrderReseller < 0 {
+		return models.ProductResponse{}, errors.New("min_order_reseller cannot be negative")
+	}
+
+	input.Slug, err = service.productSlug(0, input.Slug, input.NamaProduk)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	response, err := service.repository.Insert(input, userId)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
 
-	return service.repository.Insert(input)
+	service.refreshSuggestion(response)
+	return response, nil
 }
 
 func (service *productServiceImpl) Update(id uint, request models.ProductRequest, userId uint) (models.ProductResponse, error) {
@@ -108,13 +291,34 @@ func (service *productServiceImpl) Update(id uint, request models.ProductRequest
 
 	// Add store ID to request
 	request.StoreID = store.ID
+	if err := service.checkSKU(request.StoreID, request.SKU, id); err != nil {
+		return models.ProductResponse{}, err
+	}
+	if request.MinOrderReseller < 0 {
+		return models.ProductResponse{}, errors.New("min_order_reseller cannot be negative")
+	}
+
+	// Keep the current slug unless the name changes or another slug is requested
+	existing, err := service.repository.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	source := request.Slug
+	if source == "" && request.NamaProduk == existing.NamaProduk {
+		source = existing.Slug
+	}
+	request.Slug, err = service.productSlug(id, source, request.NamaProduk)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
 
 	// Update product
-	response, err := service.repository.Update(id, request)
+	response, err := service.repository.Update(id, request, userId)
 	if err != nil {
 		return models.ProductResponse{}, err
 	}
 
+	service.refreshSuggestion(response)
 	return response, nil
 }
 
@@ -135,6 +339,7 @@ func (service *productServiceImpl) Delete(id uint, userId uint) (models.ProductR
 		return models.ProductResponse{}, errors.New("failed to delete product")
 	}
 
+	service.suggestions.Remove(search.KindProduct, id)
 	return product, nil
 }
 
@@ -142,14 +347,179 @@ func (service *productServiceImpl) FindByCategory(categoryID string) ([]models.P
 	return service.repository.FindByCategory(categoryID)
 }
 
-func (service *productServiceImpl) SearchProducts(query string) ([]models.ProductResponse, error) {
-	return service.repository.SearchProducts(query)
+func (service *productServiceImpl) SearchProducts(query string, mode string, limit int, page int) (models.Pagination, error) {
+	if mode == "" {
+		mode = "natural"
+	}
+	if mode != "natural" && mode != "boolean" {
+		return models.Pagination{}, errors.New("mode must be natural or boolean")
+	}
+
+	result := models.Pagination{
+		Limit:   limit,
+		Page:    page,
+		Rows:    []models.ProductSearchResult{},
+		Keyword: query,
+	}
+
+	// A query of only stop words or short words cannot match the index
+	normalized := search.Normalize(query)
+	if normalized.Empty() {
+		return result, nil
+	}
+
+	request := responder.Pagination{Limit: limit, Page: page, Keyword: query}
+	var response responder.Pagination
+	var err error
+	if mode == "boolean" {
+		response, err = service.repository.SearchProducts(request, normalized.Boolean(), true)
+	} else {
+		response, err = service.repository.SearchProducts(request, normalized.Natural(), false)
+	}
+	if err != nil {
+		return models.Pagination{}, err
+	}
+
+	result.TotalRows = response.TotalRows
+	result.TotalPages = response.TotalPages
+	result.Rows = response.Rows
+	return result, nil
 }
 
 func (service *productServiceImpl) GetRelatedProducts(id uint) ([]models.ProductResponse, error) {
 	return service.repository.FindRelatedProducts(id)
 }
 
+// UpdateStatus moves a product between draft, published and archived. Only the
+// store owner and admins may do so, and a banned product stays banned until an admin lifts the ban.
+func (service *productServiceImpl) UpdateStatus(id uint, userId uint, isAdmin bool, input models.ProductStatusRequest) (models.ProductResponse, error) {
+	product, err := service.repository.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	if !isAdmin && product.Store.IDUser != userId {
+		return models.ProductResponse{}, errors.New("forbidden")
+	}
+	if product.Status == entities.ProductStatusBanned {
+		return models.ProductResponse{}, fmt.Errorf("product is banned: %s", product.AlasanBlokir)
+	}
+
+	switch input.Status {
+	case entities.ProductStatusDraft, entities.ProductStatusPublished, entities.ProductStatusArchived:
+	default:
+		return models.ProductResponse{}, errors.New("status must be draft, published or archived")
+	}
+	if err := checkProductSchedule(input.Status, input.JadwalTerbit); err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	if err := service.repository.UpdateStatus(id, input.Status, input.JadwalTerbit, ""); err != nil {
+		return models.ProductResponse{}, err
+	}
+	return service.reloadAfterStatusChange(id)
+}
+
+// Ban hides a product from buyers and locks its status until an admin unbans it
+func (service *productServiceImpl) Ban(id uint, reason string) (models.ProductResponse, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return models.ProductResponse{}, errors.New("alasan is required")
+	}
+	if _, err := service.repository.FindById(id); err != nil {
+		return models.ProductResponse{}, err
+	}
+
+	if err := service.repository.UpdateStatus(id, entities.ProductStatusBanned, nil, reason); err != nil {
+		return models.ProductResponse{}, err
+	}
+	return service.reloadAfterStatusChange(id)
+}
+
+// Unban lifts a ban; the product goes back to draft so the seller can review it before publishing again
+func (service *productServiceImpl) Unban(id uint) (models.ProductResponse, error) {
+	product, err := service.repository.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	if product.Status != entities.ProductStatusBanned {
+		return models.ProductResponse{}, errors.New("product is not banned")
+	}
+
+	if err := service.repository.UpdateStatus(id, entities.ProductStatusDraft, nil, ""); err != nil {
+		return models.ProductResponse{}, err
+	}
+	return service.reloadAfterStatusChange(id)
+}
+
+// PublishScheduled publishes the drafts whose scheduled time has passed and returns how many there were
+func (service *productServiceImpl) PublishScheduled() (int, error) {
+	products, err := service.repository.PublishDue(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, product := range products {
+		service.suggestions.Put(search.KindProduct, product.ID, product.NamaProduk)
+	}
+	return len(products), nil
+}
+
+func (service *productServiceImpl) reloadAfterStatusChange(id uint) (models.ProductResponse, error) {
+	product, err := service.repository.FindById(id)
+	if err != nil {
+		return models.ProductResponse{}, err
+	}
+	service.refreshSuggestion(product)
+	return product, nil
+}
+
+func (service *productServiceImpl) refreshSuggestion(product models.ProductResponse) {
+	refreshProductSuggestion(service.suggestions, product.ID, product.NamaProduk, productPublished(product, time.Now()))
+}
+
+// refreshProductSuggestion keeps a product in the search suggestions only while buyers can see it
+func refreshProductSuggestion(suggestions SearchSuggestionService, id uint, name string, published bool) {
+	if published {
+		suggestions.Put(search.KindProduct, id, name)
+	} else {
+		suggestions.Remove(search.KindProduct, id)
+	}
+}
+
+// checkProductSchedule only allows a scheduled publish time on a draft, and only in the future
+func checkProductSchedule(status string, publishAt *time.Time) error {
+	if publishAt == nil {
+		return nil
+	}
+	if status != entities.ProductStatusDraft {
+		return errors.New("jadwal_terbit can only be set on a draft")
+	}
+	if !publishAt.After(time.Now()) {
+		return errors.New("jadwal_terbit must be in the future")
+	}
+	return nil
+}
+
+func validProductStatus(status string) bool {
+	switch status {
+	case entities.ProductStatusDraft, entities.ProductStatusPublished, entities.ProductStatusArchived, entities.ProductStatusBanned:
+		return true
+	}
+	return false
+}
+
+// productPublished applies entities.Product.IsPublished to a mapped product
+func productPublished(product models.ProductResponse, now time.Time) bool {
+	return entities.Product{Status: product.Status, JadwalTerbit: product.JadwalTerbit}.IsPublished(now)
+}
+
+// canViewProduct reports whether viewer may open a product that might not be published
+func canViewProduct(product models.ProductResponse, viewer models.ProductViewer) bool {
+	if viewer.IsAdmin || productPublished(product, time.Now()) {
+		return true
+	}
+	return viewer.UserID != 0 && product.Store.IDUser == viewer.UserID
+}
+
 func (service *productServiceImpl) SaveProductPhoto(photo entities.FotoProduk) (models.FotoProdukResponse, error) {
 	// Ensure the PhotoURL is set from the request
 	if photo.PhotoURL == "" {
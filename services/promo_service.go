@@ -2,26 +2,43 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
 	"mini-project-evermos/repositories"
+	"sort"
+	"strings"
+	"time"
 )
 
 type ProductPromoService interface {
 	GetAll() ([]models.ProductPromoResponse, error)
 	GetById(id uint64) (models.ProductPromoResponse, error)
 	GetByProductId(productId uint32) ([]models.ProductPromoResponse, error)
-	Create(input models.ProductPromoRequest) (models.ProductPromoResponse, error)
-	Update(input models.ProductPromoRequest, id uint64, userId uint64) (models.ProductPromoResponse, error)
-	Delete(id uint64, userId uint64) error
+	Create(input models.ProductPromoRequest, userId uint64, isAdmin bool) (models.ProductPromoResponse, error)
+	Update(input models.ProductPromoRequest, id uint64, userId uint64, isAdmin bool) (models.ProductPromoResponse, error)
+	Delete(id uint64, userId uint64, isAdmin bool) error
 	ClearAll() ([]models.ProductPromoResponse, error)
+	Price(lines []models.ProductLogProcess, shipping map[uint]float64) ([]models.AppliedPromo, map[uint]float64, error)
 }
 
 type productPromoServiceImpl struct {
-	promoRepository repositories.ProductPromoRepository
+	promoRepository   repositories.ProductPromoRepository
+	productRepository repositories.ProductRepository
+	storeRepository   repositories.StoreRepository
 }
 
-func NewProductPromoService(promoRepository repositories.ProductPromoRepository) ProductPromoService {
-	return &productPromoServiceImpl{promoRepository: promoRepository}
+func NewProductPromoService(
+	promoRepository repositories.ProductPromoRepository,
+	productRepository repositories.ProductRepository,
+	storeRepository repositories.StoreRepository,
+) ProductPromoService {
+	return &productPromoServiceImpl{
+		promoRepository:   promoRepository,
+		productRepository: productRepository,
+		storeRepository:   storeRepository,
+	}
 }
 
 func (s *productPromoServiceImpl) GetAll() ([]models.ProductPromoResponse, error) {
@@ -32,14 +49,7 @@ func (s *productPromoServiceImpl) GetAll() ([]models.ProductPromoResponse, error
 
 	var responses []models.ProductPromoResponse
 	for _, promo := range promos {
-		responses = append(responses, models.ProductPromoResponse{
-			ID:        uint(promo.ID),
-			IDToko:    uint(promo.IDToko),
-			IDProduk:  uint(promo.IDProduk),
-			Promo:     promo.Promo,
-			CreatedAt: promo.CreatedAt,
-			UpdatedAt: promo.UpdatedAt,
-		})
+		responses = append(responses, toProductPromoResponse(promo))
 	}
 
 	return responses, nil
@@ -51,14 +61,7 @@ func (s *productPromoServiceImpl) GetById(id uint64) (models.ProductPromoRespons
 		return models.ProductPromoResponse{}, err
 	}
 
-	return models.ProductPromoResponse{
-		ID:        uint(promo.ID),
-		IDToko:    uint(promo.IDToko),
-		IDProduk:  uint(promo.IDProduk),
-		Promo:     promo.Promo,
-		CreatedAt: promo.CreatedAt,
-		UpdatedAt: promo.UpdatedAt,
-	}, nil
+	return toProductPromoResponse(promo), nil
 }
 
 func (s *productPromoServiceImpl) GetByProductId(productId uint32) ([]models.ProductPromoResponse, error) {
@@ -69,29 +72,30 @@ func (s *productPromoServiceImpl) GetByProductId(productId uint32) ([]models.Pro
 
 	var responses []models.ProductPromoResponse
 	for _, promo := range promos {
-		responses = append(responses, models.ProductPromoResponse{
-			ID:        uint(promo.ID),
-			IDToko:    uint(promo.IDToko),
-			IDProduk:  uint(promo.IDProduk),
-			Promo:     promo.Promo,
-			CreatedAt: promo.CreatedAt,
-			UpdatedAt: promo.UpdatedAt,
-		})
+		responses = append(responses, toProductPromoResponse(promo))
 	}
 
 	return responses, nil
 }
 
-func (s *productPromoServiceImpl) Create(input models.ProductPromoRequest) (models.ProductPromoResponse, error) {
-	promo, err := s.promoRepository.Insert(input, uint64(input.IDToko))
+func (s *productPromoServiceImpl) Create(input models.ProductPromoRequest, userId uint64, isAdmin bool) (models.ProductPromoResponse, error) {
+	promo, err := s.buildPromo(entities.ProductPromo{Aktif: true}, input)
+	if err != nil {
+		return models.ProductPromoResponse{}, err
+	}
+	if err := s.checkOwner(uint(userId), isAdmin, uint(promo.IDToko)); err != nil {
+		return models.ProductPromoResponse{}, err
+	}
+
+	created, err := s.promoRepository.Insert(promo)
 	if err != nil {
 		return models.ProductPromoResponse{}, err
 	}
 
-	return s.GetById(promo.ID)
+	return toProductPromoResponse(created), nil
 }
 
-func (s *productPromoServiceImpl) Update(input models.ProductPromoRequest, id uint64, userId uint64) (models.ProductPromoResponse, error) {
+func (s *productPromoServiceImpl) Update(input models.ProductPromoRequest, id uint64, userId uint64, isAdmin bool) (models.ProductPromoResponse, error) {
 	existingPromo, err := s.promoRepository.FindById(id)
 	if err != nil {
 		return models.ProductPromoResponse{}, err
@@ -100,16 +104,34 @@ func (s *productPromoServiceImpl) Update(input models.ProductPromoRequest, id ui
 	if existingPromo.ID == 0 {
 		return models.ProductPromoResponse{}, errors.New("promo not found")
 	}
+	if err := s.checkOwner(uint(userId), isAdmin, uint(existingPromo.IDToko)); err != nil {
+		return models.ProductPromoResponse{}, err
+	}
 
-	updatedPromo, err := s.promoRepository.Update(input, id)
+	promo, err := s.buildPromo(existingPromo, input)
 	if err != nil {
 		return models.ProductPromoResponse{}, err
 	}
+	if promo.IDToko != existingPromo.IDToko {
+		return models.ProductPromoResponse{}, errors.New("a promo cannot be moved to another store")
+	}
 
-	return s.GetById(updatedPromo.ID)
+	updatedPromo, err := s.promoRepository.Update(promo)
+	if err != nil {
+		return models.ProductPromoResponse{}, err
+	}
+
+	return toProductPromoResponse(updatedPromo), nil
 }
 
-func (s *productPromoServiceImpl) Delete(id uint64, userId uint64) error {
+func (s *productPromoServiceImpl) Delete(id uint64, userId uint64, isAdmin bool) error {
+	promo, err := s.promoRepository.FindById(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwner(uint(userId), isAdmin, uint(promo.IDToko)); err != nil {
+		return err
+	}
 	return s.promoRepository.Delete(id)
 }
 
@@ -128,3 +150,314 @@ func (s *productPromoServiceImpl) ClearAll() ([]models.ProductPromoResponse, err
 
 	return promos, nil
 }
+
+// Price runs the promos that apply now over checkout lines, see applyPromos
+func (s *productPromoServiceImpl) Price(lines []models.ProductLogProcess, shipping map[uint]float64) ([]models.AppliedPromo, map[uint]float64, error) {
+	var productIDs, storeIDs []uint
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+		storeIDs = append(storeIDs, line.StoreID)
+	}
+
+	promos, err := s.promoRepository.FindRunning(productIDs, storeIDs, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, shippingDiscounts := applyPromos(promos, lines, shipping)
+	return applied, shippingDiscounts, nil
+}
+
+// checkOwner lets only the owner of the store and admins manage its promos
+func (s *productPromoServiceImpl) checkOwner(userId uint, isAdmin bool, storeID uint) error {
+	store, _, err := s.storeRepository.FindById(storeID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && store.IDUser != userId {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+// buildPromo applies a request to promo. A product promo belongs to the
+// product's store; only free shipping can cover a whole store.
+func (s *productPromoServiceImpl) buildPromo(promo entities.ProductPromo, input models.ProductPromoRequest) (entities.ProductPromo, error) {
+	promo.Promo = strings.TrimSpace(input.Promo)
+	if promo.Promo == "" {
+		return entities.ProductPromo{}, errors.New("promo is required")
+	}
+
+	promo.IDProduk = nil
+	promo.IDToko = uint64(input.IDToko)
+	if input.IDProduk != 0 {
+		product, err := s.productRepository.FindById(input.IDProduk)
+		if err != nil {
+			return entities.ProductPromo{}, err
+		}
+		if input.IDToko != 0 && input.IDToko != product.Store.ID {
+			return entities.ProductPromo{}, errors.New("product does not belong to this store")
+		}
+		productID := product.ID
+		promo.IDProduk = &productID
+		promo.IDToko = uint64(product.Store.ID)
+	}
+	if promo.IDToko == 0 {
+		return entities.ProductPromo{}, errors.New("id_toko or id_produk is required")
+	}
+
+	promo.Jenis = input.Jenis
+	promo.BeliJumlah, promo.GratisJumlah, promo.HargaPaket = 0, 0, 0
+	promo.MinBelanja, promo.MaksOngkir = 0, 0
+	promo.Tingkat = nil
+	switch promo.Jenis {
+	case "":
+	case entities.PromoBuyGet:
+		if input.BeliJumlah < 1 || input.GratisJumlah < 1 {
+			return entities.ProductPromo{}, errors.New("beli_jumlah and gratis_jumlah must be at least 1")
+		}
+		promo.BeliJumlah, promo.GratisJumlah = input.BeliJumlah, input.GratisJumlah
+	case entities.PromoBundle:
+		if input.BeliJumlah < 2 || input.HargaPaket <= 0 {
+			return entities.ProductPromo{}, errors.New("a bundle needs beli_jumlah of at least 2 and a harga_paket")
+		}
+		promo.BeliJumlah, promo.HargaPaket = input.BeliJumlah, input.HargaPaket
+	case entities.PromoTiered:
+		if len(input.Tingkat) == 0 {
+			return entities.ProductPromo{}, errors.New("tingkat is required for a tiered promo")
+		}
+		seen := map[int]bool{}
+		for _, tier := range input.Tingkat {
+			if tier.MinJumlah < 2 || tier.Persen <= 0 || tier.Persen >= 100 {
+				return entities.ProductPromo{}, errors.New("each tier needs min_jumlah of at least 2 and persen between 0 and 100")
+			}
+			if seen[tier.MinJumlah] {
+				return entities.ProductPromo{}, fmt.Errorf("min_jumlah %d is repeated", tier.MinJumlah)
+			}
+			seen[tier.MinJumlah] = true
+			promo.Tingkat = append(promo.Tingkat, entities.ProductPromoTier{MinJumlah: tier.MinJumlah, Persen: tier.Persen})
+		}
+	case entities.PromoFreeShipping:
+		if input.MinBelanja < 0 || input.MaksOngkir < 0 {
+			return entities.ProductPromo{}, errors.New("min_belanja and maks_ongkir cannot be negative")
+		}
+		promo.MinBelanja, promo.MaksOngkir = input.MinBelanja, input.MaksOngkir
+	default:
+		return entities.ProductPromo{}, fmt.Errorf("jenis must be %s, %s, %s or %s",
+			entities.PromoBuyGet, entities.PromoBundle, entities.PromoTiered, entities.PromoFreeShipping)
+	}
+	if promo.Jenis != entities.PromoFreeShipping && promo.Jenis != "" && promo.IDProduk == nil {
+		return entities.ProductPromo{}, errors.New("id_produk is required for this promo")
+	}
+
+	if input.MulaiPada != nil && input.BerakhirPada != nil && !input.BerakhirPada.After(*input.MulaiPada) {
+		return entities.ProductPromo{}, errors.New("berakhir_pada must be after mulai_pada")
+	}
+	promo.MulaiPada, promo.BerakhirPada = input.MulaiPada, input.BerakhirPada
+	if input.Aktif != nil {
+		promo.Aktif = *input.Aktif
+	}
+	return promo, nil
+}
+
+// applyPromos prices checkout lines with the running promos. Lines of one
+// product count together towards quantities. Each product gets the one price
+// promo that saves the most, added to the Diskon of its lines; free shipping
+// is worked out afterwards on what is left to pay, one promo per store, from
+// the fees in shipping. Without fees the free shipping promos that qualify are
+// listed with no discount.
+func applyPromos(promos []entities.ProductPromo, lines []models.ProductLogProcess, shipping map[uint]float64) ([]models.AppliedPromo, map[uint]float64) {
+	productLines := map[uint][]int{}
+	var productOrder []uint
+	for i, line := range lines {
+		if _, ok := productLines[line.ProductID]; !ok {
+			productOrder = append(productOrder, line.ProductID)
+		}
+		productLines[line.ProductID] = append(productLines[line.ProductID], i)
+	}
+
+	var applied []models.AppliedPromo
+	for _, productID := range productOrder {
+		indexes := productLines[productID]
+		var best []float64
+		var bestPromo entities.ProductPromo
+		var bestTotal float64
+		for _, promo := range promos {
+			if promo.IDProduk == nil || *promo.IDProduk != productID || promo.Jenis == entities.PromoFreeShipping {
+				continue
+			}
+			discounts := promoLineDiscounts(promo, lines, indexes)
+			var total float64
+			for _, discount := range discounts {
+				total += discount
+			}
+			if total > bestTotal {
+				best, bestPromo, bestTotal = discounts, promo, total
+			}
+		}
+		for n, i := range indexes {
+			if best == nil || best[n] <= 0 {
+				continue
+			}
+			lines[i].Diskon += best[n]
+			applied = append(applied, models.AppliedPromo{
+				IDPromo:  uint(bestPromo.ID),
+				Promo:    bestPromo.Promo,
+				Jenis:    bestPromo.Jenis,
+				IDToko:   uint(bestPromo.IDToko),
+				IDProduk: bestPromo.IDProduk,
+				Baris:    i,
+				Diskon:   best[n],
+			})
+		}
+	}
+
+	shippingDiscounts := map[uint]float64{}
+	freeShipping := map[uint]models.AppliedPromo{}
+	for _, promo := range promos {
+		if promo.Jenis != entities.PromoFreeShipping {
+			continue
+		}
+		storeID := uint(promo.IDToko)
+		var spend float64
+		covered := false
+		for _, line := range lines {
+			if line.StoreID == storeID && (promo.IDProduk == nil || *promo.IDProduk == line.ProductID) {
+				spend += line.HargaTotal - line.Diskon
+				covered = true
+			}
+		}
+		if !covered || spend < promo.MinBelanja {
+			continue
+		}
+
+		discount := shipping[storeID]
+		if promo.MaksOngkir > 0 && discount > promo.MaksOngkir {
+			discount = promo.MaksOngkir
+		}
+		if shipping != nil && discount <= 0 {
+			continue
+		}
+		if current, ok := freeShipping[storeID]; ok && current.Diskon >= discount {
+			continue
+		}
+		freeShipping[storeID] = models.AppliedPromo{
+			IDPromo:  uint(promo.ID),
+			Promo:    promo.Promo,
+			Jenis:    promo.Jenis,
+			IDToko:   storeID,
+			IDProduk: promo.IDProduk,
+			Baris:    -1,
+			Diskon:   discount,
+		}
+	}
+	var storeIDs []uint
+	for storeID := range freeShipping {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	for _, storeID := range storeIDs {
+		promo := freeShipping[storeID]
+		shippingDiscounts[storeID] = promo.Diskon
+		applied = append(applied, promo)
+	}
+	return applied, shippingDiscounts
+}
+
+// promoLineDiscounts works out what a price promo takes off the lines of its
+// product, in whole rupiah. Free units go to the cheapest lines first; bundle
+// and tier savings are spread over the lines by amount.
+func promoLineDiscounts(promo entities.ProductPromo, lines []models.ProductLogProcess, indexes []int) []float64 {
+	discounts := make([]float64, len(indexes))
+	var quantity int
+	var amount float64
+	for _, i := range indexes {
+		quantity += lines[i].Kuantitas
+		amount += lines[i].HargaTotal
+	}
+	if quantity == 0 || amount <= 0 {
+		return discounts
+	}
+
+	var total float64
+	switch promo.Jenis {
+	case entities.PromoBuyGet:
+		free := quantity / (promo.BeliJumlah + promo.GratisJumlah) * promo.GratisJumlah
+		order := make([]int, len(indexes))
+		for n := range order {
+			order[n] = n
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return unitPrice(lines[indexes[order[a]]]) < unitPrice(lines[indexes[order[b]]])
+		})
+		for _, n := range order {
+			if free == 0 {
+				break
+			}
+			line := lines[indexes[n]]
+			units := line.Kuantitas
+			if units > free {
+				units = free
+			}
+			discounts[n] = math.Round(unitPrice(line) * float64(units))
+			free -= units
+		}
+		return discounts
+	case entities.PromoBundle:
+		bundles := quantity / promo.BeliJumlah
+		regular := amount / float64(quantity) * float64(promo.BeliJumlah)
+		total = math.Max(regular-promo.HargaPaket, 0) * float64(bundles)
+	case entities.PromoTiered:
+		for _, tier := range promo.Tingkat {
+			if quantity >= tier.MinJumlah {
+				total = amount * tier.Persen / 100
+			}
+		}
+	}
+	if total <= 0 {
+		return discounts
+	}
+
+	total = math.Min(math.Round(total), amount)
+	remaining := total
+	for n, i := range indexes {
+		share := math.Floor(total * lines[i].HargaTotal / amount)
+		if n == len(indexes)-1 {
+			share = remaining
+		}
+		discounts[n] = share
+		remaining -= share
+	}
+	return discounts
+}
+
+func unitPrice(line models.ProductLogProcess) float64 {
+	if line.Kuantitas == 0 {
+		return 0
+	}
+	return line.HargaTotal / float64(line.Kuantitas)
+}
+
+func toProductPromoResponse(promo entities.ProductPromo) models.ProductPromoResponse {
+	var tingkat []models.PromoTierRequest
+	for _, tier := range promo.Tingkat {
+		tingkat = append(tingkat, models.PromoTierRequest{MinJumlah: tier.MinJumlah, Persen: tier.Persen})
+	}
+	return models.ProductPromoResponse{
+		ID:           uint(promo.ID),
+		IDToko:       uint(promo.IDToko),
+		IDProduk:     promo.IDProduk,
+		Promo:        promo.Promo,
+		Jenis:        promo.Jenis,
+		BeliJumlah:   promo.BeliJumlah,
+		GratisJumlah: promo.GratisJumlah,
+		HargaPaket:   promo.HargaPaket,
+		MinBelanja:   promo.MinBelanja,
+		MaksOngkir:   promo.MaksOngkir,
+		Tingkat:      tingkat,
+		MulaiPada:    promo.MulaiPada,
+		BerakhirPada: promo.BerakhirPada,
+		Aktif:        promo.Aktif,
+		CreatedAt:    promo.CreatedAt,
+		UpdatedAt:    promo.UpdatedAt,
+	}
+}
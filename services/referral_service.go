@@ -0,0 +1,269 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// referralAlphabet leaves out characters that are easily mixed up when a code is typed
+const referralAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type ReferralService interface {
+	CreateLink(userId uint, input models.ReferralLinkRequest) (models.ReferralLinkResponse, error)
+	GetLinks(userId uint) ([]models.ReferralLinkResponse, error)
+	Open(kode string) (models.ReferralLinkResponse, error)
+	GetEarnings(userId uint, status string) (models.EarningsResponse, error)
+	Attribute(kode string, buyerId uint, lines []models.ProductLogProcess) (*models.ReferralAttribution, error)
+}
+
+type referralServiceImpl struct {
+	repository         repositories.ReferralRepository
+	resellerRepository repositories.ResellerRepository
+	productRepository  repositories.ProductRepository
+}
+
+func NewReferralService(
+	repository repositories.ReferralRepository,
+	resellerRepository repositories.ResellerRepository,
+	productRepository repositories.ProductRepository,
+) ReferralService {
+	return &referralServiceImpl{
+		repository:         repository,
+		resellerRepository: resellerRepository,
+		productRepository:  productRepository,
+	}
+}
+
+// CreateLink returns the reseller's referral link for the product, or their
+// general link without one, creating it the first time
+func (s *referralServiceImpl) CreateLink(userId uint, input models.ReferralLinkRequest) (models.ReferralLinkResponse, error) {
+	approved, err := s.isReseller(userId)
+	if err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	if !approved {
+		return models.ReferralLinkResponse{}, errors.New("forbidden")
+	}
+
+	if input.IDProduk != nil && *input.IDProduk == 0 {
+		input.IDProduk = nil
+	}
+	if input.IDProduk != nil {
+		product, err := s.productRepository.FindById(*input.IDProduk)
+		if err != nil {
+			return models.ReferralLinkResponse{}, err
+		}
+		if !productPublished(product, time.Now()) {
+			return models.ReferralLinkResponse{}, errors.New("product is not available")
+		}
+	}
+
+	link, err := s.repository.FindUserLink(userId, input.IDProduk)
+	if err == nil {
+		return toReferralLinkResponse(link), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ReferralLinkResponse{}, err
+	}
+
+	kode, err := s.newCode()
+	if err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	link, err = s.repository.Create(entities.ReferralLink{IDUser: userId, IDProduk: input.IDProduk, Kode: kode})
+	if err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	return toReferralLinkResponse(link), nil
+}
+
+func (s *referralServiceImpl) GetLinks(userId uint) ([]models.ReferralLinkResponse, error) {
+	links, err := s.repository.FindByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	responses := []models.ReferralLinkResponse{}
+	for _, link := range links {
+		responses = append(responses, toReferralLinkResponse(link))
+	}
+	return responses, nil
+}
+
+// Open is where a shared link lands: it counts the click and tells the
+// buyer's client which product to show and which code to send at checkout
+func (s *referralServiceImpl) Open(kode string) (models.ReferralLinkResponse, error) {
+	link, err := s.repository.FindByCode(strings.ToUpper(strings.TrimSpace(kode)))
+	if err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	approved, err := s.isReseller(link.IDUser)
+	if err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	if !approved {
+		return models.ReferralLinkResponse{}, errors.New("referral code not found")
+	}
+
+	if err := s.repository.CountClick(link.ID); err != nil {
+		return models.ReferralLinkResponse{}, err
+	}
+	link.Klik++
+
+	response := toReferralLinkResponse(link)
+	response.NamaReseller = link.User.Nama
+	return response, nil
+}
+
+// GetEarnings returns the reseller's commission ledger, optionally of one
+// status. The totals always cover the whole ledger.
+func (s *referralServiceImpl) GetEarnings(userId uint, status string) (models.EarningsResponse, error) {
+	switch status {
+	case "", entities.CommissionPending, entities.CommissionSettled, entities.CommissionCancelled:
+	default:
+		return models.EarningsResponse{}, errors.New("status must be tertunda, cair or batal")
+	}
+
+	commissions, err := s.repository.FindCommissions(userId, "")
+	if err != nil {
+		return models.EarningsResponse{}, err
+	}
+
+	earnings := models.EarningsResponse{Riwayat: []models.CommissionResponse{}}
+	for _, commission := range commissions {
+		switch commission.Status {
+		case entities.CommissionPending:
+			earnings.Tertunda += commission.Komisi
+		case entities.CommissionSettled:
+			earnings.Cair += commission.Komisi
+		}
+		if status == "" || commission.Status == status {
+			earnings.Riwayat = append(earnings.Riwayat, toCommissionResponse(commission))
+		}
+	}
+	return earnings, nil
+}
+
+// Attribute credits a checkout to the reseller behind the referral code, see
+// referralCommission for what each line earns. A code of a reseller who is no
+// longer approved is not credited. It runs after every discount of the lines.
+func (s *referralServiceImpl) Attribute(kode string, buyerId uint, lines []models.ProductLogProcess) (*models.ReferralAttribution, error) {
+	link, err := s.repository.FindByCode(strings.ToUpper(strings.TrimSpace(kode)))
+	if err != nil {
+		return nil, err
+	}
+	if link.IDUser == buyerId {
+		return nil, errors.New("you cannot use your own referral code")
+	}
+	approved, err := s.isReseller(link.IDUser)
+	if err != nil || !approved {
+		return nil, err
+	}
+
+	attribution := &models.ReferralAttribution{
+		IDReferral: link.ID,
+		IDUser:     link.IDUser,
+		Kode:       link.Kode,
+		Komisi:     make([]float64, len(lines)),
+	}
+	for i, line := range lines {
+		attribution.Komisi[i] = referralCommission(link, line)
+	}
+	return attribution, nil
+}
+
+// referralCommission is what a line earns the reseller through the link: the
+// margin between what the buyer pays for it after its promo, voucher and
+// coupon discounts and its reseller price. Lines the link does not cover and
+// lines the buyer got at the reseller price themselves earn nothing.
+func referralCommission(link entities.ReferralLink, line models.ProductLogProcess) float64 {
+	if !link.Covers(line.ProductID) || line.Reseller {
+		return 0
+	}
+	hargaReseller, err := strconv.ParseFloat(line.HargaReseller, 64)
+	if err != nil || hargaReseller <= 0 {
+		return 0
+	}
+	sold := line.HargaTotal - line.Diskon
+	if margin := math.Round(sold - hargaReseller*float64(line.Kuantitas)); margin > 0 {
+		return margin
+	}
+	return 0
+}
+
+func (s *referralServiceImpl) isReseller(userId uint) (bool, error) {
+	reseller, err := s.resellerRepository.FindByUser(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return reseller.IsApproved(), nil
+}
+
+// newCode draws a random eight-character code no other link uses
+func (s *referralServiceImpl) newCode() (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for i := range buf {
+			buf[i] = referralAlphabet[int(buf[i])%len(referralAlphabet)]
+		}
+
+		taken, err := s.repository.CodeTaken(string(buf))
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return string(buf), nil
+		}
+	}
+	return "", errors.New("failed to generate a referral code, please try again")
+}
+
+func toReferralLinkResponse(link entities.ReferralLink) models.ReferralLinkResponse {
+	response := models.ReferralLinkResponse{
+		ID:        link.ID,
+		Kode:      link.Kode,
+		Tautan:    "/api/v1/referrals/" + link.Kode,
+		IDProduk:  link.IDProduk,
+		Klik:      link.Klik,
+		CreatedAt: link.CreatedAt,
+	}
+	if link.IDProduk != nil {
+		response.NamaProduk = link.Product.NamaProduk
+		response.Slug = link.Product.Slug
+	}
+	return response
+}
+
+func toCommissionResponse(commission entities.Commission) models.CommissionResponse {
+	return models.CommissionResponse{
+		ID:            commission.ID,
+		IDTrx:         commission.IDTrx,
+		IDTrxToko:     commission.IDTrxToko,
+		IDTrxDetail:   commission.IDTrxDetail,
+		KodeInvoice:   commission.KodeInvoice,
+		KodeReferral:  commission.Referral.Kode,
+		IDProduk:      commission.IDProduk,
+		NamaProduk:    commission.NamaProduk,
+		Kuantitas:     commission.Kuantitas,
+		HargaJual:     commission.HargaJual,
+		HargaReseller: commission.HargaReseller,
+		Komisi:        commission.Komisi,
+		Status:        commission.Status,
+		DicairkanPada: commission.DicairkanPada,
+		CreatedAt:     commission.CreatedAt,
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"testing"
+)
+
+func TestReferralCommission(t *testing.T) {
+	productID := uint(12)
+	otherID := uint(13)
+	productLink := entities.ReferralLink{IDProduk: &productID}
+	generalLink := entities.ReferralLink{}
+
+	line := func(diskon float64) models.ProductLogProcess {
+		return models.ProductLogProcess{
+			ProductID:     productID,
+			HargaKonsumen: "150000",
+			HargaReseller: "135000",
+			Kuantitas:     3,
+			HargaTotal:    450000,
+			Diskon:        diskon,
+		}
+	}
+
+	tests := []struct {
+		name string
+		link entities.ReferralLink
+		line models.ProductLogProcess
+		want float64
+	}{
+		{"full price line earns the whole margin", productLink, line(0), 45000},
+		{"promo or coupon discount comes off the margin", productLink, line(30000), 15000},
+		{"discount above the margin earns nothing", productLink, line(60000), 0},
+		{"general link covers every product", generalLink, line(0), 45000},
+		{"link of another product earns nothing", entities.ReferralLink{IDProduk: &otherID}, line(0), 0},
+		{"line at the reseller price earns nothing", productLink, func() models.ProductLogProcess {
+			l := line(0)
+			l.Reseller = true
+			l.HargaTotal = 405000
+			return l
+		}(), 0},
+		{"product without a reseller price earns nothing", productLink, func() models.ProductLogProcess {
+			l := line(0)
+			l.HargaReseller = ""
+			return l
+		}(), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := referralCommission(tt.link, tt.line); got != tt.want {
+				t.Errorf("referralCommission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ResellerService interface {
+	Apply(userId uint, input models.ResellerApplyRequest) (models.ResellerResponse, error)
+	GetMine(userId uint) (models.ResellerResponse, error)
+	GetAll(status string) ([]models.ResellerResponse, error)
+	GetById(id uint) (models.ResellerResponse, error)
+	Approve(id uint, adminId uint) (models.ResellerResponse, error)
+	Reject(id uint, adminId uint, input models.ResellerDecisionRequest) (models.ResellerResponse, error)
+	Revoke(id uint, adminId uint, input models.ResellerDecisionRequest) (models.ResellerResponse, error)
+	IsReseller(userId uint) (bool, error)
+	PriceProduct(userId uint, product models.ProductResponse) models.ProductResponse
+	PriceProductRows(userId uint, rows interface{}) interface{}
+	PriceLines(userId uint, lines []models.ProductLogProcess) error
+}
+
+type resellerServiceImpl struct {
+	repository        repositories.ResellerRepository
+	productRepository repositories.ProductRepository
+}
+
+func NewResellerService(repository repositories.ResellerRepository, productRepository repositories.ProductRepository) ResellerService {
+	return &resellerServiceImpl{repository: repository, productRepository: productRepository}
+}
+
+// Apply sends the user's application for the reseller tier. A rejected or
+// revoked user can apply again; the application goes back in the queue.
+func (s *resellerServiceImpl) Apply(userId uint, input models.ResellerApplyRequest) (models.ResellerResponse, error) {
+	alasan := strings.TrimSpace(input.Alasan)
+	if alasan == "" {
+		return models.ResellerResponse{}, errors.New("alasan is required")
+	}
+
+	reseller, err := s.repository.FindByUser(userId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ResellerResponse{}, err
+	}
+	switch reseller.Status {
+	case entities.ResellerPending:
+		return models.ResellerResponse{}, errors.New("your reseller application is still being reviewed")
+	case entities.ResellerApproved:
+		return models.ResellerResponse{}, errors.New("you are already a reseller")
+	}
+
+	reseller.IDUser = userId
+	reseller.NamaUsaha = strings.TrimSpace(input.NamaUsaha)
+	reseller.Alasan = alasan
+	saved, err := s.repository.Apply(reseller)
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(saved), nil
+}
+
+func (s *resellerServiceImpl) GetMine(userId uint) (models.ResellerResponse, error) {
+	reseller, err := s.repository.FindByUser(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.ResellerResponse{}, errors.New("reseller application not found")
+	}
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(reseller), nil
+}
+
+func (s *resellerServiceImpl) GetAll(status string) ([]models.ResellerResponse, error) {
+	switch status {
+	case "", entities.ResellerPending, entities.ResellerApproved, entities.ResellerRejected, entities.ResellerRevoked:
+	default:
+		return nil, errors.New("status must be menunggu, disetujui, ditolak or dicabut")
+	}
+
+	resellers, err := s.repository.FindAll(status)
+	if err != nil {
+		return nil, err
+	}
+	responses := []models.ResellerResponse{}
+	for _, reseller := range resellers {
+		responses = append(responses, toResellerResponse(reseller))
+	}
+	return responses, nil
+}
+
+func (s *resellerServiceImpl) GetById(id uint) (models.ResellerResponse, error) {
+	reseller, err := s.repository.FindById(id)
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(reseller), nil
+}
+
+// Approve puts a pending applicant on the reseller tier
+func (s *resellerServiceImpl) Approve(id uint, adminId uint) (models.ResellerResponse, error) {
+	reseller, err := s.repository.Decide(id, entities.ResellerPending, entities.ResellerApproved, adminId, "")
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(reseller), nil
+}
+
+func (s *resellerServiceImpl) Reject(id uint, adminId uint, input models.ResellerDecisionRequest) (models.ResellerResponse, error) {
+	reseller, err := s.repository.Decide(id, entities.ResellerPending, entities.ResellerRejected, adminId, strings.TrimSpace(input.Catatan))
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(reseller), nil
+}
+
+// Revoke takes an approved reseller off the tier; orders already placed keep
+// the prices they were placed at
+func (s *resellerServiceImpl) Revoke(id uint, adminId uint, input models.ResellerDecisionRequest) (models.ResellerResponse, error) {
+	catatan := strings.TrimSpace(input.Catatan)
+	if catatan == "" {
+		return models.ResellerResponse{}, errors.New("catatan is required")
+	}
+	reseller, err := s.repository.Decide(id, entities.ResellerApproved, entities.ResellerRevoked, adminId, catatan)
+	if err != nil {
+		return models.ResellerResponse{}, err
+	}
+	return toResellerResponse(reseller), nil
+}
+
+// IsReseller reports whether the user is an approved reseller
+func (s *resellerServiceImpl) IsReseller(userId uint) (bool, error) {
+	if userId == 0 {
+		return false, nil
+	}
+	reseller, err := s.repository.FindByUser(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return reseller.IsApproved(), nil
+}
+
+// PriceProduct shows an approved reseller what they pay for the product and
+// its variants, from the product's minimum order. Other users get the
+// product unchanged.
+func (s *resellerServiceImpl) PriceProduct(userId uint, product models.ProductResponse) models.ProductResponse {
+	if approved, err := s.IsReseller(userId); err != nil || !approved {
+		return product
+	}
+	return resellerProduct(product)
+}
+
+// PriceProductRows applies PriceProduct to the rows of a product page
+func (s *resellerServiceImpl) PriceProductRows(userId uint, rows interface{}) interface{} {
+	if approved, err := s.IsReseller(userId); err != nil || !approved {
+		return rows
+	}
+
+	switch rows := rows.(type) {
+	case []models.ProductResponse:
+		responses := make([]models.ProductResponse, len(rows))
+		for i, row := range rows {
+			responses[i] = resellerProduct(row)
+		}
+		return responses
+	case []models.ProductSearchResult:
+		responses := make([]models.ProductSearchResult, len(rows))
+		for i, row := range rows {
+			responses[i] = models.ProductSearchResult{
+				ProductResponse: resellerProduct(row.ProductResponse),
+				Relevansi:       row.Relevansi,
+			}
+		}
+		return responses
+	}
+	return rows
+}
+
+// PriceLines prices the checkout lines of an approved reseller at the reseller
+// price. Lines of the same product count together towards its minimum order;
+// below it, or where a discount made the consumer price lower, a line keeps
+// the consumer price.
+func (s *resellerServiceImpl) PriceLines(userId uint, lines []models.ProductLogProcess) error {
+	approved, err := s.IsReseller(userId)
+	if err != nil || !approved {
+		return err
+	}
+
+	quantities := map[uint]int{}
+	for _, line := range lines {
+		quantities[line.ProductID] += line.Kuantitas
+	}
+
+	minimums := map[uint]int{}
+	for i, line := range lines {
+		minimum, ok := minimums[line.ProductID]
+		if !ok {
+			product, err := s.productRepository.FindById(line.ProductID)
+			if err != nil {
+				return fmt.Errorf("failed to get product details: %v", err)
+			}
+			minimum = product.MinOrderReseller
+			minimums[line.ProductID] = minimum
+		}
+		if quantities[line.ProductID] < minimum {
+			continue
+		}
+
+		price, ok := resellerUnitPrice(line.HargaKonsumen, line.HargaReseller)
+		if !ok {
+			continue
+		}
+		lines[i].Reseller = true
+		lines[i].HargaTotal = price * float64(line.Kuantitas)
+	}
+	return nil
+}
+
+// resellerProduct fills in the prices an approved reseller pays
+func resellerProduct(product models.ProductResponse) models.ProductResponse {
+	if _, ok := resellerUnitPrice(product.HargaKonsumen, product.HargaReseller); ok {
+		product.HargaAnda = product.HargaReseller
+	}
+
+	if product.Varian != nil {
+		variants := make([]models.ProductVariantResponse, len(product.Varian))
+		for i, variant := range product.Varian {
+			if _, ok := resellerUnitPrice(variant.HargaKonsumen, variant.HargaReseller); ok {
+				variant.HargaAnda = variant.HargaReseller
+			}
+			variants[i] = variant
+		}
+		product.Varian = variants
+	}
+	return product
+}
+
+// resellerUnitPrice returns the reseller price when it is below the consumer
+// price the buyer would pay otherwise, discounts included
+func resellerUnitPrice(hargaKonsumen string, hargaReseller string) (float64, bool) {
+	reseller, err := strconv.ParseFloat(hargaReseller, 64)
+	if err != nil || reseller <= 0 {
+		return 0, false
+	}
+	if konsumen, err := strconv.ParseFloat(hargaKonsumen, 64); err == nil && konsumen <= reseller {
+		return 0, false
+	}
+	return reseller, true
+}
+
+func toResellerResponse(reseller entities.Reseller) models.ResellerResponse {
+	return models.ResellerResponse{
+		ID:             reseller.ID,
+		IDUser:         reseller.IDUser,
+		NamaUser:       reseller.User.Nama,
+		Email:          reseller.User.Email,
+		NamaUsaha:      reseller.NamaUsaha,
+		Alasan:         reseller.Alasan,
+		Status:         reseller.Status,
+		Catatan:        reseller.Catatan,
+		DiputuskanPada: reseller.DiputuskanPada,
+		CreatedAt:      reseller.CreatedAt,
+		UpdatedAt:      reseller.UpdatedAt,
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"mini-project-evermos/models"
+	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/search"
+)
+
+const maxSuggestionLimit = 20
+
+type SearchSuggestionService interface {
+	Rebuild() error
+	Put(kind string, id uint, name string)
+	Remove(kind string, id uint)
+	Suggest(query string, limit int) models.SearchSuggestionResponse
+}
+
+type searchSuggestionServiceImpl struct {
+	repository repositories.SearchSuggestionRepository
+	index      *search.Index
+}
+
+func NewSearchSuggestionService(repository repositories.SearchSuggestionRepository) SearchSuggestionService {
+	return &searchSuggestionServiceImpl{
+		repository: repository,
+		index:      search.NewIndex(),
+	}
+}
+
+// Rebuild reloads the index from the database
+func (service *searchSuggestionServiceImpl) Rebuild() error {
+	items, err := service.repository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	suggestions := make([]search.Suggestion, len(items))
+	for i, item := range items {
+		suggestions[i] = search.Suggestion{Kind: item.Tipe, ID: item.ID, Name: item.Nama}
+	}
+	service.index.Replace(suggestions)
+	return nil
+}
+
+// Put keeps the index fresh after a product, category or store is created or renamed
+func (service *searchSuggestionServiceImpl) Put(kind string, id uint, name string) {
+	service.index.Put(kind, id, name)
+}
+
+func (service *searchSuggestionServiceImpl) Remove(kind string, id uint) {
+	service.index.Remove(kind, id)
+}
+
+func (service *searchSuggestionServiceImpl) Suggest(query string, limit int) models.SearchSuggestionResponse {
+	if limit <= 0 || limit > maxSuggestionLimit {
+		limit = 5
+	}
+
+	matches := service.index.Suggest(query, limit)
+	response := models.SearchSuggestionResponse{
+		Query:    query,
+		Produk:   toSearchSuggestionItems(matches[search.KindProduct]),
+		Category: toSearchSuggestionItems(matches[search.KindCategory]),
+		Toko:     toSearchSuggestionItems(matches[search.KindStore]),
+	}
+
+	// Only propose a correction when the query as typed finds nothing
+	if len(response.Produk)+len(response.Category)+len(response.Toko) == 0 {
+		response.DidYouMean = service.index.DidYouMean(query)
+	}
+
+	return response
+}
+
+func toSearchSuggestionItems(suggestions []search.Suggestion) []models.SearchSuggestionItem {
+	items := []models.SearchSuggestionItem{}
+	for _, suggestion := range suggestions {
+		items = append(items, models.SearchSuggestionItem{
+			Tipe: suggestion.Kind,
+			ID:   suggestion.ID,
+			Nama: suggestion.Name,
+		})
+	}
+	return items
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/gosimple/slug"
+)
+
+// uniqueSlug slugifies text and appends -2, -3, ... until exists reports the slug as free
+func uniqueSlug(text string, fallback string, exists func(string) (bool, error)) (string, error) {
+	base := slug.Make(text)
+	if base == "" {
+		base = fallback
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
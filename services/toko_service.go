@@ -7,6 +7,7 @@ import (
 	"mini-project-evermos/models/entities"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/repositories"
+	"mini-project-evermos/utils/search"
 	"strconv"
 	"time"
 )
@@ -59,13 +60,15 @@ type StoreService interface {
 type storeServiceImpl struct {
 	repository           repositories.StoreRepository
 	storePhotoRepository repositories.StorePhotoRepository // Changed type
+	suggestions          SearchSuggestionService
 }
 
 // Update constructor to accept correct types
-func NewStoreService(storeRepository *repositories.StoreRepository, storePhotoRepository *repositories.StorePhotoRepository) StoreService {
+func NewStoreService(storeRepository *repositories.StoreRepository, storePhotoRepository *repositories.StorePhotoRepository, suggestions SearchSuggestionService) StoreService {
 	return &storeServiceImpl{
 		repository:           *storeRepository,
 		storePhotoRepository: *storePhotoRepository,
+		suggestions:          suggestions,
 	}
 }
 
@@ -92,6 +95,8 @@ func (service *storeServiceImpl) GetAll(limit, page int, keyword string) (models
 			IDUser:        store.IDUser, // Add this line
 			NamaToko:      store.NamaToko,
 			DeskripsiToko: store.DeskripsiToko,
+			IDProvinsi:    store.IDProvinsi,
+			IDKota:        store.IDKota,
 			FotoToko:      photoData, // Changed this line
 			CreatedAt:     store.CreatedAt,
 			UpdatedAt:     store.UpdatedAt,
@@ -118,6 +123,8 @@ func (service *storeServiceImpl) GetByUserId(user_id uint) (models.StoreResponse
 		ID:            store.ID,
 		NamaToko:      store.NamaToko,
 		DeskripsiToko: store.DeskripsiToko, // Add this field
+		IDProvinsi:    store.IDProvinsi,
+		IDKota:        store.IDKota,
 		CreatedAt:     store.CreatedAt,
 		UpdatedAt:     store.UpdatedAt,
 	}
@@ -144,6 +151,8 @@ func (service *storeServiceImpl) GetById(id uint, user_id uint) (models.StoreRes
 		IDUser:        store.IDUser, // Add this line to include the IDUser
 		NamaToko:      store.NamaToko,
 		DeskripsiToko: store.DeskripsiToko,
+		IDProvinsi:    store.IDProvinsi,
+		IDKota:        store.IDKota,
 		FotoToko:      fotoResponses,
 		CreatedAt:     store.CreatedAt,
 		UpdatedAt:     store.UpdatedAt,
@@ -158,6 +167,8 @@ func (service *storeServiceImpl) Create(input models.StoreProcess) (models.Store
 		IDUser:        input.UserID,
 		NamaToko:      input.NamaToko,
 		DeskripsiToko: input.DeskripsiToko, // Add this field
+		IDProvinsi:    input.IDProvinsi,
+		IDKota:        input.IDKota,
 	}
 
 	// Create photo URLs array with the correct id_foto
@@ -177,6 +188,7 @@ func (service *storeServiceImpl) Create(input models.StoreProcess) (models.Store
 	if err != nil {
 		return models.StoreResponse{}, err
 	}
+	service.suggestions.Put(search.KindStore, result.ID, result.NamaToko)
 
 	// Get latest photos from store photo repository
 	latestPhotos, err := service.storePhotoRepository.FindByToko(result.ID)
@@ -193,6 +205,8 @@ func (service *storeServiceImpl) Create(input models.StoreProcess) (models.Store
 		IDUser:        result.IDUser, // Add this line
 		NamaToko:      result.NamaToko,
 		DeskripsiToko: result.DeskripsiToko,
+		IDProvinsi:    result.IDProvinsi,
+		IDKota:        result.IDKota,
 		FotoToko:      fotoResponses,
 		CreatedAt:     result.CreatedAt,
 		UpdatedAt:     result.UpdatedAt,
@@ -217,6 +231,8 @@ func (service *storeServiceImpl) Edit(input models.StoreProcess) (models.StoreRe
 	req := entities.Store{
 		NamaToko:      input.NamaToko,
 		DeskripsiToko: input.DeskripsiToko,
+		IDProvinsi:    input.IDProvinsi,
+		IDKota:        input.IDKota,
 		UrlFoto:       filename,
 	}
 
@@ -238,6 +254,7 @@ func (service *storeServiceImpl) Edit(input models.StoreProcess) (models.StoreRe
 	if err != nil {
 		return models.StoreResponse{}, err
 	}
+	service.suggestions.Put(search.KindStore, updated_store.ID, updated_store.NamaToko)
 
 	updated_fotoTokos := convertStorePhotoToFotoToko(updated_photos)
 	photoResponses := service.convertPhotosToResponse(updated_fotoTokos)
@@ -249,6 +266,8 @@ func (service *storeServiceImpl) Edit(input models.StoreProcess) (models.StoreRe
 		IDUser:        updated_store.IDUser, // Add this line to include IDUser
 		NamaToko:      updated_store.NamaToko,
 		DeskripsiToko: updated_store.DeskripsiToko,
+		IDProvinsi:    updated_store.IDProvinsi,
+		IDKota:        updated_store.IDKota,
 		FotoToko:      fotoResponses,
 		CreatedAt:     updated_store.CreatedAt,
 		UpdatedAt:     updated_store.UpdatedAt,
@@ -308,12 +327,15 @@ func (service *storeServiceImpl) Delete(id uint, user_id uint) (models.StoreResp
 	if err != nil || !success {
 		return models.StoreResponse{}, err
 	}
+	service.suggestions.Remove(search.KindStore, id)
 
 	return models.StoreResponse{
 		ID:            store.ID,
 		IDUser:        store.IDUser, // Add this line to include IDUser
 		NamaToko:      store.NamaToko,
 		DeskripsiToko: store.DeskripsiToko,
+		IDProvinsi:    store.IDProvinsi,
+		IDKota:        store.IDKota,
 		FotoToko:      fotoResponses,
 		CreatedAt:     store.CreatedAt,
 		UpdatedAt:     store.UpdatedAt,
@@ -3,15 +3,19 @@ package services
 import (
 	"errors"
 	"fmt"
+	"math"
 	"mini-project-evermos/models"
 	"mini-project-evermos/models/responder"
 	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type TransactionService interface {
 	GetAll(limit int, page int, keyword string) (responder.Pagination, error)
 	GetById(id uint, user_id uint) (models.TransactionResponse, error)
+	GetByInvoiceCode(code string, user_id uint) (models.TransactionResponse, error)
 	Create(input models.TransactionRequest, user_id uint) (models.TransactionResponse, error)
 	Update(id uint, user_id uint, input models.TransactionUpdateRequest) (models.TransactionResponse, error)
 	Delete(id uint, user_id uint) error
@@ -24,6 +28,15 @@ type transactionServiceImpl struct {
 	userRepository    repositories.UserRepository
 	regionService     RegionService
 	productLogRepo    repositories.ProductLogRepository
+	shippingService   ShippingService
+	invoiceService    InvoiceNumberService
+	variantRepo       repositories.ProductVariantRepository
+	couponService     ProductCouponService
+	promoService      ProductPromoService
+	flashSaleService  FlashSaleService
+	voucherService    StoreVoucherService
+	resellerService   ResellerService
+	referralService   ReferralService
 }
 
 func NewTransactionService(
@@ -33,6 +46,15 @@ func NewTransactionService(
 	userRepository *repositories.UserRepository,
 	regionService *RegionService,
 	productLogRepo *repositories.ProductLogRepository,
+	shippingService *ShippingService,
+	invoiceService *InvoiceNumberService,
+	variantRepo *repositories.ProductVariantRepository,
+	couponService *ProductCouponService,
+	promoService *ProductPromoService,
+	flashSaleService *FlashSaleService,
+	voucherService *StoreVoucherService,
+	resellerService *ResellerService,
+	referralService *ReferralService,
 ) TransactionService {
 	return &transactionServiceImpl{
 		repository:        *transactionRepository,
@@ -41,17 +63,57 @@ func NewTransactionService(
 		userRepository:    *userRepository,
 		regionService:     *regionService,
 		productLogRepo:    *productLogRepo,
+		shippingService:   *shippingService,
+		invoiceService:    *invoiceService,
+		variantRepo:       *variantRepo,
+		couponService:     *couponService,
+		promoService:      *promoService,
+		flashSaleService:  *flashSaleService,
+		voucherService:    *voucherService,
+		resellerService:   *resellerService,
+		referralService:   *referralService,
 	}
 }
 
 func (service *transactionServiceImpl) Create(input models.TransactionRequest, user_id uint) (models.TransactionResponse, error) {
+	// The buyer comes from the token, never from the request body
+	input.UserID = user_id
+
+	// Lines are priced and taken from stock by their quantity, so a quantity
+	// below one would lower the total and add stock
+	if len(input.Products) == 0 {
+		return models.TransactionResponse{}, errors.New("products are required")
+	}
+	for _, item := range input.Products {
+		if item.Quantity < 1 {
+			return models.TransactionResponse{}, fmt.Errorf("quantity of product %d must be at least 1", item.ProductID)
+		}
+	}
+
+	// Quote the chosen courier from the local rate table
+	shipping, err := service.shippingService.QuoteFor(models.ShippingQuoteRequest{
+		AlamatPengiriman: input.AlamatPengiriman,
+		Products:         input.Products,
+	}, input.Kurir, input.Layanan)
+	if err != nil {
+		return models.TransactionResponse{}, fmt.Errorf("failed to calculate shipping cost: %v", err)
+	}
+
+	now := time.Now()
+	kodeInvoice, err := service.invoiceService.NextTransactionCode(now)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+
 	// Create transaction process data
 	transactionProcess := models.TransactionProcessData{
 		Transaction: models.Transaction{
-			UserID:           input.UserID,
+			UserID:           user_id,
 			AlamatPengiriman: input.AlamatPengiriman,
-			HargaTotal:       int(input.HargaTotal),
-			KodeInvoice:      fmt.Sprintf("INV-%d-%s", input.UserID, time.Now().Format("20060102150405")),
+			OngkosKirim:      shipping.OngkosKirim,
+			Kurir:            shipping.Kurir,
+			LayananKirim:     shipping.Layanan,
+			KodeInvoice:      kodeInvoice,
 			MethodBayar:      input.MethodBayar,
 		},
 	}
@@ -63,26 +125,162 @@ func (service *transactionServiceImpl) Create(input models.TransactionRequest, u
 		if err != nil {
 			return models.TransactionResponse{}, fmt.Errorf("failed to get product details: %v", err)
 		}
+		if !productPublished(product, now) {
+			return models.TransactionResponse{}, fmt.Errorf("product %s is not available", product.NamaProduk)
+		}
+
+		variant, err := resolveVariant(service.variantRepo, product.ID, item.VariantID)
+		if err != nil {
+			return models.TransactionResponse{}, err
+		}
+
+		var deskripsi string
+		if product.Deskripsi != nil {
+			deskripsi = *product.Deskripsi
+		}
 
 		// Create product log entry
 		logProduct := models.ProductLogProcess{
 			ProductID:     product.ID,
 			NamaProduk:    product.NamaProduk,
 			Slug:          product.Slug,
-			HargaReseller: fmt.Sprintf("%.2f", product.HargaReseller),
-			HargaKonsumen: fmt.Sprintf("%.2f", product.HargaKonsumen),
-			Deskripsi:     *product.Deskripsi,
+			HargaReseller: product.HargaReseller,
+			HargaKonsumen: product.HargaKonsumen,
+			Deskripsi:     deskripsi,
 			StoreID:       product.Store.ID,
 			CategoryID:    product.Category.ID,
 			Kuantitas:     item.Quantity,
-			HargaTotal:    item.Price * float64(item.Quantity),
 		}
+
+		// Snapshot the chosen variant and its price, discounted like the product
+		if variant != nil {
+			logProduct.HargaKonsumen, logProduct.HargaReseller = variantPrices(product, *variant, now)
+			logProduct.VariantID = &variant.ID
+			logProduct.SKU = variant.SKU
+			logProduct.NamaVarian = variant.Kombinasi
+		}
+
+		// The line is priced from the catalog, never from the request
+		price, err := strconv.ParseFloat(logProduct.HargaKonsumen, 64)
+		if err != nil {
+			return models.TransactionResponse{}, fmt.Errorf("product %s has an invalid price", product.NamaProduk)
+		}
+		logProduct.HargaTotal = price * float64(item.Quantity)
 		logProducts = append(logProducts, logProduct)
 	}
 
+	// Approved resellers pay the reseller price from each product's minimum
+	// order, then lines of products on a live flash sale are priced at the
+	// sale price
+	if err := service.resellerService.PriceLines(user_id, logProducts); err != nil {
+		return models.TransactionResponse{}, err
+	}
+	if err := service.flashSaleService.Apply(user_id, logProducts); err != nil {
+		return models.TransactionResponse{}, err
+	}
+
+	// Run the store promos next: price promos go into the lines' Diskon and
+	// free shipping is taken off each store's shipping fee
+	shippingFees := map[uint]float64{}
+	for _, parcel := range shipping.Rincian {
+		shippingFees[parcel.IDToko] += parcel.OngkosKirim
+	}
+	promos, shippingDiscounts, err := service.promoService.Price(logProducts, shippingFees)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	transactionProcess.Promos = promos
+	for _, promo := range promos {
+		if promo.Baris >= 0 {
+			transactionProcess.Transaction.Diskon += promo.Diskon
+		} else {
+			transactionProcess.Transaction.DiskonOngkir += promo.Diskon
+		}
+	}
+
+	// Take the sellers' vouchers off their stores' lines; the wallet claims are
+	// used with the transaction
+	if len(input.VoucherToko) > 0 {
+		uses, discounts, err := service.voucherService.Apply(user_id, input.VoucherToko, logProducts)
+		if err != nil {
+			return models.TransactionResponse{}, err
+		}
+		for i := range logProducts {
+			logProducts[i].Diskon += discounts[i]
+		}
+		for _, use := range uses {
+			transactionProcess.Transaction.Diskon += use.Diskon
+		}
+		transactionProcess.Vouchers = uses
+	}
+
+	// Take the coupon off what is left of the lines it covers; it is redeemed
+	// with the transaction
+	if code := strings.TrimSpace(input.KodeKupon); code != "" {
+		redemption, discounts, err := service.couponService.Apply(code, user_id, logProducts)
+		if err != nil {
+			return models.TransactionResponse{}, err
+		}
+		for i := range logProducts {
+			logProducts[i].Diskon += discounts[i]
+		}
+		transactionProcess.Coupon = &redemption
+		transactionProcess.Transaction.Diskon += redemption.Diskon
+		transactionProcess.Transaction.KodeKupon = redemption.KodeKupon
+	}
+
+	// The total is the lines plus shipping less every discount, the same sum
+	// the store orders are split into
+	var subtotal float64
+	for _, line := range logProducts {
+		subtotal += line.HargaTotal
+	}
+	transactionProcess.Transaction.HargaTotal = int(math.Round(subtotal + shipping.OngkosKirim -
+		transactionProcess.Transaction.Diskon - transactionProcess.Transaction.DiskonOngkir))
+
+	// Credit the reseller whose referral link brought the buyer; the
+	// commission is booked with the transaction
+	if code := strings.TrimSpace(input.KodeReferral); code != "" {
+		referral, err := service.referralService.Attribute(code, user_id, logProducts)
+		if err != nil {
+			return models.TransactionResponse{}, err
+		}
+		transactionProcess.Referral = referral
+	}
+
 	// Add log products to transaction process
 	transactionProcess.LogProduct = logProducts
 
+	// Ship each line from the warehouse its parcel was quoted from
+	for _, parcel := range shipping.Rincian {
+		for _, line := range parcel.Baris {
+			logProducts[line].WarehouseID = parcel.IDGudang
+		}
+	}
+
+	// Split the checkout into one store order per store; a store shipping from
+	// several warehouses pays the fees of all its parcels
+	storeOrderIndex := map[uint]int{}
+	for _, parcel := range shipping.Rincian {
+		if idx, ok := storeOrderIndex[parcel.IDToko]; ok {
+			transactionProcess.StoreOrders[idx].OngkosKirim += parcel.OngkosKirim
+			continue
+		}
+		storeInvoice, err := service.invoiceService.NextStoreOrderCode(parcel.IDToko, now)
+		if err != nil {
+			return models.TransactionResponse{}, err
+		}
+		transactionProcess.StoreOrders = append(transactionProcess.StoreOrders, models.StoreOrderProcess{
+			StoreID:      parcel.IDToko,
+			KodeInvoice:  storeInvoice,
+			OngkosKirim:  parcel.OngkosKirim,
+			DiskonOngkir: shippingDiscounts[parcel.IDToko],
+			Kurir:        shipping.Kurir,
+			LayananKirim: shipping.Layanan,
+		})
+		storeOrderIndex[parcel.IDToko] = len(transactionProcess.StoreOrders) - 1
+	}
+
 	// Create transaction and process logs
 	trxID, err := service.repository.Insert(transactionProcess)
 	if err != nil {
@@ -112,13 +310,23 @@ func (service *transactionServiceImpl) Create(input models.TransactionRequest, u
 
 	// Return response
 	return models.TransactionResponse{
-		ID:          transaction.ID,
-		UserID:      input.UserID,
-		HargaTotal:  transaction.HargaTotal,
-		KodeInvoice: transaction.KodeInvoice,
-		MethodBayar: transaction.MethodBayar,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:           transaction.ID,
+		UserID:       user_id,
+		HargaTotal:   transaction.HargaTotal,
+		OngkosKirim:  transaction.OngkosKirim,
+		Diskon:       transaction.Diskon,
+		DiskonOngkir: transaction.DiskonOngkir,
+		KodeKupon:    transaction.KodeKupon,
+		Promos:       toTransactionPromoResponses(transaction.Promos),
+		VoucherToko:  toTransactionVoucherResponses(transaction.Vouchers),
+		KodeReferral: transaction.KodeReferral,
+		Kurir:        transaction.Kurir,
+		LayananKirim: transaction.LayananKirim,
+		KodeInvoice:  transaction.KodeInvoice,
+		MethodBayar:  transaction.MethodBayar,
+		StoreOrders:  toStoreOrderResponses(transaction.StoreOrders),
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 		Address: models.AddressResponse{
 			ID:           address.ID,
 			IDUser:       address.IDUser,
@@ -183,13 +391,23 @@ func (service *transactionServiceImpl) GetById(id uint, user_id uint) (models.Tr
 	}
 
 	response := models.TransactionResponse{
-		ID:          transaction.ID,
-		UserID:      transaction.IDUser,
-		HargaTotal:  transaction.HargaTotal,
-		KodeInvoice: transaction.KodeInvoice,
-		MethodBayar: transaction.MethodBayar,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:           transaction.ID,
+		UserID:       transaction.IDUser,
+		HargaTotal:   transaction.HargaTotal,
+		OngkosKirim:  transaction.OngkosKirim,
+		Diskon:       transaction.Diskon,
+		DiskonOngkir: transaction.DiskonOngkir,
+		KodeKupon:    transaction.KodeKupon,
+		Promos:       toTransactionPromoResponses(transaction.Promos),
+		VoucherToko:  toTransactionVoucherResponses(transaction.Vouchers),
+		KodeReferral: transaction.KodeReferral,
+		Kurir:        transaction.Kurir,
+		LayananKirim: transaction.LayananKirim,
+		KodeInvoice:  transaction.KodeInvoice,
+		MethodBayar:  transaction.MethodBayar,
+		StoreOrders:  toStoreOrderResponses(transaction.StoreOrders),
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 		Address: models.AddressResponse{
 			ID:           transaction.Address.ID,
 			IDUser:       transaction.IDUser,
@@ -213,6 +431,25 @@ func (service *transactionServiceImpl) GetById(id uint, user_id uint) (models.Tr
 	return response, nil
 }
 
+// GetByInvoiceCode finds a transaction by its own invoice code or one of its store orders' codes
+func (service *transactionServiceImpl) GetByInvoiceCode(code string, user_id uint) (models.TransactionResponse, error) {
+	transaction, err := service.repository.FindByInvoiceCode(code)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+
+	// Invoice numbers are sequential, so only the buyer or an admin may look one up
+	user, err := service.userRepository.FindById(user_id)
+	if err != nil {
+		return models.TransactionResponse{}, err
+	}
+	if !user.IsAdmin && transaction.IDUser != user_id {
+		return models.TransactionResponse{}, errors.New("forbidden")
+	}
+
+	return service.GetById(transaction.ID, user_id)
+}
+
 func (service *transactionServiceImpl) GetAll(limit int, page int, keyword string) (responder.Pagination, error) {
 	request := responder.Pagination{}
 	request.Limit = limit
@@ -285,13 +522,16 @@ func (service *transactionServiceImpl) Update(id uint, user_id uint, input model
 
 	// Return updated response
 	response := models.TransactionResponse{
-		ID:          updated.ID,
-		UserID:      updated.IDUser,
-		HargaTotal:  updated.HargaTotal,
-		KodeInvoice: updated.KodeInvoice,
-		MethodBayar: updated.MethodBayar,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:           updated.ID,
+		UserID:       updated.IDUser,
+		HargaTotal:   updated.HargaTotal,
+		OngkosKirim:  updated.OngkosKirim,
+		Kurir:        updated.Kurir,
+		LayananKirim: updated.LayananKirim,
+		KodeInvoice:  updated.KodeInvoice,
+		MethodBayar:  updated.MethodBayar,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 		Address: models.AddressResponse{
 			ID:           updated.Address.ID,
 			IDUser:       updated.IDUser,
@@ -335,5 +575,5 @@ func (service *transactionServiceImpl) Delete(id uint, user_id uint) error {
 	}
 
 	// Delete the transaction
-	return service.repository.Delete(id)
+	return service.repository.Delete(id, user_id)
 }
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+)
+
+// storeOrderTransitions lists the statuses a seller may move a store order to
+var storeOrderTransitions = map[string][]string{
+	"pending":    {"processing", "cancelled"},
+	"processing": {"shipped", "cancelled"},
+	"shipped":    {"delivered"},
+}
+
+type StoreOrderService interface {
+	GetByTrxId(userID uint, trxID uint) ([]models.StoreOrderResponse, error)
+	GetMine(userID uint, status string) ([]models.StoreOrderResponse, error)
+	GetById(userID uint, id uint) (models.StoreOrderResponse, error)
+	UpdateStatus(userID uint, id uint, input models.StoreOrderStatusRequest) (models.StoreOrderResponse, error)
+}
+
+type storeOrderServiceImpl struct {
+	repository            repositories.StoreOrderRepository
+	transactionRepository repositories.TransactionRepository
+	storeRepository       repositories.StoreRepository
+	userRepository        repositories.UserRepository
+}
+
+func NewStoreOrderService(
+	repository repositories.StoreOrderRepository,
+	transactionRepository repositories.TransactionRepository,
+	storeRepository repositories.StoreRepository,
+	userRepository repositories.UserRepository,
+) StoreOrderService {
+	return &storeOrderServiceImpl{
+		repository:            repository,
+		transactionRepository: transactionRepository,
+		storeRepository:       storeRepository,
+		userRepository:        userRepository,
+	}
+}
+
+// GetByTrxId lists the store orders of a transaction for its buyer
+func (s *storeOrderServiceImpl) GetByTrxId(userID uint, trxID uint) ([]models.StoreOrderResponse, error) {
+	transaction, err := s.transactionRepository.FindById(trxID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepository.FindById(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsAdmin && transaction.IDUser != userID {
+		return nil, errors.New("forbidden")
+	}
+
+	storeOrders, err := s.repository.FindByTrxId(trxID)
+	if err != nil {
+		return nil, err
+	}
+	return toStoreOrderResponses(storeOrders), nil
+}
+
+// GetMine lists the store orders received by the seller's store
+func (s *storeOrderServiceImpl) GetMine(userID uint, status string) ([]models.StoreOrderResponse, error) {
+	store, err := s.storeRepository.FindByUserId(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	storeOrders, err := s.repository.FindByStoreId(store.ID, status)
+	if err != nil {
+		return nil, err
+	}
+	return toStoreOrderResponses(storeOrders), nil
+}
+
+// GetById returns a store order to its buyer, its seller or an admin
+func (s *storeOrderServiceImpl) GetById(userID uint, id uint) (models.StoreOrderResponse, error) {
+	storeOrder, err := s.repository.FindById(id)
+	if err != nil {
+		return models.StoreOrderResponse{}, err
+	}
+
+	if storeOrder.Transaction.IDUser != userID && storeOrder.Store.IDUser != userID {
+		user, err := s.userRepository.FindById(userID)
+		if err != nil {
+			return models.StoreOrderResponse{}, err
+		}
+		if !user.IsAdmin {
+			return models.StoreOrderResponse{}, errors.New("forbidden")
+		}
+	}
+	return toStoreOrderResponse(storeOrder), nil
+}
+
+// UpdateStatus moves a store order forward; only its seller or an admin may do so
+func (s *storeOrderServiceImpl) UpdateStatus(userID uint, id uint, input models.StoreOrderStatusRequest) (models.StoreOrderResponse, error) {
+	storeOrder, err := s.repository.FindById(id)
+	if err != nil {
+		return models.StoreOrderResponse{}, err
+	}
+
+	if storeOrder.Store.IDUser != userID {
+		user, err := s.userRepository.FindById(userID)
+		if err != nil {
+			return models.StoreOrderResponse{}, err
+		}
+		if !user.IsAdmin {
+			return models.StoreOrderResponse{}, errors.New("forbidden")
+		}
+	}
+
+	allowed := false
+	for _, next := range storeOrderTransitions[storeOrder.Status] {
+		if next == input.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return models.StoreOrderResponse{}, fmt.Errorf("cannot change status from %s to %s", storeOrder.Status, input.Status)
+	}
+
+	updated, err := s.repository.UpdateStatus(id, storeOrder.Status, input.Status, userID)
+	if err != nil {
+		return models.StoreOrderResponse{}, err
+	}
+	return toStoreOrderResponse(updated), nil
+}
+
+func toStoreOrderResponses(storeOrders []entities.StoreOrder) []models.StoreOrderResponse {
+	responses := []models.StoreOrderResponse{}
+	for _, storeOrder := range storeOrders {
+		responses = append(responses, toStoreOrderResponse(storeOrder))
+	}
+	return responses
+}
+
+func toStoreOrderResponse(storeOrder entities.StoreOrder) models.StoreOrderResponse {
+	items := []models.StoreOrderItemResponse{}
+	for _, detail := range storeOrder.TrxDetail {
+		items = append(items, models.StoreOrderItemResponse{
+			ID:            detail.ID,
+			IDLogProduk:   detail.IDLogProduk,
+			NamaProduk:    detail.ProductLog.NamaProduk,
+			IDGudang:      detail.IDGudang,
+			Kuantitas:     detail.Kuantitas,
+			HargaTotal:    detail.HargaTotal,
+			Diskon:        detail.Diskon,
+			Reseller:      detail.Reseller,
+			ProductStatus: detail.ProductStatus,
+		})
+	}
+
+	return models.StoreOrderResponse{
+		ID:           storeOrder.ID,
+		IDTrx:        storeOrder.IDTrx,
+		IDToko:       storeOrder.IDToko,
+		NamaToko:     storeOrder.Store.NamaToko,
+		KodeInvoice:  storeOrder.KodeInvoice,
+		Subtotal:     storeOrder.Subtotal,
+		OngkosKirim:  storeOrder.OngkosKirim,
+		Diskon:       storeOrder.Diskon,
+		DiskonOngkir: storeOrder.DiskonOngkir,
+		HargaTotal:   storeOrder.HargaTotal,
+		Kurir:        storeOrder.Kurir,
+		LayananKirim: storeOrder.LayananKirim,
+		Status:       storeOrder.Status,
+		Items:        items,
+		CreatedAt:    storeOrder.CreatedAt,
+		UpdatedAt:    storeOrder.UpdatedAt,
+	}
+}
+
+func toTransactionPromoResponses(promos []entities.TransactionPromo) []models.TransactionPromoResponse {
+	var responses []models.TransactionPromoResponse
+	for _, promo := range promos {
+		responses = append(responses, models.TransactionPromoResponse{
+			IDPromo:     uint(promo.IDPromo),
+			Promo:       promo.Promo,
+			Jenis:       promo.Jenis,
+			IDTrxToko:   promo.IDTrxToko,
+			IDTrxDetail: promo.IDTrxDetail,
+			Diskon:      promo.Diskon,
+		})
+	}
+	return responses
+}
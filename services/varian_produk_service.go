@@ -0,0 +1,354 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits that keep the generated combinations manageable
+const (
+	maxVariantOptions      = 3
+	maxVariantCombinations = 100
+)
+
+type ProductVariantService interface {
+	GetByProductId(productID uint, includeInactive bool) (models.ProductVariantsResponse, error)
+	SetOptions(productID uint, input models.VariantOptionsRequest, userId uint) (models.ProductVariantsResponse, error)
+	Update(productID uint, variantID uint, input models.VariantUpdateRequest, userId uint) (models.ProductVariantResponse, error)
+}
+
+type productVariantServiceImpl struct {
+	repository        repositories.ProductVariantRepository
+	productRepository repositories.ProductRepository
+}
+
+func NewProductVariantService(
+	repository repositories.ProductVariantRepository,
+	productRepository repositories.ProductRepository,
+) ProductVariantService {
+	return &productVariantServiceImpl{
+		repository:        repository,
+		productRepository: productRepository,
+	}
+}
+
+func (s *productVariantServiceImpl) GetByProductId(productID uint, includeInactive bool) (models.ProductVariantsResponse, error) {
+	product, err := s.productRepository.FindById(productID)
+	if err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+
+	options, err := s.repository.FindOptionsByProductId(productID)
+	if err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+	variants, err := s.repository.FindByProductId(productID, !includeInactive)
+	if err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+
+	base := pricingBase(product)
+	return models.ProductVariantsResponse{
+		IDProduk: productID,
+		Opsi:     toVariantOptionResponses(options),
+		Varian:   toProductVariantResponses(variants, base),
+	}, nil
+}
+
+// SetOptions replaces the option axes and regenerates every combination.
+// Existing combinations keep their SKU, price, stock and photo.
+func (s *productVariantServiceImpl) SetOptions(productID uint, input models.VariantOptionsRequest, userId uint) (models.ProductVariantsResponse, error) {
+	if _, err := s.productRepository.FindById(productID); err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+	if len(input.Opsi) > maxVariantOptions {
+		return models.ProductVariantsResponse{}, fmt.Errorf("a product can have at most %d variant options", maxVariantOptions)
+	}
+
+	var options []entities.ProductVariantOption
+	seenNames := map[string]bool{}
+	combinations := 1
+	for i, opsi := range input.Opsi {
+		name := strings.TrimSpace(opsi.Nama)
+		if name == "" {
+			return models.ProductVariantsResponse{}, errors.New("option nama is required")
+		}
+		if seenNames[strings.ToLower(name)] {
+			return models.ProductVariantsResponse{}, fmt.Errorf("option %s is duplicated", name)
+		}
+		seenNames[strings.ToLower(name)] = true
+
+		option := entities.ProductVariantOption{IDProduk: productID, Nama: name, Urutan: i}
+		seenValues := map[string]bool{}
+		for j, nilai := range opsi.Nilai {
+			value := strings.TrimSpace(nilai)
+			if value == "" || seenValues[strings.ToLower(value)] {
+				continue
+			}
+			seenValues[strings.ToLower(value)] = true
+			option.Values = append(option.Values, entities.ProductVariantOptionValue{Nilai: value, Urutan: j})
+		}
+		if len(option.Values) == 0 {
+			return models.ProductVariantsResponse{}, fmt.Errorf("option %s needs at least one value", name)
+		}
+
+		combinations *= len(option.Values)
+		options = append(options, option)
+	}
+	if combinations > maxVariantCombinations {
+		return models.ProductVariantsResponse{}, fmt.Errorf("options produce %d variants, the maximum is %d", combinations, maxVariantCombinations)
+	}
+
+	existing, err := s.repository.FindByProductId(productID, false)
+	if err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+	existingByKey := map[string]entities.ProductVariant{}
+	existingBySKU := map[string]entities.ProductVariant{}
+	for _, variant := range existing {
+		existingByKey[variant.KunciKombinasi] = variant
+		existingBySKU[variant.SKU] = variant
+	}
+
+	var variants []entities.ProductVariant
+	if len(options) > 0 {
+		for _, combination := range variantCombinations(options) {
+			key, label, skuSuffix := describeCombination(options, combination)
+			sku := fmt.Sprintf("P%d-%s", productID, skuSuffix)
+
+			// A renamed option keeps its values, so fall back to the generated SKU
+			variant, ok := existingByKey[key]
+			if !ok {
+				variant, ok = existingBySKU[sku]
+			}
+			if ok {
+				variant.Kombinasi = label
+				variant.KunciKombinasi = key
+				variants = append(variants, variant)
+				continue
+			}
+			variants = append(variants, entities.ProductVariant{
+				IDProduk:       productID,
+				SKU:            sku,
+				Kombinasi:      label,
+				KunciKombinasi: key,
+			})
+		}
+	}
+
+	if err := s.repository.ReplaceOptions(productID, options, variants, userId); err != nil {
+		return models.ProductVariantsResponse{}, err
+	}
+	return s.GetByProductId(productID, true)
+}
+
+func (s *productVariantServiceImpl) Update(productID uint, variantID uint, input models.VariantUpdateRequest, userId uint) (models.ProductVariantResponse, error) {
+	product, err := s.productRepository.FindById(productID)
+	if err != nil {
+		return models.ProductVariantResponse{}, err
+	}
+
+	variant, err := s.repository.FindById(variantID)
+	if err != nil {
+		return models.ProductVariantResponse{}, err
+	}
+	if variant.IDProduk != productID {
+		return models.ProductVariantResponse{}, errors.New("variant does not belong to this product")
+	}
+
+	if sku := strings.TrimSpace(input.SKU); sku != "" {
+		variant.SKU = strings.ToUpper(sku)
+	}
+	if input.HargaKonsumen != nil {
+		if err := validateVariantPrice(*input.HargaKonsumen); err != nil {
+			return models.ProductVariantResponse{}, err
+		}
+		variant.HargaKonsumen = input.HargaKonsumen
+	}
+	if input.HargaReseller != nil {
+		if err := validateVariantPrice(*input.HargaReseller); err != nil {
+			return models.ProductVariantResponse{}, err
+		}
+		variant.HargaReseller = input.HargaReseller
+	}
+	if input.Stok != nil {
+		if *input.Stok < 0 {
+			return models.ProductVariantResponse{}, errors.New("stok cannot be negative")
+		}
+		variant.Stok = *input.Stok
+	}
+	if input.IDFotoProduk != nil {
+		if *input.IDFotoProduk == 0 {
+			variant.IDFotoProduk = nil
+		} else {
+			found := false
+			for _, foto := range product.FotoProduk {
+				if foto.ID == *input.IDFotoProduk {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return models.ProductVariantResponse{}, errors.New("photo does not belong to this product")
+			}
+			variant.IDFotoProduk = input.IDFotoProduk
+		}
+	}
+	if input.IsActive != nil {
+		variant.IsActive = *input.IsActive
+	}
+
+	updated, err := s.repository.Update(variant, userId)
+	if err != nil {
+		return models.ProductVariantResponse{}, err
+	}
+
+	base := pricingBase(product)
+	return toProductVariantResponse(updated, base), nil
+}
+
+// resolveVariant checks the variant chosen for a product. Products with active
+// variants must have one chosen; products without variants must not.
+func resolveVariant(repository repositories.ProductVariantRepository, productID uint, variantID *uint) (*entities.ProductVariant, error) {
+	if variantID == nil || *variantID == 0 {
+		hasVariants, err := repository.HasActiveVariants(productID)
+		if err != nil {
+			return nil, err
+		}
+		if hasVariants {
+			return nil, fmt.Errorf("variant_id is required for product %d", productID)
+		}
+		return nil, nil
+	}
+
+	variant, err := repository.FindById(*variantID)
+	if err != nil {
+		return nil, fmt.Errorf("variant with ID %d not found", *variantID)
+	}
+	if variant.IDProduk != productID {
+		return nil, fmt.Errorf("variant %d does not belong to product %d", *variantID, productID)
+	}
+	if !variant.IsActive {
+		return nil, fmt.Errorf("variant %s is no longer available", variant.SKU)
+	}
+	return &variant, nil
+}
+
+// variantPrices are the consumer and reseller price of a variant of product,
+// the consumer price discounted like the product's
+func variantPrices(product models.ProductResponse, variant entities.ProductVariant, now time.Time) (string, string) {
+	base := pricingBase(product)
+	hargaKonsumen, hargaReseller := variant.PriceFor(base)
+	if diskon := base.ActiveDiscount(now); diskon != nil {
+		hargaKonsumen = diskon.Apply(hargaKonsumen)
+	}
+	return hargaKonsumen, hargaReseller
+}
+
+// variantCombinations returns every pick of one value index per option
+func variantCombinations(options []entities.ProductVariantOption) [][]int {
+	combinations := [][]int{{}}
+	for _, option := range options {
+		var next [][]int
+		for _, combination := range combinations {
+			for i := range option.Values {
+				picked := append(append([]int{}, combination...), i)
+				next = append(next, picked)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+func describeCombination(options []entities.ProductVariantOption, combination []int) (string, string, string) {
+	var keys, labels, skuParts []string
+	for i, option := range options {
+		value := option.Values[combination[i]].Nilai
+		keys = append(keys, strings.ToLower(option.Nama)+"="+strings.ToLower(value))
+		labels = append(labels, value)
+		skuParts = append(skuParts, strings.ToUpper(strings.Join(strings.Fields(value), "")))
+	}
+	return strings.Join(keys, "|"), strings.Join(labels, " / "), strings.Join(skuParts, "-")
+}
+
+func validateVariantPrice(price string) error {
+	if price == "" {
+		return nil
+	}
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil || value < 0 {
+		return fmt.Errorf("invalid price %q", price)
+	}
+	return nil
+}
+
+func toVariantOptionResponses(options []entities.ProductVariantOption) []models.VariantOptionResponse {
+	responses := []models.VariantOptionResponse{}
+	for _, option := range options {
+		values := []string{}
+		for _, value := range option.Values {
+			values = append(values, value.Nilai)
+		}
+		responses = append(responses, models.VariantOptionResponse{
+			ID:     option.ID,
+			Nama:   option.Nama,
+			Urutan: option.Urutan,
+			Nilai:  values,
+		})
+	}
+	return responses
+}
+
+func toProductVariantResponses(variants []entities.ProductVariant, product entities.Product) []models.ProductVariantResponse {
+	responses := []models.ProductVariantResponse{}
+	for _, variant := range variants {
+		responses = append(responses, toProductVariantResponse(variant, product))
+	}
+	return responses
+}
+
+func toProductVariantResponse(variant entities.ProductVariant, product entities.Product) models.ProductVariantResponse {
+	hargaKonsumen, hargaReseller := variant.PriceFor(product)
+	var hargaCoret string
+	if diskon := product.ActiveDiscount(time.Now()); diskon != nil {
+		hargaCoret = hargaKonsumen
+		hargaKonsumen = diskon.Apply(hargaKonsumen)
+	}
+
+	var urlFoto string
+	if variant.FotoProduk != nil {
+		urlFoto = variant.FotoProduk.PhotoURL
+	}
+
+	return models.ProductVariantResponse{
+		ID:            variant.ID,
+		IDProduk:      variant.IDProduk,
+		SKU:           variant.SKU,
+		Kombinasi:     variant.Kombinasi,
+		HargaKonsumen: hargaKonsumen,
+		HargaCoret:    hargaCoret,
+		HargaReseller: hargaReseller,
+		Stok:          variant.Stok,
+		IDFotoProduk:  variant.IDFotoProduk,
+		URLFoto:       urlFoto,
+		IsActive:      variant.IsActive,
+		CreatedAt:     variant.CreatedAt,
+		UpdatedAt:     variant.UpdatedAt,
+	}
+}
+
+// toVariantSummary maps an optional preloaded variant for carts and wishlists
+func toVariantSummary(variant *entities.ProductVariant, product entities.Product) *models.ProductVariantResponse {
+	if variant == nil || variant.ID == 0 {
+		return nil
+	}
+	response := toProductVariantResponse(*variant, product)
+	return &response
+}
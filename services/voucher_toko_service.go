@@ -0,0 +1,332 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mini-project-evermos/models"
+	"mini-project-evermos/models/entities"
+	"mini-project-evermos/repositories"
+	"strings"
+	"time"
+)
+
+type StoreVoucherService interface {
+	GetClaimable(storeID uint) ([]models.StoreVoucherResponse, error)
+	GetByStore(storeID uint, userId uint, isAdmin bool) ([]models.StoreVoucherResponse, error)
+	GetById(id uint) (models.StoreVoucherResponse, error)
+	Create(request models.StoreVoucherRequest, userId uint, isAdmin bool) (models.StoreVoucherResponse, error)
+	Update(id uint, request models.StoreVoucherRequest, userId uint, isAdmin bool) (models.StoreVoucherResponse, error)
+	Delete(id uint, userId uint, isAdmin bool) error
+	Claim(id uint, userId uint) (models.VoucherClaimResponse, error)
+	GetWallet(userId uint) ([]models.VoucherClaimResponse, error)
+	Apply(userId uint, voucherIDs []uint, lines []models.ProductLogProcess) ([]models.StoreVoucherUse, []float64, error)
+}
+
+type storeVoucherServiceImpl struct {
+	repository      repositories.StoreVoucherRepository
+	storeRepository repositories.StoreRepository
+}
+
+func NewStoreVoucherService(repository repositories.StoreVoucherRepository, storeRepository repositories.StoreRepository) StoreVoucherService {
+	return &storeVoucherServiceImpl{repository, storeRepository}
+}
+
+// GetClaimable lists the vouchers of a store buyers can claim now
+func (s *storeVoucherServiceImpl) GetClaimable(storeID uint) ([]models.StoreVoucherResponse, error) {
+	vouchers, err := s.repository.FindClaimable(storeID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return toStoreVoucherResponses(vouchers), nil
+}
+
+// GetByStore lists every voucher of a store for its owner
+func (s *storeVoucherServiceImpl) GetByStore(storeID uint, userId uint, isAdmin bool) ([]models.StoreVoucherResponse, error) {
+	if err := s.checkOwner(storeID, userId, isAdmin); err != nil {
+		return nil, err
+	}
+	vouchers, err := s.repository.FindByStore(storeID)
+	if err != nil {
+		return nil, err
+	}
+	return toStoreVoucherResponses(vouchers), nil
+}
+
+func (s *storeVoucherServiceImpl) GetById(id uint) (models.StoreVoucherResponse, error) {
+	voucher, err := s.repository.FindById(id)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	return toStoreVoucherResponse(voucher), nil
+}
+
+func (s *storeVoucherServiceImpl) Create(request models.StoreVoucherRequest, userId uint, isAdmin bool) (models.StoreVoucherResponse, error) {
+	if err := s.checkOwner(request.IDToko, userId, isAdmin); err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	voucher, err := s.buildVoucher(entities.StoreVoucher{IDToko: request.IDToko, Aktif: true}, request)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+
+	created, err := s.repository.Create(voucher)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	return toStoreVoucherResponse(created), nil
+}
+
+// Update replaces the terms of a voucher; it stays with its store
+func (s *storeVoucherServiceImpl) Update(id uint, request models.StoreVoucherRequest, userId uint, isAdmin bool) (models.StoreVoucherResponse, error) {
+	existing, err := s.repository.FindById(id)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	if err := s.checkOwner(existing.IDToko, userId, isAdmin); err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	voucher, err := s.buildVoucher(existing, request)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	if voucher.Kuota > 0 && voucher.Kuota < existing.Diklaim {
+		return models.StoreVoucherResponse{}, fmt.Errorf("kuota cannot be less than the %d claims made", existing.Diklaim)
+	}
+
+	updated, err := s.repository.Update(voucher)
+	if err != nil {
+		return models.StoreVoucherResponse{}, err
+	}
+	return toStoreVoucherResponse(updated), nil
+}
+
+func (s *storeVoucherServiceImpl) Delete(id uint, userId uint, isAdmin bool) error {
+	voucher, err := s.repository.FindById(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwner(voucher.IDToko, userId, isAdmin); err != nil {
+		return err
+	}
+	return s.repository.Delete(id)
+}
+
+func (s *storeVoucherServiceImpl) Claim(id uint, userId uint) (models.VoucherClaimResponse, error) {
+	voucher, err := s.repository.FindById(id)
+	if err != nil {
+		return models.VoucherClaimResponse{}, err
+	}
+	if voucher.Store.IDUser == userId {
+		return models.VoucherClaimResponse{}, errors.New("you cannot claim a voucher of your own store")
+	}
+
+	claim, err := s.repository.Claim(id, userId)
+	if err != nil {
+		return models.VoucherClaimResponse{}, err
+	}
+	return toVoucherClaimResponse(claim, time.Now()), nil
+}
+
+// GetWallet lists the vouchers the buyer has claimed
+func (s *storeVoucherServiceImpl) GetWallet(userId uint) ([]models.VoucherClaimResponse, error) {
+	claims, err := s.repository.FindClaims(userId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	responses := []models.VoucherClaimResponse{}
+	for _, claim := range claims {
+		responses = append(responses, toVoucherClaimResponse(claim, now))
+	}
+	return responses, nil
+}
+
+// Apply works out the store vouchers of a checkout: one claimed voucher per
+// store, taken off that store's lines after their promo discounts. The claims
+// are used when the transaction is saved.
+func (s *storeVoucherServiceImpl) Apply(userId uint, voucherIDs []uint, lines []models.ProductLogProcess) ([]models.StoreVoucherUse, []float64, error) {
+	now := time.Now()
+	discounts := make([]float64, len(lines))
+	var uses []models.StoreVoucherUse
+	stores := map[uint]bool{}
+	for _, voucherID := range voucherIDs {
+		claim, err := s.repository.FindClaim(voucherID, userId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("voucher %d is not in your wallet", voucherID)
+		}
+		voucher := claim.Voucher
+		if claim.Status != entities.VoucherAvailable {
+			return nil, nil, fmt.Errorf("voucher %s has already been used", voucher.Kode)
+		}
+		if !voucher.IsValidAt(now) {
+			return nil, nil, fmt.Errorf("voucher %s is not valid now", voucher.Kode)
+		}
+		if stores[voucher.IDToko] {
+			return nil, nil, fmt.Errorf("only one voucher can be used per store")
+		}
+		stores[voucher.IDToko] = true
+
+		var covered []int
+		var subtotal float64
+		for i, line := range lines {
+			if line.StoreID == voucher.IDToko && line.HargaTotal > line.Diskon {
+				covered = append(covered, i)
+				subtotal += line.HargaTotal - line.Diskon
+			}
+		}
+		if subtotal <= 0 {
+			return nil, nil, fmt.Errorf("voucher %s only applies to products of %s", voucher.Kode, voucher.Store.NamaToko)
+		}
+		if subtotal < voucher.MinBelanja {
+			return nil, nil, fmt.Errorf("voucher %s needs a spend of at least %s at %s", voucher.Kode, formatRupiah(voucher.MinBelanja), voucher.Store.NamaToko)
+		}
+
+		total := voucher.Nilai
+		if voucher.Jenis == entities.CouponPercentage {
+			total = subtotal * voucher.Nilai / 100
+			if voucher.MaksDiskon > 0 && total > voucher.MaksDiskon {
+				total = voucher.MaksDiskon
+			}
+		}
+		total = math.Min(math.Round(total), subtotal)
+		for i, discount := range spreadDiscount(total, lines, covered, subtotal) {
+			discounts[i] += discount
+		}
+		uses = append(uses, models.StoreVoucherUse{
+			ClaimID:   claim.ID,
+			VoucherID: voucher.ID,
+			IDToko:    voucher.IDToko,
+			Kode:      voucher.Kode,
+			Diskon:    total,
+		})
+	}
+	return uses, discounts, nil
+}
+
+// checkOwner lets only the owner of the store and admins manage its vouchers
+func (s *storeVoucherServiceImpl) checkOwner(storeID uint, userId uint, isAdmin bool) error {
+	store, _, err := s.storeRepository.FindById(storeID)
+	if err != nil {
+		return errors.New("store not found")
+	}
+	if !isAdmin && store.IDUser != userId {
+		return errors.New("forbidden")
+	}
+	return nil
+}
+
+// buildVoucher applies a request to voucher
+func (s *storeVoucherServiceImpl) buildVoucher(voucher entities.StoreVoucher, request models.StoreVoucherRequest) (entities.StoreVoucher, error) {
+	voucher.Kode = strings.ToUpper(strings.TrimSpace(request.Kode))
+	if voucher.Kode == "" {
+		return entities.StoreVoucher{}, errors.New("kode is required")
+	}
+	taken, err := s.repository.CodeTaken(voucher.IDToko, voucher.Kode, voucher.ID)
+	if err != nil {
+		return entities.StoreVoucher{}, err
+	}
+	if taken {
+		return entities.StoreVoucher{}, fmt.Errorf("voucher code %s is already used by this store", voucher.Kode)
+	}
+
+	voucher.Nama = strings.TrimSpace(request.Nama)
+	if voucher.Nama == "" {
+		voucher.Nama = voucher.Kode
+	}
+
+	voucher.Jenis = request.Jenis
+	if voucher.Jenis == "" {
+		voucher.Jenis = entities.CouponFixed
+	}
+	switch voucher.Jenis {
+	case entities.CouponFixed:
+		if request.Nilai <= 0 {
+			return entities.StoreVoucher{}, errors.New("nilai must be greater than 0")
+		}
+	case entities.CouponPercentage:
+		if request.Nilai <= 0 || request.Nilai > 100 {
+			return entities.StoreVoucher{}, errors.New("nilai must be between 0 and 100 for a percentage voucher")
+		}
+	default:
+		return entities.StoreVoucher{}, fmt.Errorf("jenis must be %s or %s", entities.CouponPercentage, entities.CouponFixed)
+	}
+	if request.MinBelanja < 0 || request.MaksDiskon < 0 || request.Kuota < 0 {
+		return entities.StoreVoucher{}, errors.New("min_belanja, maks_diskon and kuota cannot be negative")
+	}
+	voucher.Nilai, voucher.MinBelanja, voucher.MaksDiskon, voucher.Kuota = request.Nilai, request.MinBelanja, request.MaksDiskon, request.Kuota
+
+	if request.MulaiPada != nil {
+		voucher.MulaiPada = *request.MulaiPada
+	} else if voucher.MulaiPada.IsZero() {
+		voucher.MulaiPada = time.Now()
+	}
+	if !request.BerakhirPada.After(voucher.MulaiPada) {
+		return entities.StoreVoucher{}, errors.New("berakhir_pada must be after mulai_pada")
+	}
+	voucher.BerakhirPada = request.BerakhirPada
+	if request.Aktif != nil {
+		voucher.Aktif = *request.Aktif
+	}
+	return voucher, nil
+}
+
+func toStoreVoucherResponses(vouchers []entities.StoreVoucher) []models.StoreVoucherResponse {
+	responses := []models.StoreVoucherResponse{}
+	for _, voucher := range vouchers {
+		responses = append(responses, toStoreVoucherResponse(voucher))
+	}
+	return responses
+}
+
+func toStoreVoucherResponse(voucher entities.StoreVoucher) models.StoreVoucherResponse {
+	return models.StoreVoucherResponse{
+		ID:           voucher.ID,
+		IDToko:       voucher.IDToko,
+		NamaToko:     voucher.Store.NamaToko,
+		Kode:         voucher.Kode,
+		Nama:         voucher.Nama,
+		Jenis:        voucher.Jenis,
+		Nilai:        voucher.Nilai,
+		MinBelanja:   voucher.MinBelanja,
+		MaksDiskon:   voucher.MaksDiskon,
+		Kuota:        voucher.Kuota,
+		Diklaim:      voucher.Diklaim,
+		MulaiPada:    voucher.MulaiPada,
+		BerakhirPada: voucher.BerakhirPada,
+		Aktif:        voucher.Aktif,
+		CreatedAt:    voucher.CreatedAt,
+		UpdatedAt:    voucher.UpdatedAt,
+	}
+}
+
+func toVoucherClaimResponse(claim entities.VoucherClaim, now time.Time) models.VoucherClaimResponse {
+	status := claim.Status
+	if status == entities.VoucherAvailable && (!claim.Voucher.Aktif || !claim.Voucher.BerakhirPada.After(now)) {
+		status = entities.VoucherExpired
+	}
+	return models.VoucherClaimResponse{
+		ID:          claim.ID,
+		Status:      status,
+		IDTrx:       claim.IDTrx,
+		IDTrxToko:   claim.IDTrxToko,
+		Diskon:      claim.Diskon,
+		DipakaiPada: claim.DipakaiPada,
+		Voucher:     toStoreVoucherResponse(claim.Voucher),
+		CreatedAt:   claim.CreatedAt,
+	}
+}
+
+func toTransactionVoucherResponses(claims []entities.VoucherClaim) []models.TransactionVoucherResponse {
+	var responses []models.TransactionVoucherResponse
+	for _, claim := range claims {
+		responses = append(responses, models.TransactionVoucherResponse{
+			IDVoucher: claim.IDVoucher,
+			Kode:      claim.Voucher.Kode,
+			IDToko:    claim.Voucher.IDToko,
+			IDTrxToko: claim.IDTrxToko,
+			Diskon:    claim.Diskon,
+		})
+	}
+	return responses
+}
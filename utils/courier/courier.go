@@ -0,0 +1,52 @@
+package courier
+
+import (
+	"strings"
+	"time"
+)
+
+// Shipment is the information a courier needs to look up an airway bill
+type Shipment struct {
+	Kurir     string
+	Layanan   string
+	NoResi    string
+	ShippedAt time.Time
+}
+
+// Event is a single tracking checkpoint reported by a courier
+type Event struct {
+	Status     string
+	Keterangan string
+	Lokasi     string
+	Waktu      time.Time
+}
+
+// Tracker fetches the tracking history of an airway bill from a courier
+type Tracker interface {
+	Track(shipment Shipment) ([]Event, error)
+}
+
+// Registry resolves a Tracker by courier code, falling back to a default tracker.
+// Get returns nil for an unregistered courier when there is no fallback.
+type Registry struct {
+	trackers map[string]Tracker
+	fallback Tracker
+}
+
+func NewRegistry(fallback Tracker) *Registry {
+	return &Registry{
+		trackers: map[string]Tracker{},
+		fallback: fallback,
+	}
+}
+
+func (registry *Registry) Register(kurir string, tracker Tracker) {
+	registry.trackers[strings.ToLower(kurir)] = tracker
+}
+
+func (registry *Registry) Get(kurir string) Tracker {
+	if tracker, ok := registry.trackers[strings.ToLower(kurir)]; ok {
+		return tracker
+	}
+	return registry.fallback
+}
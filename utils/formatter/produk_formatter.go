@@ -0,0 +1,56 @@
+package formatter
+
+import "mini-project-evermos/models"
+
+// PublicProduct hides the fields anonymous visitors may not see: the reseller
+// prices and minimum order, the low-stock threshold and the user account
+// behind the store
+func PublicProduct(product models.ProductResponse) models.ProductResponse {
+	product.HargaReseller = ""
+	product.MinOrderReseller = 0
+	product.BatasStok = nil
+	product.Store = PublicStore(product.Store)
+
+	variants := make([]models.ProductVariantResponse, len(product.Varian))
+	for i, variant := range product.Varian {
+		variants[i] = PublicVariant(variant)
+	}
+	if product.Varian != nil {
+		product.Varian = variants
+	}
+	return product
+}
+
+func PublicProducts(products []models.ProductResponse) []models.ProductResponse {
+	if products == nil {
+		return nil
+	}
+	responses := make([]models.ProductResponse, len(products))
+	for i, product := range products {
+		responses[i] = PublicProduct(product)
+	}
+	return responses
+}
+
+// PublicProductRows applies PublicProduct to the rows of a product page
+func PublicProductRows(rows interface{}) interface{} {
+	switch rows := rows.(type) {
+	case []models.ProductResponse:
+		return PublicProducts(rows)
+	case []models.ProductSearchResult:
+		responses := make([]models.ProductSearchResult, len(rows))
+		for i, row := range rows {
+			responses[i] = models.ProductSearchResult{
+				ProductResponse: PublicProduct(row.ProductResponse),
+				Relevansi:       row.Relevansi,
+			}
+		}
+		return responses
+	}
+	return rows
+}
+
+func PublicVariant(variant models.ProductVariantResponse) models.ProductVariantResponse {
+	variant.HargaReseller = ""
+	return variant
+}
@@ -16,3 +16,14 @@ func FormatStore(store models.StoreResponse) models.StoreResponse {
 func FormatStoreResponse(store models.StoreResponse) models.StoreResponse {
 	return FormatStore(store)
 }
+
+// PublicStore hides the owner's user account from anonymous visitors
+func PublicStore(store models.StoreResponse) models.StoreResponse {
+	store.IDUser = 0
+	return store
+}
+
+func PublicStoreDetail(store models.StoreDetailResponse) models.StoreDetailResponse {
+	store.IDUser = 0
+	return store
+}
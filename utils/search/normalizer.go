@@ -0,0 +1,146 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinTermLength matches MySQL's default innodb_ft_min_token_size; shorter
+// words are not in the FULLTEXT index and are dropped from the query.
+const MinTermLength = 3
+
+// minStemLength keeps suffix stripping from turning short words such as
+// "ikan" or "roti" into meaningless stems.
+const minStemLength = 4
+
+// Term is a single word or quoted phrase of a normalized query
+type Term struct {
+	Word    string
+	Stem    string
+	Phrase  bool
+	Exclude bool
+}
+
+// Query is a user search query split into terms, with stop words removed
+type Query struct {
+	Terms []Term
+}
+
+// stopWords are common Indonesian function words that carry no meaning in a product search
+var stopWords = map[string]bool{
+	"ada": true, "adalah": true, "agar": true, "akan": true, "aku": true, "apa": true,
+	"atau": true, "bagi": true, "bahwa": true, "belum": true, "bisa": true, "buat": true,
+	"dalam": true, "dan": true, "dari": true, "dengan": true, "dgn": true, "dia": true,
+	"hal": true, "harus": true, "ini": true, "itu": true, "jadi": true, "juga": true,
+	"kalau": true, "kami": true, "kamu": true, "karena": true, "ke": true, "kita": true,
+	"lagi": true, "lebih": true, "mau": true, "masih": true, "mereka": true, "nya": true,
+	"oleh": true, "pada": true, "paling": true, "para": true, "saja": true, "sangat": true,
+	"saya": true, "sebagai": true, "secara": true, "seperti": true, "sudah": true, "supaya": true,
+	"tapi": true, "tentang": true, "tersebut": true, "tidak": true, "untuk": true, "utk": true,
+	"yang": true, "yg": true,
+}
+
+// Normalize lowercases the query, keeps "quoted phrases" together, marks
+// words prefixed with "-" as exclusions and drops stop words and short words.
+func Normalize(raw string) Query {
+	var query Query
+
+	parts := strings.Split(raw, `"`)
+	for i, part := range parts {
+		// Odd parts were between quotes
+		if i%2 == 1 {
+			words := tokenize(part)
+			if len(words) > 0 {
+				phrase := strings.Join(words, " ")
+				query.Terms = append(query.Terms, Term{Word: phrase, Stem: phrase, Phrase: true})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			exclude := strings.HasPrefix(field, "-")
+			for _, word := range tokenize(field) {
+				if stopWords[word] || len([]rune(word)) < MinTermLength {
+					continue
+				}
+				query.Terms = append(query.Terms, Term{Word: word, Stem: Stem(word), Exclude: exclude})
+			}
+		}
+	}
+
+	return query
+}
+
+// Empty reports whether the query has no term that can match a product
+func (query Query) Empty() bool {
+	for _, term := range query.Terms {
+		if !term.Exclude {
+			return false
+		}
+	}
+	return true
+}
+
+// Natural renders the query for MATCH ... AGAINST (... IN NATURAL LANGUAGE MODE).
+// Both the word and its stem are listed so either spelling in the index matches.
+func (query Query) Natural() string {
+	seen := map[string]bool{}
+	var words []string
+	for _, term := range query.Terms {
+		if term.Exclude {
+			continue
+		}
+		for _, word := range []string{term.Word, term.Stem} {
+			if !seen[word] {
+				seen[word] = true
+				words = append(words, word)
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Boolean renders the query for MATCH ... AGAINST (... IN BOOLEAN MODE): every
+// word is required and matched by its stem as a prefix, so "sepatunya" finds
+// "sepatu" and "sepatuku" alike.
+func (query Query) Boolean() string {
+	var operands []string
+	for _, term := range query.Terms {
+		switch {
+		case term.Phrase:
+			operands = append(operands, `+"`+term.Word+`"`)
+		case term.Exclude:
+			operands = append(operands, "-"+term.Stem+"*")
+		default:
+			operands = append(operands, "+"+term.Stem+"*")
+		}
+	}
+	return strings.Join(operands, " ")
+}
+
+// Stem strips Indonesian inflectional and derivational suffixes (particles
+// -lah/-kah/-tah/-pun, possessives -ku/-mu/-nya and -kan/-an/-i). Prefixes
+// are kept on purpose: the FULLTEXT index stores whole words, and a stem is
+// only useful for matching when it is still a prefix of the indexed word.
+func Stem(word string) string {
+	for _, suffixes := range [][]string{
+		{"lah", "kah", "tah", "pun"},
+		{"nya", "ku", "mu"},
+		{"kan", "an", "i"},
+	} {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(word, suffix) && len([]rune(word))-len(suffix) >= minStemLength {
+				word = strings.TrimSuffix(word, suffix)
+				break
+			}
+		}
+	}
+	return word
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
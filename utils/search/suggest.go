@@ -0,0 +1,262 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Suggestion kinds
+const (
+	KindProduct  = "produk"
+	KindCategory = "category"
+	KindStore    = "toko"
+)
+
+// Suggestion is a named item that can be proposed while the user types
+type Suggestion struct {
+	Kind string
+	ID   uint
+	Name string
+}
+
+type suggestionKey struct {
+	kind string
+	id   uint
+}
+
+type tokenRef struct {
+	token string
+	key   suggestionKey
+}
+
+// Index is an in-memory prefix index over product, category and store names.
+// Writes only mark the index dirty; the sorted token list is rebuilt on the
+// next lookup so a burst of updates costs a single rebuild.
+type Index struct {
+	mu         sync.RWMutex
+	entries    map[suggestionKey]Suggestion
+	tokens     []tokenRef
+	vocabulary map[string]int
+	dirty      bool
+}
+
+func NewIndex() *Index {
+	return &Index{entries: map[suggestionKey]Suggestion{}, vocabulary: map[string]int{}}
+}
+
+// Replace swaps the whole content of the index
+func (index *Index) Replace(suggestions []Suggestion) {
+	entries := make(map[suggestionKey]Suggestion, len(suggestions))
+	for _, suggestion := range suggestions {
+		entries[suggestionKey{suggestion.Kind, suggestion.ID}] = suggestion
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.entries = entries
+	index.dirty = true
+}
+
+// Put adds or renames an item
+func (index *Index) Put(kind string, id uint, name string) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.entries[suggestionKey{kind, id}] = Suggestion{Kind: kind, ID: id, Name: name}
+	index.dirty = true
+}
+
+func (index *Index) Remove(kind string, id uint) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	delete(index.entries, suggestionKey{kind, id})
+	index.dirty = true
+}
+
+// Suggest returns up to limit items per kind whose name contains a word
+// starting with each word of prefix, e.g. "kaos pol" matches "Kaos Polos Hitam".
+// Names starting with the prefix rank first, then shorter names.
+func (index *Index) Suggest(prefix string, limit int) map[string][]Suggestion {
+	words := tokenize(prefix)
+	results := map[string][]Suggestion{}
+	if len(words) == 0 {
+		return results
+	}
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	for index.dirty {
+		index.mu.RUnlock()
+		index.rebuild()
+		index.mu.RLock()
+	}
+
+	// Candidates are the items with a word starting with the last, possibly incomplete, word
+	last := words[len(words)-1]
+	start := sort.Search(len(index.tokens), func(i int) bool {
+		return index.tokens[i].token >= last
+	})
+	seen := map[suggestionKey]bool{}
+	var matches []Suggestion
+	for i := start; i < len(index.tokens) && strings.HasPrefix(index.tokens[i].token, last); i++ {
+		key := index.tokens[i].key
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		suggestion := index.entries[key]
+		if containsPrefixes(tokenize(suggestion.Name), words[:len(words)-1]) {
+			matches = append(matches, suggestion)
+		}
+	}
+
+	normalizedPrefix := strings.Join(words, " ")
+	sort.SliceStable(matches, func(i, j int) bool {
+		a := strings.Join(tokenize(matches[i].Name), " ")
+		b := strings.Join(tokenize(matches[j].Name), " ")
+		aStarts := strings.HasPrefix(a, normalizedPrefix)
+		bStarts := strings.HasPrefix(b, normalizedPrefix)
+		if aStarts != bStarts {
+			return aStarts
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
+	for _, match := range matches {
+		if len(results[match.Kind]) < limit {
+			results[match.Kind] = append(results[match.Kind], match)
+		}
+	}
+	return results
+}
+
+// DidYouMean replaces every unknown word of query with the closest known word.
+// It returns "" when all words are known or nothing close enough exists.
+func (index *Index) DidYouMean(query string) string {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return ""
+	}
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	for index.dirty {
+		index.mu.RUnlock()
+		index.rebuild()
+		index.mu.RLock()
+	}
+
+	changed := false
+	for i, word := range words {
+		if index.vocabulary[word] > 0 || len([]rune(word)) < MinTermLength {
+			continue
+		}
+
+		// Allow one typo in short words and two in longer ones
+		maxDistance := 1
+		if len([]rune(word)) > 5 {
+			maxDistance = 2
+		}
+
+		best, bestDistance, bestCount := "", maxDistance+1, 0
+		for candidate, count := range index.vocabulary {
+			if abs(len(candidate)-len(word)) > maxDistance {
+				continue
+			}
+			distance := editDistance(word, candidate)
+			if distance < bestDistance ||
+				(distance == bestDistance && (count > bestCount || (count == bestCount && candidate < best))) {
+				best, bestDistance, bestCount = candidate, distance, count
+			}
+		}
+		if best != "" {
+			words[i] = best
+			changed = true
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+func (index *Index) rebuild() {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if !index.dirty {
+		return
+	}
+
+	tokens := make([]tokenRef, 0, len(index.entries)*2)
+	vocabulary := map[string]int{}
+	for key, suggestion := range index.entries {
+		for _, token := range tokenize(suggestion.Name) {
+			tokens = append(tokens, tokenRef{token: token, key: key})
+			vocabulary[token]++
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].token < tokens[j].token
+	})
+
+	index.tokens = tokens
+	index.vocabulary = vocabulary
+	index.dirty = false
+}
+
+// containsPrefixes reports whether every prefix starts at least one of tokens
+func containsPrefixes(tokens []string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		found := false
+		for _, token := range tokens {
+			if strings.HasPrefix(token, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistance is the Damerau-Levenshtein (optimal string alignment) distance,
+// so a swap of two neighbouring letters counts as a single typo
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}